@@ -167,6 +167,7 @@ const (
 	ConnectionDetailTypeFromConnectionSecretKey ConnectionDetailType = "FromConnectionSecretKey"
 	ConnectionDetailTypeFromFieldPath           ConnectionDetailType = "FromFieldPath"
 	ConnectionDetailTypeFromValue               ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeFromAnnotation          ConnectionDetailType = "FromAnnotation"
 )
 
 // A DerivedConnectionDetail specifies how to derive an XR connection detail
@@ -181,7 +182,7 @@ type DerivedConnectionDetail struct {
 	// Type sets the connection detail fetching behaviour to be used. Each
 	// connection detail type may require its own fields to be set on the
 	// ConnectionDetail object.
-	// +kubebuilder:validation:Enum=FromConnectionDetailKey;FromFieldPath;FromValue
+	// +kubebuilder:validation:Enum=FromConnectionDetailKey;FromFieldPath;FromValue;FromAnnotation
 	Type ConnectionDetailType `json:"type"`
 
 	// FromConnectionDetailKey sets an XR connection detail to the value of the
@@ -194,6 +195,11 @@ type DerivedConnectionDetail struct {
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
+	// FromAnnotation sets an XR connection detail to the value of the
+	// supplied annotation of the composed resource.
+	// +optional
+	FromAnnotation *string `json:"fromAnnotation,omitempty"`
+
 	// Value that will be propagated to the connection detail of the XR.
 	// +optional
 	Value *string `json:"value,omitempty"`
@@ -42,6 +42,11 @@ func (in *DerivedConnectionDetail) DeepCopyInto(out *DerivedConnectionDetail) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FromAnnotation != nil {
+		in, out := &in.FromAnnotation, &out.FromAnnotation
+		*out = new(string)
+		**out = **in
+	}
 	if in.Value != nil {
 		in, out := &in.Value, &out.Value
 		*out = new(string)
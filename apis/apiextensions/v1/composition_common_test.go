@@ -68,6 +68,75 @@ func TestReadinessCheckValidate(t *testing.T) {
 				},
 			},
 		},
+		"ValidTypeMatchConditionWithReason": {
+			reason: "Type matchCondition with a reason should be valid",
+			args: args{
+				r: &ReadinessCheck{
+					Type: ReadinessCheckTypeMatchCondition,
+					MatchCondition: &MatchConditionReadinessCheck{
+						Type:   "Healthy",
+						Status: "True",
+						Reason: "Provisioned",
+					},
+				},
+			},
+		},
+		"InvalidTypeMatchConditionMissing": {
+			reason: "Type matchCondition without a match condition should be invalid",
+			args: args{
+				r: &ReadinessCheck{
+					Type: ReadinessCheckTypeMatchCondition,
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "matchCondition",
+				},
+			},
+		},
+		"InvalidTypeMatchConditionMissingType": {
+			reason: "Type matchCondition without a condition type should be invalid",
+			args: args{
+				r: &ReadinessCheck{
+					Type: ReadinessCheckTypeMatchCondition,
+					MatchCondition: &MatchConditionReadinessCheck{
+						Status: "True",
+					},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "matchCondition.type",
+				},
+			},
+		},
+		"ValidTypeGreaterThanOrEqual": {
+			reason: "Type greaterThanOrEqual should be valid",
+			args: args{
+				r: &ReadinessCheck{
+					Type:               ReadinessCheckTypeGreaterThanOrEqual,
+					FieldPath:          "status.readyReplicas",
+					CompareToFieldPath: "status.desiredReplicas",
+				},
+			},
+		},
+		"InvalidTypeGreaterThanOrEqualMissingCompareToFieldPath": {
+			reason: "Type greaterThanOrEqual without a field path to compare to should be invalid",
+			args: args{
+				r: &ReadinessCheck{
+					Type:      ReadinessCheckTypeGreaterThanOrEqual,
+					FieldPath: "status.readyReplicas",
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "compareToFieldPath",
+				},
+			},
+		},
 		"ValidTypeMatchTrue": {
 			reason: "Type matchTrue should be valid",
 			args: args{
@@ -101,6 +170,67 @@ func TestReadinessCheckValidate(t *testing.T) {
 				},
 			},
 		},
+		"ValidTypeAnyOf": {
+			reason: "Type anyOf with valid readiness checks should be valid",
+			args: args{
+				r: &ReadinessCheck{
+					Type: ReadinessCheckTypeAnyOf,
+					AnyOf: []ReadinessSubCheck{
+						{Type: ReadinessCheckTypeMatchTrue, FieldPath: "status.ready"},
+						{Type: ReadinessCheckTypeNone},
+					},
+				},
+			},
+		},
+		"InvalidTypeAllOfEmpty": {
+			reason: "Type allOf should require at least one readiness check",
+			args: args{
+				r: &ReadinessCheck{
+					Type: ReadinessCheckTypeAllOf,
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "allOf",
+				},
+			},
+		},
+		"InvalidTypeAllOfSubCheck": {
+			reason: "Type allOf should require its readiness checks to be valid",
+			args: args{
+				r: &ReadinessCheck{
+					Type: ReadinessCheckTypeAllOf,
+					AllOf: []ReadinessSubCheck{
+						{Type: ReadinessCheckTypeNone},
+						{Type: ReadinessCheckTypeMatchString, FieldPath: "status.phase"},
+					},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "allOf[1].matchString",
+				},
+			},
+		},
+		"InvalidTypeAnyOfNested": {
+			reason: "Type anyOf should not allow nested readiness check groups",
+			args: args{
+				r: &ReadinessCheck{
+					Type: ReadinessCheckTypeAnyOf,
+					AnyOf: []ReadinessSubCheck{
+						{Type: ReadinessCheckTypeAllOf},
+					},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "anyOf[0].type",
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
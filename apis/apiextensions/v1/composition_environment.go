@@ -49,6 +49,15 @@ type EnvironmentConfiguration struct {
 	// +optional
 	EnvironmentConfigs []EnvironmentSource `json:"environmentConfigs,omitempty"`
 
+	// ConfigMaps selects values from ConfigMaps to merge into the in-memory
+	// environment at compose time. Values are merged after those of any
+	// EnvironmentConfigs, in the order the ConfigMaps are listed, meaning the
+	// values of ConfigMaps with a larger index take priority over ones with
+	// smaller indices. String values that parse as a boolean or number are
+	// coerced to that type.
+	// +optional
+	ConfigMaps []EnvironmentConfigMapSource `json:"configMaps,omitempty"`
+
 	// Patches is a list of environment patches that are executed before a
 	// composition's resources are composed.
 	Patches []EnvironmentPatch `json:"patches,omitempty"`
@@ -75,6 +84,12 @@ func (e *EnvironmentConfiguration) Validate() field.ErrorList {
 		}
 	}
 
+	for i, cm := range e.ConfigMaps {
+		if err := errors.WrapFieldError(cm.Validate(), field.NewPath("configMaps").Index(i)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	return errs
 }
 
@@ -171,6 +186,45 @@ func (e *EnvironmentSourceReference) Validate() *field.Error {
 	return nil
 }
 
+// An EnvironmentConfigMapSource selects values from a ConfigMap.
+type EnvironmentConfigMapSource struct {
+	// Namespace of the ConfigMap. Crossplane only reads ConfigMaps from the
+	// namespace it is installed in.
+	Namespace string `json:"namespace"`
+
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+
+	// Keys of the ConfigMap's data to merge into the environment. All keys
+	// are merged if none are specified.
+	// +optional
+	Keys []string `json:"keys,omitempty"`
+
+	// Resolution specifies whether the ConfigMap must exist. Composition
+	// fails if a Required ConfigMap doesn't exist, while an Optional
+	// ConfigMap that doesn't exist is ignored.
+	// +optional
+	// +kubebuilder:validation:Enum=Required;Optional
+	// +kubebuilder:default=Required
+	Resolution *xpv1.ResolutionPolicy `json:"resolution,omitempty"`
+}
+
+// Validate the EnvironmentConfigMapSource.
+func (e *EnvironmentConfigMapSource) Validate() *field.Error {
+	if e.Namespace == "" {
+		return field.Required(field.NewPath("namespace"), "namespace is required")
+	}
+	if e.Name == "" {
+		return field.Required(field.NewPath("name"), "name is required")
+	}
+	return nil
+}
+
+// IsOptional returns true if a ConfigMap that doesn't exist should be ignored.
+func (e *EnvironmentConfigMapSource) IsOptional() bool {
+	return e.Resolution != nil && *e.Resolution == xpv1.ResolutionPolicyOptional
+}
+
 // EnvironmentSourceSelectorModeType specifies amount of retrieved EnvironmentConfigs
 // with matching label.
 type EnvironmentSourceSelectorModeType string
@@ -96,7 +96,10 @@ type Patch struct {
 
 	// ToFieldPath is the path of the field on the resource whose value will
 	// be changed with the result of transforms. Leave empty if you'd like to
-	// propagate to the same path as fromFieldPath.
+	// propagate to the same path as fromFieldPath. A path ending in [], e.g.
+	// spec.zones[], appends the result to an array unless the array already
+	// contains it. Setting an index beyond the end of an array grows the
+	// array, filling any gap with nulls.
 	// +optional
 	ToFieldPath *string `json:"toFieldPath,omitempty"`
 
@@ -112,6 +115,53 @@ type Patch struct {
 	// Policy configures the specifics of patching behaviour.
 	// +optional
 	Policy *PatchPolicy `json:"policy,omitempty"`
+
+	// EachElement configures the patch to treat the value at fromFieldPath
+	// as an array, producing the patched array by transforming each of its
+	// elements. Only supported by FromCompositeFieldPath,
+	// FromEnvironmentFieldPath, ToCompositeFieldPath and
+	// ToEnvironmentFieldPath patches.
+	// +optional
+	EachElement *EachElementPatch `json:"eachElement,omitempty"`
+}
+
+// An EachElementPatch configures how each element of an array is patched.
+// The patch's transforms are applied to each element in turn, before any
+// element patches.
+type EachElementPatch struct {
+	// Patches build each element of the output array from fields of the
+	// corresponding element of the input array. When omitted each (possibly
+	// transformed) element of the input array is used as is.
+	// +optional
+	Patches []ElementPatch `json:"patches,omitempty"`
+}
+
+// An ElementPatch copies a field from an element of an input array to the
+// corresponding element of an output array.
+type ElementPatch struct {
+	// FromFieldPath is the path of the field on the input element whose value
+	// is to be used as input.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// ToFieldPath is the path of the field on the output element whose value
+	// will be changed with the result of transforms. Leave empty if you'd
+	// like to propagate to the same path as fromFieldPath.
+	// +optional
+	ToFieldPath *string `json:"toFieldPath,omitempty"`
+
+	// Transforms are the list of functions that are used as a FIFO pipe for the
+	// input to be transformed.
+	// +optional
+	Transforms []Transform `json:"transforms,omitempty"`
+}
+
+// GetToFieldPath returns the ToFieldPath for this ElementPatch, defaulting to
+// its FromFieldPath if not specified.
+func (p *ElementPatch) GetToFieldPath() string {
+	if p.ToFieldPath == nil {
+		return p.FromFieldPath
+	}
+	return *p.ToFieldPath
 }
 
 // GetFromFieldPath returns the FromFieldPath for this Patch, or an empty string if it is nil.
@@ -156,6 +206,9 @@ func (p *Patch) Validate() *field.Error {
 		if p.ToFieldPath == nil {
 			return field.Required(field.NewPath("toFieldPath"), fmt.Sprintf("toFieldPath must be set for patch type %s", p.Type))
 		}
+		if s := p.Combine.String; s != nil && (s.Format == "") == (s.Separator == nil) {
+			return field.Invalid(field.NewPath("combine", "string"), s, "exactly one of fmt or separator must be set")
+		}
 	default:
 		// Should never happen
 		return field.Invalid(field.NewPath("type"), p.Type, "unknown patch type")
@@ -165,6 +218,23 @@ func (p *Patch) Validate() *field.Error {
 			return verrors.WrapFieldError(err, field.NewPath("transforms").Index(i))
 		}
 	}
+	if p.EachElement != nil {
+		switch p.GetType() {
+		case PatchTypeFromCompositeFieldPath, PatchTypeFromEnvironmentFieldPath, PatchTypeToCompositeFieldPath, PatchTypeToEnvironmentFieldPath:
+		default:
+			return field.Invalid(field.NewPath("eachElement"), p.Type, "eachElement is not supported for this patch type")
+		}
+		for i, ep := range p.EachElement.Patches {
+			if ep.FromFieldPath == "" {
+				return field.Required(field.NewPath("eachElement", "patches").Index(i).Child("fromFieldPath"), "fromFieldPath must be set")
+			}
+			for j, transform := range ep.Transforms {
+				if err := transform.Validate(); err != nil {
+					return verrors.WrapFieldError(err, field.NewPath("eachElement", "patches").Index(i).Child("transforms").Index(j))
+				}
+			}
+		}
+	}
 
 	return nil
 }
@@ -185,6 +255,7 @@ type CombineStrategy string
 // CombineStrategy strategy definitions.
 const (
 	CombineStrategyString CombineStrategy = "string"
+	CombineStrategyObject CombineStrategy = "object"
 )
 
 // A Combine configures a patch that combines more than
@@ -195,20 +266,49 @@ type Combine struct {
 	// +kubebuilder:validation:MinItems=1
 	Variables []CombineVariable `json:"variables"`
 
-	// Strategy defines the strategy to use to combine the input variable values.
-	// Currently only string is supported.
-	// +kubebuilder:validation:Enum=string
+	// Strategy defines the strategy to use to combine the input variable
+	// values. The string strategy combines them into a single string. The
+	// object strategy merges them, each of which must be an object, into a
+	// single object. Keys are merged shallowly; when more than one variable
+	// has the same key the value of the last variable wins.
+	// +kubebuilder:validation:Enum=string;object
 	Strategy CombineStrategy `json:"strategy"`
 
 	// String declares that input variables should be combined into a single
 	// string, using the relevant settings for formatting purposes.
 	// +optional
 	String *StringCombine `json:"string,omitempty"`
+
+	// MissingVariablePolicy configures what happens when one of the input
+	// variables is missing. Skip (the default) skips the patch, unless the
+	// patch's fromFieldPath policy is Required in which case it returns an
+	// error. Empty combines missing variables as if they were empty strings,
+	// or empty objects when using the object strategy, unless the patch's
+	// fromFieldPath policy is Required.
+	// +optional
+	// +kubebuilder:validation:Enum=Skip;Empty
+	MissingVariablePolicy *CombineMissingVariablePolicy `json:"missingVariablePolicy,omitempty"`
 }
 
-// A StringCombine combines multiple input values into a single string.
+// A CombineMissingVariablePolicy determines what happens when one of the input
+// variables of a Combine patch is missing.
+type CombineMissingVariablePolicy string
+
+// Combine missing variable policies.
+const (
+	CombineMissingVariablePolicySkip  CombineMissingVariablePolicy = "Skip"
+	CombineMissingVariablePolicyEmpty CombineMissingVariablePolicy = "Empty"
+)
+
+// A StringCombine combines multiple input values into a single string. Exactly
+// one of fmt or separator must be set.
 type StringCombine struct {
 	// Format the input using a Go format string. See
 	// https://golang.org/pkg/fmt/ for details.
-	Format string `json:"fmt"`
+	// +optional
+	Format string `json:"fmt,omitempty"`
+
+	// Separator joins the input values, in order, using the supplied string.
+	// +optional
+	Separator *string `json:"separator,omitempty"`
 }
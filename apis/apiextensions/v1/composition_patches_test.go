@@ -149,6 +149,60 @@ func TestPatchValidate(t *testing.T) {
 				},
 			},
 		},
+		"ValidCombineSeparator": {
+			reason: "Combine with a string separator should be valid",
+			args: args{
+				patch: &Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{{FromFieldPath: "spec.region"}, {FromFieldPath: "spec.environment"}},
+						Strategy:  CombineStrategyString,
+						String:    &StringCombine{Separator: pointer.String("-")},
+					},
+					ToFieldPath: pointer.String("spec.forProvider.name"),
+				},
+			},
+		},
+		"InvalidCombineFormatAndSeparator": {
+			reason: "Combine with both a string format and separator should return error",
+			args: args{
+				patch: &Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{{FromFieldPath: "spec.region"}, {FromFieldPath: "spec.environment"}},
+						Strategy:  CombineStrategyString,
+						String:    &StringCombine{Format: "%s-%s", Separator: pointer.String("-")},
+					},
+					ToFieldPath: pointer.String("spec.forProvider.name"),
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "combine.string",
+				},
+			},
+		},
+		"InvalidCombineNeitherFormatNorSeparator": {
+			reason: "Combine with neither a string format nor separator should return error",
+			args: args{
+				patch: &Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{{FromFieldPath: "spec.region"}},
+						Strategy:  CombineStrategyString,
+						String:    &StringCombine{},
+					},
+					ToFieldPath: pointer.String("spec.forProvider.name"),
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "combine.string",
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
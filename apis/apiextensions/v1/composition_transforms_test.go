@@ -95,6 +95,41 @@ func TestTransformValidate(t *testing.T) {
 				},
 			},
 		},
+		"ValidMathMultiplyOffsetClamp": {
+			reason: "Math transform with MathTransform Multiply, Offset, ClampMin and ClampMax set should be valid",
+			args: args{
+				transform: &Transform{
+					Type: TransformTypeMath,
+					Math: &MathTransform{
+						Type:     MathTransformTypeMultiply,
+						Multiply: pointer.Int64(2),
+						Offset:   pointer.Int64(1),
+						ClampMin: pointer.Int64(1),
+						ClampMax: pointer.Int64(10),
+					},
+				},
+			},
+		},
+		"InvalidMathMultiplyClamp": {
+			reason: "Math transform with MathTransform Multiply whose ClampMin is greater than its ClampMax should be invalid",
+			args: args{
+				transform: &Transform{
+					Type: TransformTypeMath,
+					Math: &MathTransform{
+						Type:     MathTransformTypeMultiply,
+						Multiply: pointer.Int64(2),
+						ClampMin: pointer.Int64(10),
+						ClampMax: pointer.Int64(1),
+					},
+				},
+			},
+			want: want{
+				&field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "math.clampMin",
+				},
+			},
+		},
 		"InvalidMathNotDefinedAtAll": {
 			reason: "Math transform with no MathTransform set should be invalid",
 			args: args{
@@ -110,6 +145,35 @@ func TestTransformValidate(t *testing.T) {
 				},
 			},
 		},
+		"ValidStringTrimPrefix": {
+			reason: "String transform of type TrimPrefix with Trim set should be valid",
+			args: args{
+				transform: &Transform{
+					Type: TransformTypeString,
+					String: &StringTransform{
+						Type: StringTransformTypeTrimPrefix,
+						Trim: pointer.String("arn:aws:s3:::"),
+					},
+				},
+			},
+		},
+		"InvalidStringTrimSuffixNotSet": {
+			reason: "String transform of type TrimSuffix without Trim set should be invalid",
+			args: args{
+				transform: &Transform{
+					Type: TransformTypeString,
+					String: &StringTransform{
+						Type: StringTransformTypeTrimSuffix,
+					},
+				},
+			},
+			want: want{
+				&field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "string.trim",
+				},
+			},
+		},
 		"ValidMap": {
 			reason: "Map transform with MapTransform set should be valid",
 			args: args{
@@ -327,6 +391,90 @@ func TestTransformValidate(t *testing.T) {
 				},
 			},
 		},
+		"ValidHashRing": {
+			reason: "HashRing transform with buckets should be valid",
+			args: args{
+				transform: &Transform{
+					Type: TransformTypeHashRing,
+					HashRing: &HashRingTransform{
+						Buckets: []HashRingBucket{{Name: "a"}, {Name: "b", Weight: pointer.Int64(2)}},
+					},
+				},
+			},
+		},
+		"InvalidHashRingNoBuckets": {
+			reason: "HashRing transform without buckets should be invalid",
+			args: args{
+				transform: &Transform{
+					Type:     TransformTypeHashRing,
+					HashRing: &HashRingTransform{},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "hashRing.buckets",
+				},
+			},
+		},
+		"InvalidHashRingDuplicateBucket": {
+			reason: "HashRing transform with duplicate bucket names should be invalid",
+			args: args{
+				transform: &Transform{
+					Type: TransformTypeHashRing,
+					HashRing: &HashRingTransform{
+						Buckets: []HashRingBucket{{Name: "a"}, {Name: "a"}},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeDuplicate,
+					Field: "hashRing.buckets[1].name",
+				},
+			},
+		},
+		"ValidConnectionString": {
+			reason: "ConnectionString transform with a scheme should be valid",
+			args: args{
+				transform: &Transform{
+					Type:             TransformTypeConnectionString,
+					ConnectionString: &ConnectionStringTransform{Scheme: "postgres"},
+				},
+			},
+		},
+		"InvalidConnectionStringNoScheme": {
+			reason: "ConnectionString transform without a scheme should be invalid",
+			args: args{
+				transform: &Transform{
+					Type:             TransformTypeConnectionString,
+					ConnectionString: &ConnectionStringTransform{},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "connectionString.scheme",
+				},
+			},
+		},
+		"InvalidHashRingWeight": {
+			reason: "HashRing transform with a bucket weight less than one should be invalid",
+			args: args{
+				transform: &Transform{
+					Type: TransformTypeHashRing,
+					HashRing: &HashRingTransform{
+						Buckets: []HashRingBucket{{Name: "a", Weight: pointer.Int64(0)}},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "hashRing.buckets[0].weight",
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
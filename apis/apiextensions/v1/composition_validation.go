@@ -17,9 +17,11 @@ limitations under the License.
 package v1
 
 import (
-	"k8s.io/apimachinery/pkg/util/validation/field"
+	"fmt"
+	"sort"
+	"strings"
 
-	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	verrors "github.com/crossplane/crossplane/internal/validation/errors"
 )
@@ -32,11 +34,24 @@ func (c *Composition) Validate() (warns []string, errs field.ErrorList) {
 		c.validateResources,
 		c.validateFunctions,
 		c.validateEnvironment,
+		c.validateConnectionDetails,
 	}
 	for _, f := range validations {
 		errs = append(errs, f()...)
 	}
-	return nil, errs
+	return c.validateFeatureFlags(), errs
+}
+
+// validateFeatureFlags returns a warning for each unknown feature flag. Unknown
+// flags are ignored, so they don't invalidate the Composition.
+func (c *Composition) validateFeatureFlags() (warns []string) {
+	for f := range c.Spec.FeatureFlags {
+		if !IsKnownCompositionFeature(f) {
+			warns = append(warns, fmt.Sprintf("spec.featureFlags: ignoring unknown Composition feature flag %q", f))
+		}
+	}
+	sort.Strings(warns)
+	return warns
 }
 
 func (c *Composition) validateFunctions() (errs field.ErrorList) {
@@ -55,21 +70,26 @@ func (c *Composition) validateFunctions() (errs field.ErrorList) {
 
 // validatePatchSets checks that:
 // - patchSets are composed of valid patches
-// - there are no nested patchSets
+// - patchSets only nest existing patchSets, without cycles
 // - only existing patchSets are used by resources
 func (c *Composition) validatePatchSets() (errs field.ErrorList) {
 	definedPatchSets := make(map[string]bool, len(c.Spec.PatchSets))
-	for i, s := range c.Spec.PatchSets {
+	for _, s := range c.Spec.PatchSets {
 		definedPatchSets[s.Name] = true
+	}
+	for i, s := range c.Spec.PatchSets {
 		for j, p := range s.Patches {
-			if p.Type == PatchTypePatchSet {
-				errs = append(errs, field.Invalid(field.NewPath("spec", "patchSets").Index(i).Child("patches").Index(j).Child("type"), p.Type, errors.New("cannot use patches within patches").Error()))
-				continue
-			}
 			if err := p.Validate(); err != nil {
 				errs = append(errs, verrors.WrapFieldError(err, field.NewPath("spec", "patchSets").Index(i).Child("patches").Index(j)))
+				continue
+			}
+			if p.Type == PatchTypePatchSet && !definedPatchSets[*p.PatchSetName] {
+				errs = append(errs, field.Invalid(field.NewPath("spec", "patchSets").Index(i).Child("patches").Index(j).Child("patchSetName"), p.PatchSetName, "patchSetName must be the name of a declared patchSet"))
 			}
 		}
+		if cycle := patchSetCycle(c.Spec.PatchSets, s.Name); cycle != nil {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "patchSets").Index(i), s.Name, fmt.Sprintf("patchSet references itself: %s", strings.Join(cycle, " -> "))))
+		}
 	}
 	for i, r := range c.Spec.Resources {
 		for j, p := range r.Patches {
@@ -89,10 +109,44 @@ func (c *Composition) validatePatchSets() (errs field.ErrorList) {
 	return errs
 }
 
+// patchSetCycle returns the chain of patchSet references by which the named
+// patchSet references itself, if any.
+func patchSetCycle(pss []PatchSet, name string) []string {
+	refs := make(map[string][]string, len(pss))
+	for _, s := range pss {
+		for _, p := range s.Patches {
+			if p.Type == PatchTypePatchSet && p.PatchSetName != nil {
+				refs[s.Name] = append(refs[s.Name], *p.PatchSetName)
+			}
+		}
+	}
+
+	var visit func(path []string, visited map[string]bool) []string
+	visit = func(path []string, visited map[string]bool) []string {
+		for _, ref := range refs[path[len(path)-1]] {
+			if ref == name {
+				return append(path, ref)
+			}
+			if visited[ref] {
+				continue
+			}
+			visited[ref] = true
+			if cycle := visit(append(path, ref), visited); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit([]string{name}, map[string]bool{})
+}
+
 func (c *Composition) validateResources() (errs field.ErrorList) {
 	if err := c.validateResourceNames(); err != nil {
 		errs = append(errs, err...)
 	}
+	if err := c.validateResourceDependencies(); err != nil {
+		errs = append(errs, err...)
+	}
 	for i, res := range c.Spec.Resources {
 		for j, patch := range res.Patches {
 			if err := patch.Validate(); err != nil {
@@ -104,38 +158,91 @@ func (c *Composition) validateResources() (errs field.ErrorList) {
 				errs = append(errs, verrors.WrapFieldError(err, field.NewPath("spec", "resources").Index(i).Child("readinessChecks").Index(j)))
 			}
 		}
+		for j, cd := range res.ConnectionDetails {
+			for k, t := range cd.Transforms {
+				if err := t.Validate(); err != nil {
+					errs = append(errs, verrors.WrapFieldError(err, field.NewPath("spec", "resources").Index(i).Child("connectionDetails").Index(j).Child("transforms").Index(k)))
+				}
+			}
+		}
+		for j, sc := range res.StatusFromComposed {
+			if err := sc.Validate(); err != nil {
+				errs = append(errs, verrors.WrapFieldError(err, field.NewPath("spec", "resources").Index(i).Child("statusFromComposed").Index(j)))
+			}
+		}
 		// TODO(phisco): we should validate also ConnectionDetails, but would need a major refactoring
 	}
 	return errs
 }
 
+// validateResourceDependencies checks that resources only depend on other
+// resources that exist, and that their dependencies aren't cyclic.
+func (c *Composition) validateResourceDependencies() (errs field.ErrorList) {
+	names := map[string]bool{}
+	for _, res := range c.Spec.Resources {
+		if name := res.GetName(); name != "" {
+			names[name] = true
+		}
+	}
+	for i, res := range c.Spec.Resources {
+		for j, d := range res.DependsOn {
+			p := field.NewPath("spec", "resources").Index(i).Child("dependsOn").Index(j)
+			switch {
+			case d == res.GetName():
+				errs = append(errs, field.Invalid(p, d, "a resource cannot depend on itself"))
+			case !names[d]:
+				errs = append(errs, field.Invalid(p, d, "dependsOn must name a resource of this composition"))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// Repeatedly resolve resources whose dependencies are all resolved. Any
+	// resources that can't be resolved are part of, or depend on, a cycle.
+	resolved := map[string]bool{}
+	for progress := true; progress; {
+		progress = false
+		for _, res := range c.Spec.Resources {
+			name := res.GetName()
+			if name == "" || resolved[name] {
+				continue
+			}
+			ready := true
+			for _, d := range res.DependsOn {
+				ready = ready && resolved[d]
+			}
+			if ready {
+				resolved[name] = true
+				progress = true
+			}
+		}
+	}
+	for i, res := range c.Spec.Resources {
+		if name := res.GetName(); name != "" && !resolved[name] {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "resources").Index(i).Child("dependsOn"), res.DependsOn, "resource is part of, or depends on, a dependency cycle"))
+		}
+	}
+	return errs
+}
+
 // validateResourceNames checks that:
-//  1. Either all resources have a name or they are all anonymous: because if some but not all templates are named it's
-//     safest to refuse to operate. We don't have enough information to use the named composer, but using the anonymous
-//     composer may be surprising. There's a risk that someone added a new anonymous template to a Composition that
-//     otherwise uses named templates. If they added the new template to the beginning or middle of the resources array
-//     using the anonymous composer would be destructive, because it assumes template N always corresponds to existing
-//     template N.
-//  2. All resources have unique names: because other parts of the code require so.
-//  3. If the composition has any functions, it must have only named resources: This is necessary for the
+//  1. All resources have unique names: because other parts of the code require so.
+//  2. If the composition has any functions, it must have only named resources: This is necessary for the
 //     FunctionComposer to be able to associate entries in the spec.resources array with entries in a FunctionIO's observed
 //     and desired arrays
+//
+// Named and anonymous resources may be mixed. Composed resources are associated with named resources by name, and
+// with anonymous resources by order, so only the positions of anonymous resources must not change.
 func (c *Composition) validateResourceNames() (errs field.ErrorList) {
 	seen := map[string]bool{}
 	for resourceIndex, res := range c.Spec.Resources {
-		// Check that all resources have a name and that it is unique.
-		// If the composition has any functions, it must have only named resources.
 		name := res.GetName()
 		if name == "" {
 			// If the composition has any functions, it must have only named resources.
 			if len(c.Spec.Functions) != 0 {
 				errs = append(errs, field.Required(field.NewPath("spec", "resources").Index(resourceIndex).Child("name"), "cannot have anonymous resources when composition has functions"))
-				continue
-			}
-			// If it's not the first resource, and all previous one were named, then this is an error.
-			if resourceIndex != 0 && len(seen) != 0 {
-				errs = append(errs, field.Required(field.NewPath("spec", "resources").Index(resourceIndex).Child("name"), "cannot mix named and anonymous resources, all resources must have a name or none must have a name"))
-				continue
 			}
 			continue
 		}
@@ -144,11 +251,6 @@ func (c *Composition) validateResourceNames() (errs field.ErrorList) {
 			errs = append(errs, field.Duplicate(field.NewPath("spec", "resources").Index(resourceIndex).Child("name"), name))
 			continue
 		}
-		// If it's not the first resource, and all previous one were anonymous, then this is an error.
-		if resourceIndex != 0 && len(seen) == 0 {
-			errs = append(errs, field.Invalid(field.NewPath("spec", "resources").Index(resourceIndex).Child("name"), name, "cannot mix named and anonymous resources, all resources must have a name or none must have a name"))
-			continue
-		}
 		seen[name] = true
 	}
 	return errs
@@ -164,3 +266,20 @@ func (c *Composition) validateEnvironment() field.ErrorList {
 	}
 	return nil
 }
+
+// validateConnectionDetails checks that the connection details derived from
+// the composite resource are logically valid.
+func (c *Composition) validateConnectionDetails() (errs field.ErrorList) {
+	for i, cd := range c.Spec.ConnectionDetails {
+		p := field.NewPath("spec", "connectionDetails").Index(i)
+		if (cd.Type != nil && *cd.Type == ConnectionDetailTypeFromConnectionSecretKey) || (cd.Type == nil && cd.Value == nil && cd.FromConnectionSecretKey != nil) {
+			errs = append(errs, field.Invalid(p.Child("type"), ConnectionDetailTypeFromConnectionSecretKey, "connection details derived from the composite resource cannot be of type FromConnectionSecretKey"))
+		}
+		for j, t := range cd.Transforms {
+			if err := t.Validate(); err != nil {
+				errs = append(errs, verrors.WrapFieldError(err, p.Child("transforms").Index(j)))
+			}
+		}
+	}
+	return errs
+}
@@ -71,8 +71,8 @@ func TestCompositionValidateResourceName(t *testing.T) {
 				},
 			},
 		},
-		"InvalidMixedNamesExpectingAnonymous": {
-			reason: "starting with anonymous resources and mixing named resources is invalid",
+		"ValidMixedNamesStartingAnonymous": {
+			reason: "starting with anonymous resources and mixing named resources is valid",
 			args: args{
 				spec: CompositionSpec{
 					Resources: []ComposedTemplate{
@@ -81,32 +81,35 @@ func TestCompositionValidateResourceName(t *testing.T) {
 					},
 				},
 			},
-			want: want{
-				output: field.ErrorList{
-					{
-						Type:     field.ErrorTypeInvalid,
-						Field:    "spec.resources[1].name",
-						BadValue: "bar",
+		},
+		"ValidMixedNamesStartingNamed": {
+			reason: "starting with named resources and mixing anonymous resources is valid",
+			args: args{
+				spec: CompositionSpec{
+					Resources: []ComposedTemplate{
+						{Name: pointer.String("bar")},
+						{},
 					},
 				},
 			},
 		},
-		"InvalidMixedNamesExpectingNamed": {
-			reason: "starting with named resources and mixing anonymous resources is invalid",
+		"InvalidMixedNamesDuplicate": {
+			reason: "mixing named and anonymous resources doesn't allow duplicate names",
 			args: args{
 				spec: CompositionSpec{
 					Resources: []ComposedTemplate{
 						{Name: pointer.String("bar")},
 						{},
+						{Name: pointer.String("bar")},
 					},
 				},
 			},
 			want: want{
 				output: field.ErrorList{
 					{
-						Type:     field.ErrorTypeRequired,
-						Field:    "spec.resources[1].name",
-						BadValue: "",
+						Type:     field.ErrorTypeDuplicate,
+						Field:    "spec.resources[2].name",
+						BadValue: "bar",
 					},
 				},
 			},
@@ -239,7 +242,7 @@ func TestCompositionValidatePatchSets(t *testing.T) {
 			},
 		},
 		"InvalidNestedPatchSets": {
-			reason: "patchSets with nested patchSets should be invalid",
+			reason: "patchSets with nested patchSets that don't name a patchSet should be invalid",
 			args: args{
 				comp: &Composition{
 					Spec: CompositionSpec{
@@ -256,11 +259,94 @@ func TestCompositionValidatePatchSets(t *testing.T) {
 					},
 				},
 			},
+			want: want{
+				output: field.ErrorList{
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "spec.patchSets[0].patches[0].patchSetName",
+					},
+				},
+			},
+		},
+		"ValidNestedPatchSets": {
+			reason: "patchSets may nest other patchSets",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						PatchSets: []PatchSet{
+							{
+								Name: "foo",
+								Patches: []Patch{
+									{Type: PatchTypePatchSet, PatchSetName: pointer.String("bar")},
+								},
+							},
+							{
+								Name: "bar",
+								Patches: []Patch{
+									{FromFieldPath: pointer.String("spec.bar")},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"InvalidNestedUndefinedPatchSet": {
+			reason: "patchSets that nest an undefined patchSet should be invalid",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						PatchSets: []PatchSet{
+							{
+								Name: "foo",
+								Patches: []Patch{
+									{Type: PatchTypePatchSet, PatchSetName: pointer.String("bar")},
+								},
+							},
+						},
+					},
+				},
+			},
 			want: want{
 				output: field.ErrorList{
 					{
 						Type:  field.ErrorTypeInvalid,
-						Field: "spec.patchSets[0].patches[0].type",
+						Field: "spec.patchSets[0].patches[0].patchSetName",
+					},
+				},
+			},
+		},
+		"InvalidCyclicPatchSets": {
+			reason: "patchSets that reference each other should be invalid",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						PatchSets: []PatchSet{
+							{
+								Name: "foo",
+								Patches: []Patch{
+									{Type: PatchTypePatchSet, PatchSetName: pointer.String("bar")},
+								},
+							},
+							{
+								Name: "bar",
+								Patches: []Patch{
+									{Type: PatchTypePatchSet, PatchSetName: pointer.String("foo")},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				output: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "spec.patchSets[0]",
+					},
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "spec.patchSets[1]",
 					},
 				},
 			},
@@ -553,8 +639,8 @@ func TestCompositionValidateResources(t *testing.T) {
 				},
 			},
 		},
-		"InvalidComplexNamedResourcesDueToNameMixing": {
-			reason: "complex resources mixing named and anonymous resources should be invalid",
+		"ValidComplexMixedResources": {
+			reason: "complex resources mixing named and anonymous resources should be valid",
 			args: args{
 				comp: &Composition{
 					Spec: CompositionSpec{
@@ -574,15 +660,6 @@ func TestCompositionValidateResources(t *testing.T) {
 					},
 				},
 			},
-			want: want{
-				output: field.ErrorList{
-					{
-						Type:     field.ErrorTypeRequired,
-						Field:    "spec.resources[1].name",
-						BadValue: "",
-					},
-				},
-			},
 		},
 		"InvalidComplexResource": {
 			reason: "complex resource with invalid patches and readiness checks should be invalid",
@@ -611,11 +688,6 @@ func TestCompositionValidateResources(t *testing.T) {
 			},
 			want: want{
 				output: field.ErrorList{
-					{
-						Type:     field.ErrorTypeInvalid,
-						Field:    "spec.resources[1].name",
-						BadValue: "foo",
-					},
 					{
 						Type:     field.ErrorTypeRequired,
 						Field:    "spec.resources[1].patches[0].fromFieldPath",
@@ -629,6 +701,62 @@ func TestCompositionValidateResources(t *testing.T) {
 				},
 			},
 		},
+		"InvalidConnectionDetailTransform": {
+			reason: "resource with an invalid connection detail transform should be invalid",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						Resources: []ComposedTemplate{
+							{
+								Name: pointer.String("foo"),
+								ConnectionDetails: []ConnectionDetail{
+									{
+										Name:                    pointer.String("port"),
+										FromConnectionSecretKey: pointer.String("port"),
+										Transforms: []Transform{
+											{
+												Type: TransformTypeConvert,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				output: field.ErrorList{
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "spec.resources[0].connectionDetails[0].transforms[0].convert",
+					},
+				},
+			},
+		},
+		"InvalidStatusFromComposed": {
+			reason: "resource with a status field to copy but no field path should be invalid",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						Resources: []ComposedTemplate{
+							{
+								Name:               pointer.String("foo"),
+								StatusFromComposed: []StatusFromComposed{{}},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				output: field.ErrorList{
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "spec.resources[0].statusFromComposed[0].fromFieldPath",
+					},
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -856,3 +984,260 @@ func TestCompositionValidateEnvironment(t *testing.T) {
 		})
 	}
 }
+
+func TestCompositionValidateConnectionDetails(t *testing.T) {
+	type args struct {
+		comp *Composition
+	}
+	type want struct {
+		output field.ErrorList
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Valid": {
+			reason: "Should accept connection details derived from the composite resource's fields and annotations",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						ConnectionDetails: []ConnectionDetail{
+							{Name: pointer.String("endpoint"), FromFieldPath: pointer.String("spec.endpoint")},
+							{Name: pointer.String("region"), FromAnnotation: pointer.String("example.org/region")},
+							{Name: pointer.String("port"), Value: pointer.String("5432")},
+						},
+					},
+				},
+			},
+		},
+		"InvalidFromConnectionSecretKey": {
+			reason: "Should reject connection details derived from a connection secret key",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						ConnectionDetails: []ConnectionDetail{
+							{FromConnectionSecretKey: pointer.String("password")},
+						},
+					},
+				},
+			},
+			want: want{
+				output: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "spec.connectionDetails[0].type",
+					},
+				},
+			},
+		},
+		"InvalidTransform": {
+			reason: "Should reject connection details with an invalid transform",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						ConnectionDetails: []ConnectionDetail{
+							{
+								Name:          pointer.String("port"),
+								FromFieldPath: pointer.String("spec.port"),
+								Transforms:    []Transform{{Type: TransformTypeConvert}},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				output: field.ErrorList{
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "spec.connectionDetails[0].transforms[0].convert",
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gotErrs := tc.args.comp.validateConnectionDetails()
+			if diff := cmp.Diff(tc.want.output, gotErrs, sortFieldErrors(), cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nvalidateConnectionDetails(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCompositionValidateResourceDependencies(t *testing.T) {
+	type args struct {
+		comp *Composition
+	}
+	type want struct {
+		output field.ErrorList
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Valid": {
+			reason: "Should accept resources that depend on other resources of the composition",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						Resources: []ComposedTemplate{
+							{Name: pointer.String("database"), DependsOn: []string{"subnet"}},
+							{Name: pointer.String("subnet")},
+						},
+					},
+				},
+			},
+		},
+		"InvalidUnknownDependency": {
+			reason: "Should reject resources that depend on a resource the composition doesn't have",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						Resources: []ComposedTemplate{
+							{Name: pointer.String("database"), DependsOn: []string{"network"}},
+							{Name: pointer.String("subnet")},
+						},
+					},
+				},
+			},
+			want: want{
+				output: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "spec.resources[0].dependsOn[0]",
+					},
+				},
+			},
+		},
+		"InvalidSelfDependency": {
+			reason: "Should reject resources that depend on themselves",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						Resources: []ComposedTemplate{
+							{Name: pointer.String("database"), DependsOn: []string{"database"}},
+						},
+					},
+				},
+			},
+			want: want{
+				output: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "spec.resources[0].dependsOn[0]",
+					},
+				},
+			},
+		},
+		"InvalidCycle": {
+			reason: "Should reject resources that are part of, or depend on, a dependency cycle",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						Resources: []ComposedTemplate{
+							{Name: pointer.String("database"), DependsOn: []string{"subnet"}},
+							{Name: pointer.String("subnet"), DependsOn: []string{"network"}},
+							{Name: pointer.String("network"), DependsOn: []string{"subnet"}},
+							{Name: pointer.String("vpc")},
+						},
+					},
+				},
+			},
+			want: want{
+				output: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "spec.resources[0].dependsOn",
+					},
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "spec.resources[1].dependsOn",
+					},
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "spec.resources[2].dependsOn",
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gotErrs := tc.args.comp.validateResourceDependencies()
+			if diff := cmp.Diff(tc.want.output, gotErrs, sortFieldErrors(), cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nvalidateResourceDependencies(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCompositionValidateFeatureFlags(t *testing.T) {
+	type args struct {
+		comp *Composition
+	}
+	type want struct {
+		warns []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoFlags": {
+			reason: "Should not warn about a Composition without feature flags",
+			args: args{
+				comp: &Composition{},
+			},
+		},
+		"KnownFlags": {
+			reason: "Should not warn about known feature flags, whether they're enabled or disabled",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						FeatureFlags: map[string]bool{
+							CompositionFeatureDriftDetection:      true,
+							CompositionFeatureConcurrentRendering: false,
+						},
+					},
+				},
+			},
+		},
+		"UnknownFlags": {
+			reason: "Should warn about each unknown feature flag, in a stable order",
+			args: args{
+				comp: &Composition{
+					Spec: CompositionSpec{
+						FeatureFlags: map[string]bool{
+							"CoolNewFeature":                 true,
+							"AnotherFeature":                 false,
+							CompositionFeatureDriftDetection: true,
+						},
+					},
+				},
+			},
+			want: want{
+				warns: []string{
+					`spec.featureFlags: ignoring unknown Composition feature flag "AnotherFeature"`,
+					`spec.featureFlags: ignoring unknown Composition feature flag "CoolNewFeature"`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			warns, _ := tc.args.comp.Validate()
+			if diff := cmp.Diff(tc.want.warns, warns); diff != "" {
+				t.Errorf("%s\nValidate(...): -want warnings, +got warnings:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -41,6 +41,19 @@ func (c *GeneratedRevisionSpecConverter) FromRevisionSpec(source CompositionRevi
 		}
 	}
 	v1CompositionSpec.Functions = v1FunctionList
+	mapStringBool := make(map[string]bool, len(source.FeatureFlags))
+	for key, value := range source.FeatureFlags {
+		mapStringBool[key] = value
+	}
+	v1CompositionSpec.FeatureFlags = mapStringBool
+	var v1ConnectionDetailList []ConnectionDetail
+	if source.ConnectionDetails != nil {
+		v1ConnectionDetailList = make([]ConnectionDetail, len(source.ConnectionDetails))
+		for l := 0; l < len(source.ConnectionDetails); l++ {
+			v1ConnectionDetailList[l] = c.v1ConnectionDetailToV1ConnectionDetail(source.ConnectionDetails[l])
+		}
+	}
+	v1CompositionSpec.ConnectionDetails = v1ConnectionDetailList
 	var pString *string
 	if source.WriteConnectionSecretsToNamespace != nil {
 		xstring := *source.WriteConnectionSecretsToNamespace
@@ -78,6 +91,19 @@ func (c *GeneratedRevisionSpecConverter) ToRevisionSpec(source CompositionSpec)
 		}
 	}
 	v1CompositionRevisionSpec.Functions = v1FunctionList
+	mapStringBool := make(map[string]bool, len(source.FeatureFlags))
+	for key, value := range source.FeatureFlags {
+		mapStringBool[key] = value
+	}
+	v1CompositionRevisionSpec.FeatureFlags = mapStringBool
+	var v1ConnectionDetailList []ConnectionDetail
+	if source.ConnectionDetails != nil {
+		v1ConnectionDetailList = make([]ConnectionDetail, len(source.ConnectionDetails))
+		for l := 0; l < len(source.ConnectionDetails); l++ {
+			v1ConnectionDetailList[l] = c.v1ConnectionDetailToV1ConnectionDetail(source.ConnectionDetails[l])
+		}
+	}
+	v1CompositionRevisionSpec.ConnectionDetails = v1ConnectionDetailList
 	var pString *string
 	if source.WriteConnectionSecretsToNamespace != nil {
 		xstring := *source.WriteConnectionSecretsToNamespace
@@ -109,10 +135,25 @@ func (c *GeneratedRevisionSpecConverter) pV1CombineToPV1Combine(source *Combine)
 		v1Combine.Variables = v1CombineVariableList
 		v1Combine.Strategy = CombineStrategy((*source).Strategy)
 		v1Combine.String = c.pV1StringCombineToPV1StringCombine((*source).String)
+		var pV1CombineMissingVariablePolicy *CombineMissingVariablePolicy
+		if (*source).MissingVariablePolicy != nil {
+			v1CombineMissingVariablePolicy := CombineMissingVariablePolicy(*(*source).MissingVariablePolicy)
+			pV1CombineMissingVariablePolicy = &v1CombineMissingVariablePolicy
+		}
+		v1Combine.MissingVariablePolicy = pV1CombineMissingVariablePolicy
 		pV1Combine = &v1Combine
 	}
 	return pV1Combine
 }
+func (c *GeneratedRevisionSpecConverter) pV1ConnectionStringTransformToPV1ConnectionStringTransform(source *ConnectionStringTransform) *ConnectionStringTransform {
+	var pV1ConnectionStringTransform *ConnectionStringTransform
+	if source != nil {
+		var v1ConnectionStringTransform ConnectionStringTransform
+		v1ConnectionStringTransform.Scheme = (*source).Scheme
+		pV1ConnectionStringTransform = &v1ConnectionStringTransform
+	}
+	return pV1ConnectionStringTransform
+}
 func (c *GeneratedRevisionSpecConverter) pV1ContainerFunctionNetworkToPV1ContainerFunctionNetwork(source *ContainerFunctionNetwork) *ContainerFunctionNetwork {
 	var pV1ContainerFunctionNetwork *ContainerFunctionNetwork
 	if source != nil {
@@ -211,6 +252,22 @@ func (c *GeneratedRevisionSpecConverter) pV1DurationToPV1Duration(source *v11.Du
 	}
 	return pV1Duration
 }
+func (c *GeneratedRevisionSpecConverter) pV1EachElementPatchToPV1EachElementPatch(source *EachElementPatch) *EachElementPatch {
+	var pV1EachElementPatch *EachElementPatch
+	if source != nil {
+		var v1EachElementPatch EachElementPatch
+		var v1ElementPatchList []ElementPatch
+		if (*source).Patches != nil {
+			v1ElementPatchList = make([]ElementPatch, len((*source).Patches))
+			for i := 0; i < len((*source).Patches); i++ {
+				v1ElementPatchList[i] = c.v1ElementPatchToV1ElementPatch((*source).Patches[i])
+			}
+		}
+		v1EachElementPatch.Patches = v1ElementPatchList
+		pV1EachElementPatch = &v1EachElementPatch
+	}
+	return pV1EachElementPatch
+}
 func (c *GeneratedRevisionSpecConverter) pV1EnvironmentConfigurationToPV1EnvironmentConfiguration(source *EnvironmentConfiguration) *EnvironmentConfiguration {
 	var pV1EnvironmentConfiguration *EnvironmentConfiguration
 	if source != nil {
@@ -228,11 +285,19 @@ func (c *GeneratedRevisionSpecConverter) pV1EnvironmentConfigurationToPV1Environ
 			}
 		}
 		v1EnvironmentConfiguration.EnvironmentConfigs = v1EnvironmentSourceList
+		var v1EnvironmentConfigMapSourceList []EnvironmentConfigMapSource
+		if (*source).ConfigMaps != nil {
+			v1EnvironmentConfigMapSourceList = make([]EnvironmentConfigMapSource, len((*source).ConfigMaps))
+			for j := 0; j < len((*source).ConfigMaps); j++ {
+				v1EnvironmentConfigMapSourceList[j] = c.v1EnvironmentConfigMapSourceToV1EnvironmentConfigMapSource((*source).ConfigMaps[j])
+			}
+		}
+		v1EnvironmentConfiguration.ConfigMaps = v1EnvironmentConfigMapSourceList
 		var v1EnvironmentPatchList []EnvironmentPatch
 		if (*source).Patches != nil {
 			v1EnvironmentPatchList = make([]EnvironmentPatch, len((*source).Patches))
-			for j := 0; j < len((*source).Patches); j++ {
-				v1EnvironmentPatchList[j] = c.v1EnvironmentPatchToV1EnvironmentPatch((*source).Patches[j])
+			for k := 0; k < len((*source).Patches); k++ {
+				v1EnvironmentPatchList[k] = c.v1EnvironmentPatchToV1EnvironmentPatch((*source).Patches[k])
 			}
 		}
 		v1EnvironmentConfiguration.Patches = v1EnvironmentPatchList
@@ -274,6 +339,30 @@ func (c *GeneratedRevisionSpecConverter) pV1EnvironmentSourceSelectorToPV1Enviro
 	}
 	return pV1EnvironmentSourceSelector
 }
+func (c *GeneratedRevisionSpecConverter) pV1HashRingTransformToPV1HashRingTransform(source *HashRingTransform) *HashRingTransform {
+	var pV1HashRingTransform *HashRingTransform
+	if source != nil {
+		var v1HashRingTransform HashRingTransform
+		var v1HashRingBucketList []HashRingBucket
+		if (*source).Buckets != nil {
+			v1HashRingBucketList = make([]HashRingBucket, len((*source).Buckets))
+			for i := 0; i < len((*source).Buckets); i++ {
+				v1HashRingBucketList[i] = c.v1HashRingBucketToV1HashRingBucket((*source).Buckets[i])
+			}
+		}
+		v1HashRingTransform.Buckets = v1HashRingBucketList
+		pV1HashRingTransform = &v1HashRingTransform
+	}
+	return pV1HashRingTransform
+}
+func (c *GeneratedRevisionSpecConverter) pV1JSONToPV1JSON(source *v12.JSON) *v12.JSON {
+	var pV1JSON *v12.JSON
+	if source != nil {
+		v1JSON := c.v1JSONToV1JSON((*source))
+		pV1JSON = &v1JSON
+	}
+	return pV1JSON
+}
 func (c *GeneratedRevisionSpecConverter) pV1MapTransformToPV1MapTransform(source *MapTransform) *MapTransform {
 	var pV1MapTransform *MapTransform
 	if source != nil {
@@ -293,6 +382,7 @@ func (c *GeneratedRevisionSpecConverter) pV1MatchConditionReadinessCheckToPV1Mat
 		var v1MatchConditionReadinessCheck MatchConditionReadinessCheck
 		v1MatchConditionReadinessCheck.Type = v13.ConditionType((*source).Type)
 		v1MatchConditionReadinessCheck.Status = v1.ConditionStatus((*source).Status)
+		v1MatchConditionReadinessCheck.Reason = v13.ConditionReason((*source).Reason)
 		pV1MatchConditionReadinessCheck = &v1MatchConditionReadinessCheck
 	}
 	return pV1MatchConditionReadinessCheck
@@ -327,17 +417,23 @@ func (c *GeneratedRevisionSpecConverter) pV1MathTransformToPV1MathTransform(sour
 		}
 		v1MathTransform.Multiply = pInt64
 		var pInt642 *int64
-		if (*source).ClampMin != nil {
-			xint642 := *(*source).ClampMin
+		if (*source).Offset != nil {
+			xint642 := *(*source).Offset
 			pInt642 = &xint642
 		}
-		v1MathTransform.ClampMin = pInt642
+		v1MathTransform.Offset = pInt642
 		var pInt643 *int64
-		if (*source).ClampMax != nil {
-			xint643 := *(*source).ClampMax
+		if (*source).ClampMin != nil {
+			xint643 := *(*source).ClampMin
 			pInt643 = &xint643
 		}
-		v1MathTransform.ClampMax = pInt643
+		v1MathTransform.ClampMin = pInt643
+		var pInt644 *int64
+		if (*source).ClampMax != nil {
+			xint644 := *(*source).ClampMax
+			pInt644 = &xint644
+		}
+		v1MathTransform.ClampMax = pInt644
 		pV1MathTransform = &v1MathTransform
 	}
 	return pV1MathTransform
@@ -397,6 +493,22 @@ func (c *GeneratedRevisionSpecConverter) pV1PolicyToPV1Policy(source *v13.Policy
 	}
 	return pV1Policy
 }
+func (c *GeneratedRevisionSpecConverter) pV1SkipConditionToPV1SkipCondition(source *SkipCondition) *SkipCondition {
+	var pV1SkipCondition *SkipCondition
+	if source != nil {
+		var v1SkipCondition SkipCondition
+		v1SkipCondition.FromFieldPath = (*source).FromFieldPath
+		v1SkipCondition.Equals = c.v1JSONToV1JSON((*source).Equals)
+		var pBool *bool
+		if (*source).GarbageCollect != nil {
+			xbool := *(*source).GarbageCollect
+			pBool = &xbool
+		}
+		v1SkipCondition.GarbageCollect = pBool
+		pV1SkipCondition = &v1SkipCondition
+	}
+	return pV1SkipCondition
+}
 func (c *GeneratedRevisionSpecConverter) pV1StoreConfigReferenceToPV1StoreConfigReference(source *StoreConfigReference) *StoreConfigReference {
 	var pV1StoreConfigReference *StoreConfigReference
 	if source != nil {
@@ -411,6 +523,12 @@ func (c *GeneratedRevisionSpecConverter) pV1StringCombineToPV1StringCombine(sour
 	if source != nil {
 		var v1StringCombine StringCombine
 		v1StringCombine.Format = (*source).Format
+		var pString *string
+		if (*source).Separator != nil {
+			xstring := *(*source).Separator
+			pString = &xstring
+		}
+		v1StringCombine.Separator = pString
 		pV1StringCombine = &v1StringCombine
 	}
 	return pV1StringCombine
@@ -454,6 +572,14 @@ func (c *GeneratedRevisionSpecConverter) pV1StringTransformToPV1StringTransform(
 		}
 		v1StringTransform.Trim = pString2
 		v1StringTransform.Regexp = c.pV1StringTransformRegexpToPV1StringTransformRegexp((*source).Regexp)
+		var stringList []string
+		if (*source).Enum != nil {
+			stringList = make([]string, len((*source).Enum))
+			for i := 0; i < len((*source).Enum); i++ {
+				stringList[i] = (*source).Enum[i]
+			}
+		}
+		v1StringTransform.Enum = stringList
 		pV1StringTransform = &v1StringTransform
 	}
 	return pV1StringTransform
@@ -488,14 +614,74 @@ func (c *GeneratedRevisionSpecConverter) v1ComposedTemplateToV1ComposedTemplate(
 		}
 	}
 	v1ComposedTemplate.ConnectionDetails = v1ConnectionDetailList
+	var pString2 *string
+	if source.ConnectionDetailsPrefix != nil {
+		xstring2 := *source.ConnectionDetailsPrefix
+		pString2 = &xstring2
+	}
+	v1ComposedTemplate.ConnectionDetailsPrefix = pString2
+	var pString3 *string
+	if source.WriteConnectionSecretToNamespace != nil {
+		xstring3 := *source.WriteConnectionSecretToNamespace
+		pString3 = &xstring3
+	}
+	v1ComposedTemplate.WriteConnectionSecretToNamespace = pString3
+	var v1StatusFromComposedList []StatusFromComposed
+	if source.StatusFromComposed != nil {
+		v1StatusFromComposedList = make([]StatusFromComposed, len(source.StatusFromComposed))
+		for k := 0; k < len(source.StatusFromComposed); k++ {
+			v1StatusFromComposedList[k] = c.v1StatusFromComposedToV1StatusFromComposed(source.StatusFromComposed[k])
+		}
+	}
+	v1ComposedTemplate.StatusFromComposed = v1StatusFromComposedList
 	var v1ReadinessCheckList []ReadinessCheck
 	if source.ReadinessChecks != nil {
 		v1ReadinessCheckList = make([]ReadinessCheck, len(source.ReadinessChecks))
-		for k := 0; k < len(source.ReadinessChecks); k++ {
-			v1ReadinessCheckList[k] = c.v1ReadinessCheckToV1ReadinessCheck(source.ReadinessChecks[k])
+		for l := 0; l < len(source.ReadinessChecks); l++ {
+			v1ReadinessCheckList[l] = c.v1ReadinessCheckToV1ReadinessCheck(source.ReadinessChecks[l])
 		}
 	}
 	v1ComposedTemplate.ReadinessChecks = v1ReadinessCheckList
+	v1ComposedTemplate.CreationDeadline = c.pV1DurationToPV1Duration(source.CreationDeadline)
+	var pV1ConflictPolicy *ConflictPolicy
+	if source.ConflictPolicy != nil {
+		v1ConflictPolicy := ConflictPolicy(*source.ConflictPolicy)
+		pV1ConflictPolicy = &v1ConflictPolicy
+	}
+	v1ComposedTemplate.ConflictPolicy = pV1ConflictPolicy
+	var pString4 *string
+	if source.FieldManager != nil {
+		xstring4 := *source.FieldManager
+		pString4 = &xstring4
+	}
+	v1ComposedTemplate.FieldManager = pString4
+	v1ComposedTemplate.SkipIf = c.pV1SkipConditionToPV1SkipCondition(source.SkipIf)
+	var pBool *bool
+	if source.OptionalForReadiness != nil {
+		xbool := *source.OptionalForReadiness
+		pBool = &xbool
+	}
+	v1ComposedTemplate.OptionalForReadiness = pBool
+	var pV1GarbageCollectionPolicy *GarbageCollectionPolicy
+	if source.GarbageCollectionPolicy != nil {
+		v1GarbageCollectionPolicy := GarbageCollectionPolicy(*source.GarbageCollectionPolicy)
+		pV1GarbageCollectionPolicy = &v1GarbageCollectionPolicy
+	}
+	v1ComposedTemplate.GarbageCollectionPolicy = pV1GarbageCollectionPolicy
+	var pV1ManagementPolicy *ManagementPolicy
+	if source.ManagementPolicy != nil {
+		v1ManagementPolicy := ManagementPolicy(*source.ManagementPolicy)
+		pV1ManagementPolicy = &v1ManagementPolicy
+	}
+	v1ComposedTemplate.ManagementPolicy = pV1ManagementPolicy
+	var stringList []string
+	if source.DependsOn != nil {
+		stringList = make([]string, len(source.DependsOn))
+		for m := 0; m < len(source.DependsOn); m++ {
+			stringList[m] = source.DependsOn[m]
+		}
+	}
+	v1ComposedTemplate.DependsOn = stringList
 	return v1ComposedTemplate
 }
 func (c *GeneratedRevisionSpecConverter) v1ConnectionDetailToV1ConnectionDetail(source ConnectionDetail) ConnectionDetail {
@@ -525,13 +711,78 @@ func (c *GeneratedRevisionSpecConverter) v1ConnectionDetailToV1ConnectionDetail(
 	}
 	v1ConnectionDetail.FromFieldPath = pString3
 	var pString4 *string
-	if source.Value != nil {
-		xstring4 := *source.Value
+	if source.FromAnnotation != nil {
+		xstring4 := *source.FromAnnotation
 		pString4 = &xstring4
 	}
-	v1ConnectionDetail.Value = pString4
+	v1ConnectionDetail.FromAnnotation = pString4
+	var pV1FromFieldPathPolicy *FromFieldPathPolicy
+	if source.Policy != nil {
+		v1FromFieldPathPolicy := FromFieldPathPolicy(*source.Policy)
+		pV1FromFieldPathPolicy = &v1FromFieldPathPolicy
+	}
+	v1ConnectionDetail.Policy = pV1FromFieldPathPolicy
+	var pBool *bool
+	if source.Optional != nil {
+		xbool := *source.Optional
+		pBool = &xbool
+	}
+	v1ConnectionDetail.Optional = pBool
+	var v1TransformList []Transform
+	if source.Transforms != nil {
+		v1TransformList = make([]Transform, len(source.Transforms))
+		for i := 0; i < len(source.Transforms); i++ {
+			v1TransformList[i] = c.v1TransformToV1Transform(source.Transforms[i])
+		}
+	}
+	v1ConnectionDetail.Transforms = v1TransformList
+	var pString5 *string
+	if source.Value != nil {
+		xstring5 := *source.Value
+		pString5 = &xstring5
+	}
+	v1ConnectionDetail.Value = pString5
 	return v1ConnectionDetail
 }
+func (c *GeneratedRevisionSpecConverter) v1ElementPatchToV1ElementPatch(source ElementPatch) ElementPatch {
+	var v1ElementPatch ElementPatch
+	v1ElementPatch.FromFieldPath = source.FromFieldPath
+	var pString *string
+	if source.ToFieldPath != nil {
+		xstring := *source.ToFieldPath
+		pString = &xstring
+	}
+	v1ElementPatch.ToFieldPath = pString
+	var v1TransformList []Transform
+	if source.Transforms != nil {
+		v1TransformList = make([]Transform, len(source.Transforms))
+		for i := 0; i < len(source.Transforms); i++ {
+			v1TransformList[i] = c.v1TransformToV1Transform(source.Transforms[i])
+		}
+	}
+	v1ElementPatch.Transforms = v1TransformList
+	return v1ElementPatch
+}
+func (c *GeneratedRevisionSpecConverter) v1EnvironmentConfigMapSourceToV1EnvironmentConfigMapSource(source EnvironmentConfigMapSource) EnvironmentConfigMapSource {
+	var v1EnvironmentConfigMapSource EnvironmentConfigMapSource
+	v1EnvironmentConfigMapSource.Namespace = source.Namespace
+	v1EnvironmentConfigMapSource.Name = source.Name
+	var stringList []string
+	if source.Keys != nil {
+		stringList = make([]string, len(source.Keys))
+		for i := 0; i < len(source.Keys); i++ {
+			stringList[i] = source.Keys[i]
+		}
+	}
+	v1EnvironmentConfigMapSource.Keys = stringList
+	var pV1ResolutionPolicy *v13.ResolutionPolicy
+	if source.Resolution != nil {
+		v1ResolutionPolicy := v13.ResolutionPolicy(*source.Resolution)
+		pV1ResolutionPolicy = &v1ResolutionPolicy
+	}
+	v1EnvironmentConfigMapSource.Resolution = pV1ResolutionPolicy
+	return v1EnvironmentConfigMapSource
+}
 func (c *GeneratedRevisionSpecConverter) v1EnvironmentPatchToV1EnvironmentPatch(source EnvironmentPatch) EnvironmentPatch {
 	var v1EnvironmentPatch EnvironmentPatch
 	v1EnvironmentPatch.Type = PatchType(source.Type)
@@ -598,6 +849,17 @@ func (c *GeneratedRevisionSpecConverter) v1FunctionToV1Function(source Function)
 	v1Function.Container = c.pV1ContainerFunctionToPV1ContainerFunction(source.Container)
 	return v1Function
 }
+func (c *GeneratedRevisionSpecConverter) v1HashRingBucketToV1HashRingBucket(source HashRingBucket) HashRingBucket {
+	var v1HashRingBucket HashRingBucket
+	v1HashRingBucket.Name = source.Name
+	var pInt64 *int64
+	if source.Weight != nil {
+		xint64 := *source.Weight
+		pInt64 = &xint64
+	}
+	v1HashRingBucket.Weight = pInt64
+	return v1HashRingBucket
+}
 func (c *GeneratedRevisionSpecConverter) v1JSONToV1JSON(source v12.JSON) v12.JSON {
 	var v1JSON v12.JSON
 	var byteList []uint8
@@ -677,6 +939,7 @@ func (c *GeneratedRevisionSpecConverter) v1PatchToV1Patch(source Patch) Patch {
 	}
 	v1Patch.Transforms = v1TransformList
 	v1Patch.Policy = c.pV1PatchPolicyToPV1PatchPolicy(source.Policy)
+	v1Patch.EachElement = c.pV1EachElementPatchToPV1EachElementPatch(source.EachElement)
 	return v1Patch
 }
 func (c *GeneratedRevisionSpecConverter) v1ReadinessCheckToV1ReadinessCheck(source ReadinessCheck) ReadinessCheck {
@@ -685,17 +948,58 @@ func (c *GeneratedRevisionSpecConverter) v1ReadinessCheckToV1ReadinessCheck(sour
 	v1ReadinessCheck.FieldPath = source.FieldPath
 	v1ReadinessCheck.MatchString = source.MatchString
 	v1ReadinessCheck.MatchInteger = source.MatchInteger
+	v1ReadinessCheck.CompareToFieldPath = source.CompareToFieldPath
 	v1ReadinessCheck.MatchCondition = c.pV1MatchConditionReadinessCheckToPV1MatchConditionReadinessCheck(source.MatchCondition)
+	var v1ReadinessSubCheckList []ReadinessSubCheck
+	if source.AnyOf != nil {
+		v1ReadinessSubCheckList = make([]ReadinessSubCheck, len(source.AnyOf))
+		for i := 0; i < len(source.AnyOf); i++ {
+			v1ReadinessSubCheckList[i] = c.v1ReadinessSubCheckToV1ReadinessSubCheck(source.AnyOf[i])
+		}
+	}
+	v1ReadinessCheck.AnyOf = v1ReadinessSubCheckList
+	var v1ReadinessSubCheckList2 []ReadinessSubCheck
+	if source.AllOf != nil {
+		v1ReadinessSubCheckList2 = make([]ReadinessSubCheck, len(source.AllOf))
+		for j := 0; j < len(source.AllOf); j++ {
+			v1ReadinessSubCheckList2[j] = c.v1ReadinessSubCheckToV1ReadinessSubCheck(source.AllOf[j])
+		}
+	}
+	v1ReadinessCheck.AllOf = v1ReadinessSubCheckList2
 	return v1ReadinessCheck
 }
+func (c *GeneratedRevisionSpecConverter) v1ReadinessSubCheckToV1ReadinessSubCheck(source ReadinessSubCheck) ReadinessSubCheck {
+	var v1ReadinessSubCheck ReadinessSubCheck
+	v1ReadinessSubCheck.Type = ReadinessCheckType(source.Type)
+	v1ReadinessSubCheck.FieldPath = source.FieldPath
+	v1ReadinessSubCheck.MatchString = source.MatchString
+	v1ReadinessSubCheck.MatchInteger = source.MatchInteger
+	v1ReadinessSubCheck.CompareToFieldPath = source.CompareToFieldPath
+	v1ReadinessSubCheck.MatchCondition = c.pV1MatchConditionReadinessCheckToPV1MatchConditionReadinessCheck(source.MatchCondition)
+	return v1ReadinessSubCheck
+}
+func (c *GeneratedRevisionSpecConverter) v1StatusFromComposedToV1StatusFromComposed(source StatusFromComposed) StatusFromComposed {
+	var v1StatusFromComposed StatusFromComposed
+	v1StatusFromComposed.FromFieldPath = source.FromFieldPath
+	var pString *string
+	if source.ToFieldPath != nil {
+		xstring := *source.ToFieldPath
+		pString = &xstring
+	}
+	v1StatusFromComposed.ToFieldPath = pString
+	return v1StatusFromComposed
+}
 func (c *GeneratedRevisionSpecConverter) v1TransformToV1Transform(source Transform) Transform {
 	var v1Transform Transform
 	v1Transform.Type = TransformType(source.Type)
 	v1Transform.Math = c.pV1MathTransformToPV1MathTransform(source.Math)
 	v1Transform.Map = c.pV1MapTransformToPV1MapTransform(source.Map)
+	v1Transform.MapDefault = c.pV1JSONToPV1JSON(source.MapDefault)
 	v1Transform.Match = c.pV1MatchTransformToPV1MatchTransform(source.Match)
 	v1Transform.String = c.pV1StringTransformToPV1StringTransform(source.String)
 	v1Transform.Convert = c.pV1ConvertTransformToPV1ConvertTransform(source.Convert)
+	v1Transform.HashRing = c.pV1HashRingTransformToPV1HashRingTransform(source.HashRing)
+	v1Transform.ConnectionString = c.pV1ConnectionStringTransformToPV1ConnectionStringTransform(source.ConnectionString)
 	return v1Transform
 }
 func (c *GeneratedRevisionSpecConverter) v1TypeReferenceToV1TypeReference(source TypeReference) TypeReference {
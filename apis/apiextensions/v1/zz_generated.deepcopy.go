@@ -39,6 +39,11 @@ func (in *Combine) DeepCopyInto(out *Combine) {
 	if in.String != nil {
 		in, out := &in.String, &out.String
 		*out = new(StringCombine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MissingVariablePolicy != nil {
+		in, out := &in.MissingVariablePolicy, &out.MissingVariablePolicy
+		*out = new(CombineMissingVariablePolicy)
 		**out = **in
 	}
 }
@@ -91,6 +96,23 @@ func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConnectionDetailsPrefix != nil {
+		in, out := &in.ConnectionDetailsPrefix, &out.ConnectionDetailsPrefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.WriteConnectionSecretToNamespace != nil {
+		in, out := &in.WriteConnectionSecretToNamespace, &out.WriteConnectionSecretToNamespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.StatusFromComposed != nil {
+		in, out := &in.StatusFromComposed, &out.StatusFromComposed
+		*out = make([]StatusFromComposed, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.ReadinessChecks != nil {
 		in, out := &in.ReadinessChecks, &out.ReadinessChecks
 		*out = make([]ReadinessCheck, len(*in))
@@ -98,6 +120,46 @@ func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CreationDeadline != nil {
+		in, out := &in.CreationDeadline, &out.CreationDeadline
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ConflictPolicy != nil {
+		in, out := &in.ConflictPolicy, &out.ConflictPolicy
+		*out = new(ConflictPolicy)
+		**out = **in
+	}
+	if in.FieldManager != nil {
+		in, out := &in.FieldManager, &out.FieldManager
+		*out = new(string)
+		**out = **in
+	}
+	if in.SkipIf != nil {
+		in, out := &in.SkipIf, &out.SkipIf
+		*out = new(SkipCondition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OptionalForReadiness != nil {
+		in, out := &in.OptionalForReadiness, &out.OptionalForReadiness
+		*out = new(bool)
+		**out = **in
+	}
+	if in.GarbageCollectionPolicy != nil {
+		in, out := &in.GarbageCollectionPolicy, &out.GarbageCollectionPolicy
+		*out = new(GarbageCollectionPolicy)
+		**out = **in
+	}
+	if in.ManagementPolicy != nil {
+		in, out := &in.ManagementPolicy, &out.ManagementPolicy
+		*out = new(ManagementPolicy)
+		**out = **in
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedTemplate.
@@ -508,6 +570,20 @@ func (in *CompositionRevisionSpec) DeepCopyInto(out *CompositionRevisionSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.FeatureFlags != nil {
+		in, out := &in.FeatureFlags, &out.FeatureFlags
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ConnectionDetails != nil {
+		in, out := &in.ConnectionDetails, &out.ConnectionDetails
+		*out = make([]ConnectionDetail, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.WriteConnectionSecretsToNamespace != nil {
 		in, out := &in.WriteConnectionSecretsToNamespace, &out.WriteConnectionSecretsToNamespace
 		*out = new(string)
@@ -576,6 +652,20 @@ func (in *CompositionSpec) DeepCopyInto(out *CompositionSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.FeatureFlags != nil {
+		in, out := &in.FeatureFlags, &out.FeatureFlags
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ConnectionDetails != nil {
+		in, out := &in.ConnectionDetails, &out.ConnectionDetails
+		*out = make([]ConnectionDetail, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.WriteConnectionSecretsToNamespace != nil {
 		in, out := &in.WriteConnectionSecretsToNamespace, &out.WriteConnectionSecretsToNamespace
 		*out = new(string)
@@ -621,6 +711,28 @@ func (in *ConnectionDetail) DeepCopyInto(out *ConnectionDetail) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FromAnnotation != nil {
+		in, out := &in.FromAnnotation, &out.FromAnnotation
+		*out = new(string)
+		**out = **in
+	}
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(FromFieldPathPolicy)
+		**out = **in
+	}
+	if in.Optional != nil {
+		in, out := &in.Optional, &out.Optional
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = make([]Transform, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Value != nil {
 		in, out := &in.Value, &out.Value
 		*out = new(string)
@@ -638,6 +750,21 @@ func (in *ConnectionDetail) DeepCopy() *ConnectionDetail {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionStringTransform) DeepCopyInto(out *ConnectionStringTransform) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionStringTransform.
+func (in *ConnectionStringTransform) DeepCopy() *ConnectionStringTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionStringTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerFunction) DeepCopyInto(out *ContainerFunction) {
 	*out = *in
@@ -788,6 +915,80 @@ func (in *ConvertTransform) DeepCopy() *ConvertTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EachElementPatch) DeepCopyInto(out *EachElementPatch) {
+	*out = *in
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]ElementPatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EachElementPatch.
+func (in *EachElementPatch) DeepCopy() *EachElementPatch {
+	if in == nil {
+		return nil
+	}
+	out := new(EachElementPatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementPatch) DeepCopyInto(out *ElementPatch) {
+	*out = *in
+	if in.ToFieldPath != nil {
+		in, out := &in.ToFieldPath, &out.ToFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = make([]Transform, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementPatch.
+func (in *ElementPatch) DeepCopy() *ElementPatch {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementPatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentConfigMapSource) DeepCopyInto(out *EnvironmentConfigMapSource) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resolution != nil {
+		in, out := &in.Resolution, &out.Resolution
+		*out = new(commonv1.ResolutionPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentConfigMapSource.
+func (in *EnvironmentConfigMapSource) DeepCopy() *EnvironmentConfigMapSource {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentConfigMapSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvironmentConfiguration) DeepCopyInto(out *EnvironmentConfiguration) {
 	*out = *in
@@ -805,6 +1006,13 @@ func (in *EnvironmentConfiguration) DeepCopyInto(out *EnvironmentConfiguration)
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]EnvironmentConfigMapSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Patches != nil {
 		in, out := &in.Patches, &out.Patches
 		*out = make([]EnvironmentPatch, len(*in))
@@ -1008,6 +1216,48 @@ func (in *GeneratedRevisionSpecConverter) DeepCopy() *GeneratedRevisionSpecConve
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HashRingBucket) DeepCopyInto(out *HashRingBucket) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HashRingBucket.
+func (in *HashRingBucket) DeepCopy() *HashRingBucket {
+	if in == nil {
+		return nil
+	}
+	out := new(HashRingBucket)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HashRingTransform) DeepCopyInto(out *HashRingTransform) {
+	*out = *in
+	if in.Buckets != nil {
+		in, out := &in.Buckets, &out.Buckets
+		*out = make([]HashRingBucket, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HashRingTransform.
+func (in *HashRingTransform) DeepCopy() *HashRingTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(HashRingTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MapTransform) DeepCopyInto(out *MapTransform) {
 	*out = *in
@@ -1102,6 +1352,11 @@ func (in *MathTransform) DeepCopyInto(out *MathTransform) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.Offset != nil {
+		in, out := &in.Offset, &out.Offset
+		*out = new(int64)
+		**out = **in
+	}
 	if in.ClampMin != nil {
 		in, out := &in.ClampMin, &out.ClampMin
 		*out = new(int64)
@@ -1159,6 +1414,11 @@ func (in *Patch) DeepCopyInto(out *Patch) {
 		*out = new(PatchPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.EachElement != nil {
+		in, out := &in.EachElement, &out.EachElement
+		*out = new(EachElementPatch)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Patch.
@@ -1226,6 +1486,20 @@ func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 		*out = new(MatchConditionReadinessCheck)
 		**out = **in
 	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]ReadinessSubCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllOf != nil {
+		in, out := &in.AllOf, &out.AllOf
+		*out = make([]ReadinessSubCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessCheck.
@@ -1238,6 +1512,67 @@ func (in *ReadinessCheck) DeepCopy() *ReadinessCheck {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessSubCheck) DeepCopyInto(out *ReadinessSubCheck) {
+	*out = *in
+	if in.MatchCondition != nil {
+		in, out := &in.MatchCondition, &out.MatchCondition
+		*out = new(MatchConditionReadinessCheck)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessSubCheck.
+func (in *ReadinessSubCheck) DeepCopy() *ReadinessSubCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessSubCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SkipCondition) DeepCopyInto(out *SkipCondition) {
+	*out = *in
+	in.Equals.DeepCopyInto(&out.Equals)
+	if in.GarbageCollect != nil {
+		in, out := &in.GarbageCollect, &out.GarbageCollect
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SkipCondition.
+func (in *SkipCondition) DeepCopy() *SkipCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(SkipCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusFromComposed) DeepCopyInto(out *StatusFromComposed) {
+	*out = *in
+	if in.ToFieldPath != nil {
+		in, out := &in.ToFieldPath, &out.ToFieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusFromComposed.
+func (in *StatusFromComposed) DeepCopy() *StatusFromComposed {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusFromComposed)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StoreConfigReference) DeepCopyInto(out *StoreConfigReference) {
 	*out = *in
@@ -1256,6 +1591,11 @@ func (in *StoreConfigReference) DeepCopy() *StoreConfigReference {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StringCombine) DeepCopyInto(out *StringCombine) {
 	*out = *in
+	if in.Separator != nil {
+		in, out := &in.Separator, &out.Separator
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringCombine.
@@ -1291,6 +1631,11 @@ func (in *StringTransform) DeepCopyInto(out *StringTransform) {
 		*out = new(StringTransformRegexp)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Enum != nil {
+		in, out := &in.Enum, &out.Enum
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransform.
@@ -1336,6 +1681,11 @@ func (in *Transform) DeepCopyInto(out *Transform) {
 		*out = new(MapTransform)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MapDefault != nil {
+		in, out := &in.MapDefault, &out.MapDefault
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Match != nil {
 		in, out := &in.Match, &out.Match
 		*out = new(MatchTransform)
@@ -1351,6 +1701,16 @@ func (in *Transform) DeepCopyInto(out *Transform) {
 		*out = new(ConvertTransform)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HashRing != nil {
+		in, out := &in.HashRing, &out.HashRing
+		*out = new(HashRingTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConnectionString != nil {
+		in, out := &in.ConnectionString, &out.ConnectionString
+		*out = new(ConnectionStringTransform)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Transform.
@@ -20,6 +20,7 @@ package v1beta1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -73,7 +74,8 @@ type ComposedTemplate struct {
 	// the resources array are named entries may added, deleted, and reordered
 	// as long as their names do not change. When entries are not named the
 	// length and order of the resources array should be treated as immutable.
-	// Either all or no entries must be named.
+	// When only some entries are named, the position of each entry that is not
+	// named should be treated as immutable.
 	// +optional
 	Name *string `json:"name,omitempty"`
 
@@ -91,12 +93,153 @@ type ComposedTemplate struct {
 	// +optional
 	ConnectionDetails []ConnectionDetail `json:"connectionDetails,omitempty"`
 
+	// ConnectionDetailsPrefix is prepended to the name of each connection
+	// detail of this composed resource, e.g. a prefix of `primary-db-`
+	// publishes its `username` connection detail as `primary-db-username`.
+	// This avoids collisions between composed resources that expose
+	// connection details of the same name.
+	// +optional
+	ConnectionDetailsPrefix *string `json:"connectionDetailsPrefix,omitempty"`
+
+	// WriteConnectionSecretToNamespace overrides the namespace of this
+	// composed resource's writeConnectionSecretToRef, after its patches are
+	// applied. It has no effect if the composed resource doesn't write a
+	// connection secret. The namespace of the secret is otherwise unchanged.
+	// +optional
+	WriteConnectionSecretToNamespace *string `json:"writeConnectionSecretToNamespace,omitempty"`
+
+	// StatusFromComposed copies fields of this composed resource's status to
+	// the composite resource's status each time the composed resource is
+	// observed. Fields that don't exist are not copied.
+	// +optional
+	StatusFromComposed []StatusFromComposed `json:"statusFromComposed,omitempty"`
+
 	// ReadinessChecks allows users to define custom readiness checks. All checks
 	// have to return true in order for resource to be considered ready. The
 	// default readiness check is to have the "Ready" condition to be "True".
 	// +optional
 	// +kubebuilder:default={{type:"MatchCondition",matchCondition:{type:"Ready",status:"True"}}}
 	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+
+	// CreationDeadline is the time, relative to the creation of the composite
+	// resource, within which this composed resource must become ready. The
+	// composite resource is considered degraded if the composed resource is
+	// not ready once the deadline has passed. Resources without a deadline
+	// may become ready at any time.
+	// +optional
+	CreationDeadline *metav1.Duration `json:"creationDeadline,omitempty"`
+
+	// ConflictPolicy configures how conflicts with other field managers are
+	// resolved when this composed resource is applied. Setting a conflict
+	// policy causes the composed resource to be applied using server-side
+	// apply. Fail returns an error, Force takes ownership of any conflicting
+	// fields, and Yield leaves conflicting fields to their current managers.
+	// +optional
+	// +kubebuilder:validation:Enum=Fail;Force;Yield
+	ConflictPolicy *ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// FieldManager is the name of the field manager used when this composed
+	// resource is applied using server-side apply, i.e. when it has a
+	// conflict policy. Use it with a Force conflict policy to take ownership
+	// of fields from another field manager, for example when migrating a
+	// composed resource from another controller. Defaults to the composer's
+	// field manager.
+	// +optional
+	FieldManager *string `json:"fieldManager,omitempty"`
+
+	// SkipIf configures a condition that, when met, causes this template to
+	// be skipped. A skipped template is not composed.
+	// +optional
+	SkipIf *SkipCondition `json:"skipIf,omitempty"`
+
+	// OptionalForReadiness indicates that this composed resource should not
+	// block the readiness of the composite resource. Optional resources are
+	// otherwise composed, and garbage collected, like any other. Failing to
+	// apply an optional resource is reported but is not an error. A composite
+	// resource whose composed resources are all optional is ready once they
+	// have been composed.
+	// +optional
+	OptionalForReadiness *bool `json:"optionalForReadiness,omitempty"`
+
+	// GarbageCollectionPolicy configures what happens to this composed
+	// resource once it no longer corresponds to any template, for example
+	// because this template was renamed or removed. Delete (the default)
+	// deletes the composed resource. Orphan releases it from the composite
+	// resource instead. The policy is recorded on the composed resource when
+	// it is composed, so it must be explicitly set to Delete to revert from
+	// Orphan.
+	// +optional
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	GarbageCollectionPolicy *GarbageCollectionPolicy `json:"garbageCollectionPolicy,omitempty"`
+
+	// ManagementPolicy configures how this composed resource is managed.
+	// Default composed resources are created, updated, and garbage
+	// collected. ObserveOnly composed resources are never created, updated,
+	// or garbage collected - they are only observed. An ObserveOnly composed
+	// resource must already exist, and its base should specify the name of
+	// the existing resource. It still contributes connection details and
+	// readiness.
+	// +optional
+	// +kubebuilder:validation:Enum=Default;ObserveOnly
+	ManagementPolicy *ManagementPolicy `json:"managementPolicy,omitempty"`
+
+	// DependsOn lists the names of the templates this composed resource
+	// depends on. When the composite resource is deleted this composed
+	// resource is deleted, and must be gone, before any of the composed
+	// resources it depends on are deleted. Dependencies must not be cyclic.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// A StatusFromComposed copies a field of a composed resource's status to the
+// composite resource's status.
+type StatusFromComposed struct {
+	// FromFieldPath is the path of the field to copy, relative to the composed
+	// resource's status, e.g. `atProvider.ipAddress`.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// ToFieldPath is the path of the field to copy to, relative to the
+	// composite resource's status. Defaults to FromFieldPath.
+	// +optional
+	ToFieldPath *string `json:"toFieldPath,omitempty"`
+}
+
+// GetToFieldPath returns the path of the field to copy to, relative to the
+// composite resource's status.
+func (s *StatusFromComposed) GetToFieldPath() string {
+	if s.ToFieldPath != nil {
+		return *s.ToFieldPath
+	}
+	return s.FromFieldPath
+}
+
+// Validate the StatusFromComposed.
+func (s *StatusFromComposed) Validate() *field.Error {
+	if s.FromFieldPath == "" {
+		return field.Required(field.NewPath("fromFieldPath"), "fromFieldPath is required")
+	}
+	if s.ToFieldPath != nil && *s.ToFieldPath == "" {
+		return field.Invalid(field.NewPath("toFieldPath"), *s.ToFieldPath, "toFieldPath must not be empty")
+	}
+	return nil
+}
+
+// A SkipCondition causes a template to be skipped when a field of the
+// composite resource equals a value.
+type SkipCondition struct {
+	// FromFieldPath is the path of the field of the composite resource to
+	// compare. The template is not skipped if the field does not exist.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// Equals is the value the field must equal for the template to be
+	// skipped.
+	Equals extv1.JSON `json:"equals"`
+
+	// GarbageCollect specifies whether an existing composed resource that was
+	// created from this template should be deleted when the template is
+	// skipped. Existing composed resources are left untouched by default.
+	// +optional
+	GarbageCollect *bool `json:"garbageCollect,omitempty"`
 }
 
 // GetName returns the name of the composed template or an empty string if it is nil.
@@ -107,6 +250,57 @@ func (ct *ComposedTemplate) GetName() string {
 	return ""
 }
 
+// Composition feature flags. See the FeatureFlags field of a Composition.
+const (
+	// CompositionFeatureDriftDetection records whether each composed resource
+	// had drifted from its desired state before it was applied.
+	CompositionFeatureDriftDetection = "DriftDetection"
+
+	// CompositionFeatureConcurrentRendering renders and applies composed
+	// resources concurrently.
+	CompositionFeatureConcurrentRendering = "ConcurrentRendering"
+)
+
+// IsKnownCompositionFeature returns true if the supplied Composition feature
+// flag is supported.
+func IsKnownCompositionFeature(flag string) bool {
+	switch flag {
+	case CompositionFeatureDriftDetection, CompositionFeatureConcurrentRendering:
+		return true
+	}
+	return false
+}
+
+// A ConflictPolicy determines how field ownership conflicts are resolved when
+// a composed resource is applied.
+type ConflictPolicy string
+
+// Conflict policies.
+const (
+	ConflictPolicyFail  ConflictPolicy = "Fail"
+	ConflictPolicyForce ConflictPolicy = "Force"
+	ConflictPolicyYield ConflictPolicy = "Yield"
+)
+
+// A GarbageCollectionPolicy determines what happens to a composed resource
+// that no longer corresponds to any template.
+type GarbageCollectionPolicy string
+
+// Garbage collection policies.
+const (
+	GarbageCollectionPolicyDelete GarbageCollectionPolicy = "Delete"
+	GarbageCollectionPolicyOrphan GarbageCollectionPolicy = "Orphan"
+)
+
+// A ManagementPolicy determines how a composed resource is managed.
+type ManagementPolicy string
+
+// Management policies.
+const (
+	ManagementPolicyDefault     ManagementPolicy = "Default"
+	ManagementPolicyObserveOnly ManagementPolicy = "ObserveOnly"
+)
+
 // ReadinessCheckType is used for readiness check types.
 type ReadinessCheckType string
 
@@ -119,17 +313,27 @@ const (
 	ReadinessCheckTypeMatchFalse     ReadinessCheckType = "MatchFalse"
 	ReadinessCheckTypeMatchCondition ReadinessCheckType = "MatchCondition"
 	ReadinessCheckTypeNone           ReadinessCheckType = "None"
+	ReadinessCheckTypeAnyOf          ReadinessCheckType = "AnyOf"
+	ReadinessCheckTypeAllOf          ReadinessCheckType = "AllOf"
+
+	ReadinessCheckTypeGreaterThanOrEqual ReadinessCheckType = "GreaterThanOrEqual"
 )
 
 // IsValid returns nil if the readiness check type is valid, or an error otherwise.
 func (t *ReadinessCheckType) IsValid() bool {
 	switch *t {
-	case ReadinessCheckTypeNonEmpty, ReadinessCheckTypeMatchString, ReadinessCheckTypeMatchInteger, ReadinessCheckTypeMatchTrue, ReadinessCheckTypeMatchFalse, ReadinessCheckTypeMatchCondition, ReadinessCheckTypeNone:
+	case ReadinessCheckTypeNonEmpty, ReadinessCheckTypeMatchString, ReadinessCheckTypeMatchInteger, ReadinessCheckTypeMatchTrue, ReadinessCheckTypeMatchFalse, ReadinessCheckTypeMatchCondition, ReadinessCheckTypeNone, ReadinessCheckTypeAnyOf, ReadinessCheckTypeAllOf, ReadinessCheckTypeGreaterThanOrEqual:
 		return true
 	}
 	return false
 }
 
+// IsGroup returns true if the readiness check type combines a group of
+// readiness checks.
+func (t *ReadinessCheckType) IsGroup() bool {
+	return *t == ReadinessCheckTypeAnyOf || *t == ReadinessCheckTypeAllOf
+}
+
 // ReadinessCheck is used to indicate how to tell whether a resource is ready
 // for consumption
 type ReadinessCheck struct {
@@ -138,7 +342,7 @@ type ReadinessCheck struct {
 	// or 0?
 
 	// Type indicates the type of probe you'd like to use.
-	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"MatchCondition";"MatchTrue";"MatchFalse";"None"
+	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"MatchCondition";"MatchTrue";"MatchFalse";"None";"GreaterThanOrEqual";"AnyOf";"AllOf"
 	Type ReadinessCheckType `json:"type"`
 
 	// FieldPath shows the path of the field whose value will be used.
@@ -153,9 +357,75 @@ type ReadinessCheck struct {
 	// +optional
 	MatchInteger int64 `json:"matchInteger,omitempty"`
 
+	// CompareToFieldPath is the path of the integer field that the integer
+	// field at FieldPath must be greater than or equal to if you're using
+	// "GreaterThanOrEqual" type.
+	// +optional
+	CompareToFieldPath string `json:"compareToFieldPath,omitempty"`
+
 	// MatchCondition specifies the condition you'd like to match if you're using "MatchCondition" type.
 	// +optional
 	MatchCondition *MatchConditionReadinessCheck `json:"matchCondition,omitempty"`
+
+	// AnyOf is the group of readiness checks of which at least one must pass
+	// if you're using "AnyOf" type.
+	// +optional
+	AnyOf []ReadinessSubCheck `json:"anyOf,omitempty"`
+
+	// AllOf is the group of readiness checks of which all must pass if you're
+	// using "AllOf" type.
+	// +optional
+	AllOf []ReadinessSubCheck `json:"allOf,omitempty"`
+}
+
+// A ReadinessSubCheck is a readiness check that is part of an "AnyOf" or
+// "AllOf" group of readiness checks. Groups may not be nested.
+type ReadinessSubCheck struct {
+	// Type indicates the type of probe you'd like to use.
+	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"MatchCondition";"MatchTrue";"MatchFalse";"None";"GreaterThanOrEqual"
+	Type ReadinessCheckType `json:"type"`
+
+	// FieldPath shows the path of the field whose value will be used.
+	// +optional
+	FieldPath string `json:"fieldPath,omitempty"`
+
+	// MatchString is the value you'd like to match if you're using "MatchString" type.
+	// +optional
+	MatchString string `json:"matchString,omitempty"`
+
+	// MatchInt is the value you'd like to match if you're using "MatchInt" type.
+	// +optional
+	MatchInteger int64 `json:"matchInteger,omitempty"`
+
+	// CompareToFieldPath is the path of the integer field that the integer
+	// field at FieldPath must be greater than or equal to if you're using
+	// "GreaterThanOrEqual" type.
+	// +optional
+	CompareToFieldPath string `json:"compareToFieldPath,omitempty"`
+
+	// MatchCondition specifies the condition you'd like to match if you're using "MatchCondition" type.
+	// +optional
+	MatchCondition *MatchConditionReadinessCheck `json:"matchCondition,omitempty"`
+}
+
+// ReadinessCheck returns the readiness check equivalent to this sub-check.
+func (r *ReadinessSubCheck) ReadinessCheck() *ReadinessCheck {
+	return &ReadinessCheck{
+		Type:               r.Type,
+		FieldPath:          r.FieldPath,
+		MatchString:        r.MatchString,
+		MatchInteger:       r.MatchInteger,
+		CompareToFieldPath: r.CompareToFieldPath,
+		MatchCondition:     r.MatchCondition,
+	}
+}
+
+// Validate checks if the readiness sub-check is logically valid.
+func (r *ReadinessSubCheck) Validate() *field.Error {
+	if r.Type.IsGroup() {
+		return field.Invalid(field.NewPath("type"), string(r.Type), "readiness check groups cannot be nested")
+	}
+	return r.ReadinessCheck().Validate()
 }
 
 // MatchConditionReadinessCheck is used to indicate how to tell whether a resource is ready
@@ -168,6 +438,11 @@ type MatchConditionReadinessCheck struct {
 	// Status is the status of the condition you'd like to match.
 	// +kubebuilder:default="True"
 	Status corev1.ConditionStatus `json:"status"`
+
+	// Reason is the reason of the condition you'd like to match. The reason
+	// is not matched if it is not specified.
+	// +optional
+	Reason xpv1.ConditionReason `json:"reason,omitempty"`
 }
 
 // Validate checks if the match condition is logically valid.
@@ -202,11 +477,22 @@ func (r *ReadinessCheck) Validate() *field.Error { //nolint:gocyclo // This func
 		if r.MatchInteger == 0 {
 			return field.Required(field.NewPath("matchInteger"), "cannot be 0 for type MatchInteger")
 		}
+	case ReadinessCheckTypeGreaterThanOrEqual:
+		if r.CompareToFieldPath == "" {
+			return field.Required(field.NewPath("compareToFieldPath"), "cannot be empty for type GreaterThanOrEqual")
+		}
 	case ReadinessCheckTypeMatchCondition:
+		if r.MatchCondition == nil {
+			return field.Required(field.NewPath("matchCondition"), "cannot be empty for type MatchCondition")
+		}
 		if err := r.MatchCondition.Validate(); err != nil {
 			return errors.WrapFieldError(err, field.NewPath("matchCondition"))
 		}
 		return nil
+	case ReadinessCheckTypeAnyOf:
+		return validateReadinessSubChecks(field.NewPath("anyOf"), r.AnyOf)
+	case ReadinessCheckTypeAllOf:
+		return validateReadinessSubChecks(field.NewPath("allOf"), r.AllOf)
 	case ReadinessCheckTypeNonEmpty, ReadinessCheckTypeMatchFalse, ReadinessCheckTypeMatchTrue:
 		// No specific validation required.
 	}
@@ -217,6 +503,18 @@ func (r *ReadinessCheck) Validate() *field.Error { //nolint:gocyclo // This func
 	return nil
 }
 
+func validateReadinessSubChecks(p *field.Path, rcs []ReadinessSubCheck) *field.Error {
+	if len(rcs) == 0 {
+		return field.Required(p, "must specify at least one readiness check")
+	}
+	for i := range rcs {
+		if err := rcs[i].Validate(); err != nil {
+			return errors.WrapFieldError(err, p.Index(i))
+		}
+	}
+	return nil
+}
+
 // A ConnectionDetailType is a type of connection detail.
 type ConnectionDetailType string
 
@@ -226,6 +524,7 @@ const (
 	ConnectionDetailTypeFromConnectionSecretKey ConnectionDetailType = "FromConnectionSecretKey"
 	ConnectionDetailTypeFromFieldPath           ConnectionDetailType = "FromFieldPath"
 	ConnectionDetailTypeFromValue               ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeFromAnnotation          ConnectionDetailType = "FromAnnotation"
 )
 
 // ConnectionDetail includes the information about the propagation of the connection
@@ -245,8 +544,9 @@ type ConnectionDetail struct {
 	// 1. FromValue
 	// 2. FromConnectionSecretKey
 	// 3. FromFieldPath
+	// 4. FromAnnotation
 	// +optional
-	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue
+	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue;FromAnnotation
 	Type *ConnectionDetailType `json:"type,omitempty"`
 
 	// FromConnectionSecretKey is the key that will be used to fetch the value
@@ -260,6 +560,36 @@ type ConnectionDetail struct {
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
+	// FromAnnotation is the key of the annotation of the composed resource
+	// whose value will be propagated to the connection secret of the
+	// composite resource. Name must be specified if the type is
+	// FromAnnotation.
+	// +optional
+	FromAnnotation *string `json:"fromAnnotation,omitempty"`
+
+	// Policy specifies how to handle a FromFieldPath or FromAnnotation
+	// connection detail whose field path or annotation does not exist. The
+	// default is 'Optional', which means the connection detail will be
+	// omitted. Use 'Required' if the connection details should fail to be
+	// extracted instead.
+	// +kubebuilder:validation:Enum=Optional;Required
+	// +optional
+	Policy *FromFieldPathPolicy `json:"policy,omitempty"`
+
+	// Optional causes the connection detail to be omitted if it can't be
+	// extracted, for example because its transforms fail, rather than
+	// failing to compose resources. A connection detail that is optional
+	// is omitted even if its policy is 'Required'.
+	// +optional
+	Optional *bool `json:"optional,omitempty"`
+
+	// Transforms are the list of functions that are used to transform the
+	// extracted value before it is propagated to the connection secret of
+	// the composite resource. Values that aren't strings once transformed
+	// are propagated as JSON.
+	// +optional
+	Transforms []Transform `json:"transforms,omitempty"`
+
 	// Value that will be propagated to the connection secret of the composite
 	// resource. May be set to inject a fixed, non-sensitive connection secret
 	// value, for example a well-known port.
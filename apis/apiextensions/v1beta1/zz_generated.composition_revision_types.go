@@ -66,6 +66,28 @@ type CompositionRevisionSpec struct {
 	// +optional
 	Functions []Function `json:"functions,omitempty"`
 
+	// FeatureFlags enable or disable experimental composition behaviors for
+	// composite resources that use this Composition. Supported flags are
+	// DriftDetection, which records whether each composed resource had
+	// drifted from its desired state before it was applied, and
+	// ConcurrentRendering, which renders and applies composed resources
+	// concurrently. Flags that are not set use the default behavior of the
+	// Crossplane instance. Unknown flags are ignored, with a warning when the
+	// Composition is validated.
+	//
+	// THIS IS AN ALPHA FIELD. Do not use it in production. It may be changed
+	// or removed without notice.
+	// +optional
+	FeatureFlags map[string]bool `json:"featureFlags,omitempty"`
+
+	// ConnectionDetails lists the propagation secret keys that are derived
+	// from the composite resource itself, rather than from a composed
+	// resource. They're extracted before the connection details of composed
+	// resources, so a composed resource may override them. Connection details
+	// of type FromConnectionSecretKey are not supported.
+	// +optional
+	ConnectionDetails []ConnectionDetail `json:"connectionDetails,omitempty"`
+
 	// WriteConnectionSecretsToNamespace specifies the namespace in which the
 	// connection secrets of composite resource dynamically provisioned using
 	// this composition will be created.
@@ -104,6 +104,8 @@ type startCommand struct {
 	EnableExternalSecretStores               bool `group:"Alpha Features:" help:"Enable support for External Secret Stores."`
 	EnableCompositionFunctions               bool `group:"Alpha Features:" help:"Enable support for Composition Functions."`
 	EnableCompositionWebhookSchemaValidation bool `group:"Alpha Features:" help:"Enable support for Composition validation using schemas."`
+	EnableExternalNameAdoption               bool `group:"Alpha Features:" help:"Enable composite resources to adopt existing resources by external name."`
+	EnableCanonicalRendering                 bool `group:"Alpha Features:" help:"Enable canonicalizing rendered composed resources before they're applied."`
 
 	// These are GA features that previously had alpha or beta feature flags.
 	// You can't turn off a GA feature. We maintain the flags to avoid breaking
@@ -195,6 +197,14 @@ func (c *startCommand) Run(s *runtime.Scheme, log logging.Logger) error { //noli
 		feats.Enable(features.EnableAlphaCompositionWebhookSchemaValidation)
 		log.Info("Alpha feature enabled", "flag", features.EnableAlphaCompositionWebhookSchemaValidation)
 	}
+	if c.EnableExternalNameAdoption {
+		feats.Enable(features.EnableAlphaExternalNameAdoption)
+		log.Info("Alpha feature enabled", "flag", features.EnableAlphaExternalNameAdoption)
+	}
+	if c.EnableCanonicalRendering {
+		feats.Enable(features.EnableAlphaCanonicalRendering)
+		log.Info("Alpha feature enabled", "flag", features.EnableAlphaCanonicalRendering)
+	}
 	if !c.EnableCompositionRevisions {
 		log.Info("CompositionRevisions feature is GA and cannot be disabled. The --enable-composition-revisions flag will be removed in a future release.")
 	}
@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"math"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// Error strings.
+const (
+	errFmtSetAnnotationSum = "cannot set sum of annotation %q at composite resource field path %q"
+	errConvertComposite    = "cannot convert composite resource"
+	errFmtAnnotationNaN    = "cannot sum annotation %q of composed resource %s: value %q is not a number"
+)
+
+// An AnnotationSum sums a numeric annotation of each composed resource, for
+// example a cost annotation set by a provider, into a field of the composite
+// resource.
+type AnnotationSum struct {
+	// Annotation is the annotation to sum.
+	Annotation string
+
+	// ToFieldPath is the composite resource field path to which the sum is
+	// written, e.g. status.cost.
+	ToFieldPath string
+}
+
+// Aggregate the supplied composed resources' annotations into the supplied
+// composite resource. Composed resources without the annotation are skipped.
+// Composed resources with an annotation that is not a number are skipped, and
+// a warning event is returned for each of them.
+func (s AnnotationSum) Aggregate(xr resource.Composite, cds []resource.Composed) ([]event.Event, error) {
+	events := make([]event.Event, 0)
+	var sum float64
+	for _, cd := range cds {
+		v, ok := cd.GetAnnotations()[s.Annotation]
+		if !ok {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			events = append(events, event.Warning(reasonCompose, errors.Errorf(errFmtAnnotationNaN, s.Annotation, cd.GetName(), v)))
+			continue
+		}
+		sum += f
+	}
+
+	p, err := fieldpath.PaveObject(xr)
+	if err != nil {
+		return nil, errors.Wrap(err, errPaveComposite)
+	}
+	if err := p.SetValue(s.ToFieldPath, sum); err != nil {
+		return nil, errors.Wrapf(err, errFmtSetAnnotationSum, s.Annotation, s.ToFieldPath)
+	}
+	return events, errors.Wrap(runtime.DefaultUnstructuredConverter.FromUnstructured(p.UnstructuredContent(), xr), errConvertComposite)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestAnnotationSumAggregate(t *testing.T) {
+	cost := "example.org/cost"
+	withCost := func(name, v string) resource.Composed {
+		return composed.New(func(cd *composed.Unstructured) {
+			cd.SetName(name)
+			if v != "" {
+				cd.SetAnnotations(map[string]string{cost: v})
+			}
+		})
+	}
+	newXR := func() *composite.Unstructured {
+		xr := composite.New()
+		xr.SetAPIVersion("example.org/v1")
+		xr.SetKind("XR")
+		return xr
+	}
+	xrWithCost := func(sum any) *composite.Unstructured {
+		xr := newXR()
+		xr.Object["status"] = map[string]any{"cost": sum}
+		return xr
+	}
+
+	type args struct {
+		xr  *composite.Unstructured
+		cds []resource.Composed
+	}
+	type want struct {
+		xr     *composite.Unstructured
+		events []event.Event
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Sum": {
+			reason: "We should sum the annotation of all composed resources into the composite resource.",
+			args: args{
+				xr:  newXR(),
+				cds: []resource.Composed{withCost("a", "1.5"), withCost("b", "2"), withCost("c", "0.25")},
+			},
+			want: want{
+				xr: xrWithCost(float64(3.75)),
+			},
+		},
+		"AnnotationAbsent": {
+			reason: "We should skip composed resources that don't have the annotation, e.g. because they were only just created.",
+			args: args{
+				xr:  xrWithCost(int64(10)),
+				cds: []resource.Composed{withCost("a", "1"), withCost("b", "")},
+			},
+			want: want{
+				xr: xrWithCost(int64(1)),
+			},
+		},
+		"NoComposedResources": {
+			reason: "The sum should be zero if there are no composed resources.",
+			args: args{
+				xr: newXR(),
+			},
+			want: want{
+				xr: xrWithCost(int64(0)),
+			},
+		},
+		"NotANumber": {
+			reason: "We should skip composed resources whose annotation isn't a number, and return a warning.",
+			args: args{
+				xr:  newXR(),
+				cds: []resource.Composed{withCost("a", "1"), withCost("b", "lots"), withCost("c", "NaN")},
+			},
+			want: want{
+				xr: xrWithCost(int64(1)),
+				events: []event.Event{
+					event.Warning(reasonCompose, errors.Errorf(errFmtAnnotationNaN, cost, "b", "lots")),
+					event.Warning(reasonCompose, errors.Errorf(errFmtAnnotationNaN, cost, "c", "NaN")),
+				},
+			},
+		},
+		"SetError": {
+			reason: "We should return an error if we can't set the sum.",
+			args: args{
+				xr: func() *composite.Unstructured {
+					xr := newXR()
+					xr.Object["status"] = "wat"
+					return xr
+				}(),
+			},
+			want: want{
+				xr: func() *composite.Unstructured {
+					xr := newXR()
+					xr.Object["status"] = "wat"
+					return xr
+				}(),
+				err: errors.Wrapf(errors.New("status is not an object"), errFmtSetAnnotationSum, cost, "status.cost"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := AnnotationSum{Annotation: cost, ToFieldPath: "status.cost"}
+			events, err := s.Aggregate(tc.args.xr, tc.args.cds)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nAggregate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.events, events, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nAggregate(...): -want events, +got events:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.xr, tc.args.xr); diff != "" {
+				t.Errorf("\n%s\nAggregate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
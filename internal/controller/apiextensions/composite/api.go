@@ -25,6 +25,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
@@ -40,7 +41,10 @@ import (
 
 // Error strings.
 const (
-	errApplySecret = "cannot apply connection secret"
+	errApplySecret      = "cannot apply connection secret"
+	errGetXRSecret      = "cannot get connection secret"
+	errUpdateSecret     = "cannot update connection secret"
+	errRemoveSecretKeys = "cannot remove connection details from connection secret"
 
 	errNoCompatibleComposition         = "no compatible Compositions found"
 	errNoCompatibleCompositionRevision = "no compatible CompositionRevisions found"
@@ -64,13 +68,14 @@ const (
 // it through a set of permitted keys.
 type APIFilteredSecretPublisher struct {
 	client resource.Applicator
+	kube   client.Client
 	filter []string
 }
 
 // NewAPIFilteredSecretPublisher returns a ConnectionPublisher that only
 // publishes connection secret keys that are included in the supplied filter.
 func NewAPIFilteredSecretPublisher(c client.Client, filter []string) *APIFilteredSecretPublisher {
-	return &APIFilteredSecretPublisher{client: resource.NewAPIPatchingApplicator(c), filter: filter}
+	return &APIFilteredSecretPublisher{client: resource.NewAPIPatchingApplicator(c), kube: c, filter: filter}
 }
 
 // PublishConnection publishes the supplied ConnectionDetails to the Secret
@@ -113,11 +118,37 @@ func (a *APIFilteredSecretPublisher) PublishConnection(ctx context.Context, o re
 	return true, nil
 }
 
-// UnpublishConnection is no-op since PublishConnection only creates resources
-// that will be garbage collected by Kubernetes when the managed resource is
-// deleted.
-func (a *APIFilteredSecretPublisher) UnpublishConnection(_ context.Context, _ resource.ConnectionSecretOwner, _ managed.ConnectionDetails) error {
-	return nil
+// UnpublishConnection removes the supplied ConnectionDetails from the Secret
+// referenced in the resource. The Secret itself is never deleted; it will be
+// garbage collected by Kubernetes when the resource is deleted.
+func (a *APIFilteredSecretPublisher) UnpublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) error {
+	ref := o.GetWriteConnectionSecretToReference()
+	if ref == nil || len(c) == 0 {
+		return nil
+	}
+
+	s := &corev1.Secret{}
+	if err := a.kube.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, s); err != nil {
+		return errors.Wrap(resource.IgnoreNotFound(err), errGetXRSecret)
+	}
+
+	// Don't touch a secret we wouldn't be allowed to publish to.
+	if err := resource.ConnectionSecretMustBeControllableBy(o.GetUID())(ctx, s, nil); err != nil {
+		return errors.Wrap(err, errRemoveSecretKeys)
+	}
+
+	removed := false
+	for key := range c {
+		if _, ok := s.Data[key]; ok {
+			delete(s.Data, key)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+
+	return errors.Wrap(a.kube.Update(ctx, s), errUpdateSecret)
 }
 
 // An APIRevisionFetcher selects the appropriate CompositionRevision for a
@@ -21,6 +21,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -96,6 +97,25 @@ func TestPublishConnection(t *testing.T) {
 				published: false,
 			},
 		},
+		"ExistingSecretUnchanged": {
+			reason: "We should not rewrite an existing secret whose data is identical to the connection details.",
+			args: args{
+				applicator: resource.NewAPIPatchingApplicator(&test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						s := resource.ConnectionSecretFor(owner, owner.GetObjectKind().GroupVersionKind())
+						s.Data = managed.ConnectionDetails{"cool": {42}}
+						s.DeepCopyInto(obj.(*corev1.Secret))
+						return nil
+					}),
+					MockPatch: test.NewMockPatchFn(errors.New("we should not patch an unchanged secret")),
+				}),
+				o: owner,
+				c: managed.ConnectionDetails{"cool": {42}},
+			},
+			want: want{
+				published: false,
+			},
+		},
 		"SuccessfulPublish": {
 			reason: "If the secret changed we should publish it.",
 			args: args{
@@ -138,7 +158,7 @@ func TestPublishConnection(t *testing.T) {
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			a := &APIFilteredSecretPublisher{tc.args.applicator, tc.args.filter}
+			a := &APIFilteredSecretPublisher{client: tc.args.applicator, filter: tc.args.filter}
 			got, err := a.PublishConnection(context.Background(), tc.args.o, tc.args.c)
 			if diff := cmp.Diff(tc.want.published, got); diff != "" {
 				t.Errorf("\n%s\nPublish(...): -want, +got:\n%s", tc.reason, diff)
@@ -150,6 +170,141 @@ func TestPublishConnection(t *testing.T) {
 	}
 }
 
+func TestUnpublishConnection(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	owner := &fake.MockConnectionSecretOwner{
+		WriterTo: &xpv1.SecretReference{
+			Namespace: "coolnamespace",
+			Name:      "coolsecret",
+		},
+	}
+
+	type args struct {
+		kube client.Client
+		o    resource.ConnectionSecretOwner
+		c    managed.ConnectionDetails
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"ResourceDoesNotPublishSecret": {
+			reason: "We should not unpublish anything for a resource with a nil GetWriteConnectionSecretToReference.",
+			args: args{
+				o: &fake.MockConnectionSecretOwner{},
+				c: managed.ConnectionDetails{"stale": nil},
+			},
+		},
+		"NoConnectionDetails": {
+			reason: "We should leave the secret alone if there are no connection details to unpublish.",
+			args: args{
+				o: owner,
+			},
+		},
+		"SecretNotFound": {
+			reason: "We should not return an error if the secret doesn't exist.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "coolsecret")),
+				},
+				o: owner,
+				c: managed.ConnectionDetails{"stale": nil},
+			},
+		},
+		"GetError": {
+			reason: "We should return any error encountered getting the secret.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(errBoom),
+				},
+				o: owner,
+				c: managed.ConnectionDetails{"stale": nil},
+			},
+			want: errors.Wrap(errBoom, errGetXRSecret),
+		},
+		"NotControllable": {
+			reason: "We should not remove keys from a secret controlled by another resource.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						ctrl := true
+						obj.SetOwnerReferences([]metav1.OwnerReference{{UID: "someone-else", Controller: &ctrl}})
+						obj.(*corev1.Secret).Data = map[string][]byte{"cool": {42}, "stale": {41}}
+						return nil
+					}),
+				},
+				o: owner,
+				c: managed.ConnectionDetails{"stale": nil},
+			},
+			want: errors.Wrap(errors.Errorf("existing secret is not controlled by UID %q", ""), errRemoveSecretKeys),
+		},
+		"KeysNotPresent": {
+			reason: "We should not update the secret if none of the supplied keys are present.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						obj.(*corev1.Secret).Type = resource.SecretTypeConnection
+						obj.(*corev1.Secret).Data = map[string][]byte{"cool": {42}}
+						return nil
+					}),
+				},
+				o: owner,
+				c: managed.ConnectionDetails{"stale": nil},
+			},
+		},
+		"UpdateError": {
+			reason: "We should return any error encountered updating the secret.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						obj.(*corev1.Secret).Type = resource.SecretTypeConnection
+						obj.(*corev1.Secret).Data = map[string][]byte{"cool": {42}, "stale": {41}}
+						return nil
+					}),
+					MockUpdate: test.NewMockUpdateFn(errBoom),
+				},
+				o: owner,
+				c: managed.ConnectionDetails{"stale": nil},
+			},
+			want: errors.Wrap(errBoom, errUpdateSecret),
+		},
+		"Success": {
+			reason: "We should remove only the supplied keys from the secret.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						obj.(*corev1.Secret).Type = resource.SecretTypeConnection
+						obj.(*corev1.Secret).Data = map[string][]byte{"cool": {42}, "stale": {41}}
+						return nil
+					}),
+					MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+						want := map[string][]byte{"cool": {42}}
+						if diff := cmp.Diff(want, obj.(*corev1.Secret).Data); diff != "" {
+							t.Errorf("-want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				o: owner,
+				c: managed.ConnectionDetails{"stale": nil},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := &APIFilteredSecretPublisher{kube: tc.args.kube}
+			err := a.UnpublishConnection(context.Background(), tc.args.o, tc.args.c)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nUnpublish(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestFetchRevision(t *testing.T) {
 	errBoom := errors.New("boom")
 	manual := xpv1.UpdateManual
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// fieldOwnerComposer is the field manager used when composed resources are
+// applied using server-side apply.
+const fieldOwnerComposer = "apiextensions.crossplane.io/composer"
+
+// Error strings.
+const (
+	errSSAGet      = "cannot get object"
+	errSSAPatch    = "cannot server-side apply object"
+	errSSAForce    = "cannot force server-side apply object"
+	errSSAYield    = "cannot server-side apply object after yielding conflicting fields"
+	errSSAConflict = "cannot server-side apply object due to conflicting field managers"
+
+	errFmtUnsupportedConflictPolicy = "unsupported conflict policy %q"
+	errFmtYieldField                = "cannot yield conflicting field %q"
+)
+
+// A ServerSideApplicator applies objects using server-side apply, resolving
+// field ownership conflicts according to a ConflictPolicy.
+type ServerSideApplicator struct {
+	client client.Client
+	owner  string
+}
+
+// NewServerSideApplicator returns a ServerSideApplicator that applies objects
+// as the supplied field owner.
+func NewServerSideApplicator(c client.Client, owner string) *ServerSideApplicator {
+	return &ServerSideApplicator{client: c, owner: owner}
+}
+
+// WithFieldOwner returns a copy of this ServerSideApplicator that applies
+// objects as the supplied field owner.
+func (a *ServerSideApplicator) WithFieldOwner(owner string) *ServerSideApplicator {
+	return &ServerSideApplicator{client: a.client, owner: owner}
+}
+
+// Apply the supplied object using server-side apply. ApplyOptions are called
+// with the current and desired object before it is applied, unless the object
+// does not yet exist. Apply returns the fields that conflicted with other field
+// managers, if any, and were resolved according to the supplied policy.
+func (a *ServerSideApplicator) Apply(ctx context.Context, o client.Object, p v1.ConflictPolicy, ao ...resource.ApplyOption) ([]string, error) {
+	current := o.DeepCopyObject().(client.Object)
+	err := a.client.Get(ctx, types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}, current)
+	if resource.IgnoreNotFound(err) != nil {
+		return nil, errors.Wrap(err, errSSAGet)
+	}
+	if err == nil {
+		for _, fn := range ao {
+			if err := fn(ctx, current, o); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Server-side apply configurations must not include these fields.
+	o.SetResourceVersion("")
+	o.SetManagedFields(nil)
+
+	err = a.client.Patch(ctx, o, client.Apply, client.FieldOwner(a.owner))
+	fields := conflictingFields(err)
+	if len(fields) == 0 {
+		return nil, errors.Wrap(err, errSSAPatch)
+	}
+
+	switch p {
+	case v1.ConflictPolicyFail:
+		return fields, errors.Wrap(err, errSSAConflict)
+	case v1.ConflictPolicyForce:
+		return fields, errors.Wrap(a.client.Patch(ctx, o, client.Apply, client.FieldOwner(a.owner), client.ForceOwnership), errSSAForce)
+	case v1.ConflictPolicyYield:
+		if err := yieldFields(o, fields); err != nil {
+			return fields, err
+		}
+		return fields, errors.Wrap(a.client.Patch(ctx, o, client.Apply, client.FieldOwner(a.owner)), errSSAYield)
+	}
+	return fields, errors.Errorf(errFmtUnsupportedConflictPolicy, p)
+}
+
+// conflictingFields returns the fields that caused the supplied error, if it
+// is a server-side apply conflict.
+func conflictingFields(err error) []string {
+	if !kerrors.IsConflict(err) {
+		return nil
+	}
+	var s kerrors.APIStatus
+	if !errors.As(err, &s) || s.Status().Details == nil {
+		return nil
+	}
+	fields := make([]string, 0, len(s.Status().Details.Causes))
+	for _, c := range s.Status().Details.Causes {
+		if c.Type == metav1.CauseTypeFieldManagerConflict && c.Field != "" {
+			fields = append(fields, c.Field)
+		}
+	}
+	return fields
+}
+
+// yieldFields removes the supplied conflicting fields from the supplied
+// object, so that they are left to their current field managers.
+func yieldFields(o runtime.Object, fields []string) error {
+	p, err := fieldpath.PaveObject(o)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		// Server-side apply reports fields with a leading period, e.g.
+		// .spec.replicas.
+		if err := p.DeleteField(strings.TrimPrefix(f, ".")); err != nil {
+			return errors.Wrapf(err, errFmtYieldField, f)
+		}
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(p.UnstructuredContent(), o)
+}
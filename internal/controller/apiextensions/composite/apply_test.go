@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestServerSideApplicatorApply(t *testing.T) {
+	errBoom := errors.New("boom")
+	errConflict := kerrors.NewApplyConflict([]metav1.StatusCause{{
+		Type:    metav1.CauseTypeFieldManagerConflict,
+		Message: `conflict with "kubectl": .spec.replicas`,
+		Field:   ".spec.replicas",
+	}}, "Apply failed with 1 conflict")
+
+	desired := func() *composed.Unstructured {
+		return composed.New(func(cd *composed.Unstructured) {
+			cd.SetAPIVersion("example.org/v1")
+			cd.SetKind("Composed")
+			cd.SetName("cool-composed")
+			cd.SetResourceVersion("42")
+			cd.Object["spec"] = map[string]any{"replicas": int64(3), "size": "large"}
+		})
+	}
+
+	// conflicting simulates a server-side apply conflict over spec.replicas
+	// with another field manager. The conflict is resolved by forcing
+	// ownership, or by not applying spec.replicas.
+	conflicting := func(ctx context.Context, obj client.Object, p client.Patch, opts ...client.PatchOption) error {
+		if p != client.Apply {
+			return errors.New("unexpected patch type")
+		}
+		po := &client.PatchOptions{}
+		po.ApplyOptions(opts)
+		if po.FieldManager != fieldOwnerComposer {
+			return errors.New("unexpected field manager")
+		}
+		if po.Force != nil && *po.Force {
+			return nil
+		}
+		if _, ok := obj.(*composed.Unstructured).Object["spec"].(map[string]any)["replicas"]; ok {
+			return errConflict
+		}
+		return nil
+	}
+
+	type args struct {
+		o  *composed.Unstructured
+		p  v1.ConflictPolicy
+		ao []resource.ApplyOption
+	}
+	type want struct {
+		o      *composed.Unstructured
+		fields []string
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		client client.Client
+		args   args
+		want   want
+	}{
+		"GetError": {
+			reason: "We should return any error encountered getting the current object.",
+			client: &test.MockClient{
+				MockGet: test.NewMockGetFn(errBoom),
+			},
+			args: args{
+				o: desired(),
+				p: v1.ConflictPolicyFail,
+			},
+			want: want{
+				o:   desired(),
+				err: errors.Wrap(errBoom, errSSAGet),
+			},
+		},
+		"ApplyOptionError": {
+			reason: "We should return any error returned by an ApplyOption.",
+			client: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil),
+			},
+			args: args{
+				o: desired(),
+				p: v1.ConflictPolicyFail,
+				ao: []resource.ApplyOption{func(_ context.Context, _, _ runtime.Object) error {
+					return errBoom
+				}},
+			},
+			want: want{
+				o:   desired(),
+				err: errBoom,
+			},
+		},
+		"NoConflict": {
+			reason: "We should successfully apply an object that doesn't conflict with any other field manager.",
+			client: &test.MockClient{
+				MockGet:   test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "cool-composed")),
+				MockPatch: conflicting,
+			},
+			args: args{
+				o: composed.New(func(cd *composed.Unstructured) {
+					cd.SetName("cool-composed")
+					cd.Object["spec"] = map[string]any{"size": "large"}
+				}),
+				p: v1.ConflictPolicyFail,
+			},
+			want: want{
+				o: composed.New(func(cd *composed.Unstructured) {
+					cd.SetName("cool-composed")
+					cd.Object["spec"] = map[string]any{"size": "large"}
+				}),
+			},
+		},
+		"Fail": {
+			reason: "We should return an error if the object conflicts with another field manager and our policy is Fail.",
+			client: &test.MockClient{
+				MockGet:   test.NewMockGetFn(nil),
+				MockPatch: conflicting,
+			},
+			args: args{
+				o: desired(),
+				p: v1.ConflictPolicyFail,
+			},
+			want: want{
+				o: composed.New(func(cd *composed.Unstructured) {
+					cd.SetAPIVersion("example.org/v1")
+					cd.SetKind("Composed")
+					cd.SetName("cool-composed")
+					cd.Object["spec"] = map[string]any{"replicas": int64(3), "size": "large"}
+				}),
+				fields: []string{".spec.replicas"},
+				err:    errors.Wrap(errConflict, errSSAConflict),
+			},
+		},
+		"Force": {
+			reason: "We should force ownership of conflicting fields if our policy is Force.",
+			client: &test.MockClient{
+				MockGet:   test.NewMockGetFn(nil),
+				MockPatch: conflicting,
+			},
+			args: args{
+				o: desired(),
+				p: v1.ConflictPolicyForce,
+			},
+			want: want{
+				o: composed.New(func(cd *composed.Unstructured) {
+					cd.SetAPIVersion("example.org/v1")
+					cd.SetKind("Composed")
+					cd.SetName("cool-composed")
+					cd.Object["spec"] = map[string]any{"replicas": int64(3), "size": "large"}
+				}),
+				fields: []string{".spec.replicas"},
+			},
+		},
+		"Yield": {
+			reason: "We should stop applying conflicting fields if our policy is Yield, leaving them to their current field manager.",
+			client: &test.MockClient{
+				MockGet:   test.NewMockGetFn(nil),
+				MockPatch: conflicting,
+			},
+			args: args{
+				o: desired(),
+				p: v1.ConflictPolicyYield,
+			},
+			want: want{
+				o: composed.New(func(cd *composed.Unstructured) {
+					cd.SetAPIVersion("example.org/v1")
+					cd.SetKind("Composed")
+					cd.SetName("cool-composed")
+					cd.Object["spec"] = map[string]any{"size": "large"}
+				}),
+				fields: []string{".spec.replicas"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewServerSideApplicator(tc.client, fieldOwnerComposer)
+			fields, err := a.Apply(context.Background(), tc.args.o, tc.args.p, tc.args.ao...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApply(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.fields, fields); diff != "" {
+				t.Errorf("\n%s\nApply(...): -want fields, +got fields:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.o, tc.args.o); diff != "" {
+				t.Errorf("\n%s\nApply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
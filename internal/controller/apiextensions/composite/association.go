@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+// Error strings.
+const (
+	errFmtListExternalName      = "cannot list composed resources of kind %q with external name %q"
+	errFmtAmbiguousExternalName = "cannot associate composed template %q by external name %q: it matches %d composed resources: %s"
+)
+
+// An ExternalNameAssociator associates composed templates with existing
+// resources by their external name. It uses another associator, then
+// associates any template that wasn't associated with a composed resource
+// with the existing resource of the same kind whose external name annotation
+// matches the one declared by the template's base. This allows a composite
+// resource to deterministically adopt pre-existing resources.
+//
+// Only existing resources labelled as owned by the composite resource's team
+// are candidates for adoption. A composite resource that isn't owned by a team
+// may only adopt resources labelled as composed by it, for example because it
+// lost its references to them. Resources controlled by another resource are
+// never adopted.
+type ExternalNameAssociator struct {
+	wrapped CompositionTemplateAssociator
+	client  client.Reader
+}
+
+// NewExternalNameAssociator returns a CompositionTemplateAssociator that
+// associates templates the supplied associator didn't with existing resources
+// by their external name.
+func NewExternalNameAssociator(c client.Reader, wrapped CompositionTemplateAssociator) *ExternalNameAssociator {
+	return &ExternalNameAssociator{wrapped: wrapped, client: c}
+}
+
+// AssociateTemplates with composed resources. Templates whose base doesn't
+// declare an external name, or whose external name doesn't match any existing
+// resource, are associated by the wrapped associator alone. It's an error for
+// more than one existing resource to match an external name.
+func (a *ExternalNameAssociator) AssociateTemplates(ctx context.Context, cr resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+	tas, _, err := a.AssociateTemplatesWithReport(ctx, cr, ct)
+	return tas, err
+}
+
+// WithoutGarbageCollection returns a copy of this associator that wraps a
+// copy of its wrapped associator with garbage collection disabled, if the
+// wrapped associator garbage collects composed resources.
+func (a *ExternalNameAssociator) WithoutGarbageCollection() CompositionTemplateAssociator {
+	na := *a
+	if ca, ok := a.wrapped.(CollectingAssociator); ok {
+		na.wrapped = ca.WithoutGarbageCollection()
+	}
+	return &na
+}
+
+// AssociateTemplatesWithReport associates templates with composed resources,
+// like AssociateTemplates. It returns the wrapped associator's report, if the
+// wrapped associator is a ReportingAssociator.
+func (a *ExternalNameAssociator) AssociateTemplatesWithReport(ctx context.Context, cr resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, AssociationReport, error) {
+	var tas []TemplateAssociation
+	var report AssociationReport
+	var err error
+	if ra, ok := a.wrapped.(ReportingAssociator); ok {
+		tas, report, err = ra.AssociateTemplatesWithReport(ctx, cr, ct)
+	} else {
+		tas, err = a.wrapped.AssociateTemplates(ctx, cr, ct)
+	}
+	if err != nil {
+		return nil, AssociationReport{}, err
+	}
+
+	sel := adoptable(cr)
+	if sel == nil {
+		return tas, report, nil
+	}
+
+	for i := range tas {
+		if tas[i].Reference.Name != "" {
+			continue
+		}
+		ref, err := a.adopt(ctx, cr, tas[i].Template, sel)
+		if err != nil {
+			return nil, AssociationReport{}, err
+		}
+		if ref != nil {
+			tas[i].Reference = *ref
+		}
+	}
+
+	return tas, report, nil
+}
+
+// adopt returns a reference to the existing resource the supplied template
+// should adopt, if any.
+func (a *ExternalNameAssociator) adopt(ctx context.Context, cr resource.Composite, t v1.ComposedTemplate, sel client.MatchingLabels) (*corev1.ObjectReference, error) {
+	// We can't know the external name of a template we can't parse. The
+	// error will be reported when the template is rendered.
+	base := composed.New()
+	if err := json.Unmarshal(t.Base.Raw, base); err != nil {
+		return nil, nil
+	}
+	en := meta.GetExternalName(base)
+	if en == "" {
+		return nil, nil
+	}
+
+	ref := corev1.ObjectReference{APIVersion: base.GetAPIVersion(), Kind: base.GetKind()}
+	l := composed.NewList(composed.FromReferenceToList(ref))
+	if err := a.client.List(ctx, l, client.InNamespace(base.GetNamespace()), sel); err != nil {
+		return nil, errors.Wrapf(err, errFmtListExternalName, base.GetKind(), en)
+	}
+
+	matches := make([]string, 0, 1)
+	for _, u := range l.Items {
+		if meta.GetExternalName(&u) != en {
+			continue
+		}
+		// We mustn't adopt a resource another resource controls.
+		if c := metav1.GetControllerOf(&u); c != nil && c.UID != cr.GetUID() {
+			continue
+		}
+		matches = append(matches, u.GetName())
+		ref.Namespace = u.GetNamespace()
+		ref.Name = u.GetName()
+		ref.UID = u.GetUID()
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &ref, nil
+	default:
+		return nil, errors.Errorf(errFmtAmbiguousExternalName, pointer.StringDeref(t.Name, ""), en, len(matches), strings.Join(matches, ", "))
+	}
+}
+
+// adoptable returns a selector matching the resources the supplied composite
+// resource may adopt, or nil if it may not adopt any.
+func adoptable(cr resource.Composite) client.MatchingLabels {
+	if team := GetOwnerTeam(cr); team != "" {
+		return client.MatchingLabels{LabelKeyOwnerTeam: team}
+	}
+	if prefix := cr.GetLabels()[xcrd.LabelKeyNamePrefixForComposed]; prefix != "" {
+		return client.MatchingLabels{xcrd.LabelKeyNamePrefixForComposed: prefix}
+	}
+	return nil
+}
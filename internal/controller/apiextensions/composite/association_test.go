@@ -0,0 +1,236 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestExternalNameAssociator(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	withExternalName := v1.ComposedTemplate{
+		Name: pointer.String("cool-resource"),
+		Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket","metadata":{"annotations":{"crossplane.io/external-name":"cool-bucket"}}}`)},
+	}
+	withoutExternalName := v1.ComposedTemplate{
+		Name: pointer.String("cool-resource"),
+		Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket"}`)},
+	}
+	existing := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-xr-1234"}
+
+	bucket := func(name, externalName string) kunstructured.Unstructured {
+		u := kunstructured.Unstructured{}
+		u.SetAPIVersion("example.org/v1")
+		u.SetKind("Bucket")
+		u.SetName(name)
+		meta.SetExternalName(&u, externalName)
+		return u
+	}
+	controlled := func(u kunstructured.Unstructured, uid types.UID) kunstructured.Unstructured {
+		ctrl := true
+		u.SetOwnerReferences([]metav1.OwnerReference{{Controller: &ctrl, UID: uid}})
+		return u
+	}
+	list := func(items ...kunstructured.Unstructured) test.MockListFn {
+		return func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+			// Only resources owned by the XR's team are listed.
+			lo := &client.ListOptions{}
+			lo.ApplyOptions(opts)
+			if lo.LabelSelector.String() != LabelKeyOwnerTeam+"=cool-team" {
+				return nil
+			}
+			obj.(*composed.UnstructuredList).Items = items
+			return nil
+		}
+	}
+	xr := func() *fake.Composite {
+		cr := &fake.Composite{}
+		cr.SetUID("cool-xr")
+		SetOwnerTeam(cr, "cool-team")
+		return cr
+	}
+	associator := func(tas ...TemplateAssociation) CompositionTemplateAssociator {
+		return CompositionTemplateAssociatorFn(func(_ context.Context, _ resource.Composite, _ []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+			return tas, nil
+		})
+	}
+
+	type params struct {
+		xr      resource.Composite
+		c       client.Reader
+		wrapped CompositionTemplateAssociator
+	}
+	type want struct {
+		tas []TemplateAssociation
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		params params
+		want   want
+	}{
+		"WrappedError": {
+			reason: "We should return any error encountered by the wrapped associator.",
+			params: params{
+				xr: xr(),
+				wrapped: CompositionTemplateAssociatorFn(func(_ context.Context, _ resource.Composite, _ []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+					return nil, errBoom
+				}),
+			},
+			want: want{
+				err: errBoom,
+			},
+		},
+		"AlreadyAssociated": {
+			reason: "We should not re-associate a template that the wrapped associator associated.",
+			params: params{
+				xr:      xr(),
+				c:       &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				wrapped: associator(TemplateAssociation{Template: withExternalName, Reference: existing}),
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: withExternalName, Reference: existing}},
+			},
+		},
+		"NoExternalName": {
+			reason: "We should fall back to the wrapped associator's association for a template without an external name.",
+			params: params{
+				xr:      xr(),
+				c:       &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				wrapped: associator(TemplateAssociation{Template: withoutExternalName}),
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: withoutExternalName}},
+			},
+		},
+		"ListError": {
+			reason: "We should return any error encountered listing resources by external name.",
+			params: params{
+				xr:      xr(),
+				c:       &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				wrapped: associator(TemplateAssociation{Template: withExternalName}),
+			},
+			want: want{
+				err: errors.Wrapf(errBoom, errFmtListExternalName, "Bucket", "cool-bucket"),
+			},
+		},
+		"NoMatch": {
+			reason: "We should leave a template unassociated if no existing resource has its external name.",
+			params: params{
+				xr:      xr(),
+				c:       &test.MockClient{MockList: list(bucket("other", "other-bucket"))},
+				wrapped: associator(TemplateAssociation{Template: withExternalName}),
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: withExternalName}},
+			},
+		},
+		"Match": {
+			reason: "We should associate a template with the existing resource that has its external name.",
+			params: params{
+				xr:      xr(),
+				c:       &test.MockClient{MockList: list(bucket("other", "other-bucket"), bucket("adopted", "cool-bucket"))},
+				wrapped: associator(TemplateAssociation{Template: withExternalName}),
+			},
+			want: want{
+				tas: []TemplateAssociation{{
+					Template:  withExternalName,
+					Reference: corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "adopted"},
+				}},
+			},
+		},
+		"NotAdoptable": {
+			reason: "We should not adopt existing resources if the XR has neither an owner team nor a composed resource label.",
+			params: params{
+				xr:      &fake.Composite{},
+				c:       &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				wrapped: associator(TemplateAssociation{Template: withExternalName}),
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: withExternalName}},
+			},
+		},
+		"ControlledByAnother": {
+			reason: "We should not adopt an existing resource that is controlled by another resource.",
+			params: params{
+				xr:      xr(),
+				c:       &test.MockClient{MockList: list(controlled(bucket("adopted", "cool-bucket"), "other-xr"))},
+				wrapped: associator(TemplateAssociation{Template: withExternalName}),
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: withExternalName}},
+			},
+		},
+		"ControlledByXR": {
+			reason: "We should associate a template with an existing resource that the XR already controls.",
+			params: params{
+				xr:      xr(),
+				c:       &test.MockClient{MockList: list(controlled(bucket("adopted", "cool-bucket"), "cool-xr"))},
+				wrapped: associator(TemplateAssociation{Template: withExternalName}),
+			},
+			want: want{
+				tas: []TemplateAssociation{{
+					Template:  withExternalName,
+					Reference: corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "adopted"},
+				}},
+			},
+		},
+		"Ambiguous": {
+			reason: "We should return an error if more than one existing resource has a template's external name.",
+			params: params{
+				xr:      xr(),
+				c:       &test.MockClient{MockList: list(bucket("a", "cool-bucket"), bucket("b", "cool-bucket"))},
+				wrapped: associator(TemplateAssociation{Template: withExternalName}),
+			},
+			want: want{
+				err: errors.Errorf(errFmtAmbiguousExternalName, "cool-resource", "cool-bucket", 2, "a, b"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewExternalNameAssociator(tc.params.c, tc.params.wrapped)
+			got, err := a.AssociateTemplates(context.Background(), tc.params.xr, nil)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplates(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.tas, got); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplates(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
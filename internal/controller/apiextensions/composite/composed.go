@@ -16,6 +16,8 @@ specific language governing permissions and limitations under the License.
 package composite
 
 import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
@@ -34,6 +36,27 @@ type ComposedResource struct {
 	// Ready indicates whether this composed resource is ready - i.e. whether
 	// all of its readiness checks passed.
 	Ready bool
+
+	// Drifted indicates whether this composed resource had drifted from its
+	// desired state before it was applied. It is only set when drift
+	// detection is enabled, and is never set for newly created resources.
+	Drifted bool
+
+	// DeadlineExceeded indicates whether this composed resource was not ready
+	// by its creation deadline.
+	DeadlineExceeded bool
+
+	// Optional indicates that this composed resource doesn't block the
+	// readiness of the composite resource.
+	Optional bool
+
+	// Revision is the name of the CompositionRevision this composed resource
+	// was composed from.
+	Revision string
+
+	// GroupVersionKind is the kind of this composed resource. It is unset if
+	// the composed resource couldn't be rendered.
+	GroupVersionKind schema.GroupVersionKind
 }
 
 // ComposedResourceState tracks the state of a composed resource through the
@@ -85,6 +108,12 @@ func MergeComposedResourceStates(old, new ComposedResourceState) ComposedResourc
 	if new.Ready {
 		out.Ready = new.Ready
 	}
+	if new.Drifted {
+		out.Drifted = new.Drifted
+	}
+	if new.DeadlineExceeded {
+		out.DeadlineExceeded = new.DeadlineExceeded
+	}
 	if new.TemplateRenderErr != nil {
 		out.TemplateRenderErr = new.TemplateRenderErr
 	}
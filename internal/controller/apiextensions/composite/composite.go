@@ -27,6 +27,23 @@ import (
 // Annotation keys.
 const (
 	AnnotationKeyCompositionResourceName = "crossplane.io/composition-resource-name"
+	AnnotationKeyDesiredChecksum         = "crossplane.io/composition-desired-checksum"
+	AnnotationKeyGarbageCollectionPolicy = "crossplane.io/composition-garbage-collection-policy"
+	AnnotationKeyRenderChecksum          = "crossplane.io/composition-render-checksum"
+	AnnotationKeyCompositionName         = "crossplane.io/composition-name"
+	AnnotationKeyCompositionRevisionName = "crossplane.io/composition-revision-name"
+	AnnotationKeyManagedBy               = "crossplane.io/managed-by"
+
+	// AnnotationKeyLastAppliedConfiguration is the configuration of a
+	// composed resource that was last applied, as compact JSON.
+	AnnotationKeyLastAppliedConfiguration = "crossplane.io/composition-last-applied-configuration"
+)
+
+// Label keys.
+const (
+	// LabelKeyOwnerTeam is the team that owns a composite resource and the
+	// resources composed by it.
+	LabelKeyOwnerTeam = "crossplane.io/owner-team"
 )
 
 // SetCompositionResourceName sets the name of the composition template used to
@@ -41,6 +58,99 @@ func GetCompositionResourceName(o metav1.Object) string {
 	return o.GetAnnotations()[AnnotationKeyCompositionResourceName]
 }
 
+// SetDesiredChecksum sets the checksum of the desired state of a composed
+// resource as an annotation.
+func SetDesiredChecksum(o metav1.Object, sum string) {
+	meta.AddAnnotations(o, map[string]string{AnnotationKeyDesiredChecksum: sum})
+}
+
+// GetDesiredChecksum gets the checksum of the desired state of a composed
+// resource that was last applied from its annotations.
+func GetDesiredChecksum(o metav1.Object) string {
+	return o.GetAnnotations()[AnnotationKeyDesiredChecksum]
+}
+
+// SetLastAppliedConfiguration sets the configuration of a composed resource
+// that is about to be applied as an annotation.
+func SetLastAppliedConfiguration(o metav1.Object, cfg string) {
+	meta.AddAnnotations(o, map[string]string{AnnotationKeyLastAppliedConfiguration: cfg})
+}
+
+// GetLastAppliedConfiguration gets the configuration of a composed resource
+// that was last applied from its annotations.
+func GetLastAppliedConfiguration(o metav1.Object) string {
+	return o.GetAnnotations()[AnnotationKeyLastAppliedConfiguration]
+}
+
+// SetRenderChecksum sets the checksum of the inputs used to render a composed
+// resource as an annotation.
+func SetRenderChecksum(o metav1.Object, sum string) {
+	meta.AddAnnotations(o, map[string]string{AnnotationKeyRenderChecksum: sum})
+}
+
+// GetRenderChecksum gets the checksum of the inputs used to render a composed
+// resource that was last applied from its annotations.
+func GetRenderChecksum(o metav1.Object) string {
+	return o.GetAnnotations()[AnnotationKeyRenderChecksum]
+}
+
+// SetGarbageCollectionPolicy sets the garbage collection policy of a composed
+// resource as an annotation.
+func SetGarbageCollectionPolicy(o metav1.Object, p v1.GarbageCollectionPolicy) {
+	meta.AddAnnotations(o, map[string]string{AnnotationKeyGarbageCollectionPolicy: string(p)})
+}
+
+// GetGarbageCollectionPolicy gets the garbage collection policy of a composed
+// resource from its annotations. It returns Delete if no policy is set.
+func GetGarbageCollectionPolicy(o metav1.Object) v1.GarbageCollectionPolicy {
+	if p := o.GetAnnotations()[AnnotationKeyGarbageCollectionPolicy]; p != "" {
+		return v1.GarbageCollectionPolicy(p)
+	}
+	return v1.GarbageCollectionPolicyDelete
+}
+
+// SetStandardAnnotations annotates a composed resource with the name of the
+// supplied CompositionRevision, the name of its Composition, and the supplied
+// manager. Annotations the composed resource already has are not overwritten.
+// The manager annotation is omitted if the supplied manager is empty.
+func SetStandardAnnotations(o metav1.Object, rev *v1.CompositionRevision, manager string) {
+	add := map[string]string{
+		AnnotationKeyCompositionName:         rev.GetLabels()[v1.LabelCompositionName],
+		AnnotationKeyCompositionRevisionName: rev.GetName(),
+		AnnotationKeyManagedBy:               manager,
+	}
+	for k, v := range add {
+		if _, exists := o.GetAnnotations()[k]; exists || v == "" {
+			delete(add, k)
+		}
+	}
+	if len(add) > 0 {
+		meta.AddAnnotations(o, add)
+	}
+}
+
+// SetOwnerTeam sets the team that owns a resource as a label. It is a no-op if
+// the supplied team is empty.
+func SetOwnerTeam(o metav1.Object, team string) {
+	if team == "" {
+		return
+	}
+	meta.AddLabels(o, map[string]string{LabelKeyOwnerTeam: team})
+}
+
+// GetOwnerTeam gets the team that owns a resource from its labels.
+func GetOwnerTeam(o metav1.Object) string {
+	return o.GetLabels()[LabelKeyOwnerTeam]
+}
+
+// SameOwnerTeam returns true if the supplied resources are owned by the same
+// team. Resources that aren't owned by any team are considered to be owned by
+// the same team as any other resource.
+func SameOwnerTeam(a, b metav1.Object) bool {
+	ta, tb := GetOwnerTeam(a), GetOwnerTeam(b)
+	return ta == "" || tb == "" || ta == tb
+}
+
 // Returns types of patches that are from a composed resource _to_ a composite resource.
 func patchTypesToXR() []v1.PatchType {
 	return []v1.PatchType{v1.PatchTypeToCompositeFieldPath, v1.PatchTypeCombineToComposite}
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Error strings.
+const (
+	errSelectComposer = "cannot select a Composer"
+)
+
+// A CompositionMode determines how a Composition composes resources.
+type CompositionMode string
+
+// Composition modes.
+const (
+	// CompositionModePatchAndTransform composes resources using a
+	// Composition's bases, patches, and transforms.
+	CompositionModePatchAndTransform CompositionMode = "PatchAndTransform"
+
+	// CompositionModeFunctions composes resources using a Composition's
+	// bases, patches, and transforms, then its Composition Functions.
+	CompositionModeFunctions CompositionMode = "Functions"
+)
+
+// A CompositionModeFn returns the CompositionMode of the supplied
+// CompositionRevision.
+type CompositionModeFn func(rev *v1.CompositionRevision) CompositionMode
+
+// ModeOf returns the CompositionMode of the supplied CompositionRevision. A
+// revision that specifies any Composition Functions uses Functions mode. All
+// others use PatchAndTransform mode.
+func ModeOf(rev *v1.CompositionRevision) CompositionMode {
+	if rev != nil && len(rev.Spec.Functions) > 0 {
+		return CompositionModeFunctions
+	}
+	return CompositionModePatchAndTransform
+}
+
+// A ComposerSelector selects the Composer that should be used to compose
+// resources for the supplied XR and composition request.
+type ComposerSelector interface {
+	SelectComposer(ctx context.Context, xr resource.Composite, req CompositionRequest) (Composer, error)
+}
+
+// A ComposerSelectorFn selects a Composer.
+type ComposerSelectorFn func(ctx context.Context, xr resource.Composite, req CompositionRequest) (Composer, error)
+
+// SelectComposer selects a Composer.
+func (fn ComposerSelectorFn) SelectComposer(ctx context.Context, xr resource.Composite, req CompositionRequest) (Composer, error) {
+	return fn(ctx, xr, req)
+}
+
+// A ModeComposerSelector selects a Composer according to the CompositionMode
+// of the requested CompositionRevision.
+type ModeComposerSelector struct {
+	modes map[CompositionMode]Composer
+	mode  CompositionModeFn
+}
+
+// A ModeComposerSelectorOption configures a ModeComposerSelector.
+type ModeComposerSelectorOption func(s *ModeComposerSelector)
+
+// WithModeComposer configures a ModeComposerSelector to select the supplied
+// Composer for revisions of the supplied CompositionMode.
+func WithModeComposer(m CompositionMode, c Composer) ModeComposerSelectorOption {
+	return func(s *ModeComposerSelector) {
+		s.modes[m] = c
+	}
+}
+
+// WithCompositionModeFn configures how a ModeComposerSelector determines the
+// CompositionMode of a CompositionRevision. ModeOf is used by default.
+func WithCompositionModeFn(fn CompositionModeFn) ModeComposerSelectorOption {
+	return func(s *ModeComposerSelector) {
+		s.mode = fn
+	}
+}
+
+// NewModeComposerSelector returns a ComposerSelector that selects a Composer
+// according to the CompositionMode of the requested CompositionRevision. The
+// supplied Composer is selected for PatchAndTransform mode, and for any mode
+// that no Composer is configured for. Composers for other modes may be
+// configured using WithModeComposer.
+func NewModeComposerSelector(pt Composer, o ...ModeComposerSelectorOption) *ModeComposerSelector {
+	s := &ModeComposerSelector{modes: map[CompositionMode]Composer{CompositionModePatchAndTransform: pt}, mode: ModeOf}
+	for _, fn := range o {
+		fn(s)
+	}
+	return s
+}
+
+// SelectComposer selects the Composer for the CompositionMode of the
+// requested CompositionRevision. It falls back to the PatchAndTransform
+// Composer if no Composer is configured for the mode. For example a revision
+// that uses Composition Functions is composed using only its patches and
+// transforms when Composition Functions aren't enabled.
+func (s *ModeComposerSelector) SelectComposer(_ context.Context, _ resource.Composite, req CompositionRequest) (Composer, error) {
+	if c, ok := s.modes[s.mode(req.Revision)]; ok {
+		return c, nil
+	}
+	return s.modes[CompositionModePatchAndTransform], nil
+}
+
+// A SelectingComposer composes resources using the Composer selected by a
+// ComposerSelector. This allows new modes of composition to be added without
+// changing the Reconciler.
+type SelectingComposer struct {
+	selector ComposerSelector
+}
+
+// NewSelectingComposer returns a Composer that composes resources using the
+// Composer selected by the supplied ComposerSelector.
+func NewSelectingComposer(s ComposerSelector) *SelectingComposer {
+	return &SelectingComposer{selector: s}
+}
+
+// Compose resources using the selected Composer.
+func (c *SelectingComposer) Compose(ctx context.Context, xr resource.Composite, req CompositionRequest) (CompositionResult, error) {
+	cp, err := c.selector.SelectComposer(ctx, xr, req)
+	if err != nil {
+		return CompositionResult{}, errors.Wrap(err, errSelectComposer)
+	}
+	return cp.Compose(ctx, xr, req)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestSelectingComposer(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	pt := &MockComposer{res: CompositionResult{ConnectionDetails: managed.ConnectionDetails{"mode": []byte("pt")}}}
+	alt := &MockComposer{res: CompositionResult{ConnectionDetails: managed.ConnectionDetails{"mode": []byte("alt")}}}
+
+	withFunctions := &v1.CompositionRevision{Spec: v1.CompositionRevisionSpec{Functions: []v1.Function{{Name: "cool-fn"}}}}
+
+	type want struct {
+		res CompositionResult
+		err error
+	}
+
+	cases := map[string]struct {
+		reason   string
+		selector ComposerSelector
+		req      CompositionRequest
+		want     want
+	}{
+		"SelectorError": {
+			reason: "We should return any error encountered while selecting a Composer.",
+			selector: ComposerSelectorFn(func(_ context.Context, _ resource.Composite, _ CompositionRequest) (Composer, error) {
+				return nil, errBoom
+			}),
+			req: CompositionRequest{Revision: &v1.CompositionRevision{}},
+			want: want{
+				err: errors.Wrap(errBoom, errSelectComposer),
+			},
+		},
+		"UnconfiguredMode": {
+			reason:   "We should fall back to the P&T Composer when no Composer is configured for the revision's mode.",
+			selector: NewModeComposerSelector(pt),
+			req:      CompositionRequest{Revision: withFunctions},
+			want: want{
+				res: pt.res,
+			},
+		},
+		"PatchAndTransformMode": {
+			reason:   "We should use the P&T Composer for a revision that uses PatchAndTransform mode.",
+			selector: NewModeComposerSelector(pt, WithModeComposer(CompositionModeFunctions, alt)),
+			req:      CompositionRequest{Revision: &v1.CompositionRevision{}},
+			want: want{
+				res: pt.res,
+			},
+		},
+		"InjectedComposer": {
+			reason:   "We should use the Composer configured for the revision's mode.",
+			selector: NewModeComposerSelector(pt, WithModeComposer(CompositionModeFunctions, alt)),
+			req:      CompositionRequest{Revision: withFunctions},
+			want: want{
+				res: alt.res,
+			},
+		},
+		"CustomMode": {
+			reason: "We should use the supplied function to determine the revision's mode.",
+			selector: NewModeComposerSelector(pt,
+				WithModeComposer(CompositionMode("Cool"), alt),
+				WithCompositionModeFn(func(_ *v1.CompositionRevision) CompositionMode { return CompositionMode("Cool") }),
+			),
+			req: CompositionRequest{Revision: &v1.CompositionRevision{}},
+			want: want{
+				res: alt.res,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := NewSelectingComposer(tc.selector)
+			res, err := c.Compose(context.Background(), &fake.Composite{}, tc.req)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.res, res); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -31,17 +31,70 @@ import (
 )
 
 const (
-	errPatchSetType             = "a patch in a PatchSet cannot be of type PatchSet"
 	errCombineRequiresVariables = "combine patch types require at least one variable"
 
 	errFmtUndefinedPatchSet           = "cannot find PatchSet by name %s"
+	errFmtPatchSetCycle               = "cannot inline PatchSets that reference themselves: %s"
 	errFmtInvalidPatchType            = "patch type %s is unsupported"
 	errFmtCombineStrategyNotSupported = "combine strategy %s is not supported"
 	errFmtCombineConfigMissing        = "given combine strategy %s requires configuration"
 	errFmtCombineStrategyFailed       = "%s strategy could not combine"
+	errFmtCombineNotObject            = "variable at index %d is a %T, not an object"
 	errFmtExpandingArrayFieldPaths    = "cannot expand ToFieldPath %s"
+	errFmtAppendNotArray              = "cannot append to %s: it is not an array, got %T"
+	errFmtEachElementNotArray         = "eachElement requires an array input, got %T"
+	errFmtElementNotObject            = "element patches require an object element, got %T"
+	errFmtEachElementAtIndex          = "cannot patch element at index %d"
+	errFmtElementPatchAtIndex         = "element patch at index %d"
 )
 
+// A PatchError is returned when a patch can't be applied. It identifies the
+// patch that couldn't be applied, and why.
+type PatchError struct {
+	index int
+	patch v1.Patch
+	err   error
+}
+
+// NewPatchError returns a PatchError indicating that the supplied patch, at the
+// supplied index of its array of patches, couldn't be applied because of the
+// supplied error.
+func NewPatchError(index int, p v1.Patch, err error) *PatchError {
+	return &PatchError{index: index, patch: p, err: err}
+}
+
+// Error returns a message describing the patch that couldn't be applied.
+func (e *PatchError) Error() string {
+	return fmt.Sprintf(errFmtPatch, e.index) + ": " + e.err.Error()
+}
+
+// Unwrap returns the reason the patch couldn't be applied.
+func (e *PatchError) Unwrap() error {
+	return e.err
+}
+
+// Index returns the index of the patch that couldn't be applied.
+func (e *PatchError) Index() int {
+	return e.index
+}
+
+// Type returns the type of the patch that couldn't be applied.
+func (e *PatchError) Type() v1.PatchType {
+	return e.patch.GetType()
+}
+
+// FromFieldPath returns the field path the patch that couldn't be applied
+// patches from, if any.
+func (e *PatchError) FromFieldPath() string {
+	return e.patch.GetFromFieldPath()
+}
+
+// ToFieldPath returns the field path the patch that couldn't be applied
+// patches to, if any.
+func (e *PatchError) ToFieldPath() string {
+	return e.patch.GetToFieldPath()
+}
+
 // ApplyEnvironmentPatch executes a patching operation between the cp and env objects.
 func ApplyEnvironmentPatch(p v1.EnvironmentPatch, cp, env runtime.Object) error {
 	// TODO(negz): Should this take composite.Resource and *env.Environment as
@@ -122,6 +175,60 @@ func ResolveTransforms(c v1.Patch, input any) (any, error) {
 	return input, nil
 }
 
+// ResolveEachElement applies a patch's transforms and element patches to each
+// element of the supplied array, returning the resulting array.
+func ResolveEachElement(p v1.Patch, input any) (any, error) {
+	in, ok := input.([]any)
+	if !ok {
+		return nil, errors.Errorf(errFmtEachElementNotArray, input)
+	}
+
+	out := make([]any, len(in))
+	for i := range in {
+		e, err := ResolveTransforms(p, in[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtEachElementAtIndex, i)
+		}
+		if len(p.EachElement.Patches) > 0 {
+			e, err = resolveElementPatches(p.EachElement.Patches, e)
+			if err != nil {
+				return nil, errors.Wrapf(err, errFmtEachElementAtIndex, i)
+			}
+		}
+		out[i] = e
+	}
+	return out, nil
+}
+
+// resolveElementPatches builds a new object by copying fields from the
+// supplied element, which must itself be an object.
+func resolveElementPatches(eps []v1.ElementPatch, element any) (any, error) {
+	from, ok := element.(map[string]any)
+	if !ok {
+		return nil, errors.Errorf(errFmtElementNotObject, element)
+	}
+
+	to := fieldpath.Pave(map[string]any{})
+	for i, ep := range eps {
+		v, err := fieldpath.Pave(from).GetValue(ep.FromFieldPath)
+		if fieldpath.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtElementPatchAtIndex, i)
+		}
+		for j, t := range ep.Transforms {
+			if v, err = Resolve(t, v); err != nil {
+				return nil, errors.Wrapf(errors.Wrapf(err, errFmtTransformAtIndex, j), errFmtElementPatchAtIndex, i)
+			}
+		}
+		if err := to.SetValue(ep.GetToFieldPath(), v); err != nil {
+			return nil, errors.Wrapf(err, errFmtElementPatchAtIndex, i)
+		}
+	}
+	return to.UnstructuredContent(), nil
+}
+
 // patchFieldValueToMultiple, given a path with wildcards in an array index,
 // expands the arrays paths in the "to" object and patches the value into each
 // of the resulting fields, returning any errors as they occur.
@@ -149,17 +256,52 @@ func patchFieldValueToMultiple(fieldPath string, value any, to runtime.Object, m
 	return runtime.DefaultUnstructuredConverter.FromUnstructured(paved.UnstructuredContent(), to)
 }
 
+// Metadata field path prefixes.
+const (
+	fieldPathLabels      = "metadata.labels"
+	fieldPathAnnotations = "metadata.annotations"
+)
+
+// LabelFieldPath returns the field path of the supplied label key. The key is
+// bracketed, so it may contain dots and slashes.
+func LabelFieldPath(key string) string {
+	return fieldPathLabels + "[" + key + "]"
+}
+
+// AnnotationFieldPath returns the field path of the supplied annotation key.
+// The key is bracketed, so it may contain dots and slashes.
+func AnnotationFieldPath(key string) string {
+	return fieldPathAnnotations + "[" + key + "]"
+}
+
+// metadataFieldPath returns the supplied field path with the key of any label
+// or annotation it refers to using dot notation bracketed. Label and
+// annotation keys frequently contain dots (e.g. app.kubernetes.io/name), so
+// everything after metadata.labels. or metadata.annotations. is treated as a
+// single key. Other field paths are returned unchanged.
+func metadataFieldPath(path string) string {
+	switch {
+	case strings.HasPrefix(path, fieldPathLabels+".") && len(path) > len(fieldPathLabels)+1:
+		return LabelFieldPath(strings.TrimPrefix(path, fieldPathLabels+"."))
+	case strings.HasPrefix(path, fieldPathAnnotations+".") && len(path) > len(fieldPathAnnotations)+1:
+		return AnnotationFieldPath(strings.TrimPrefix(path, fieldPathAnnotations+"."))
+	}
+	return path
+}
+
 // ApplyFromFieldPathPatch patches the "to" resource, using a source field
 // on the "from" resource. Values may be transformed if any are defined on
-// the patch.
+// the patch. Label and annotation keys may be referenced using dot notation,
+// e.g. metadata.labels.app.kubernetes.io/name, even if they contain dots.
 func ApplyFromFieldPathPatch(p v1.Patch, from, to runtime.Object) error {
 	if p.FromFieldPath == nil {
 		return errors.Errorf(errFmtRequiredField, "FromFieldPath", p.Type)
 	}
+	fromFieldPath := metadataFieldPath(*p.FromFieldPath)
 
 	// Default to patching the same field on the composed resource.
 	if p.ToFieldPath == nil {
-		p.ToFieldPath = p.FromFieldPath
+		p.ToFieldPath = &fromFieldPath
 	}
 
 	fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
@@ -167,7 +309,7 @@ func ApplyFromFieldPathPatch(p v1.Patch, from, to runtime.Object) error {
 		return err
 	}
 
-	in, err := fieldpath.Pave(fromMap).GetValue(*p.FromFieldPath)
+	in, err := fieldpath.Pave(fromMap).GetValue(fromFieldPath)
 	if IsOptionalFieldPathNotFound(err, p.Policy) {
 		return nil
 	}
@@ -180,8 +322,14 @@ func ApplyFromFieldPathPatch(p v1.Patch, from, to runtime.Object) error {
 		mo = p.Policy.MergeOptions
 	}
 
-	// Apply transform pipeline
-	out, err := ResolveTransforms(p, in)
+	// Apply transform pipeline, either to the input as a whole or to each of
+	// its elements.
+	var out any
+	if p.EachElement != nil {
+		out, err = ResolveEachElement(p, in)
+	} else {
+		out, err = ResolveTransforms(p, in)
+	}
 	if err != nil {
 		return err
 	}
@@ -236,6 +384,13 @@ func ApplyCombineFromVariablesPatch(p v1.Patch, from, to runtime.Object) error {
 		// expecting 3 fields '%s-%s-%s' but only
 		// receiving 2 values).
 		if IsOptionalFieldPathNotFound(err, p.Policy) {
+			if p.Combine.MissingVariablePolicy != nil && *p.Combine.MissingVariablePolicy == v1.CombineMissingVariablePolicyEmpty {
+				in[i] = ""
+				if p.Combine.Strategy == v1.CombineStrategyObject {
+					in[i] = map[string]any{}
+				}
+				continue
+			}
 			return nil
 		}
 		if err != nil {
@@ -285,13 +440,17 @@ func Combine(c v1.Combine, vars []any) (any, error) {
 		if c.String == nil {
 			return nil, errors.Errorf(errFmtCombineConfigMissing, c.Strategy)
 		}
+		if c.String.Separator != nil {
+			out, err = CombineStringSeparator(*c.String.Separator, vars)
+			break
+		}
 		out, err = CombineString(c.String.Format, vars)
+	case v1.CombineStrategyObject:
+		out, err = CombineObject(vars)
 	default:
 		return nil, errors.Errorf(errFmtCombineStrategyNotSupported, c.Strategy)
 	}
 
-	// Note: There are currently no tests or triggers to exercise this error as
-	// our only strategy ("String") uses fmt.Sprintf, which cannot return an error.
 	return out, errors.Wrapf(err, errFmtCombineStrategyFailed, string(c.Strategy))
 }
 
@@ -301,38 +460,97 @@ func CombineString(format string, vars []any) (any, error) {
 	return fmt.Sprintf(format, vars...), nil
 }
 
+// CombineStringSeparator returns a single output by joining the string
+// representation of all of its input variables with the supplied separator.
+func CombineStringSeparator(sep string, vars []any) (any, error) {
+	ss := make([]string, len(vars))
+	for i := range vars {
+		ss[i] = fmt.Sprint(vars[i])
+	}
+	return strings.Join(ss, sep), nil
+}
+
+// CombineObject returns a single object by merging all of its input variables,
+// which must be objects. Keys are merged shallowly; when more than one input
+// variable has the same key the value of the last one wins.
+func CombineObject(vars []any) (any, error) {
+	out := map[string]any{}
+	for i := range vars {
+		m, ok := vars[i].(map[string]any)
+		if !ok {
+			return nil, errors.Errorf(errFmtCombineNotObject, i, vars[i])
+		}
+		for k, v := range m {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
 // ComposedTemplates returns the supplied composed resource templates with any
 // supplied patchsets dereferenced.
 func ComposedTemplates(pss []v1.PatchSet, cts []v1.ComposedTemplate) ([]v1.ComposedTemplate, error) {
 	pn := make(map[string][]v1.Patch)
 	for _, s := range pss {
-		for _, p := range s.Patches {
-			if p.Type == v1.PatchTypePatchSet {
-				return nil, errors.New(errPatchSetType)
-			}
-		}
 		pn[s.Name] = s.Patches
 	}
 
 	ct := make([]v1.ComposedTemplate, len(cts))
 	for i, r := range cts {
-		var po []v1.Patch
-		for _, p := range r.Patches {
-			if p.Type != v1.PatchTypePatchSet {
-				po = append(po, p)
-				continue
-			}
-			if p.PatchSetName == nil {
-				return nil, errors.Errorf(errFmtRequiredField, "PatchSetName", p.Type)
-			}
-			ps, ok := pn[*p.PatchSetName]
-			if !ok {
-				return nil, errors.Errorf(errFmtUndefinedPatchSet, *p.PatchSetName)
-			}
-			po = append(po, ps...)
+		po, err := inlinePatchSets(pn, r.Patches, nil)
+		if err != nil {
+			return nil, err
 		}
 		ct[i] = r
 		ct[i].Patches = po
 	}
 	return ct, nil
 }
+
+// inlinePatchSets returns the supplied patches with any references to the
+// supplied patch sets replaced by the patches they contain. Patch sets may
+// themselves reference patch sets. The supplied path is the chain of patch
+// sets being inlined, which is used to detect cycles.
+func inlinePatchSets(pn map[string][]v1.Patch, patches []v1.Patch, path []string) ([]v1.Patch, error) {
+	var po []v1.Patch
+	for _, p := range patches {
+		if p.Type != v1.PatchTypePatchSet {
+			po = append(po, p)
+			continue
+		}
+		if p.PatchSetName == nil {
+			return nil, errors.Errorf(errFmtRequiredField, "PatchSetName", p.Type)
+		}
+		name := *p.PatchSetName
+		for _, n := range path {
+			if n == name {
+				return nil, errors.Errorf(errFmtPatchSetCycle, strings.Join(append(path, name), " -> "))
+			}
+		}
+		ps, ok := pn[name]
+		if !ok {
+			return nil, errors.Errorf(errFmtUndefinedPatchSet, name)
+		}
+		inlined, err := inlinePatchSets(pn, ps, append(path[:len(path):len(path)], name))
+		if err != nil {
+			return nil, err
+		}
+		po = append(po, inlined...)
+	}
+	return po, nil
+}
+
+// EffectiveRevision returns a copy of the supplied CompositionRevision with its
+// patch sets inlined into its composed templates. This is the revision a
+// PTComposer composes resources with, so it may be used to audit exactly what
+// a Composition will do. The supplied revision is not modified.
+func EffectiveRevision(rev *v1.CompositionRevision) (*v1.CompositionRevision, error) {
+	out := rev.DeepCopy()
+	ct, err := ComposedTemplates(out.Spec.PatchSets, out.Spec.Resources)
+	if err != nil {
+		return nil, errors.Wrap(err, errInline)
+	}
+	out.Spec.Resources = ct
+	out.Spec.PatchSets = nil
+	return out, nil
+}
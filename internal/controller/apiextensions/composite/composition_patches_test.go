@@ -17,7 +17,9 @@ limitations under the License.
 package composite
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -31,6 +33,8 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
@@ -47,6 +51,7 @@ func TestPatchApply(t *testing.T) {
 		_, err := p.GetValue(path)
 		return err
 	}
+	empty := v1.CombineMissingVariablePolicyEmpty
 
 	type args struct {
 		patch v1.Patch
@@ -128,6 +133,76 @@ func TestPatchApply(t *testing.T) {
 				err: nil,
 			},
 		},
+		"ConvertObjectToJSON": {
+			reason: "Should patch an object as a JSON string when it is converted to a string using the json format",
+			args: args{
+				patch: v1.Patch{
+					Type:          v1.PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.String("objectMeta.labels"),
+					ToFieldPath:   pointer.String("objectMeta.annotations[labels]"),
+					Transforms: []v1.Transform{{
+						Type: v1.TransformTypeConvert,
+						Convert: &v1.ConvertTransform{
+							ToType: v1.TransformIOTypeString,
+							Format: (*v1.ConvertTransformFormat)(pointer.String(string(v1.ConvertTransformFormatJSON))),
+						},
+					}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"Test": "blah"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "cd",
+						Annotations: map[string]string{"labels": `{"Test":"blah"}`},
+					},
+				},
+			},
+		},
+		"ConvertJSONToObject": {
+			reason: "Should patch a JSON string as an object when it is converted to an object using the json format",
+			args: args{
+				patch: v1.Patch{
+					Type:          v1.PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.String("objectMeta.annotations[labels]"),
+					ToFieldPath:   pointer.String("objectMeta.labels"),
+					Transforms: []v1.Transform{{
+						Type: v1.TransformTypeConvert,
+						Convert: &v1.ConvertTransform{
+							ToType: v1.TransformIOTypeObject,
+							Format: (*v1.ConvertTransformFormat)(pointer.String(string(v1.ConvertTransformFormatJSON))),
+						},
+					}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "cp",
+						Annotations: map[string]string{"labels": `{"Test":"blah"}`},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"Test": "blah"},
+					},
+				},
+			},
+		},
 		"ValidCompositeFieldPathPatchWithNilLastPublishTime": {
 			reason: "Should correctly apply a CompositeFieldPathPatch with valid settings",
 			args: args{
@@ -899,6 +974,259 @@ func TestPatchApply(t *testing.T) {
 				err: nil,
 			},
 		},
+		"MissingOptionalInputFieldCombinedAsEmpty": {
+			reason: "Should combine a missing optional variable as an empty string if the missing variable policy is Empty",
+			args: args{
+				patch: v1.Patch{
+					Type: v1.PatchTypeCombineFromComposite,
+					Combine: &v1.Combine{
+						Variables: []v1.CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels.source2"},
+							{FromFieldPath: "objectMeta.labels.source3"},
+						},
+						Strategy:              v1.CombineStrategyString,
+						String:                &v1.StringCombine{Format: "%s-%s-%s"},
+						MissingVariablePolicy: &empty,
+					},
+					ToFieldPath: pointer.String("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo", "source3": "baz"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo", "source3": "baz"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd", Labels: map[string]string{"destination": "foo--baz"}},
+				},
+				err: nil,
+			},
+		},
+		"MissingRequiredInputFieldNotCombinedAsEmpty": {
+			reason: "Should return an error if a required variable is missing, even if the missing variable policy is Empty",
+			args: args{
+				patch: v1.Patch{
+					Type: v1.PatchTypeCombineFromComposite,
+					Combine: &v1.Combine{
+						Variables: []v1.CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels.source2"},
+							{FromFieldPath: "objectMeta.labels.source3"},
+						},
+						Strategy:              v1.CombineStrategyString,
+						String:                &v1.StringCombine{Format: "%s-%s-%s"},
+						MissingVariablePolicy: &empty,
+					},
+					ToFieldPath: pointer.String("objectMeta.labels.destination"),
+					Policy: &v1.PatchPolicy{
+						FromFieldPath: func() *v1.FromFieldPathPolicy {
+							s := v1.FromFieldPathPolicyRequired
+							return &s
+						}(),
+					},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo", "source3": "baz"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo", "source3": "baz"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+				err: func() error {
+					_, err := fieldpath.Pave(map[string]any{"objectMeta": map[string]any{"labels": map[string]any{}}}).GetValue("objectMeta.labels.source2")
+					return err
+				}(),
+			},
+		},
+		"ValidCombineWithSeparator": {
+			reason: "Should join variables using the separator",
+			args: args{
+				patch: v1.Patch{
+					Type: v1.PatchTypeCombineFromComposite,
+					Combine: &v1.Combine{
+						Variables: []v1.CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels.source2"},
+							{FromFieldPath: "objectMeta.labels.source3"},
+						},
+						Strategy:              v1.CombineStrategyString,
+						String:                &v1.StringCombine{Separator: pointer.String("-")},
+						MissingVariablePolicy: nil,
+					},
+					ToFieldPath: pointer.String("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo", "source2": "bar", "source3": "baz"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo", "source2": "bar", "source3": "baz"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd", Labels: map[string]string{"destination": "foo-bar-baz"}},
+				},
+				err: nil,
+			},
+		},
+		"ValidCombineObject": {
+			reason: "Should merge object variables into a single object, with the value of the last variable winning when keys conflict",
+			args: args{
+				patch: v1.Patch{
+					Type: v1.PatchTypeCombineFromComposite,
+					Combine: &v1.Combine{
+						Variables: []v1.CombineVariable{
+							{FromFieldPath: "objectMeta.labels"},
+							{FromFieldPath: "objectMeta.annotations"},
+						},
+						Strategy: v1.CombineStrategyObject,
+					},
+					ToFieldPath: pointer.String("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "cp",
+						Labels:      map[string]string{"source1": "foo", "shared": "label"},
+						Annotations: map[string]string{"source2": "bar", "shared": "annotation"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "cp",
+						Labels:      map[string]string{"source1": "foo", "shared": "label"},
+						Annotations: map[string]string{"source2": "bar", "shared": "annotation"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd", Labels: map[string]string{"source1": "foo", "source2": "bar", "shared": "annotation"}},
+				},
+			},
+		},
+		"ValidCombineObjectMissingVariableEmpty": {
+			reason: "Should merge a missing variable as an empty object when the missing variable policy is Empty",
+			args: args{
+				patch: v1.Patch{
+					Type: v1.PatchTypeCombineFromComposite,
+					Combine: &v1.Combine{
+						Variables: []v1.CombineVariable{
+							{FromFieldPath: "objectMeta.labels"},
+							{FromFieldPath: "objectMeta.annotations"},
+						},
+						Strategy:              v1.CombineStrategyObject,
+						MissingVariablePolicy: &empty,
+					},
+					ToFieldPath: pointer.String("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd", Labels: map[string]string{"source1": "foo"}},
+				},
+			},
+		},
+		"InvalidCombineObjectNotObject": {
+			reason: "Should return an error if a variable combined using the object strategy is not an object",
+			args: args{
+				patch: v1.Patch{
+					Type: v1.PatchTypeCombineFromComposite,
+					Combine: &v1.Combine{
+						Variables: []v1.CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels"},
+						},
+						Strategy: v1.CombineStrategyObject,
+					},
+					ToFieldPath: pointer.String("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+				err: errors.Wrapf(errors.Errorf(errFmtCombineNotObject, 0, "foo"), errFmtCombineStrategyFailed, v1.CombineStrategyObject),
+			},
+		},
 		"ValidCombineFromComposite": {
 			reason: "Should correctly apply a CombineFromComposite patch with valid settings",
 			args: args{
@@ -1090,32 +1418,368 @@ func TestPatchApply(t *testing.T) {
 	}
 }
 
-func TestOptionalFieldPathNotFound(t *testing.T) {
-	errBoom := errors.New("boom")
-	errNotFound := func() error {
-		p := &fieldpath.Paved{}
-		_, err := p.GetValue("boom")
-		return err
+func TestPatchApplyMetadata(t *testing.T) {
+	xr := func(labels map[string]string) *composite.Unstructured {
+		cp := composite.New()
+		cp.SetAPIVersion("example.org/v1")
+		cp.SetKind("XBucket")
+		cp.SetLabels(labels)
+		return cp
 	}
-	required := v1.FromFieldPathPolicyRequired
-	optional := v1.FromFieldPathPolicyOptional
+	cd := func(region any) *composed.Unstructured {
+		o := composed.New()
+		o.SetAPIVersion("example.org/v1")
+		o.SetKind("Bucket")
+		if region != nil {
+			o.Object["spec"] = map[string]any{"region": region}
+		}
+		return o
+	}
+
 	type args struct {
+		patch v1.Patch
+		cp    *composite.Unstructured
+	}
+	type want struct {
+		cd  *composed.Unstructured
 		err error
-		p   *v1.PatchPolicy
 	}
 
 	cases := map[string]struct {
 		reason string
-		args
-		want bool
+		args   args
+		want   want
 	}{
-		"NotAnError": {
-			reason: "Should perform patch if no error finding field.",
-			args:   args{},
-			want:   false,
-		},
-		"NotFieldNotFoundError": {
-			reason: "Should return error if something other than field not found.",
+		"PresentLabel": {
+			reason: "We should copy the value of a label that exists.",
+			args: args{
+				patch: v1.Patch{
+					Type:          v1.PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.String(LabelFieldPath("region")),
+					ToFieldPath:   pointer.String("spec.region"),
+				},
+				cp: xr(map[string]string{"region": "us-west-2"}),
+			},
+			want: want{
+				cd: cd("us-west-2"),
+			},
+		},
+		"AbsentOptionalLabel": {
+			reason: "We should not patch, or return an error, if an optional label does not exist.",
+			args: args{
+				patch: v1.Patch{
+					Type:          v1.PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.String(LabelFieldPath("region")),
+					ToFieldPath:   pointer.String("spec.region"),
+				},
+				cp: xr(map[string]string{"zone": "us-west-2a"}),
+			},
+			want: want{
+				cd: cd(nil),
+			},
+		},
+		"DottedLabelKey": {
+			reason: "We should copy the value of a label whose key contains dots, referenced using dot notation.",
+			args: args{
+				patch: v1.Patch{
+					Type:          v1.PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.String("metadata.labels.topology.kubernetes.io/region"),
+					ToFieldPath:   pointer.String("spec.region"),
+				},
+				cp: xr(map[string]string{"topology.kubernetes.io/region": "us-west-2"}),
+			},
+			want: want{
+				cd: cd("us-west-2"),
+			},
+		},
+		"DottedAnnotationKey": {
+			reason: "We should copy the value of an annotation whose key contains dots, referenced using a helper.",
+			args: args{
+				patch: v1.Patch{
+					Type:          v1.PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.String(AnnotationFieldPath("example.org/region")),
+					ToFieldPath:   pointer.String("spec.region"),
+				},
+				cp: func() *composite.Unstructured {
+					cp := xr(nil)
+					cp.SetAnnotations(map[string]string{"example.org/region": "us-west-2"})
+					return cp
+				}(),
+			},
+			want: want{
+				cd: cd("us-west-2"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := cd(nil)
+			err := Apply(tc.args.patch, tc.args.cp, got)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApply(err): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, got); diff != "" {
+				t.Errorf("\n%s\nApply(cd): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPatchError(t *testing.T) {
+	required := v1.FromFieldPathPolicyRequired
+	patches := []v1.Patch{
+		{
+			Type:          v1.PatchTypeToCompositeFieldPath,
+			FromFieldPath: pointer.String("spec.exists"),
+			ToFieldPath:   pointer.String("status.exists"),
+		},
+		{
+			Type:          v1.PatchTypeToCompositeFieldPath,
+			FromFieldPath: pointer.String("spec.missing"),
+			ToFieldPath:   pointer.String("status.missing"),
+			Policy:        &v1.PatchPolicy{FromFieldPath: &required},
+		},
+	}
+
+	type want struct {
+		index int
+		typ   v1.PatchType
+		from  string
+		to    string
+		msg   string
+	}
+
+	cases := map[string]struct {
+		reason string
+		err    error
+		want   want
+	}{
+		"RenderComposite": {
+			reason: "We should be able to identify the patch that couldn't be applied when rendering an XR.",
+			err: func() error {
+				xr := composite.New()
+				xr.SetAPIVersion("example.org/v1")
+				xr.SetKind("XR")
+				cd := composed.New()
+				cd.Object = map[string]any{"apiVersion": "example.org/v1", "kind": "Composed", "spec": map[string]any{"exists": "yes"}}
+				err := RenderComposite(context.Background(), xr, cd, v1.ComposedTemplate{Patches: patches}, nil)
+				return errors.Wrap(err, errRenderCR)
+			}(),
+			want: want{
+				index: 1,
+				typ:   v1.PatchTypeToCompositeFieldPath,
+				from:  "spec.missing",
+				to:    "status.missing",
+				msg:   errRenderCR + ": cannot apply the patch at index 1: spec.missing: no such field",
+			},
+		},
+		"Wrapped": {
+			reason: "We should be able to identify the patch that couldn't be applied from a wrapped PatchError.",
+			err:    errors.Wrap(NewPatchError(0, v1.Patch{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.String("spec.a")}, errors.New("boom")), "outer"),
+			want: want{
+				index: 0,
+				typ:   v1.PatchTypeFromCompositeFieldPath,
+				from:  "spec.a",
+				msg:   "outer: cannot apply the patch at index 0: boom",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			pe := &PatchError{}
+			if !errors.As(tc.err, &pe) {
+				t.Fatalf("\n%s\nerrors.As(...): %q is not a PatchError", tc.reason, tc.err)
+			}
+			got := want{index: pe.Index(), typ: pe.Type(), from: pe.FromFieldPath(), to: pe.ToFieldPath(), msg: tc.err.Error()}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nerrors.As(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPatchApplyArrays(t *testing.T) {
+	required := v1.FromFieldPathPolicyRequired
+
+	xr := func(spec map[string]any) *composite.Unstructured {
+		xr := composite.New()
+		xr.SetAPIVersion("example.org/v1")
+		xr.SetKind("XR")
+		xr.Object["spec"] = spec
+		return xr
+	}
+	cd := func(spec map[string]any) *composed.Unstructured {
+		return composed.New(func(cd *composed.Unstructured) {
+			cd.SetAPIVersion("example.org/v1")
+			cd.SetKind("Composed")
+			if spec != nil {
+				cd.Object["spec"] = spec
+			}
+		})
+	}
+	patch := func(from, to string) v1.Patch {
+		return v1.Patch{
+			Type:          v1.PatchTypeFromCompositeFieldPath,
+			FromFieldPath: pointer.String(from),
+			ToFieldPath:   pointer.String(to),
+		}
+	}
+
+	type args struct {
+		patch v1.Patch
+		cp    *composite.Unstructured
+		cd    *composed.Unstructured
+	}
+	type want struct {
+		cd  *composed.Unstructured
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"Append": {
+			reason: "A ToFieldPath ending in [] should append to the array.",
+			args: args{
+				patch: patch("spec.zone", "spec.zones[]"),
+				cp:    xr(map[string]any{"zone": "b"}),
+				cd:    cd(map[string]any{"zones": []any{"a"}}),
+			},
+			want: want{
+				cd: cd(map[string]any{"zones": []any{"a", "b"}}),
+			},
+		},
+		"AppendToMissingArray": {
+			reason: "A ToFieldPath ending in [] should create the array if it doesn't exist.",
+			args: args{
+				patch: patch("spec.zone", "spec.zones[]"),
+				cp:    xr(map[string]any{"zone": "b"}),
+				cd:    cd(nil),
+			},
+			want: want{
+				cd: cd(map[string]any{"zones": []any{"b"}}),
+			},
+		},
+		"AppendExistingValue": {
+			reason: "A ToFieldPath ending in [] should not append a value the array already contains.",
+			args: args{
+				patch: patch("spec.zone", "spec.zones[]"),
+				cp:    xr(map[string]any{"zone": "a"}),
+				cd:    cd(map[string]any{"zones": []any{"a"}}),
+			},
+			want: want{
+				cd: cd(map[string]any{"zones": []any{"a"}}),
+			},
+		},
+		"AppendNotArray": {
+			reason: "A ToFieldPath ending in [] should return an error if the field is not an array.",
+			args: args{
+				patch: patch("spec.zone", "spec.zones[]"),
+				cp:    xr(map[string]any{"zone": "b"}),
+				cd:    cd(map[string]any{"zones": "a"}),
+			},
+			want: want{
+				cd:  cd(map[string]any{"zones": "a"}),
+				err: fmt.Errorf(errFmtAppendNotArray, "spec.zones", "a"),
+			},
+		},
+		"ExplicitIndex": {
+			reason: "A ToFieldPath with an explicit index should set that element of the array.",
+			args: args{
+				patch: patch("spec.zone", "spec.zones[1]"),
+				cp:    xr(map[string]any{"zone": "c"}),
+				cd:    cd(map[string]any{"zones": []any{"a", "b"}}),
+			},
+			want: want{
+				cd: cd(map[string]any{"zones": []any{"a", "c"}}),
+			},
+		},
+		"ExplicitIndexOutOfRange": {
+			reason: "A ToFieldPath with an index beyond the end of the array should grow the array, filling any gap with nulls.",
+			args: args{
+				patch: patch("spec.zone", "spec.zones[3]"),
+				cp:    xr(map[string]any{"zone": "d"}),
+				cd:    cd(map[string]any{"zones": []any{"a"}}),
+			},
+			want: want{
+				cd: cd(map[string]any{"zones": []any{"a", nil, nil, "d"}}),
+			},
+		},
+		"OptionalFromIndexOutOfRange": {
+			reason: "A FromFieldPath with an index beyond the end of the array should be a no-op if the patch is optional.",
+			args: args{
+				patch: patch("spec.zones[3]", "spec.zone"),
+				cp:    xr(map[string]any{"zones": []any{"a"}}),
+				cd:    cd(nil),
+			},
+			want: want{
+				cd: cd(nil),
+			},
+		},
+		"RequiredFromIndexOutOfRange": {
+			reason: "A FromFieldPath with an index beyond the end of the array should return an error if the patch is required.",
+			args: args{
+				patch: func() v1.Patch {
+					p := patch("spec.zones[3]", "spec.zone")
+					p.Policy = &v1.PatchPolicy{FromFieldPath: &required}
+					return p
+				}(),
+				cp: xr(map[string]any{"zones": []any{"a"}}),
+				cd: cd(nil),
+			},
+			want: want{
+				cd: cd(nil),
+				err: func() error {
+					_, err := fieldpath.Pave(map[string]any{"spec": map[string]any{"zones": []any{"a"}}}).GetValue("spec.zones[3]")
+					return err
+				}(),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Apply(tc.args.patch, tc.args.cp, tc.args.cd)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApply(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nApply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestOptionalFieldPathNotFound(t *testing.T) {
+	errBoom := errors.New("boom")
+	errNotFound := func() error {
+		p := &fieldpath.Paved{}
+		_, err := p.GetValue("boom")
+		return err
+	}
+	required := v1.FromFieldPathPolicyRequired
+	optional := v1.FromFieldPathPolicyOptional
+	type args struct {
+		err error
+		p   *v1.PatchPolicy
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want bool
+	}{
+		"NotAnError": {
+			reason: "Should perform patch if no error finding field.",
+			args:   args{},
+			want:   false,
+		},
+		"NotFieldNotFoundError": {
+			reason: "Should return error if something other than field not found.",
 			args: args{
 				err: errBoom,
 			},
@@ -1196,6 +1860,136 @@ func TestComposedTemplates(t *testing.T) {
 		args
 		want
 	}{
+		"NestedPatchSets": {
+			reason: "Patch sets that reference other patch sets should be inlined recursively",
+			args: args{
+				pss: []v1.PatchSet{
+					{
+						Name: "base",
+						Patches: []v1.Patch{
+							{
+								Type:          v1.PatchTypeFromCompositeFieldPath,
+								FromFieldPath: pointer.String("metadata.name"),
+							},
+							{
+								Type:         v1.PatchTypePatchSet,
+								PatchSetName: pointer.String("labels"),
+							},
+						},
+					},
+					{
+						Name: "labels",
+						Patches: []v1.Patch{
+							{
+								Type:          v1.PatchTypeFromCompositeFieldPath,
+								FromFieldPath: pointer.String("metadata.labels"),
+							},
+						},
+					},
+				},
+				cts: []v1.ComposedTemplate{
+					{
+						Patches: []v1.Patch{
+							{
+								Type:         v1.PatchTypePatchSet,
+								PatchSetName: pointer.String("base"),
+							},
+							{
+								Type:          v1.PatchTypeFromCompositeFieldPath,
+								FromFieldPath: pointer.String("metadata.namespace"),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				ct: []v1.ComposedTemplate{
+					{
+						Patches: []v1.Patch{
+							{
+								Type:          v1.PatchTypeFromCompositeFieldPath,
+								FromFieldPath: pointer.String("metadata.name"),
+							},
+							{
+								Type:          v1.PatchTypeFromCompositeFieldPath,
+								FromFieldPath: pointer.String("metadata.labels"),
+							},
+							{
+								Type:          v1.PatchTypeFromCompositeFieldPath,
+								FromFieldPath: pointer.String("metadata.namespace"),
+							},
+						},
+					},
+				},
+			},
+		},
+		"CyclicPatchSets": {
+			reason: "Patch sets that reference each other should return an error",
+			args: args{
+				pss: []v1.PatchSet{
+					{
+						Name: "a",
+						Patches: []v1.Patch{
+							{
+								Type:         v1.PatchTypePatchSet,
+								PatchSetName: pointer.String("b"),
+							},
+						},
+					},
+					{
+						Name: "b",
+						Patches: []v1.Patch{
+							{
+								Type:         v1.PatchTypePatchSet,
+								PatchSetName: pointer.String("a"),
+							},
+						},
+					},
+				},
+				cts: []v1.ComposedTemplate{
+					{
+						Patches: []v1.Patch{
+							{
+								Type:         v1.PatchTypePatchSet,
+								PatchSetName: pointer.String("a"),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtPatchSetCycle, "a -> b -> a"),
+			},
+		},
+		"NestedUndefinedPatchSet": {
+			reason: "Patch sets that reference an undefined patch set should return an error",
+			args: args{
+				pss: []v1.PatchSet{
+					{
+						Name: "a",
+						Patches: []v1.Patch{
+							{
+								Type:         v1.PatchTypePatchSet,
+								PatchSetName: pointer.String("nope"),
+							},
+						},
+					},
+				},
+				cts: []v1.ComposedTemplate{
+					{
+						Patches: []v1.Patch{
+							{
+								Type:         v1.PatchTypePatchSet,
+								PatchSetName: pointer.String("a"),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtUndefinedPatchSet, "nope"),
+			},
+		},
 		"NoCompositionPatchSets": {
 			reason: "Patches defined on a composite resource should be applied correctly if no PatchSets are defined on the composition",
 			args: args{
@@ -1383,6 +2177,101 @@ func TestComposedTemplates(t *testing.T) {
 	}
 }
 
+func TestEffectiveRevision(t *testing.T) {
+	fromName := v1.Patch{
+		Type:          v1.PatchTypeFromCompositeFieldPath,
+		FromFieldPath: pointer.String("metadata.name"),
+	}
+	fromRegion := v1.Patch{
+		Type:          v1.PatchTypeFromCompositeFieldPath,
+		FromFieldPath: pointer.String("spec.region"),
+	}
+	rev := func(pss []v1.PatchSet, cts ...v1.ComposedTemplate) *v1.CompositionRevision {
+		return &v1.CompositionRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: "cool-revision"},
+			Spec: v1.CompositionRevisionSpec{
+				PatchSets: pss,
+				Resources: cts,
+				Revision:  1,
+			},
+		}
+	}
+
+	type want struct {
+		rev *v1.CompositionRevision
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		rev    *v1.CompositionRevision
+		want   want
+	}{
+		"UndefinedPatchSet": {
+			reason: "Errors inlining patch sets should be returned, as they would be when composing.",
+			rev: rev(nil, v1.ComposedTemplate{
+				Patches: []v1.Patch{{Type: v1.PatchTypePatchSet, PatchSetName: pointer.String("nope")}},
+			}),
+			want: want{
+				err: errors.Wrap(errors.Errorf(errFmtUndefinedPatchSet, "nope"), errInline),
+			},
+		},
+		"InlinedPatchSets": {
+			reason: "Patch sets should be inlined into the composed templates that reference them, and removed.",
+			rev: rev(
+				[]v1.PatchSet{{Name: "common", Patches: []v1.Patch{fromName}}},
+				v1.ComposedTemplate{
+					Name:    pointer.String("a"),
+					Patches: []v1.Patch{{Type: v1.PatchTypePatchSet, PatchSetName: pointer.String("common")}, fromRegion},
+				},
+				v1.ComposedTemplate{
+					Name:    pointer.String("b"),
+					Patches: []v1.Patch{fromRegion},
+				},
+			),
+			want: want{
+				rev: rev(nil,
+					v1.ComposedTemplate{Name: pointer.String("a"), Patches: []v1.Patch{fromName, fromRegion}},
+					v1.ComposedTemplate{Name: pointer.String("b"), Patches: []v1.Patch{fromRegion}},
+				),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			in := tc.rev.DeepCopy()
+			got, err := EffectiveRevision(tc.rev)
+
+			if diff := cmp.Diff(tc.want.rev, got); diff != "" {
+				t.Errorf("\n%s\nEffectiveRevision(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nEffectiveRevision(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(in, tc.rev); diff != "" {
+				t.Errorf("\n%s\nEffectiveRevision(...): the supplied revision should not be modified: -want, +got:\n%s", tc.reason, diff)
+			}
+			if got == nil {
+				return
+			}
+
+			// The effective revision should round-trip.
+			raw, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("json.Marshal(...): %s", err)
+			}
+			rt := &v1.CompositionRevision{}
+			if err := json.Unmarshal(raw, rt); err != nil {
+				t.Fatalf("json.Unmarshal(...): %s", err)
+			}
+			if diff := cmp.Diff(got, rt); diff != "" {
+				t.Errorf("\n%s\nEffectiveRevision(...): the effective revision should round-trip: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestResolveTransforms(t *testing.T) {
 	type args struct {
 		ts    []v1.Transform
@@ -1473,3 +2362,137 @@ func TestResolveTransforms(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveEachElement(t *testing.T) {
+	asJSON := func(val any) extv1.JSON {
+		raw, err := json.Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return extv1.JSON{Raw: raw}
+	}
+	mapTransform := v1.Transform{
+		Type: v1.TransformTypeMap,
+		Map:  &v1.MapTransform{Pairs: map[string]extv1.JSON{"small": asJSON("t3.small"), "large": asJSON("t3.large")}},
+	}
+
+	type args struct {
+		p     v1.Patch
+		input any
+	}
+	type want struct {
+		output any
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NotAnArray": {
+			reason: "We should return an error if the input is not an array.",
+			args: args{
+				p:     v1.Patch{EachElement: &v1.EachElementPatch{}},
+				input: "small",
+			},
+			want: want{
+				err: errors.Errorf(errFmtEachElementNotArray, "small"),
+			},
+		},
+		"EmptyArray": {
+			reason: "An empty input array should produce an empty output array.",
+			args: args{
+				p:     v1.Patch{EachElement: &v1.EachElementPatch{}},
+				input: []any{},
+			},
+			want: want{
+				output: []any{},
+			},
+		},
+		"TransformEachElement": {
+			reason: "We should apply the patch's transforms to each element of the array.",
+			args: args{
+				p: v1.Patch{
+					Transforms:  []v1.Transform{mapTransform},
+					EachElement: &v1.EachElementPatch{},
+				},
+				input: []any{"small", "large"},
+			},
+			want: want{
+				output: []any{"t3.small", "t3.large"},
+			},
+		},
+		"TransformError": {
+			reason: "We should return any error encountered transforming an element, including the element's index.",
+			args: args{
+				p: v1.Patch{
+					Transforms:  []v1.Transform{mapTransform},
+					EachElement: &v1.EachElementPatch{},
+				},
+				input: []any{"small", "huge"},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrapf(errors.Wrapf(errors.Errorf(errFmtMapNotFound, "huge"), errFmtTransformTypeFailed, v1.TransformTypeMap), errFmtTransformAtIndex, 0), errFmtEachElementAtIndex, 1),
+			},
+		},
+		"ElementPatches": {
+			reason: "We should build each output element from the element patches, renaming and transforming fields.",
+			args: args{
+				p: v1.Patch{
+					EachElement: &v1.EachElementPatch{
+						Patches: []v1.ElementPatch{
+							{
+								FromFieldPath: "name",
+								ToFieldPath:   pointer.String("id"),
+							},
+							{
+								FromFieldPath: "size",
+								ToFieldPath:   pointer.String("spec.instanceType"),
+								Transforms:    []v1.Transform{mapTransform},
+							},
+							{
+								// This field doesn't exist on every element.
+								FromFieldPath: "zone",
+							},
+						},
+					},
+				},
+				input: []any{
+					map[string]any{"name": "a", "size": "small", "zone": "us-west-2a"},
+					map[string]any{"name": "b", "size": "large"},
+				},
+			},
+			want: want{
+				output: []any{
+					map[string]any{"id": "a", "spec": map[string]any{"instanceType": "t3.small"}, "zone": "us-west-2a"},
+					map[string]any{"id": "b", "spec": map[string]any{"instanceType": "t3.large"}},
+				},
+			},
+		},
+		"ElementNotAnObject": {
+			reason: "We should return an error if element patches are applied to an element that is not an object.",
+			args: args{
+				p: v1.Patch{
+					EachElement: &v1.EachElementPatch{
+						Patches: []v1.ElementPatch{{FromFieldPath: "name"}},
+					},
+				},
+				input: []any{"a"},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtElementNotObject, "a"), errFmtEachElementAtIndex, 0),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveEachElement(tc.args.p, tc.args.input)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nResolveEachElement(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.output, got); diff != "" {
+				t.Errorf("\n%s\nResolveEachElement(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -17,19 +17,37 @@ limitations under the License.
 package composite
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	kjson "sigs.k8s.io/json"
+	"sigs.k8s.io/yaml"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
@@ -44,9 +62,12 @@ import (
 const (
 	errGetComposed      = "cannot get composed resource"
 	errGCComposed       = "cannot garbage collect composed resource"
+	errOrphanComposed   = "cannot orphan composed resource"
 	errApply            = "cannot apply composed resource"
 	errFetchDetails     = "cannot fetch connection details"
 	errExtractDetails   = "cannot extract composite resource connection details from composed resource"
+	errExtractXRDetails = "cannot extract connection details from composite resource"
+	errMergeDetails     = "cannot merge composite resource connection details"
 	errReadiness        = "cannot check whether composed resource is ready"
 	errUnmarshal        = "cannot unmarshal base template"
 	errGetSecret        = "cannot get connection secret of composed resource"
@@ -56,9 +77,23 @@ const (
 	errInline           = "cannot inline Composition patch sets"
 	errRenderCR         = "cannot render composite resource"
 	errSetControllerRef = "cannot set controller reference"
-
-	errFmtResourceName = "composed resource %q"
-	errFmtPatch        = "cannot apply the patch at index %d"
+	errCanonicalize     = "cannot canonicalize composed resource"
+	errMutate           = "cannot mutate rendered composed resource"
+	errMapKind          = "cannot determine whether the kind of the composed resource template's base is installed"
+	errListComposed     = "cannot list composed resources"
+	errInterrupted      = "composition was interrupted"
+
+	errFmtResourceName         = "composed resource %q"
+	errFmtUnknownTemplate      = "cannot compose unknown composed template %q"
+	errFmtSkipCondition        = "cannot evaluate skip condition of composed resource %q"
+	errFmtApplyOptional        = "cannot apply optional composed resource %q"
+	errFmtNoLongerReady        = "composed resource %q is no longer ready"
+	errFmtUncontrolledResource = "composed resource %s %q doesn't correspond to a composed resource template, but was not garbage collected because it is controlled by another resource"
+	errFmtObserveOnlyNotFound  = "cannot observe observe-only composed resource %q: it does not exist"
+	errFmtPatch                = "cannot apply the patch at index %d"
+	errFmtDuplicateTemplate    = "composed resource templates at index %d and %d are both named %q: template names must be unique"
+	errFmtMultiDocBase         = "base template must be a single YAML document, not %d documents"
+	errFmtUnknownKind          = "composed resource template's base has apiVersion %q and kind %q, which is not an installed kind of resource"
 )
 
 // TODO(negz): Move P&T Composition logic into its own package?
@@ -115,6 +150,327 @@ func WithComposedConnectionDetailsExtractor(e ConnectionDetailsExtractor) PTComp
 	}
 }
 
+// WithComposedDriftDetection configures a PatchAndTransformComposer to record
+// whether each extant composed resource had drifted from its desired state
+// before it was applied.
+func WithComposedDriftDetection() PTComposerOption {
+	return func(c *PTComposer) {
+		c.detectDrift = true
+	}
+}
+
+// WithWriteBudget configures a PatchAndTransformComposer to apply at most the
+// supplied number of composed resources each time it composes resources for
+// an XR. Applying any remaining composed resources is deferred until the XR is
+// next composed. The budget does not include writes to the XR itself. A budget
+// of less than one is unlimited.
+func WithWriteBudget(n int) PTComposerOption {
+	return func(c *PTComposer) {
+		c.budget = NewWriteBudget(n)
+	}
+}
+
+// WithComposedTemplateCache configures a PatchAndTransformComposer to cache the
+// composed templates of CompositionRevisions once their patch sets have been
+// inlined, rather than inlining them each time it composes resources.
+func WithComposedTemplateCache(tc *ComposedTemplateCache) PTComposerOption {
+	return func(c *PTComposer) {
+		c.templates = tc
+	}
+}
+
+// WithInheritedScheduling configures a PatchAndTransformComposer to project
+// the XR's scheduling constraints into composed workloads after they are
+// rendered. Composed resources the constraints can't be projected into are
+// reported as warning events. It wraps the composed resource renderer, so it
+// should be supplied after any option that replaces it.
+func WithInheritedScheduling(o ...SchedulingRendererOption) PTComposerOption {
+	return func(c *PTComposer) {
+		r := NewSchedulingRenderer(c.composed.Renderer, o...)
+		c.composed.Renderer = r
+		c.renderPaths = append(c.renderPaths, r.path)
+	}
+}
+
+// WithLabelPropagation configures a PatchAndTransformComposer to copy the
+// supplied labels from the XR to each composed resource after it is rendered.
+// Labels the composed resource already has, for example because its template
+// sets them, are not overwritten. It wraps the composed resource renderer, so
+// it should be supplied after any option that replaces it.
+func WithLabelPropagation(keys ...string) PTComposerOption {
+	return func(c *PTComposer) {
+		c.composed.Renderer = NewLabelPropagatingRenderer(c.composed.Renderer, keys...)
+	}
+}
+
+// WithStandardAnnotations configures a PatchAndTransformComposer to annotate
+// each composed resource after it is rendered with the names of the
+// CompositionRevision and Composition it was composed from, and with the
+// supplied manager. Annotations set by the composed resource's template are
+// not overwritten.
+func WithStandardAnnotations(manager string) PTComposerOption {
+	return func(c *PTComposer) {
+		c.annotate = true
+		c.manager = manager
+	}
+}
+
+// WithLastAppliedAnnotation configures a PatchAndTransformComposer to annotate
+// each composed resource before it is applied with its rendered configuration,
+// as compact JSON. This allows the desired and observed state of a composed
+// resource to be compared. The annotation doesn't affect whether a composed
+// resource is considered unchanged since it was last applied.
+func WithLastAppliedAnnotation() PTComposerOption {
+	return func(c *PTComposer) {
+		c.lastApplied = true
+	}
+}
+
+// WithPostRenderMutator configures a PatchAndTransformComposer to mutate each
+// composed resource using the supplied function after it is rendered, and
+// before it is applied. A composed resource the function fails to mutate is
+// treated like one that couldn't be rendered - it isn't applied, and the error
+// is reported as a warning. It wraps the composed resource renderer, so it
+// should be supplied after any option that replaces it.
+func WithPostRenderMutator(fn PostRenderMutator) PTComposerOption {
+	return func(c *PTComposer) {
+		c.composed.Renderer = NewPostRenderMutatingRenderer(c.composed.Renderer, fn)
+		c.renderOpaque = true
+	}
+}
+
+// WithCanonicalRendering configures a PatchAndTransformComposer to
+// canonicalize each composed resource after it is rendered, and before it is
+// applied, so that rendering the same inputs always produces the same composed
+// resource. It wraps the composed resource renderer, so it should be supplied
+// after any option that replaces it. Composed resources aren't canonicalized
+// by default.
+func WithCanonicalRendering() PTComposerOption {
+	return func(c *PTComposer) {
+		c.composed.Renderer = NewCanonicalizingRenderer(c.composed.Renderer)
+	}
+}
+
+// WithDesiredChecksums configures a PatchAndTransformComposer to annotate each
+// composed resource with a checksum of its desired state, and to skip applying
+// a composed resource if its desired state is unchanged since it was last
+// applied. Note that this means changes made to a composed resource by anything
+// other than the composer will not be corrected until its desired state
+// changes. Composed resources with a conflict policy are always applied.
+func WithDesiredChecksums() PTComposerOption {
+	return func(c *PTComposer) {
+		c.checksums = true
+	}
+}
+
+// WithRenderSkipping configures a PatchAndTransformComposer to annotate each
+// composed resource with a checksum of the inputs used to render it, and to
+// neither render nor apply an existing composed resource if it was last
+// rendered from the same inputs. Its connection details and readiness are
+// still observed. Like WithDesiredChecksums, this means changes made to a
+// composed resource by anything other than the composer will not be corrected
+// until its inputs change. Composed resources whose templates patch the
+// environment are always rendered. The XR's labels, including any propagated
+// by WithLabelPropagation, and the scheduling constraints inherited by
+// WithInheritedScheduling are render inputs. A PostRenderMutator may read
+// anything, so composed resources are always rendered when one is configured.
+func WithRenderSkipping() PTComposerOption {
+	return func(c *PTComposer) {
+		c.skipRender = true
+	}
+}
+
+// WithPublishConnectionDetailsWhenReady configures a PatchAndTransformComposer
+// to withhold the connection details extracted from each composed resource
+// until the composed resource is ready, so that stale or empty connection
+// details aren't published. Withheld connection details are not considered
+// stale.
+func WithPublishConnectionDetailsWhenReady() PTComposerOption {
+	return func(c *PTComposer) {
+		c.readyConnection = true
+	}
+}
+
+// WithFieldManager configures the name of the field manager a
+// PatchAndTransformComposer uses when it applies composed resources using
+// server-side apply, i.e. those with a conflict policy. A composed resource
+// template may override it.
+func WithFieldManager(name string) PTComposerOption {
+	return func(c *PTComposer) {
+		c.ssa = c.ssa.WithFieldOwner(name)
+	}
+}
+
+// WithComposedAnnotationSum configures a PatchAndTransformComposer to sum the
+// supplied numeric annotation of each observed composed resource into the
+// supplied XR field path, e.g. to aggregate a cost annotation set by providers.
+func WithComposedAnnotationSum(annotation, toFieldPath string) PTComposerOption {
+	return func(c *PTComposer) {
+		c.sum = &AnnotationSum{Annotation: annotation, ToFieldPath: toFieldPath}
+	}
+}
+
+// WithStaleConnectionDetailsRemoval configures a PatchAndTransformComposer to
+// identify connection details that were published for an XR but no longer have
+// a source, so that they may be removed. The supplied fetcher is used to fetch
+// the XR's currently published connection details.
+func WithStaleConnectionDetailsRemoval(f managed.ConnectionDetailsFetcher) PTComposerOption {
+	return func(c *PTComposer) {
+		c.xrConnection = f
+	}
+}
+
+// WithComposedConnectionDetailsPrecedence configures whether connection
+// details extracted from a composed resource take precedence over those
+// fetched from its connection secret when both have the same key. Extracted
+// connection details take precedence by default.
+func WithComposedConnectionDetailsPrecedence(p ConnectionDetailsPrecedence) PTComposerOption {
+	return func(c *PTComposer) {
+		c.connPrecedence = p
+	}
+}
+
+// WithClock configures how a PatchAndTransformComposer determines the current
+// time, for example when checking composed resource creation deadlines.
+func WithClock(now func() time.Time) PTComposerOption {
+	return func(c *PTComposer) {
+		c.now = now
+	}
+}
+
+// WithComposedSizeLimit configures a PatchAndTransformComposer to check the
+// estimated size of each composed resource before applying it. Composed
+// resources larger than the supplied limit, in bytes, are not applied. Those
+// larger than the supplied fraction of the limit are applied, but reported as
+// nearly too large. A limit or threshold of zero selects the default.
+func WithComposedSizeLimit(limit int, threshold float64) PTComposerOption {
+	return func(c *PTComposer) {
+		c.size = &SizeLimit{Limit: DefaultComposedSizeLimit, WarningThreshold: DefaultComposedSizeWarningThreshold}
+		if limit > 0 {
+			c.size.Limit = limit
+		}
+		if threshold > 0 {
+			c.size.WarningThreshold = threshold
+		}
+	}
+}
+
+// defaultRenderConcurrency is how many composed resources are rendered and
+// applied concurrently for a Composition that enables the ConcurrentRendering
+// feature flag, unless more concurrency is configured.
+const defaultRenderConcurrency = 5
+
+// WithRenderConcurrency configures a PatchAndTransformComposer to render and
+// apply up to n composed resources concurrently. A Composition's
+// ConcurrentRendering feature flag takes precedence over this option. Errors
+// applying composed resources are reported as warning events rather than
+// returned, and composed resources that couldn't be applied aren't observed.
+// Composition only fails if it's interrupted. Composed resources are always
+// rendered sequentially when composing with an environment, because
+// rendering them may patch the environment.
+func WithRenderConcurrency(n int) PTComposerOption {
+	return func(c *PTComposer) {
+		c.concurrency = n
+	}
+}
+
+// WithMetrics configures a PatchAndTransformComposer to record metrics about
+// each composition: a histogram of how long it took, and gauges of how many
+// composed resources it rendered and garbage collected, labelled by
+// CompositionRevision. Use NewComposerMetrics to create and register the
+// metrics once, and share them by every PatchAndTransformComposer.
+func WithMetrics(m *ComposerMetrics) PTComposerOption {
+	return func(c *PTComposer) {
+		c.metrics = m
+	}
+}
+
+// WithCompositePatchMerging configures a PatchAndTransformComposer to merge
+// patches into the XR rather than replacing the value at their field path, so
+// that patches from different composed resources may each contribute keys to
+// the same map. Slices are merged according to the supplied strategy. Note that
+// keys a composed resource no longer exposes aren't removed from the XR. It
+// replaces the composite resource renderer.
+func WithCompositePatchMerging(s SliceMergeStrategy) PTComposerOption {
+	return func(c *PTComposer) {
+		c.composite = NewMergingCompositeRenderer(s)
+	}
+}
+
+// WithConnectionDetailConflictPolicy configures how a
+// PatchAndTransformComposer handles composed resources that expose different
+// values for the same XR connection detail. By default the last composed
+// resource, in template order, wins.
+func WithConnectionDetailConflictPolicy(p ConnectionDetailConflictPolicy) PTComposerOption {
+	return func(c *PTComposer) {
+		c.connConflict = p
+	}
+}
+
+// WithReadinessErrorPolicy configures how a PatchAndTransformComposer handles
+// an error checking whether a composed resource is ready. By default the error
+// fails composition.
+func WithReadinessErrorPolicy(p ReadinessErrorPolicy) PTComposerOption {
+	return func(c *PTComposer) {
+		c.readinessErr = p
+	}
+}
+
+// WithCompositeReadinessAggregator configures how a PatchAndTransformComposer
+// determines whether an XR is ready, given the readiness of its composed
+// resources. By default an XR is ready when all of its required composed
+// resources are ready.
+func WithCompositeReadinessAggregator(a CompositeReadinessAggregator) PTComposerOption {
+	return func(c *PTComposer) {
+		c.readiness = a
+	}
+}
+
+// WithRequeueHinter configures how a PatchAndTransformComposer suggests how
+// long to wait before the XR is composed again, for example to compose it
+// more often while its composed resources are becoming ready. By default no
+// duration is suggested.
+func WithRequeueHinter(h RequeueHinter) PTComposerOption {
+	return func(c *PTComposer) {
+		c.requeue = h
+	}
+}
+
+// WithComposerLogger configures the logger a PatchAndTransformComposer uses
+// to log each step of composition, at debug level. Nothing is logged by
+// default.
+func WithComposerLogger(l logging.Logger) PTComposerOption {
+	return func(c *PTComposer) {
+		c.log = l
+	}
+}
+
+// A CompositeUpdateStrategy determines how a PatchAndTransformComposer persists
+// the composed resource references of an XR before it applies its composed
+// resources.
+type CompositeUpdateStrategy string
+
+// Composite update strategies.
+const (
+	// CompositeUpdateStrategyUpdate updates the entire XR.
+	CompositeUpdateStrategyUpdate CompositeUpdateStrategy = "Update"
+
+	// CompositeUpdateStrategyPatch merge patches only the fields of the XR
+	// the composer owns; its composed resource references and owning team.
+	// Unlike an update, a patch can't overwrite changes to other fields, and
+	// it doesn't fail if the XR was changed since it was read.
+	CompositeUpdateStrategyPatch CompositeUpdateStrategy = "Patch"
+)
+
+// WithCompositeUpdateStrategy configures how a PatchAndTransformComposer
+// persists the composed resource references of an XR. By default the entire
+// XR is updated.
+func WithCompositeUpdateStrategy(s CompositeUpdateStrategy) PTComposerOption {
+	return func(c *PTComposer) {
+		c.xrUpdate = s
+	}
+}
+
 type composedResource struct {
 	Renderer
 	managed.ConnectionDetailsFetcher
@@ -132,6 +488,74 @@ type PTComposer struct {
 	composite   Renderer
 	composition CompositionTemplateAssociator
 	composed    composedResource
+
+	ssa    *ServerSideApplicator
+	budget *WriteBudget
+
+	// templates caches the inlined composed templates of Composition
+	// revisions, if set.
+	templates *ComposedTemplateCache
+
+	// size limits the size of the composed resources that are applied, if
+	// set.
+	size *SizeLimit
+
+	// sum aggregates an annotation of observed composed resources into the
+	// XR, if set.
+	sum *AnnotationSum
+
+	// connPrecedence determines whether connection details extracted from a
+	// composed resource take precedence over those fetched from it.
+	connPrecedence ConnectionDetailsPrecedence
+
+	// xrConnection fetches the XR's published connection details. Stale
+	// connection details are only identified when it is set.
+	xrConnection managed.ConnectionDetailsFetcher
+
+	// metrics records metrics about each composition, if set.
+	metrics *ComposerMetrics
+
+	// connConflict determines how conflicting connection details exposed by
+	// different composed resources are merged.
+	connConflict ConnectionDetailConflictPolicy
+
+	// xrUpdate determines how the XR's composed resource references are
+	// persisted.
+	xrUpdate CompositeUpdateStrategy
+
+	// readinessErr determines how errors checking whether composed
+	// resources are ready are handled.
+	readinessErr ReadinessErrorPolicy
+
+	// readiness aggregates the readiness of composed resources to determine
+	// whether the XR is ready, if set.
+	readiness CompositeReadinessAggregator
+
+	// requeue suggests when the XR should be composed again, if set.
+	requeue RequeueHinter
+
+	// log logs each step of composition at debug level.
+	log logging.Logger
+
+	// manager is the managed-by annotation of composed resources. Composed
+	// resources are only annotated when annotate is true.
+	manager string
+
+	// renderPaths are XR field paths read by renderers that wrap the
+	// composed resource renderer. They're render inputs when skipping
+	// renders. Rendering is never skipped when renderOpaque is true, because
+	// a wrapping renderer may read anything.
+	renderPaths  []string
+	renderOpaque bool
+
+	detectDrift     bool
+	annotate        bool
+	lastApplied     bool
+	checksums       bool
+	skipRender      bool
+	readyConnection bool
+	concurrency     int
+	now             func() time.Time
 }
 
 // NewPTComposer returns a Composer that composes resources using Patch and
@@ -143,6 +567,7 @@ func NewPTComposer(kube client.Client, o ...PTComposerOption) *PTComposer {
 
 	c := &PTComposer{
 		client: resource.ClientApplicator{Client: kube, Applicator: resource.NewAPIPatchingApplicator(kube)},
+		ssa:    NewServerSideApplicator(kube, fieldOwnerComposer),
 
 		// TODO(negz): Once Composition Functions are GA this Composer will only
 		// need to handle legacy Compositions that use anonymous templates. This
@@ -157,6 +582,8 @@ func NewPTComposer(kube client.Client, o ...PTComposerOption) *PTComposer {
 			ConnectionDetailsFetcher:   NewSecretConnectionDetailsFetcher(kube),
 			ConnectionDetailsExtractor: ConnectionDetailsExtractorFn(ExtractConnectionDetails),
 		},
+		now: time.Now,
+		log: logging.NewNopLogger(),
 	}
 
 	for _, fn := range o {
@@ -170,18 +597,56 @@ func NewPTComposer(kube client.Client, o ...PTComposerOption) *PTComposer {
 // supplied Composition.
 func (c *PTComposer) Compose(ctx context.Context, xr resource.Composite, req CompositionRequest) (CompositionResult, error) { //nolint:gocyclo // Breaking this up doesn't seem worth yet more layers of abstraction.
 	// Inline PatchSets before composing resources.
-	ct, err := ComposedTemplates(req.Revision.Spec.PatchSets, req.Revision.Spec.Resources)
+	ct, err := c.templates.ComposedTemplates(req.Revision)
 	if err != nil {
 		return CompositionResult{}, errors.Wrap(err, errInline)
 	}
+	log := c.log.WithValues("composite", xr.GetName())
+	log.Debug("Inlined composed resource templates", "revision", req.Revision.GetName(), "templates", len(ct))
+
+	if c.metrics != nil {
+		start := c.now()
+		defer func() { c.metrics.ObserveDuration(req.Revision.GetName(), start, c.now()) }()
+	}
+
+	// An XR that doesn't declare an owning team inherits the team of its
+	// Composition, if any. The team is propagated to composed resources at
+	// render time, and guards them from being associated with or garbage
+	// collected by an XR owned by a different team.
+	if GetOwnerTeam(xr) == "" {
+		SetOwnerTeam(xr, GetOwnerTeam(req.Revision))
+	}
+
+	// We validate the requested subset before we associate templates, because
+	// associating templates may garbage collect composed resources.
+	subset, err := templateSubset(ct, req.Subset)
+	if err != nil {
+		return CompositionResult{}, err
+	}
 
-	tas, err := c.composition.AssociateTemplates(ctx, xr, ct)
+	// Composed resources outside the requested subset must be left
+	// untouched, so we don't garbage collect when composing a subset.
+	var assoc CompositionTemplateAssociator = c.composition
+	if ca, ok := assoc.(CollectingAssociator); ok && subset != nil {
+		assoc = ca.WithoutGarbageCollection()
+	}
+
+	var tas []TemplateAssociation
+	var report AssociationReport
+	if a, ok := assoc.(ReportingAssociator); ok {
+		tas, report, err = a.AssociateTemplatesWithReport(ctx, xr, ct)
+	} else {
+		tas, err = assoc.AssociateTemplates(ctx, xr, ct)
+	}
 	if err != nil {
 		return CompositionResult{}, errors.Wrap(err, errAssociate)
 	}
+	collected := report.Collected
+	log.Debug("Associated composed resource templates with composed resources", "associated", associated(tas), "uncontrolled", len(report.Uncontrolled), "retained", len(report.Retained))
 
 	// If we have an environment, run all environment patches before composing
-	// resources.
+	// resources. We don't compose resources if any environment patch fails,
+	// because they may be rendered using an incomplete environment.
 	if req.Environment != nil && req.Revision.Spec.Environment != nil {
 		for i, p := range req.Revision.Spec.Environment.Patches {
 			if err := ApplyEnvironmentPatch(p, xr, req.Environment); err != nil {
@@ -192,64 +657,315 @@ func (c *PTComposer) Compose(ctx context.Context, xr resource.Composite, req Com
 
 	events := make([]event.Event, 0)
 
+	for _, ref := range report.Uncontrolled {
+		events = append(events, event.Warning(reasonCompose, errors.Errorf(errFmtUncontrolledResource, ref.Kind, ref.Name)))
+	}
+
+	// Composition feature flags take precedence over how the composer was
+	// configured. Flags we don't recognise are ignored; Composition
+	// validation warns about them.
+	flags := req.Revision.Spec.FeatureFlags
+	driftDetection := featureEnabled(flags, v1.CompositionFeatureDriftDetection, c.detectDrift)
+	concurrency := renderConcurrency(flags, c.concurrency)
+
 	// We optimistically render all composed resources that we are able to with
 	// the expectation that any that we fail to render will subsequently have
 	// their error corrected by manual intervention or propagation of a required
 	// input. Errors are recorded, but not considered fatal to the composition
 	// process.
 	refs := make([]corev1.ObjectReference, len(tas))
-	cds := make([]ComposedResourceState, len(tas))
+	cds := make([]ComposedResourceState, 0, len(tas))
+	idx := make([]int, 0, len(tas))
+	excluded := make(map[int]bool)
 	for i := range tas {
 		ta := tas[i]
 
 		// If this resource is anonymous its "name" is just its index.
 		name := pointer.StringDeref(ta.Template.Name, strconv.Itoa(i))
-		r := composed.New(composed.FromReference(ta.Reference))
 
-		rerr := c.composed.Render(ctx, xr, r, ta.Template, req.Environment)
-		if rerr != nil {
-			events = append(events, event.Warning(reasonCompose, errors.Wrapf(rerr, errFmtResourceName, name)))
+		// Templates outside the requested subset are neither rendered nor
+		// applied, and their composed resources are never garbage collected.
+		// We still observe them, so that their connection details and
+		// readiness are reflected by the XR.
+		exclude := subset != nil && !subset[name]
+
+		// Templates whose skip condition is met are not composed. Their
+		// existing composed resource is left untouched unless it should be
+		// garbage collected. We always keep a reference that preserves the
+		// template's position, so that anonymous templates can still be
+		// associated with their composed resources by order.
+		if sc := ta.Template.SkipIf; sc != nil {
+			skip, err := skipped(xr, sc)
+			if err != nil {
+				events = append(events, event.Warning(reasonCompose, errors.Wrapf(err, errFmtSkipCondition, name)))
+				refs[i] = ta.Reference
+				continue
+			}
+			if skip {
+				refs[i] = ta.Reference
+				if !exclude && ta.Reference.Name != "" && pointer.BoolDeref(sc.GarbageCollect, false) {
+					deleted, err := c.deleteComposed(ctx, xr, ta.Reference)
+					if err != nil {
+						return CompositionResult{}, err
+					}
+					if deleted {
+						log.Debug("Deleted composed resource because its template was skipped", "resource-name", name)
+						events = append(events, event.Normal(reasonCompose, fmt.Sprintf("Deleted composed resource %q because its template was skipped", name)))
+						collected = append(collected, ta.Reference)
+						refs[i] = corev1.ObjectReference{APIVersion: ta.Reference.APIVersion, Kind: ta.Reference.Kind}
+					}
+				}
+				if refs[i].Kind == "" {
+					refs[i] = baseReference(ta.Template)
+				}
+				continue
+			}
+		}
+
+		if exclude {
+			refs[i] = ta.Reference
+			excluded[len(cds)] = true
 		}
+		cds = append(cds, ComposedResourceState{
+			ComposedResource: ComposedResource{ResourceName: name, Optional: pointer.BoolDeref(ta.Template.OptionalForReadiness, false)},
+			Template:         &ta.Template,
+			Resource:         composed.New(composed.FromReference(ta.Reference)),
+		})
+		idx = append(idx, i)
+	}
 
-		cds[i] = ComposedResourceState{
-			ComposedResource:  ComposedResource{ResourceName: name},
-			TemplateRenderErr: rerr,
-			Template:          &ta.Template,
-			Resource:          r,
+	// Rendering may patch the environment, so we can only render
+	// concurrently if we don't have one.
+	n := concurrency
+	if req.Environment != nil {
+		n = 1
+	}
+	unchanged := make([]bool, len(cds))
+	warnings := make([]error, len(cds))
+	forEach(n, len(cds), func(i int) {
+		// There's no point rendering any more composed resources if our
+		// context was cancelled, e.g. because we're shutting down.
+		if err := ctx.Err(); err != nil {
+			cds[i].TemplateRenderErr = err
+			return
+		}
+		if excluded[i] {
+			return
+		}
+		var err error
+		unchanged[i], err = c.renderComposed(ctx, xr, &cds[i], req.Environment)
+		if IsRenderWarning(err) {
+			warnings[i], err = err, nil
+		}
+		cds[i].TemplateRenderErr = err
+	})
+	if err := ctx.Err(); err != nil {
+		return CompositionResult{}, errors.Wrap(err, errInterrupted)
+	}
+	for i := range cds {
+		if excluded[i] {
+			continue
+		}
+		if cds[i].TemplateRenderErr == nil && !unchanged[i] {
+			if c.annotate {
+				SetStandardAnnotations(cds[i].Resource, req.Revision, c.manager)
+			}
+			if c.lastApplied {
+				cds[i].TemplateRenderErr = setLastAppliedConfiguration(cds[i].Resource)
+			}
+		}
+		switch err := cds[i].TemplateRenderErr; {
+		case err != nil:
+			log.Debug("Cannot render composed resource", "resource-name", cds[i].ResourceName, "error", err)
+			events = append(events, event.Warning(reasonCompose, errors.Wrapf(err, errFmtResourceName, cds[i].ResourceName)))
+		case unchanged[i]:
+			log.Debug("Composed resource was rendered from unchanged inputs", "resource-name", cds[i].ResourceName)
+		default:
+			log.Debug("Rendered composed resource", "resource-name", cds[i].ResourceName)
+		}
+		if warnings[i] != nil {
+			events = append(events, event.Warning(reasonCompose, errors.Wrapf(warnings[i], errFmtResourceName, cds[i].ResourceName)))
 		}
-		refs[i] = *meta.ReferenceTo(r, r.GetObjectKind().GroupVersionKind())
+		r := cds[i].Resource
+		refs[idx[i]] = *meta.ReferenceTo(r, r.GetObjectKind().GroupVersionKind())
 	}
 
 	// We persist references to our composed resources before we create
 	// them. This way we can render composed resources with
 	// non-deterministic names, and also potentially recover from any errors
 	// we encounter while applying composed resources without leaking them.
-	xr.SetResourceReferences(refs)
-	if err := c.client.Update(ctx, xr); err != nil {
+	// References to composed resources we retained are persisted after those
+	// of our templates, so that they don't affect association by order.
+	xr.SetResourceReferences(append(refs, report.Retained...))
+	if err := c.updateComposite(ctx, xr); err != nil {
 		return CompositionResult{}, errors.Wrap(err, errUpdate)
 	}
 
 	// We apply all of our composed resources before we observe them and update
 	// in the loop below. This ensures that issues observing and processing one
-	// composed resource won't block the application of another.
-	for _, cd := range cds {
+	// composed resource won't block the application of another. If we have a
+	// write budget we start applying where we left off last time, so that the
+	// same composed resources aren't deferred every time.
+	start := c.budget.Start(xr.GetUID(), len(cds))
+	apply := make([]int, 0, len(cds))
+	deferred := make([]string, 0)
+	unobserved := make(map[int]bool)
+	next := -1
+	for k := range cds {
+		i := (start + k) % len(cds)
+		cd := &cds[i]
+
 		// If we were unable to render the composed resource we should not try
-		// and apply it.
-		if cd.TemplateRenderErr != nil {
+		// and apply it. Nor should we apply it if it was last rendered from
+		// the same inputs, and thus wasn't rendered again.
+		if cd.TemplateRenderErr != nil || unchanged[i] {
+			continue
+		}
+
+		// We never apply the composed resources of excluded templates. We
+		// just observe them, if they exist. Those that don't exist yet
+		// aren't ready.
+		if excluded[i] {
+			exists := false
+			if cd.Resource.GetName() != "" {
+				var err error
+				if exists, err = c.getComposed(ctx, cd.Resource); err != nil {
+					return CompositionResult{}, err
+				}
+			}
+			unobserved[i] = !exists
+			continue
+		}
+
+		// We never apply observe-only composed resources. We just observe
+		// them, if they exist.
+		if observeOnly(*cd.Template) {
+			exists, err := c.getComposed(ctx, cd.Resource)
+			if err != nil {
+				return CompositionResult{}, err
+			}
+			if !exists {
+				events = append(events, event.Warning(reasonCompose, errors.Errorf(errFmtObserveOnlyNotFound, cd.ResourceName)))
+				unobserved[i] = true
+			}
+			continue
+		}
+
+		// Once our write budget is exhausted we defer applying composed
+		// resources until we're next called. We still observe deferred
+		// resources, if they exist.
+		if c.budget.Exhausted(len(apply)) {
+			if next < 0 {
+				next = i
+			}
+			deferred = append(deferred, cd.ResourceName)
+			exists, err := c.getComposed(ctx, cd.Resource)
+			if err != nil {
+				return CompositionResult{}, err
+			}
+			unobserved[i] = !exists
 			continue
 		}
-		o := []resource.ApplyOption{resource.MustBeControllableBy(xr.GetUID())}
-		o = append(o, mergeOptions(filterPatches(cd.Template.Patches, patchTypesFromXR()...))...)
-		if err := c.client.Apply(ctx, cd.Resource, o...); err != nil {
-			return CompositionResult{}, errors.Wrap(err, errApply)
+
+		// Composed resources that are too large to apply would be rejected
+		// by the API server, so we don't try to apply them.
+		if c.size != nil {
+			warning, err := c.size.Check(cd.ResourceName, cd.Resource)
+			if err != nil {
+				events = append(events, event.Warning(reasonCompose, err))
+				unobserved[i] = true
+				continue
+			}
+			if warning != nil {
+				events = append(events, event.Warning(reasonCompose, warning))
+			}
+		}
+		apply = append(apply, i)
+	}
+
+	// handle the result of applying the composed resource at index i. Failing
+	// to apply an optional composed resource is reported, but doesn't block
+	// composition. Nor does failing to apply a composed resource because it
+	// was concurrently updated. We don't observe composed resources we failed
+	// to apply.
+	handle := func(i int, forced []string, err error) error {
+		cd := &cds[i]
+		if err != nil {
+			log.Debug("Cannot apply composed resource", "resource-name", cd.ResourceName, "error", err)
+		} else {
+			log.Debug("Applied composed resource", "resource-name", cd.ResourceName)
+		}
+		switch {
+		case err != nil && cd.Optional:
+			events = append(events, event.Warning(reasonCompose, errors.Wrapf(err, errFmtApplyOptional, cd.ResourceName)))
+			unobserved[i] = true
+		case err != nil && applyConflict(cd, err):
+			events = append(events, event.Warning(reasonCompose, errors.Wrapf(errors.Wrap(err, errApply), errFmtResourceName, cd.ResourceName)))
+			unobserved[i] = true
+		case err != nil:
+			return errors.Wrap(err, errApply)
+		case len(forced) > 0:
+			events = append(events, event.Normal(reasonCompose, fmt.Sprintf("Forced ownership of conflicting fields of composed resource %q: %s", cd.ResourceName, strings.Join(forced, ", "))))
+		}
+		return nil
+	}
+
+	if concurrency > 1 {
+		forced := make([][]string, len(cds))
+		errs := make([]error, len(cds))
+		forEach(concurrency, len(apply), func(k int) {
+			i := apply[k]
+			if errs[i] = ctx.Err(); errs[i] != nil {
+				return
+			}
+			forced[i], errs[i] = c.applyComposed(ctx, xr, &cds[i], driftDetection)
+		})
+		if err := ctx.Err(); err != nil {
+			return CompositionResult{}, errors.Wrap(err, errInterrupted)
+		}
+		// Every composed resource has been applied (or not), so failing to
+		// apply one shouldn't fail composition and hide whether the others
+		// were applied. We report the failure, and don't observe the composed
+		// resource.
+		for _, i := range apply {
+			if err := handle(i, forced[i], errs[i]); err != nil {
+				events = append(events, event.Warning(reasonCompose, errors.Wrapf(err, errFmtResourceName, cds[i].ResourceName)))
+				unobserved[i] = true
+			}
+		}
+	}
+	if concurrency <= 1 {
+		for _, i := range apply {
+			// We've already persisted references to all of our composed
+			// resources, so those we've applied won't be leaked if we
+			// return early because our context was cancelled.
+			if err := ctx.Err(); err != nil {
+				return CompositionResult{}, errors.Wrap(err, errInterrupted)
+			}
+			forced, err := c.applyComposed(ctx, xr, &cds[i], driftDetection)
+			if err := handle(i, forced, err); err != nil {
+				return CompositionResult{}, err
+			}
 		}
 	}
+	c.budget.Resume(xr.GetUID(), next)
+	if len(deferred) > 0 {
+		events = append(events, event.Normal(reasonCompose, fmt.Sprintf("Write budget exhausted; deferred applying composed resources: %s", strings.Join(deferred, ", "))))
+	}
 
+	// Connection details are merged in template order, regardless of whether
+	// composed resources were rendered concurrently, so that conflicts are
+	// resolved deterministically.
 	conn := managed.ConnectionDetails{}
+	exposedBy := map[string]string{}
+	copiedBy := map[string]string{}
+	withheld := managed.ConnectionDetails{}
+	observed := make([]resource.Composed, 0, len(cds))
 	for i := range cds {
-		// If we were unable to render the composed resource we should not try
-		// to observe it.
-		if cds[i].TemplateRenderErr != nil {
+		// If we were unable to render the composed resource, or we deferred
+		// applying it and it doesn't exist yet, we should not try to observe
+		// it.
+		if cds[i].TemplateRenderErr != nil || unobserved[i] {
 			continue
 		}
 
@@ -257,6 +973,10 @@ func (c *PTComposer) Compose(ctx context.Context, xr resource.Composite, req Com
 			return CompositionResult{}, errors.Wrap(err, errRenderCR)
 		}
 
+		if err := copyStatusFromComposed(xr, cds[i].Resource, cds[i].ResourceName, cds[i].Template.StatusFromComposed, copiedBy, c.connConflict); err != nil {
+			return CompositionResult{}, errors.Wrap(err, errRenderCR)
+		}
+
 		cds[i].ConnectionDetails, err = c.composed.FetchConnection(ctx, cds[i].Resource)
 		if err != nil {
 			return CompositionResult{}, errors.Wrap(err, errFetchDetails)
@@ -266,15 +986,98 @@ func (c *PTComposer) Compose(ctx context.Context, xr resource.Composite, req Com
 		if err != nil {
 			return CompositionResult{}, errors.Wrap(err, errExtractDetails)
 		}
+		e = resolveConnectionDetails(cds[i].ConnectionDetails, e, c.connPrecedence)
+		e = prefixConnectionDetails(e, pointer.StringDeref(cds[i].Template.ConnectionDetailsPrefix, ""))
 
-		for key, val := range e {
-			conn[key] = val
+		cds[i].Ready, err = c.composed.IsReady(ctx, cds[i].Resource, ReadinessChecksFromComposedTemplate(cds[i].Template)...)
+		if err != nil {
+			if c.readinessErr != ReadinessErrorPolicyRetry {
+				return CompositionResult{}, errors.Wrap(err, errReadiness)
+			}
+			// Treat the composed resource as not ready yet. We'll check
+			// again next time the XR is composed.
+			events = append(events, event.Warning(reasonCompose, errors.Wrapf(errors.Wrap(err, errReadiness), errFmtResourceName, cds[i].ResourceName)))
+			cds[i].Ready = false
 		}
 
-		cds[i].Ready, err = c.composed.IsReady(ctx, cds[i].Resource, ReadinessChecksFromComposedTemplate(cds[i].Template)...)
+		log.Debug("Checked whether composed resource is ready", "resource-name", cds[i].ResourceName, "ready", cds[i].Ready)
+
+		// Connection details of composed resources that aren't ready yet may
+		// be stale or empty, so we may withhold them until they're ready.
+		if c.readyConnection && !cds[i].Ready {
+			for k, v := range e {
+				withheld[k] = v
+			}
+		} else if err := mergeConnectionDetails(conn, exposedBy, cds[i].ResourceName, e, c.connConflict); err != nil {
+			return CompositionResult{}, errors.Wrap(err, errMergeDetails)
+		}
+
+		observed = append(observed, cds[i].Resource)
+	}
+
+	// Connection details derived from the XR are extracted once its status has
+	// been rendered from all composed resources. Connection details exposed by
+	// composed resources take precedence.
+	if len(req.Revision.Spec.ConnectionDetails) > 0 {
+		xrc, err := c.composed.ExtractConnection(xr, nil, ExtractConfigsFromConnectionDetails(req.Revision.Spec.ConnectionDetails)...)
+		if err != nil {
+			return CompositionResult{}, errors.Wrap(err, errExtractXRDetails)
+		}
+		if err := mergeCompositeConnectionDetails(conn, exposedBy, xrc, c.connConflict); err != nil {
+			return CompositionResult{}, errors.Wrap(err, errMergeDetails)
+		}
+	}
+
+	// Report composed resources whose readiness changed since the XR was
+	// last composed. We only know the previous readiness of composed
+	// resources that existed before this composition.
+	if req.PreviouslyNotReady != nil {
+		for i := range cds {
+			if tas[idx[i]].Reference.Name == "" || cds[i].TemplateRenderErr != nil || unobserved[i] {
+				continue
+			}
+			if e, ok := readinessTransition(cds[i].ComposedResource, req.PreviouslyNotReady[cds[i].ResourceName]); ok {
+				events = append(events, e)
+			}
+		}
+	}
+
+	if c.sum != nil {
+		e, err := c.sum.Aggregate(xr, observed)
+		if err != nil {
+			return CompositionResult{}, err
+		}
+		events = append(events, e...)
+	}
+
+	var stale []string
+	if c.xrConnection != nil {
+		xc, err := c.xrConnection.FetchConnection(ctx, xr)
 		if err != nil {
-			return CompositionResult{}, errors.Wrap(err, errReadiness)
+			return CompositionResult{}, errors.Wrap(err, errFetchXRConnectionDetails)
+		}
+		// Withheld connection details still have a source, so they're not
+		// stale. Nor are connection details the Composition derives from the
+		// XR.
+		sourced := managed.ConnectionDetails{}
+		for _, d := range []managed.ConnectionDetails{withheld, conn} {
+			for k, v := range d {
+				sourced[k] = v
+			}
+		}
+		for _, cfg := range ExtractConfigsFromConnectionDetails(req.Revision.Spec.ConnectionDetails) {
+			if _, ok := sourced[cfg.Name]; !ok {
+				sourced[cfg.Name] = nil
+			}
 		}
+		stale = staleConnectionDetails(xc, ct, sourced)
+	}
+
+	// Composed resources that aren't ready by their creation deadline degrade
+	// the XR. This includes resources we were unable to render or apply.
+	now := c.now()
+	for i := range cds {
+		cds[i].DeadlineExceeded = !cds[i].Ready && CreationDeadlineExceeded(xr.GetCreationTimestamp(), cds[i].Template.CreationDeadline, now)
 	}
 
 	// Call Apply so that we do not just replace fields on existing XR but
@@ -296,45 +1099,471 @@ func (c *PTComposer) Compose(ctx context.Context, xr resource.Composite, req Com
 	}
 
 	out := make([]ComposedResource, len(cds))
+	rendered := 0
 	for i := range cds {
 		out[i] = cds[i].ComposedResource
+		out[i].Revision = req.Revision.GetName()
+		if cds[i].TemplateRenderErr == nil {
+			out[i].GroupVersionKind = cds[i].Resource.GetObjectKind().GroupVersionKind()
+			rendered++
+		}
 	}
 
-	return CompositionResult{ConnectionDetails: conn, Composed: out, Events: events}, nil
-}
+	if c.metrics != nil {
+		c.metrics.SetComposed(req.Revision.GetName(), rendered, len(collected))
+	}
 
-// toXRPatchesFromTAs selects patches defined in composed templates,
-// whose type is one of the XR-targeting patches
-// (e.g. v1.PatchTypeToCompositeFieldPath or v1.PatchTypeCombineToComposite)
-func toXRPatchesFromTAs(tas []TemplateAssociation) []v1.Patch {
-	filtered := make([]v1.Patch, 0, len(tas))
-	for _, ta := range tas {
-		filtered = append(filtered, filterPatches(ta.Template.Patches,
-			patchTypesToXR()...)...)
+	res := CompositionResult{ConnectionDetails: conn, StaleConnectionDetails: stale, Composed: out, GarbageCollected: collected, Events: events, Requeue: len(deferred) > 0}
+	if c.readiness != nil {
+		res.CompositeReady = pointer.Bool(c.readiness.AggregateReadiness(out))
 	}
-	return filtered
+	if c.requeue != nil {
+		res.RequeueAfter = c.requeue.RequeueHint(res)
+	}
+	for _, ref := range collected {
+		log.Debug("Garbage collected composed resource", "kind", ref.Kind, "name", ref.Name)
+	}
+	log.Debug("Composed resources", "ready", res.Ready(), "rendered", rendered, "deferred", len(deferred))
+	return res, nil
 }
 
-// filterPatches selects patches whose type belong to the list onlyTypes
-func filterPatches(pas []v1.Patch, onlyTypes ...v1.PatchType) []v1.Patch {
-	filtered := make([]v1.Patch, 0, len(pas))
-	include := make(map[v1.PatchType]bool)
-	for _, t := range onlyTypes {
-		include[t] = true
+// updateComposite persists the supplied XR according to our update strategy.
+func (c *PTComposer) updateComposite(ctx context.Context, xr resource.Composite) error {
+	if c.xrUpdate != CompositeUpdateStrategyPatch {
+		return c.client.Update(ctx, xr)
 	}
-	for _, p := range pas {
-		if include[p.Type] {
-			filtered = append(filtered, p)
-		}
+
+	// We patch only the fields we own. We patch a copy of the XR so that the
+	// API server's response doesn't reset any other changes made while
+	// composing.
+	owned := map[string]any{"spec": map[string]any{"resourceRefs": xr.GetResourceReferences()}}
+	if team := GetOwnerTeam(xr); team != "" {
+		owned["metadata"] = map[string]any{"labels": map[string]string{LabelKeyOwnerTeam: team}}
 	}
-	return filtered
+	data, err := json.Marshal(owned)
+	if err != nil {
+		return err
+	}
+	cp := xr.DeepCopyObject().(client.Object)
+	if err := c.client.Patch(ctx, cp, client.RawPatch(types.MergePatchType, data)); err != nil {
+		return err
+	}
+	xr.SetResourceVersion(cp.GetResourceVersion())
+	return nil
 }
 
-// A TemplateAssociation associates a composed resource template with a composed
-// resource. If no such resource exists the reference will be empty.
-type TemplateAssociation struct {
-	Template  v1.ComposedTemplate
-	Reference corev1.ObjectReference
+// associated returns the number of the supplied template associations that
+// associate a template with an existing composed resource.
+func associated(tas []TemplateAssociation) int {
+	n := 0
+	for _, ta := range tas {
+		if ta.Reference.Name != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// getComposed gets the current state of the supplied composed resource. It
+// returns false if the composed resource doesn't exist.
+func (c *PTComposer) getComposed(ctx context.Context, cd resource.Composed) (bool, error) {
+	err := c.client.Get(ctx, types.NamespacedName{Namespace: cd.GetNamespace(), Name: cd.GetName()}, cd)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, errors.Wrap(err, errGetComposed)
+}
+
+// readinessTransition returns an event if the readiness of the supplied
+// composed resource differs from its previous readiness.
+func readinessTransition(cd ComposedResource, previouslyNotReady bool) (event.Event, bool) {
+	switch {
+	case cd.Ready && previouslyNotReady:
+		return event.Normal(reasonCompose, fmt.Sprintf("Composed resource %q is now ready", cd.ResourceName)), true
+	case !cd.Ready && !previouslyNotReady:
+		return event.Warning(reasonCompose, errors.Errorf(errFmtNoLongerReady, cd.ResourceName)), true
+	}
+	return event.Event{}, false
+}
+
+// setLastAppliedConfiguration annotates the supplied rendered composed
+// resource with its configuration.
+func setLastAppliedConfiguration(cd resource.Composed) error {
+	cfg, err := LastAppliedConfiguration(cd)
+	if err != nil {
+		return err
+	}
+	SetLastAppliedConfiguration(cd, cfg)
+	return nil
+}
+
+// renderComposed renders the supplied composed resource. If render skipping is
+// enabled and the composed resource was last rendered from the same inputs it
+// instead gets the composed resource's current state, and returns true.
+func (c *PTComposer) renderComposed(ctx context.Context, xr resource.Composite, cd *ComposedResourceState, env *Environment) (bool, error) {
+	if !c.skipRender || c.renderOpaque || observeOnly(*cd.Template) || rendersEnvironment(*cd.Template) {
+		return false, c.composed.Render(ctx, xr, cd.Resource, *cd.Template, env)
+	}
+
+	sum, err := RenderChecksum(xr, *cd.Template, env, c.renderPaths...)
+	if err != nil {
+		return false, err
+	}
+
+	if cd.Resource.GetName() != "" {
+		ref := *meta.ReferenceTo(cd.Resource, cd.Resource.GetObjectKind().GroupVersionKind())
+		exists, err := c.getComposed(ctx, cd.Resource)
+		if err != nil {
+			return false, err
+		}
+		if exists && GetRenderChecksum(cd.Resource) == sum {
+			return true, nil
+		}
+
+		// Render the composed resource from scratch, not from its current
+		// state.
+		cd.Resource = composed.New(composed.FromReference(ref))
+	}
+
+	// A RenderWarning doesn't prevent the composed resource from being
+	// applied, so we return it only after we've recorded the checksum.
+	err = c.composed.Render(ctx, xr, cd.Resource, *cd.Template, env)
+	if err != nil && !IsRenderWarning(err) {
+		return false, err
+	}
+	SetRenderChecksum(cd.Resource, sum)
+	return false, err
+}
+
+// applyComposed applies the supplied composed resource. It returns the fields
+// of which it forcibly took ownership from another field manager, if any.
+func (c *PTComposer) applyComposed(ctx context.Context, xr resource.Composite, cd *ComposedResourceState, driftDetection bool) ([]string, error) {
+	o := []resource.ApplyOption{resource.MustBeControllableBy(xr.GetUID())}
+	o = append(o, mergeOptions(filterPatches(cd.Template.Patches, patchTypesFromXR()...))...)
+	if driftDetection {
+		o = append(o, detectDrift(&cd.Drifted))
+	}
+
+	// Composed resources with a conflict policy are applied using server-side
+	// apply. Forcibly taking ownership of fields from another field manager
+	// is always reported.
+	if p := cd.Template.ConflictPolicy; p != nil {
+		ssa := c.ssa
+		if fm := cd.Template.FieldManager; fm != nil {
+			ssa = ssa.WithFieldOwner(*fm)
+		}
+		fields, err := ssa.Apply(ctx, cd.Resource, *p, o...)
+		if err != nil || *p != v1.ConflictPolicyForce {
+			return nil, err
+		}
+		return fields, nil
+	}
+
+	// If the desired state of this composed resource hasn't changed since we
+	// last applied it we don't apply it again.
+	if c.checksums {
+		sum, err := DesiredChecksum(cd.Resource)
+		if err != nil {
+			return nil, err
+		}
+		SetDesiredChecksum(cd.Resource, sum)
+		o = append(o, skipUnchanged(sum))
+	}
+
+	if err := c.client.Apply(ctx, cd.Resource, o...); err != nil && !resource.IsNotAllowed(err) {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// applyConflict returns true if the supplied error indicates that the supplied
+// composed resource could not be applied because it was updated since we last
+// read it. Such optimistic concurrency conflicts are transient. Conflicts with
+// other field managers when applying a composed resource with a conflict
+// policy are not.
+func applyConflict(cd *ComposedResourceState, err error) bool {
+	return cd.Template.ConflictPolicy == nil && kerrors.IsConflict(err)
+}
+
+// forEach calls fn with each index in [0, n), using up to c concurrent
+// goroutines. Indices are processed in order when c is less than two. It
+// returns once every call has returned.
+func forEach(c, n int, fn func(i int)) {
+	if c < 2 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	g := &errgroup.Group{}
+	g.SetLimit(c)
+	for i := 0; i < n; i++ {
+		i := i // Pin the loop variable before using it in a Goroutine.
+		g.Go(func() error {
+			fn(i)
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// skipped returns true if the supplied composite resource meets the supplied
+// skip condition. The condition is not met if the field does not exist.
+func skipped(xr resource.Composite, sc *v1.SkipCondition) (bool, error) {
+	p, err := fieldpath.PaveObject(xr)
+	if err != nil {
+		return false, err
+	}
+	in, err := p.GetValue(sc.FromFieldPath)
+	if fieldpath.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Round-trip the field's value through JSON so that it may be compared
+	// with the value we expect.
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return false, err
+	}
+	var got, want any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(sc.Equals.Raw, &want); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(got, want), nil
+}
+
+// DefaultWriteBudgetTTL is how long a WriteBudget remembers where to resume
+// composing an XR. An XR that is deferred is typically composed again
+// immediately, so an XR that isn't composed again within this long has most
+// likely been deleted.
+const DefaultWriteBudgetTTL = 1 * time.Hour
+
+// A WriteBudget limits how many composed resources are applied each time
+// resources are composed for an XR. It remembers which composed resource was
+// deferred first, so that composition can resume from there. It forgets XRs
+// that aren't composed again within its TTL, so that it doesn't grow without
+// bound as XRs are deleted. A nil WriteBudget is unlimited.
+type WriteBudget struct {
+	limit int
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu    sync.Mutex
+	next  map[types.UID]writeBudgetEntry
+	swept time.Time
+}
+
+type writeBudgetEntry struct {
+	index   int
+	resumed time.Time
+}
+
+// NewWriteBudget returns a WriteBudget that allows the supplied number of
+// composed resources to be applied each time resources are composed.
+func NewWriteBudget(limit int) *WriteBudget {
+	return &WriteBudget{limit: limit, ttl: DefaultWriteBudgetTTL, now: time.Now, next: make(map[types.UID]writeBudgetEntry)}
+}
+
+// Start returns the index of the first of the supplied number of composed
+// resources that should be applied for the supplied XR.
+func (b *WriteBudget) Start(xr types.UID, n int) int {
+	if b == nil || n == 0 {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.next[xr]
+	if !ok || b.now().Sub(e.resumed) > b.ttl {
+		return 0
+	}
+	return e.index % n
+}
+
+// Exhausted returns true if the supplied number of writes exhausts the budget.
+func (b *WriteBudget) Exhausted(writes int) bool {
+	return b != nil && b.limit > 0 && writes >= b.limit
+}
+
+// Resume records the index of the composed resource that should be applied
+// first next time resources are composed for the supplied XR. A negative index
+// indicates that no composed resources were deferred.
+func (b *WriteBudget) Resume(xr types.UID, i int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+
+	// Evict XRs we haven't resumed within our TTL, at most once per TTL.
+	if now.Sub(b.swept) > b.ttl {
+		for uid, e := range b.next {
+			if now.Sub(e.resumed) > b.ttl {
+				delete(b.next, uid)
+			}
+		}
+		b.swept = now
+	}
+
+	if i < 0 {
+		delete(b.next, xr)
+		return
+	}
+	b.next[xr] = writeBudgetEntry{index: i, resumed: now}
+}
+
+// baseReference returns a reference to the kind of resource the supplied
+// template composes. The reference does not name a resource.
+func baseReference(t v1.ComposedTemplate) corev1.ObjectReference {
+	tm := metav1.TypeMeta{}
+	_ = json.Unmarshal(t.Base.Raw, &tm)
+	return corev1.ObjectReference{APIVersion: tm.APIVersion, Kind: tm.Kind}
+}
+
+// deleteComposed deletes the referenced composed resource, if it exists and
+// is controlled by the supplied composite resource. It returns true if the
+// composed resource was deleted.
+func (c *PTComposer) deleteComposed(ctx context.Context, xr resource.Composite, ref corev1.ObjectReference) (bool, error) {
+	cd := composed.New(composed.FromReference(ref))
+	err := c.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, errGetComposed)
+	}
+
+	// We don't delete composed resources we don't control.
+	if ctrl := metav1.GetControllerOf(cd); ctrl == nil || ctrl.UID != xr.GetUID() {
+		return false, nil
+	}
+
+	if err := c.client.Delete(ctx, cd); resource.IgnoreNotFound(err) != nil {
+		return false, errors.Wrap(err, errGCComposed)
+	}
+	return true, nil
+}
+
+// staleConnectionDetails returns the keys of the supplied current connection
+// details that no longer have a source. A key has a source if it was extracted
+// from a composed resource, or if any of the supplied templates declares a
+// connection detail with that name. The latter ensures we don't remove keys
+// from composed resources we were unable to render or observe, or keys whose
+// values are constants.
+func staleConnectionDetails(current managed.ConnectionDetails, ct []v1.ComposedTemplate, conn managed.ConnectionDetails) []string {
+	sourced := make(map[string]bool, len(conn))
+	for key := range conn {
+		sourced[key] = true
+	}
+	for i := range ct {
+		prefix := pointer.StringDeref(ct[i].ConnectionDetailsPrefix, "")
+		for _, cfg := range ExtractConfigsFromTemplate(&ct[i]) {
+			sourced[prefix+cfg.Name] = true
+		}
+	}
+
+	stale := make([]string, 0)
+	for key := range current {
+		if !sourced[key] {
+			stale = append(stale, key)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// featureEnabled returns whether the supplied feature flag is enabled. Flags
+// that are not set return the supplied default.
+func featureEnabled(flags map[string]bool, flag string, dflt bool) bool {
+	if v, ok := flags[flag]; ok {
+		return v
+	}
+	return dflt
+}
+
+// renderConcurrency returns how many composed resources should be rendered
+// and applied concurrently, given the supplied feature flags and configured
+// concurrency. Disabling the ConcurrentRendering flag renders sequentially.
+// Enabling it renders concurrently, using defaultRenderConcurrency unless more
+// concurrency is configured.
+func renderConcurrency(flags map[string]bool, configured int) int {
+	enabled, ok := flags[v1.CompositionFeatureConcurrentRendering]
+	switch {
+	case !ok:
+		return configured
+	case !enabled:
+		return 1
+	case configured > defaultRenderConcurrency:
+		return configured
+	default:
+		return defaultRenderConcurrency
+	}
+}
+
+// templateSubset returns the set of template names that should be composed,
+// or nil if all templates should be composed. It returns an error if any of
+// the requested names does not match a named template.
+func templateSubset(ct []v1.ComposedTemplate, names []string) (map[string]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]bool, len(ct))
+	for _, t := range ct {
+		if t.Name != nil {
+			known[*t.Name] = true
+		}
+	}
+	subset := make(map[string]bool, len(names))
+	for _, n := range names {
+		if !known[n] {
+			return nil, errors.Errorf(errFmtUnknownTemplate, n)
+		}
+		subset[n] = true
+	}
+	return subset, nil
+}
+
+// toXRPatchesFromTAs selects patches defined in composed templates,
+// whose type is one of the XR-targeting patches
+// (e.g. v1.PatchTypeToCompositeFieldPath or v1.PatchTypeCombineToComposite)
+func toXRPatchesFromTAs(tas []TemplateAssociation) []v1.Patch {
+	filtered := make([]v1.Patch, 0, len(tas))
+	for _, ta := range tas {
+		filtered = append(filtered, filterPatches(ta.Template.Patches,
+			patchTypesToXR()...)...)
+	}
+	return filtered
+}
+
+// filterPatches selects patches whose type belong to the list onlyTypes
+func filterPatches(pas []v1.Patch, onlyTypes ...v1.PatchType) []v1.Patch {
+	filtered := make([]v1.Patch, 0, len(pas))
+	include := make(map[v1.PatchType]bool)
+	for _, t := range onlyTypes {
+		include[t] = true
+	}
+	for _, p := range pas {
+		if include[p.Type] {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// A TemplateAssociation associates a composed resource template with a composed
+// resource. If no such resource exists the reference will be empty.
+type TemplateAssociation struct {
+	Template  v1.ComposedTemplate
+	Reference corev1.ObjectReference
 }
 
 // AssociateByOrder associates the supplied templates with the supplied resource
@@ -342,6 +1571,17 @@ type TemplateAssociation struct {
 // The returned array will always be of the same length as the supplied array of
 // templates. Any additional references will be truncated.
 func AssociateByOrder(t []v1.ComposedTemplate, r []corev1.ObjectReference) []TemplateAssociation {
+	a, _ := AssociateByOrderWithExtras(t, r)
+	return a
+}
+
+// AssociateByOrderWithExtras associates the supplied templates with the
+// supplied resource references by order, like AssociateByOrder. Rather than
+// truncating any additional references it also returns them, for example so
+// that the composed resources they reference may be garbage collected. It
+// returns no additional references if there are no more references than
+// templates.
+func AssociateByOrderWithExtras(t []v1.ComposedTemplate, r []corev1.ObjectReference) ([]TemplateAssociation, []corev1.ObjectReference) {
 	a := make([]TemplateAssociation, len(t))
 	for i := range t {
 		a[i] = TemplateAssociation{Template: t[i]}
@@ -356,7 +1596,10 @@ func AssociateByOrder(t []v1.ComposedTemplate, r []corev1.ObjectReference) []Tem
 		a[i].Reference = r[i]
 	}
 
-	return a
+	if len(r) <= len(t) {
+		return a, nil
+	}
+	return a, r[len(t):]
 }
 
 // A CompositionTemplateAssociator returns an array of template associations.
@@ -372,60 +1615,307 @@ func (fn CompositionTemplateAssociatorFn) AssociateTemplates(ctx context.Context
 	return fn(ctx, cr, ct)
 }
 
+// A ReportingAssociator associates templates with composed resources, and
+// reports what it did with the composed resources it didn't associate.
+type ReportingAssociator interface {
+	AssociateTemplatesWithReport(context.Context, resource.Composite, []v1.ComposedTemplate) ([]TemplateAssociation, AssociationReport, error)
+}
+
+// A CollectingAssociator associates templates with composed resources, and
+// may garbage collect composed resources while doing so.
+type CollectingAssociator interface {
+	// WithoutGarbageCollection returns an equivalent associator that never
+	// garbage collects composed resources.
+	WithoutGarbageCollection() CompositionTemplateAssociator
+}
+
+// An AssociationReport reports references to composed resources that a
+// ReportingAssociator didn't associate with a template.
+type AssociationReport struct {
+	// Uncontrolled composed resources don't correspond to a template, but
+	// weren't garbage collected because they're controlled by another
+	// resource. Their references should be dropped.
+	Uncontrolled []corev1.ObjectReference
+
+	// Retained composed resources don't correspond to a template, but
+	// weren't garbage collected because garbage collection is disabled, or
+	// because they couldn't be associated by order with a template. Their
+	// references should be preserved, so that they may be garbage collected
+	// once garbage collection is enabled.
+	Retained []corev1.ObjectReference
+
+	// Collected composed resources don't correspond to a template, and were
+	// deleted. Composed resources that were released, left, or were already
+	// being deleted aren't collected.
+	Collected []corev1.ObjectReference
+}
+
 // A GarbageCollectingAssociator associates a Composition's resource templates
 // with (references to) composed resources. It tries to associate them by
-// checking the template name annotation of each referenced resource. If any
-// template or existing composed resource can't be associated by name it falls
-// back to associating them by order. If it encounters a referenced resource
+// checking the template name annotation of each referenced resource. If no
+// template is named, or an existing composed resource isn't annotated while
+// all templates are named, it falls back to associating them by order. If only
+// some templates are named it associates annotated composed resources by name,
+// and unannotated composed resources with the template at their position. If
+// it encounters a referenced resource
 // that corresponds to a non-existent template the resource will be garbage
 // collected (i.e. deleted).
 type GarbageCollectingAssociator struct {
 	client client.Client
+	orphan OrphanStrategy
+
+	// extras determines whether composed resources referenced after the
+	// last template are treated as orphans when associating by order.
+	extras bool
+
+	// uncontrolled determines whether references to composed resources
+	// controlled by another resource are reported.
+	uncontrolled bool
+
+	// gc determines whether orphaned composed resources are garbage
+	// collected.
+	gc bool
+
+	// list determines whether composed resources of the same kind are
+	// listed, rather than got one at a time.
+	list bool
+}
+
+// An OrphanStrategy determines what a GarbageCollectingAssociator does with
+// an orphaned composed resource - i.e. one created from a template that no
+// longer exists, for example because the XR switched to a different
+// Composition.
+type OrphanStrategy string
+
+// Orphan strategies.
+const (
+	// OrphanStrategyDelete deletes orphaned composed resources.
+	OrphanStrategyDelete OrphanStrategy = "Delete"
+
+	// OrphanStrategyLeave leaves orphaned composed resources in place, but
+	// releases them from the composite resource's control so that they
+	// aren't garbage collected along with it.
+	OrphanStrategyLeave OrphanStrategy = "Leave"
+
+	// OrphanStrategyAdoptIfMatching associates an orphaned composed resource
+	// with the first template of the same kind that isn't associated with a
+	// composed resource. Orphaned composed resources that can't be adopted
+	// are deleted.
+	OrphanStrategyAdoptIfMatching OrphanStrategy = "AdoptIfMatching"
+)
+
+// A GarbageCollectingAssociatorOption configures a
+// GarbageCollectingAssociator.
+type GarbageCollectingAssociatorOption func(a *GarbageCollectingAssociator)
+
+// WithOrphanStrategy configures what a GarbageCollectingAssociator does with
+// orphaned composed resources. Composed resources whose garbage collection
+// policy is Orphan are released rather than deleted, regardless of strategy.
+// Deleting a composed resource whose deletion policy is Orphan orphans the
+// external resource it represents.
+func WithOrphanStrategy(s OrphanStrategy) GarbageCollectingAssociatorOption {
+	return func(a *GarbageCollectingAssociator) {
+		a.orphan = s
+	}
+}
+
+// WithExtraReferenceCollection configures a GarbageCollectingAssociator to
+// treat composed resources that are referenced after the last template as
+// orphans when it associates templates by order, for example because templates
+// were removed from the end of an array of anonymous templates. By default
+// references to such composed resources are dropped, leaving the composed
+// resources untouched.
+func WithExtraReferenceCollection() GarbageCollectingAssociatorOption {
+	return func(a *GarbageCollectingAssociator) {
+		a.extras = true
+	}
+}
+
+// WithUncontrolledResourceReporting configures a GarbageCollectingAssociator
+// to report references to composed resources that it can't garbage collect
+// because they're controlled by another resource, which may indicate that
+// more than one resource is trying to control them. By default references to
+// such composed resources are silently dropped.
+func WithUncontrolledResourceReporting() GarbageCollectingAssociatorOption {
+	return func(a *GarbageCollectingAssociator) {
+		a.uncontrolled = true
+	}
+}
+
+// WithGarbageCollection configures whether a GarbageCollectingAssociator
+// garbage collects orphaned composed resources. When garbage collection is
+// disabled orphaned composed resources are left untouched, and reported as
+// retained so that their references are preserved until garbage collection is
+// enabled again. Garbage collection is enabled by default.
+func WithGarbageCollection(enabled bool) GarbageCollectingAssociatorOption {
+	return func(a *GarbageCollectingAssociator) {
+		a.gc = enabled
+	}
+}
+
+// WithComposedResourceListing configures a GarbageCollectingAssociator to
+// list the composed resources an XR references using a single API call when
+// they're all of the same kind, rather than getting each of them. Composed
+// resources are listed by the label that associates them with the XR. Any
+// referenced composed resource that isn't listed, for example because it
+// doesn't have the label, is got as usual.
+func WithComposedResourceListing() GarbageCollectingAssociatorOption {
+	return func(a *GarbageCollectingAssociator) {
+		a.list = true
+	}
 }
 
 // NewGarbageCollectingAssociator returns a CompositionTemplateAssociator that
 // may garbage collect composed resources.
-func NewGarbageCollectingAssociator(c client.Client) *GarbageCollectingAssociator {
-	return &GarbageCollectingAssociator{client: c}
+func NewGarbageCollectingAssociator(c client.Client, o ...GarbageCollectingAssociatorOption) *GarbageCollectingAssociator {
+	a := &GarbageCollectingAssociator{client: c, orphan: OrphanStrategyDelete, gc: true}
+	for _, fn := range o {
+		fn(a)
+	}
+	return a
+}
+
+// WithoutGarbageCollection returns a copy of this associator with garbage
+// collection disabled. Composed resources it would have garbage collected are
+// reported as retained.
+func (a *GarbageCollectingAssociator) WithoutGarbageCollection() CompositionTemplateAssociator {
+	na := *a
+	na.gc = false
+	return &na
 }
 
 // AssociateTemplates with composed resources.
-func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, error) { //nolint:gocyclo // Only slightly over (13).
+func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+	tas, _, err := a.AssociateTemplatesWithReport(ctx, cr, ct)
+	return tas, err
+}
+
+// AssociateTemplatesWithReport associates templates with composed resources,
+// like AssociateTemplates. It also reports references to composed resources
+// that it didn't garbage collect because garbage collection is disabled and,
+// if configured to, references to composed resources that it dropped because
+// they're controlled by another resource.
+func (a *GarbageCollectingAssociator) AssociateTemplatesWithReport(ctx context.Context, cr resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, AssociationReport, error) { //nolint:gocyclo // Only slightly over (13).
 	templates := map[string]int{}
+	anonymous := 0
+	var dup error
 	for i, t := range ct {
 		if t.Name == nil {
-			// If our templates aren't named we fall back to assuming that the
-			// existing resource reference array (if any) already matches the
-			// order of our resource template array.
-			return AssociateByOrder(ct, cr.GetResourceReferences()), nil
+			anonymous++
+			continue
+		}
+		// Composed resources are associated with their template by name, so
+		// templates that share a name would be associated with each other's
+		// composed resources.
+		if j, ok := templates[*t.Name]; ok && dup == nil {
+			dup = errors.Errorf(errFmtDuplicateTemplate, j, i, *t.Name)
+			continue
 		}
 		templates[*t.Name] = i
 	}
+	if dup != nil {
+		return nil, AssociationReport{}, dup
+	}
+
+	// If our templates aren't named we fall back to assuming that the
+	// existing resource reference array (if any) already matches the order
+	// of our resource template array.
+	if anonymous > 0 && anonymous == len(ct) {
+		return a.associateByOrder(ctx, cr, ct)
+	}
 
 	tas := make([]TemplateAssociation, len(ct))
 	for i := range ct {
 		tas[i] = TemplateAssociation{Template: ct[i]}
 	}
 
-	for _, ref := range cr.GetResourceReferences() {
+	// We never annotate observe-only composed resources with the name of
+	// their template, so we can't associate them by name. Instead we
+	// associate them with the reference to the resource their template's base
+	// names, wherever it appears. We never garbage collect them.
+	refs := cr.GetResourceReferences()
+	observed := map[corev1.ObjectReference]bool{}
+	for i := range ct {
+		if !observeOnly(ct[i]) {
+			continue
+		}
+		for _, ref := range refs {
+			if ref.Name == "" || observed[ref] || !observes(ct[i], ref) {
+				continue
+			}
+			tas[i].Reference = ref
+			observed[ref] = true
+			break
+		}
+	}
+
+	listed, err := a.listComposed(ctx, cr, refs, observed)
+	if err != nil {
+		return nil, AssociationReport{}, err
+	}
+
+	// When some of our templates are anonymous we associate unannotated
+	// composed resources by order, once we've associated every annotated
+	// composed resource by name. These are the positions of the references
+	// to unannotated composed resources, and whether they no longer exist.
+	positional := make([]int, 0)
+	missing := map[int]bool{}
+
+	orphans := make([]*composed.Unstructured, 0)
+	report := AssociationReport{}
+	for j, ref := range refs {
 		// If reference does not have a name then we haven't rendered it yet.
-		if ref.Name == "" {
+		// Observe-only resources are already associated.
+		if ref.Name == "" || observed[ref] {
 			continue
 		}
-		cd := composed.New(composed.FromReference(ref))
 		nn := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
-		err := a.client.Get(ctx, nn, cd)
+		cd, ok := listed[nn]
+		var err error
+		if !ok {
+			cd = composed.New(composed.FromReference(ref))
+			err = a.client.Get(ctx, nn, cd)
+		}
 
-		// We believe we created this resource, but it no longer exists.
+		// We believe we created this resource, but it no longer exists. We
+		// preserve its reference if it may belong to an anonymous template,
+		// like associating by order would.
 		if kerrors.IsNotFound(err) {
+			if anonymous > 0 {
+				positional = append(positional, j)
+				missing[j] = true
+			}
 			continue
 		}
 
 		if err != nil {
-			return nil, errors.Wrap(err, errGetComposed)
+			return nil, AssociationReport{}, errors.Wrap(err, errGetComposed)
+		}
+
+		// This existing resource is owned by a different team. Even if its
+		// template name matches one of ours we shouldn't adopt it, and we
+		// mustn't garbage collect it.
+		if !SameOwnerTeam(cr, cd) {
+			continue
 		}
 
 		name := GetCompositionResourceName(cd)
+
+		// This existing resource isn't associated with a template, and we
+		// don't control it. It's likely an observe-only resource whose
+		// template no longer names it. We mustn't associate it with another
+		// template, which would apply that template over it.
+		if c := metav1.GetControllerOf(cd); name == "" && (c == nil || c.UID != cr.GetUID()) {
+			continue
+		}
+
+		// Composed resources created from anonymous templates aren't
+		// annotated with a template name. We'll associate this one by order.
+		if name == "" && anonymous > 0 {
+			positional = append(positional, j)
+			continue
+		}
+
 		if name == "" {
 			// All of our templates are named, but this existing composed
 			// resource is not associated with a named template. It's likely
@@ -434,7 +1924,7 @@ func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr
 			// reference array already matches the order of our resource
 			// template array. Existing composed resources should be annotated
 			// at render time with the name of the template used to create them.
-			return AssociateByOrder(ct, cr.GetResourceReferences()), nil
+			return a.associateByOrder(ctx, cr, ct)
 		}
 
 		// Inject the reference to this existing resource into the references
@@ -450,17 +1940,238 @@ func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr
 
 		// We want to garbage collect this resource, but we don't control it.
 		if c := metav1.GetControllerOf(cd); c != nil && c.UID != cr.GetUID() {
+			if a.uncontrolled {
+				report.Uncontrolled = append(report.Uncontrolled, ref)
+			}
+			continue
+		}
+
+		// We want to garbage collect this resource, but garbage collection
+		// is disabled. We retain its reference so that we can garbage
+		// collect it once garbage collection is enabled.
+		if !a.gc {
+			report.Retained = append(report.Retained, ref)
+			continue
+		}
+
+		// This existing resource does not correspond to an extant template.
+		// It's an orphan.
+		orphans = append(orphans, cd)
+	}
+
+	// Associate unannotated composed resources with the template at their
+	// position, unless that template is already associated by name. This
+	// template may be named if our Composition was just migrated from
+	// anonymous to named templates. We can't tell which template any other
+	// unannotated composed resource corresponds to, so we retain rather than
+	// garbage collect them.
+	for _, j := range positional {
+		if j < len(tas) && tas[j].Reference.Name == "" {
+			tas[j].Reference = refs[j]
+			continue
+		}
+		if !missing[j] {
+			report.Retained = append(report.Retained, refs[j])
+		}
+	}
+
+	for _, cd := range orphans {
+		deleted, err := a.handleOrphan(ctx, tas, cd)
+		if err != nil {
+			return nil, AssociationReport{}, err
+		}
+		if deleted {
+			report.Collected = append(report.Collected, referenceTo(cd))
+		}
+	}
+
+	return tas, report, nil
+}
+
+// listComposed lists the supplied XR's composed resources, if configured to
+// and if all of the supplied references that aren't observed are of the same
+// kind. It returns the listed composed resources that are of that kind, keyed
+// by namespace and name. It returns nothing if it didn't list them.
+func (a *GarbageCollectingAssociator) listComposed(ctx context.Context, cr resource.Composite, refs []corev1.ObjectReference, observed map[corev1.ObjectReference]bool) (map[types.NamespacedName]*composed.Unstructured, error) {
+	label := cr.GetLabels()[xcrd.LabelKeyNamePrefixForComposed]
+	if !a.list || label == "" {
+		return nil, nil
+	}
+
+	var kind *corev1.ObjectReference
+	n := 0
+	for i := range refs {
+		ref := refs[i]
+		if ref.Name == "" || observed[ref] {
+			continue
+		}
+		if kind != nil && (ref.APIVersion != kind.APIVersion || ref.Kind != kind.Kind) {
+			return nil, nil
+		}
+		kind = &ref
+		n++
+	}
+
+	// There's no point listing fewer than two composed resources.
+	if n < 2 {
+		return nil, nil
+	}
+
+	l := composed.NewList(composed.FromReferenceToList(*kind))
+	if err := a.client.List(ctx, l, client.MatchingLabels{xcrd.LabelKeyNamePrefixForComposed: label}); err != nil {
+		return nil, errors.Wrap(err, errListComposed)
+	}
+	listed := make(map[types.NamespacedName]*composed.Unstructured, len(l.Items))
+	for i := range l.Items {
+		cd := &composed.Unstructured{Unstructured: l.Items[i]}
+		cd.SetAPIVersion(kind.APIVersion)
+		cd.SetKind(kind.Kind)
+		listed[types.NamespacedName{Namespace: cd.GetNamespace(), Name: cd.GetName()}] = cd
+	}
+	return listed, nil
+}
+
+// associateByOrder associates the supplied templates with the supplied XR's
+// composed resource references by order. If configured to, it handles any
+// composed resources referenced after the last template as orphans, or
+// retains them if garbage collection is disabled.
+func (a *GarbageCollectingAssociator) associateByOrder(ctx context.Context, cr resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, AssociationReport, error) {
+	tas, extras := AssociateByOrderWithExtras(ct, cr.GetResourceReferences())
+	report := AssociationReport{}
+	if !a.extras {
+		return tas, report, nil
+	}
+	for _, ref := range extras {
+		if ref.Name == "" {
+			continue
+		}
+		cd := composed.New(composed.FromReference(ref))
+		err := a.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, AssociationReport{}, errors.Wrap(err, errGetComposed)
+		}
+
+		// We mustn't garbage collect composed resources we don't control.
+		if !SameOwnerTeam(cr, cd) {
 			continue
 		}
+		if c := metav1.GetControllerOf(cd); c == nil || c.UID != cr.GetUID() {
+			continue
+		}
+
+		if !a.gc {
+			report.Retained = append(report.Retained, ref)
+			continue
+		}
+		deleted, err := a.handleOrphan(ctx, tas, cd)
+		if err != nil {
+			return nil, AssociationReport{}, err
+		}
+		if deleted {
+			report.Collected = append(report.Collected, ref)
+		}
+	}
+	return tas, report, nil
+}
 
-		// This existing resource does not correspond to an extant template. It
-		// should be garbage collected.
-		if err := a.client.Delete(ctx, cd); resource.IgnoreNotFound(err) != nil {
-			return nil, errors.Wrap(err, errGCComposed)
+// handleOrphan handles the supplied orphaned composed resource according to
+// our orphan strategy. Orphaned composed resources should be garbage
+// collected unless we leave or adopt them. It returns true if it deleted the
+// orphaned composed resource.
+func (a *GarbageCollectingAssociator) handleOrphan(ctx context.Context, tas []TemplateAssociation, cd *composed.Unstructured) (bool, error) {
+	// This orphan is already being deleted. There's no need to delete it
+	// again, and we mustn't adopt it.
+	if meta.WasDeleted(cd) {
+		return false, nil
+	}
+
+	switch a.orphan {
+	case OrphanStrategyLeave:
+		return false, errors.Wrap(a.release(ctx, cd), errOrphanComposed)
+	case OrphanStrategyAdoptIfMatching:
+		for i := range tas {
+			// Observe-only templates must never be associated with a
+			// composed resource we created.
+			if tas[i].Reference.Name != "" || observeOnly(tas[i].Template) || !templateOfKind(tas[i].Template, cd) {
+				continue
+			}
+			tas[i].Reference = *meta.ReferenceTo(cd, cd.GetObjectKind().GroupVersionKind())
+			return false, nil
 		}
+	case OrphanStrategyDelete:
 	}
 
-	return tas, nil
+	// The template this composed resource was rendered from asked for it to
+	// be orphaned rather than deleted. We release it from our control so
+	// that it won't be garbage collected when the composite resource is.
+	if GetGarbageCollectionPolicy(cd) == v1.GarbageCollectionPolicyOrphan {
+		return false, errors.Wrap(a.release(ctx, cd), errOrphanComposed)
+	}
+
+	err := a.client.Delete(ctx, cd)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, errors.Wrap(err, errGCComposed)
+}
+
+// release the supplied composed resource by removing its controller reference
+// and the annotation that associates it with a template.
+func (a *GarbageCollectingAssociator) release(ctx context.Context, cd *composed.Unstructured) error {
+	refs := make([]metav1.OwnerReference, 0, len(cd.GetOwnerReferences()))
+	for _, ref := range cd.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	cd.SetOwnerReferences(refs)
+	meta.RemoveAnnotations(cd, AnnotationKeyCompositionResourceName)
+	return resource.IgnoreNotFound(a.client.Update(ctx, cd))
+}
+
+// observeOnly returns true if the supplied template's composed resource
+// should be observed, but never created, updated, or deleted.
+func observeOnly(t v1.ComposedTemplate) bool {
+	return t.ManagementPolicy != nil && *t.ManagementPolicy == v1.ManagementPolicyObserveOnly
+}
+
+// observes returns true if the supplied observe-only template's base names the
+// referenced composed resource. A base that doesn't specify a namespace
+// observes a resource of the same kind and name in any namespace, because the
+// composed resource may have been defaulted to the claim's namespace.
+func observes(t v1.ComposedTemplate, ref corev1.ObjectReference) bool {
+	raw, err := baseJSON(t.Base.Raw)
+	if err != nil {
+		return false
+	}
+	base := composed.New()
+	if err := json.Unmarshal(raw, base); err != nil {
+		return false
+	}
+	if base.GetAPIVersion() != ref.APIVersion || base.GetKind() != ref.Kind || base.GetName() != ref.Name {
+		return false
+	}
+	return base.GetNamespace() == "" || base.GetNamespace() == ref.Namespace
+}
+
+// referenceTo returns a reference to the supplied composed resource, in the
+// form an XR uses to reference its composed resources.
+func referenceTo(cd *composed.Unstructured) corev1.ObjectReference {
+	return corev1.ObjectReference{APIVersion: cd.GetAPIVersion(), Kind: cd.GetKind(), Namespace: cd.GetNamespace(), Name: cd.GetName()}
+}
+
+// templateOfKind returns true if the supplied template's base is of the same
+// kind as the supplied composed resource.
+func templateOfKind(t v1.ComposedTemplate, cd *composed.Unstructured) bool {
+	base := composed.New()
+	if err := json.Unmarshal(t.Base.Raw, base); err != nil {
+		return false
+	}
+	return base.GetObjectKind().GroupVersionKind() == cd.GetObjectKind().GroupVersionKind()
 }
 
 // Observation is the result of composed reconciliation.
@@ -482,14 +2193,123 @@ func (c RenderFn) Render(cp resource.Composite, cd resource.Composed, t v1.Compo
 // create against an API server in order to name and validate the rendered
 // resource.
 type APIDryRunRenderer struct {
-	client client.Client
+	client  client.Client
+	rand    RandSource
+	retries int
+
+	// seed is used to derive a source of randomness for each composed
+	// resource template, if set.
+	seed *int64
+
+	// mapper is used to validate the kind of rendered composed resources,
+	// if set.
+	mapper kmeta.RESTMapper
+
+	// scopes is used to determine whether rendered composed resources are
+	// namespaced, in order to default their namespace, if set.
+	scopes kmeta.RESTMapper
+
+	// block determines whether the controller reference of rendered
+	// composed resources blocks deletion of the composite resource.
+	block bool
+
+	// types is used to strictly decode composed resource template bases,
+	// if set.
+	types runtime.ObjectCreater
+}
+
+// DefaultNameCollisionRetries is the default number of times an
+// APIDryRunRenderer retries a dry-run create that failed because the name the
+// API server generated was already taken.
+const DefaultNameCollisionRetries = 3
+
+// An APIDryRunRendererOption configures an APIDryRunRenderer.
+type APIDryRunRendererOption func(*APIDryRunRenderer)
+
+// WithRandomNames configures an APIDryRunRenderer to name composed resources
+// itself using the supplied source of randomness, rather than asking the API
+// server to name them.
+func WithRandomNames(r RandSource) APIDryRunRendererOption {
+	return func(rd *APIDryRunRenderer) {
+		rd.rand = r
+	}
+}
+
+// WithSeededRandomNames configures an APIDryRunRenderer to name composed
+// resources itself, rather than asking the API server to name them. Each
+// composed resource template gets its own pseudo-random source, derived from
+// the supplied seed and the template, so the names rendered for a template
+// don't depend on which other templates were rendered before it. This makes
+// naming deterministic, and is intended for testing.
+func WithSeededRandomNames(seed int64) APIDryRunRendererOption {
+	return func(rd *APIDryRunRenderer) {
+		rd.seed = &seed
+	}
+}
+
+// WithNameCollisionRetries configures how many times an APIDryRunRenderer
+// retries a dry-run create that failed because the name the API server
+// generated was already taken. The API server generates a new name each time.
+// Zero disables retries.
+func WithNameCollisionRetries(n int) APIDryRunRendererOption {
+	return func(rd *APIDryRunRenderer) {
+		rd.retries = n
+	}
+}
+
+// WithKindValidation configures an APIDryRunRenderer to use the supplied
+// RESTMapper to check that the apiVersion and kind of each composed resource
+// template's base are of an installed kind of resource. This returns a clearer
+// error, sooner, than the API server would when we tried to create it.
+func WithKindValidation(m kmeta.RESTMapper) APIDryRunRendererOption {
+	return func(rd *APIDryRunRenderer) {
+		rd.mapper = m
+	}
+}
+
+// WithNamespaceDefaulting configures an APIDryRunRenderer to use the supplied
+// RESTMapper to determine whether each composed resource is of a namespaced
+// kind. Composed resources of a namespaced kind that don't specify a namespace
+// are rendered into the namespace of the composite resource's claim, if any.
+// Composed resources of a cluster scoped kind are unaffected.
+func WithNamespaceDefaulting(m kmeta.RESTMapper) APIDryRunRendererOption {
+	return func(rd *APIDryRunRenderer) {
+		rd.scopes = m
+	}
+}
+
+// WithBlockOwnerDeletion configures whether the controller reference an
+// APIDryRunRenderer adds to each composed resource blocks deletion of the
+// composite resource, i.e. whether its blockOwnerDeletion field is true. The
+// composite resource is the composed resource's controller regardless. Owner
+// deletion is blocked by default.
+func WithBlockOwnerDeletion(block bool) APIDryRunRendererOption {
+	return func(rd *APIDryRunRenderer) {
+		rd.block = block
+	}
+}
+
+// WithStrictDecoding configures an APIDryRunRenderer to reject composed
+// resource templates whose base has fields that aren't part of its kind of
+// resource, for example because they're misspelled. Bases are decoded into a
+// typed object created by the supplied ObjectCreater. Bases of kinds the
+// ObjectCreater doesn't know about are decoded leniently, as they are by
+// default.
+func WithStrictDecoding(oc runtime.ObjectCreater) APIDryRunRendererOption {
+	return func(rd *APIDryRunRenderer) {
+		rd.types = oc
+	}
 }
 
 // NewAPIDryRunRenderer returns a Renderer of composed resources that may
 // perform a dry-run create against an API server in order to name and validate
 // it.
-func NewAPIDryRunRenderer(c client.Client) *APIDryRunRenderer {
-	return &APIDryRunRenderer{client: c}
+func NewAPIDryRunRenderer(c client.Client, o ...APIDryRunRendererOption) *APIDryRunRenderer {
+	r := &APIDryRunRenderer{client: c, retries: DefaultNameCollisionRetries, block: true}
+	for _, fn := range o {
+		fn(r)
+	}
+	return r
 }
 
 // Render the supplied composed resource using the supplied composite resource
@@ -500,10 +2320,21 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 	name := cd.GetName()
 	namespace := cd.GetNamespace()
 
-	if err := json.Unmarshal(t.Base.Raw, cd); err != nil {
+	raw, err := baseJSON(t.Base.Raw)
+	if err != nil {
 		return errors.Wrap(err, errUnmarshal)
 	}
 
+	if err := json.Unmarshal(raw, cd); err != nil {
+		return errors.Wrap(err, errUnmarshal)
+	}
+
+	if r.types != nil {
+		if err := decodeStrict(r.types, cd.GetObjectKind().GroupVersionKind(), raw); err != nil {
+			return errors.Wrap(err, errUnmarshal)
+		}
+	}
+
 	// We think this composed resource exists, but when we rendered its template
 	// its kind changed. This shouldn't happen. Either someone changed the kind
 	// in the template or we're trying to use the wrong template (e.g. because
@@ -512,10 +2343,28 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 		return errors.New(errKindChanged)
 	}
 
+	if r.mapper != nil {
+		gvk := cd.GetObjectKind().GroupVersionKind()
+		_, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if kmeta.IsNoMatchError(err) {
+			return errors.Errorf(errFmtUnknownKind, gvk.GroupVersion().String(), gvk.Kind)
+		}
+		if err != nil {
+			return errors.Wrap(err, errMapKind)
+		}
+	}
+
 	if cp.GetLabels()[xcrd.LabelKeyNamePrefixForComposed] == "" {
 		return errors.New(errNamePrefix)
 	}
 
+	// Observe-only composed resources must already exist, so we can't name
+	// them. We use the name and namespace specified by their template.
+	if name == "" && observeOnly(t) {
+		name = cd.GetName()
+		namespace = cd.GetNamespace()
+	}
+
 	// Unmarshalling the template will overwrite any existing fields, so we must
 	// restore the existing name, if any. We also set generate name in case we
 	// haven't yet named this composed resource.
@@ -525,28 +2374,47 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 
 	for i := range t.Patches {
 		if err := Apply(t.Patches[i], cp, cd, patchTypesFromXR()...); err != nil {
-			return errors.Wrapf(err, errFmtPatch, i)
+			return NewPatchError(i, t.Patches[i], err)
 		}
 		if env != nil {
 			if err := ApplyToObjects(t.Patches[i], env, cd, patchTypesFromToEnvironment()...); err != nil {
-				return errors.Wrapf(err, errFmtPatch, i)
+				return NewPatchError(i, t.Patches[i], err)
 			}
 		}
 	}
 
+	// Patches take precedence over the default namespace.
+	if err := r.defaultNamespace(cp, cd); err != nil {
+		return err
+	}
+
+	// The connection secret namespace override takes precedence over any
+	// namespace set by patches.
+	if ns := t.WriteConnectionSecretToNamespace; ns != nil {
+		if ref := cd.GetWriteConnectionSecretToReference(); ref != nil {
+			ref.Namespace = *ns
+			cd.SetWriteConnectionSecretToReference(ref)
+		}
+	}
+
 	// Composed labels and annotations should be rendered after patches are applied
 	meta.AddLabels(cd, map[string]string{
 		xcrd.LabelKeyNamePrefixForComposed: cp.GetLabels()[xcrd.LabelKeyNamePrefixForComposed],
 		xcrd.LabelKeyClaimName:             cp.GetLabels()[xcrd.LabelKeyClaimName],
 		xcrd.LabelKeyClaimNamespace:        cp.GetLabels()[xcrd.LabelKeyClaimNamespace],
 	})
+	SetOwnerTeam(cd, GetOwnerTeam(cp))
 
 	if t.Name != nil {
 		SetCompositionResourceName(cd, *t.Name)
 	}
+	if t.GarbageCollectionPolicy != nil {
+		SetGarbageCollectionPolicy(cd, *t.GarbageCollectionPolicy)
+	}
 
 	// We do this last to ensure that a Composition cannot influence controller references.
 	or := meta.AsController(meta.TypedReferenceTo(cp, cp.GetObjectKind().GroupVersionKind()))
+	or.BlockOwnerDeletion = pointer.Bool(r.block)
 	if err := meta.AddControllerReference(cd, or); err != nil {
 		return errors.Wrap(err, errSetControllerRef)
 	}
@@ -561,12 +2429,215 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 		return nil
 	}
 
+	// If we have our own source of randomness we name the resource ourselves,
+	// the same way the API server would.
+	if r.seed != nil {
+		cd.SetName(GenerateName(cd.GetGenerateName(), NewSeededRandSource(TemplateSeed(*r.seed, t))))
+		return nil
+	}
+	if r.rand != nil {
+		cd.SetName(GenerateName(cd.GetGenerateName(), r.rand))
+		return nil
+	}
+
 	// The API server returns an available name derived from generateName when
 	// we perform a dry-run create. This name is likely (but not guaranteed) to
 	// be available when we create the composed resource. If the API server
 	// generates a name that is unavailable it will return a 500 ServerTimeout
-	// error.
-	return errors.Wrap(r.client.Create(ctx, cd, client.DryRunAll), errName)
+	// or a 409 AlreadyExists error. We retry the latter, because the API server
+	// will generate a different name next time.
+	err = r.client.Create(ctx, cd, client.DryRunAll)
+	for i := 0; i < r.retries && kerrors.IsAlreadyExists(err); i++ {
+		cd.SetName("")
+		err = r.client.Create(ctx, cd, client.DryRunAll)
+	}
+	return errors.Wrap(err, errName)
+}
+
+// decodeStrict decodes the supplied raw JSON into a new object of the supplied
+// kind, returning an error naming any unknown fields. It returns nil if the
+// supplied ObjectCreater doesn't know about the kind.
+func decodeStrict(oc runtime.ObjectCreater, gvk schema.GroupVersionKind, raw []byte) error {
+	o, err := oc.New(gvk)
+	if runtime.IsNotRegisteredError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	strict, err := kjson.UnmarshalStrict(raw, o, kjson.DisallowUnknownFields)
+	if err != nil {
+		return err
+	}
+	if len(strict) > 0 {
+		return errors.Join(strict...)
+	}
+	return nil
+}
+
+// defaultNamespace sets the namespace of the supplied composed resource to the
+// namespace of the supplied XR's claim, if the composed resource is of a
+// namespaced kind and doesn't already specify a namespace.
+func (r *APIDryRunRenderer) defaultNamespace(cp resource.Composite, cd resource.Composed) error {
+	ns := cp.GetLabels()[xcrd.LabelKeyClaimNamespace]
+	if r.scopes == nil || ns == "" || cd.GetNamespace() != "" {
+		return nil
+	}
+	gvk := cd.GetObjectKind().GroupVersionKind()
+	m, err := r.scopes.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if kmeta.IsNoMatchError(err) {
+		return errors.Errorf(errFmtUnknownKind, gvk.GroupVersion().String(), gvk.Kind)
+	}
+	if err != nil {
+		return errors.Wrap(err, errMapKind)
+	}
+	if m.Scope.Name() == kmeta.RESTScopeNameNamespace {
+		cd.SetNamespace(ns)
+	}
+	return nil
+}
+
+// baseJSON returns the supplied composed resource template base as JSON. Bases
+// are usually JSON, but may be authored as a single YAML document. Bases that
+// are neither a YAML object nor JSON are returned unchanged, so that they fail
+// to unmarshal as JSON.
+func baseJSON(raw []byte) ([]byte, error) {
+	if utilyaml.IsJSONBuffer(raw) {
+		return raw, nil
+	}
+
+	docs := 0
+	r := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(raw)))
+	for {
+		doc, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) > 0 {
+			docs++
+		}
+	}
+	if docs > 1 {
+		return nil, errors.Errorf(errFmtMultiDocBase, docs)
+	}
+
+	j, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !utilyaml.IsJSONBuffer(j) {
+		return raw, nil
+	}
+	return j, nil
+}
+
+// A LabelPropagatingRenderer renders composed resources using another
+// Renderer, then copies a set of labels from the composite resource to them.
+type LabelPropagatingRenderer struct {
+	wrapped Renderer
+	keys    []string
+}
+
+// NewLabelPropagatingRenderer returns a Renderer that copies the supplied
+// labels from the composite resource to the composed resources rendered by the
+// supplied Renderer.
+func NewLabelPropagatingRenderer(r Renderer, keys ...string) *LabelPropagatingRenderer {
+	return &LabelPropagatingRenderer{wrapped: r, keys: keys}
+}
+
+// Render the supplied composed resource using the wrapped Renderer, then copy
+// any of the propagated labels that the composite resource has to it. Labels
+// the rendered composed resource already has are not overwritten.
+func (r *LabelPropagatingRenderer) Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+	if err := r.wrapped.Render(ctx, cp, cd, t, env); err != nil {
+		return err
+	}
+	add := make(map[string]string, len(r.keys))
+	for _, k := range r.keys {
+		v, ok := cp.GetLabels()[k]
+		if !ok {
+			continue
+		}
+		if _, exists := cd.GetLabels()[k]; exists {
+			continue
+		}
+		add[k] = v
+	}
+	if len(add) > 0 {
+		meta.AddLabels(cd, add)
+	}
+	return nil
+}
+
+// A PostRenderMutator mutates a composed resource after it is rendered from
+// the supplied template, and before it is applied.
+type PostRenderMutator func(xr resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error
+
+// A PostRenderMutatingRenderer renders composed resources using another
+// Renderer, then mutates them using a PostRenderMutator.
+type PostRenderMutatingRenderer struct {
+	wrapped Renderer
+	mutate  PostRenderMutator
+}
+
+// NewPostRenderMutatingRenderer returns a Renderer that mutates the composed
+// resources rendered by the supplied Renderer using the supplied
+// PostRenderMutator.
+func NewPostRenderMutatingRenderer(r Renderer, fn PostRenderMutator) *PostRenderMutatingRenderer {
+	return &PostRenderMutatingRenderer{wrapped: r, mutate: fn}
+}
+
+// Render the supplied composed resource using the wrapped Renderer, then
+// mutate it. Composed resources that couldn't be rendered aren't mutated.
+func (r *PostRenderMutatingRenderer) Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+	if err := r.wrapped.Render(ctx, cp, cd, t, env); err != nil {
+		return err
+	}
+	return errors.Wrap(r.mutate(cp, cd, t), errMutate)
+}
+
+// A CanonicalizingRenderer renders composed resources using another Renderer,
+// then canonicalizes them. This ensures that rendering the same inputs always
+// produces the same composed resource, regardless of how the wrapped Renderer
+// built it.
+type CanonicalizingRenderer struct {
+	wrapped Renderer
+}
+
+// NewCanonicalizingRenderer returns a Renderer that canonicalizes the composed
+// resources rendered by the supplied Renderer.
+func NewCanonicalizingRenderer(r Renderer) *CanonicalizingRenderer {
+	return &CanonicalizingRenderer{wrapped: r}
+}
+
+// Render the supplied composed resource using the wrapped Renderer, then
+// canonicalize it.
+func (r *CanonicalizingRenderer) Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+	if err := r.wrapped.Render(ctx, cp, cd, t, env); err != nil {
+		return err
+	}
+	return errors.Wrap(Canonicalize(cd), errCanonicalize)
+}
+
+// Canonicalize the supplied object by round-tripping it through JSON. Object
+// keys are always serialized in sorted order, numbers are decoded as either
+// int64 or float64, and typed slices and maps are decoded as []any and
+// map[string]any, so objects with equivalent content are deeply equal and have
+// identical serialized representations. The order of array elements is
+// preserved.
+func Canonicalize(o runtime.Object) error {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	m := make(map[string]any)
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(m, o)
 }
 
 // RenderComposite renders the supplied composite resource using the supplied composed
@@ -574,7 +2645,7 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 func RenderComposite(_ context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ *Environment) error {
 	for i, p := range t.Patches {
 		if err := Apply(p, cp, cd, patchTypesToXR()...); err != nil {
-			return errors.Wrapf(err, errFmtPatch, i)
+			return NewPatchError(i, p, err)
 		}
 	}
 
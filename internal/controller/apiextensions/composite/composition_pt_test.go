@@ -18,35 +18,111 @@ package composite
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	kjson "sigs.k8s.io/json"
+	"sigs.k8s.io/yaml"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
+// acceptingClient returns a client that accepts every update to an XR and
+// every composed resource it applies.
+func acceptingClient() *test.MockClient {
+	return &test.MockClient{
+		MockUpdate: test.NewMockUpdateFn(nil),
+
+		// Apply uses Get and Patch.
+		MockGet:   test.NewMockGetFn(nil),
+		MockPatch: test.NewMockPatchFn(nil),
+	}
+}
+
+// composing returns options that configure a PTComposer to successfully
+// compose the supplied template associations. Composed resources render and
+// become ready without error, and expose no connection details. The supplied
+// options are applied last, so they may override any of these.
+func composing(tas []TemplateAssociation, o ...PTComposerOption) []PTComposerOption {
+	nop := RendererFn(func(_ context.Context, _ resource.Composite, _ resource.Composed, _ v1.ComposedTemplate, _ *Environment) error {
+		return nil
+	})
+	return append([]PTComposerOption{
+		WithTemplateAssociator(CompositionTemplateAssociatorFn(func(_ context.Context, _ resource.Composite, _ []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+			return tas, nil
+		})),
+		WithComposedRenderer(nop),
+		WithCompositeRenderer(nop),
+		WithComposedConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(_ context.Context, _ resource.ConnectionSecretOwner) (managed.ConnectionDetails, error) {
+			return nil, nil
+		})),
+		WithComposedReadinessChecker(ReadinessCheckerFn(func(_ context.Context, _ ConditionedObject, _ ...ReadinessCheck) (bool, error) {
+			return true, nil
+		})),
+	}, o...)
+}
+
 func TestPTCompose(t *testing.T) {
 	errBoom := errors.New("boom")
 	details := managed.ConnectionDetails{"a": []byte("b")}
 
+	checksummed := func() *composed.Unstructured {
+		return composed.New(func(cd *composed.Unstructured) {
+			cd.SetAPIVersion("example.org/v1")
+			cd.SetKind("Composed")
+			cd.SetName("cool-composed")
+			cd.Object["spec"] = map[string]any{"replicas": int64(3)}
+		})
+	}
+	checksum, _ := DesiredChecksum(checksummed())
+	composedGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Composed"}
+	force := v1.ConflictPolicyForce
+	observe := v1.ManagementPolicyObserveOnly
+
+	// This environment patch fails because its required field path doesn't
+	// exist.
+	envPatchRequiredMissing := v1.EnvironmentPatch{
+		Type:          v1.PatchTypeFromCompositeFieldPath,
+		FromFieldPath: pointer.String("spec.missing"),
+		ToFieldPath:   pointer.String("data.missing"),
+		Policy:        &v1.PatchPolicy{FromFieldPath: func() *v1.FromFieldPathPolicy { p := v1.FromFieldPathPolicyRequired; return &p }()},
+	}
+
+	// This context is cancelled while composing resources.
+	interrupted, interrupt := context.WithCancel(context.Background())
+	defer interrupt()
+
 	type params struct {
 		kube client.Client
 		o    []PTComposerOption
@@ -99,6 +175,7 @@ func TestPTCompose(t *testing.T) {
 				},
 			},
 			args: args{
+				xr: &fake.Composite{},
 				req: CompositionRequest{
 					Revision: &v1.CompositionRevision{},
 				},
@@ -107,7 +184,54 @@ func TestPTCompose(t *testing.T) {
 				err: errors.Wrap(errBoom, errAssociate),
 			},
 		},
-		// TODO(negz): Test handling of ApplyEnvironmentPatch errors.
+		"InheritOwnerTeam": {
+			reason: "A composite resource that doesn't declare an owning team should inherit its Composition's team before templates are associated.",
+			params: params{
+				o: []PTComposerOption{
+					WithTemplateAssociator(CompositionTemplateAssociatorFn(func(ctx context.Context, c resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+						// Surface the team we were called with.
+						return nil, errors.New(GetOwnerTeam(c))
+					})),
+				},
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{LabelKeyOwnerTeam: "platform"}},
+					},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errors.New("platform"), errAssociate),
+			},
+		},
+		"ApplyEnvironmentPatchError": {
+			reason: "We should return any error encountered while applying an environment patch, rather than composing resources.",
+			params: params{
+				o: []PTComposerOption{
+					WithTemplateAssociator(CompositionTemplateAssociatorFn(func(ctx context.Context, c resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+						return nil, nil
+					})),
+				},
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							Environment: &v1.EnvironmentConfiguration{
+								Patches: []v1.EnvironmentPatch{envPatchRequiredMissing},
+							},
+						},
+					},
+					Environment: &Environment{},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(ApplyEnvironmentPatch(envPatchRequiredMissing, &fake.Composite{}, &Environment{}), errFmtPatchEnvironment, 0),
+			},
+		},
 		"RenderComposedError": {
 			reason: "We should include any error encountered while rendering a composed resource as a warning, not as the returned error.",
 			params: params{
@@ -186,6 +310,30 @@ func TestPTCompose(t *testing.T) {
 				err: errors.Wrap(errBoom, errUpdate),
 			},
 		},
+		"PatchCompositeError": {
+			reason: "We should return any error encountered while patching our composite resource with references.",
+			params: params{
+				kube: &test.MockClient{
+					MockPatch: test.NewMockPatchFn(errBoom),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithCompositeUpdateStrategy(CompositeUpdateStrategyPatch),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errUpdate),
+			},
+		},
 		"ApplyComposedError": {
 			reason: "We should return any error encountered while applying a composed resource.",
 			params: params{
@@ -219,6 +367,276 @@ func TestPTCompose(t *testing.T) {
 				err: errors.Wrap(errors.Wrap(errBoom, "cannot get object"), errApply),
 			},
 		},
+		"ApplyOptionalComposedError": {
+			reason: "We should report, but not return, an error encountered while applying an optional composed resource.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply calls Get and Patch. Only applying the composed
+					// resource fails.
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "cool-composed" {
+							return errBoom
+						}
+						return nil
+					}),
+					MockPatch: test.NewMockPatchFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name:                 pointer.String("cool-resource"),
+						OptionalForReadiness: pointer.Bool(true),
+					},
+				}},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName("cool-composed")
+						return nil
+					})),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						t.Errorf("IsReady(...): unexpected readiness check of a composed resource that could not be applied")
+						return true, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName: "cool-resource",
+						Optional:     true,
+					}},
+					ConnectionDetails: managed.ConnectionDetails{},
+					Events: []event.Event{
+						event.Warning(reasonCompose, errors.Wrapf(errors.Wrap(errBoom, "cannot get object"), errFmtApplyOptional, "cool-resource")),
+					},
+				},
+			},
+		},
+		"ApplyComposedConflict": {
+			reason: "We should report, but not return, a conflict encountered while applying a composed resource, and continue applying the others.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply calls Get and Patch. Only patching the conflicting
+					// composed resource fails.
+					MockGet: test.NewMockGetFn(nil),
+					MockPatch: test.NewMockPatchFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "conflicting-composed" {
+							return kerrors.NewConflict(schema.GroupResource{}, "conflicting-composed", errBoom)
+						}
+						return nil
+					}),
+				},
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("conflicting")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("cool")}},
+				},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName(*t.Name + "-composed")
+						return nil
+					})),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						if o.GetName() == "conflicting-composed" {
+							t.Errorf("IsReady(...): unexpected readiness check of a composed resource that could not be applied")
+						}
+						return true, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "conflicting"},
+						{ResourceName: "cool", Ready: true},
+					},
+					ConnectionDetails: managed.ConnectionDetails{},
+					Events: []event.Event{
+						event.Warning(reasonCompose, errors.Wrapf(errors.Wrap(errors.Wrap(kerrors.NewConflict(schema.GroupResource{}, "conflicting-composed", errBoom), "cannot patch object"), errApply), errFmtResourceName, "conflicting")),
+					},
+				},
+			},
+		},
+		"ConcurrentRenderAndApply": {
+			reason: "When rendering and applying concurrently we should report errors rendering a composed resource, or applying an optional one, as warnings, and return composed resources in template order.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply calls Get and Patch. Only applying the failing
+					// composed resource fails.
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "failing-composed" {
+							return errBoom
+						}
+						return nil
+					}),
+					MockPatch: test.NewMockPatchFn(nil),
+				},
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("a")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("broken")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("failing"), OptionalForReadiness: pointer.Bool(true)}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("z")}},
+				},
+					WithRenderConcurrency(4),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						if *t.Name == "broken" {
+							return errBoom
+						}
+						cd.SetName(*t.Name + "-composed")
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						// Later composed resources should win conflicts.
+						return managed.ConnectionDetails{"from": []byte(cd.GetName())}, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "a", Ready: true},
+						{ResourceName: "broken"},
+						{ResourceName: "failing", Optional: true},
+						{ResourceName: "z", Ready: true},
+					},
+					ConnectionDetails: managed.ConnectionDetails{"from": []byte("z-composed")},
+					Events: []event.Event{
+						event.Warning(reasonCompose, errors.Wrapf(errBoom, errFmtResourceName, "broken")),
+						event.Warning(reasonCompose, errors.Wrapf(errors.Wrap(errBoom, "cannot get object"), errFmtApplyOptional, "failing")),
+					},
+				},
+			},
+		},
+		"ConcurrentApplyFailure": {
+			reason: "When applying concurrently we should report an error applying a required composed resource as a warning, not observe it, and still observe the composed resources that were applied.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply calls Get and Patch. Only applying the failing
+					// composed resource fails.
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "failing-composed" {
+							return errBoom
+						}
+						return nil
+					}),
+					MockPatch: test.NewMockPatchFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "failing-composed" {
+							t.Errorf("Patch(...): unexpected patch of a composed resource that could not be got")
+						}
+						return nil
+					}),
+				},
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("a")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("failing")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("z")}},
+				},
+					WithRenderConcurrency(4),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName(*t.Name + "-composed")
+						return nil
+					})),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						if o.GetName() == "failing-composed" {
+							t.Errorf("IsReady(...): unexpected readiness check of a composed resource that could not be applied")
+						}
+						return true, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "a", Ready: true},
+						{ResourceName: "failing"},
+						{ResourceName: "z", Ready: true},
+					},
+					ConnectionDetails: managed.ConnectionDetails{},
+					Events: []event.Event{
+						event.Warning(reasonCompose, errors.Wrapf(errors.Wrap(errors.Wrap(errBoom, "cannot get object"), errApply), errFmtResourceName, "failing")),
+					},
+				},
+			},
+		},
+		"ComposedTooLarge": {
+			reason: "We should not apply a composed resource that is too large, and should report it with a friendly warning.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply calls Get and Patch. Only the XR should be
+					// applied.
+					MockGet: test.NewMockGetFn(nil),
+					MockPatch: test.NewMockPatchFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "huge-composed" {
+							t.Errorf("Patch(...): unexpected patch of a composed resource that is too large")
+						}
+						return nil
+					}),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithComposedSizeLimit(1024, 0),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName("huge-composed")
+						cd.(*composed.Unstructured).Object["spec"] = map[string]any{"config": strings.Repeat("a", 2048)}
+						return nil
+					})),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						t.Errorf("IsReady(...): unexpected readiness check of a composed resource that was not applied")
+						return true, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName: "cool-resource",
+					}},
+					ConnectionDetails: managed.ConnectionDetails{},
+					Events: []event.Event{
+						event.Warning(reasonCompose, errors.Errorf(errFmtComposedTooLarge, "cool-resource", 2132, 1024)),
+					},
+				},
+			},
+		},
 		"CompositeRenderError": {
 			reason: "We should return any error encountered while rendering the Composite.",
 			params: params{
@@ -296,6 +714,49 @@ func TestPTCompose(t *testing.T) {
 				err: errors.Wrap(errBoom, errFetchDetails),
 			},
 		},
+		"ConnectionSecretNotFound": {
+			reason: "We should treat a composed resource's connection secret that doesn't exist yet as having no connection details.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply calls Get and Patch. The connection secret
+					// doesn't exist yet.
+					MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+						if _, ok := obj.(*corev1.Secret); ok {
+							return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+						}
+						return nil
+					},
+					MockPatch: test.NewMockPatchFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetWriteConnectionSecretToReference(&xpv1.SecretReference{Namespace: "cool-ns", Name: "cool-secret"})
+						return nil
+					})),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						return false, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed:          []ComposedResource{{ResourceName: "cool-resource"}},
+					ConnectionDetails: managed.ConnectionDetails{},
+				},
+			},
+		},
 		"ExtractConnectionDetailsError": {
 			reason: "We should return any error encountered while extracting a composed resource's connection details.",
 			params: params{
@@ -385,21 +846,98 @@ func TestPTCompose(t *testing.T) {
 				err: errors.Wrap(errBoom, errReadiness),
 			},
 		},
-		"CompositeApplyError": {
-			reason: "We should return any error encountered while applying the Composite.",
+		"CheckReadinessErrorFailPolicy": {
+			reason: "When configured to fail on readiness check errors, we should return any error encountered while checking whether a composed resource is ready.",
 			params: params{
 				kube: &test.MockClient{
 					MockUpdate: test.NewMockUpdateFn(nil),
 
-					// Apply calls Get and Patch. We won't hit this for any
-					// composed resources because none we returned by the
-					// TemplateAssociator below.
-					MockGet:   test.NewMockGetFn(errBoom),
+					// Apply calls Get and Patch
+					MockGet:   test.NewMockGetFn(nil),
 					MockPatch: test.NewMockPatchFn(nil),
 				},
-				o: []PTComposerOption{
-					WithTemplateAssociator(CompositionTemplateAssociatorFn(func(ctx context.Context, c resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, error) {
-						return nil, nil
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithReadinessErrorPolicy(ReadinessErrorPolicyFail),
+					WithComposedConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.ConnectionSecretOwner) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						return false, errBoom
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errReadiness),
+			},
+		},
+		"CheckReadinessErrorRetryPolicy": {
+			reason: "When configured to retry on readiness check errors, we should treat the composed resource as not ready and emit a warning event rather than returning an error.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply calls Get and Patch
+					MockGet:   test.NewMockGetFn(nil),
+					MockPatch: test.NewMockPatchFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithReadinessErrorPolicy(ReadinessErrorPolicyRetry),
+					WithComposedConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.ConnectionSecretOwner) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						return false, errBoom
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName: "cool-resource",
+						Ready:        false,
+					}},
+					ConnectionDetails: managed.ConnectionDetails{},
+					Events: []event.Event{
+						event.Warning(reasonCompose, errors.Wrapf(errors.Wrap(errBoom, errReadiness), errFmtResourceName, "cool-resource")),
+					},
+				},
+			},
+		},
+		"CompositeApplyError": {
+			reason: "We should return any error encountered while applying the Composite.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply calls Get and Patch. We won't hit this for any
+					// composed resources because none we returned by the
+					// TemplateAssociator below.
+					MockGet:   test.NewMockGetFn(errBoom),
+					MockPatch: test.NewMockPatchFn(nil),
+				},
+				o: []PTComposerOption{
+					WithTemplateAssociator(CompositionTemplateAssociatorFn(func(ctx context.Context, c resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+						return nil, nil
 					})),
 					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
 						return nil
@@ -468,235 +1006,4249 @@ func TestPTCompose(t *testing.T) {
 				},
 			},
 		},
-	}
-
-	for name, tc := range cases {
-		t.Run(name, func(t *testing.T) {
-
-			c := NewPTComposer(tc.params.kube, tc.params.o...)
-			res, err := c.Compose(tc.args.ctx, tc.args.xr, tc.args.req)
-
-			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("\n%s\nCompose(...): -want, +got:\n%s", tc.reason, diff)
-			}
-
-			if diff := cmp.Diff(tc.want.res, res, cmpopts.EquateEmpty()); diff != "" {
-				t.Errorf("\n%s\nCompose(...): -want, +got:\n%s", tc.reason, diff)
-			}
-		})
-	}
-}
-
-func TestRender(t *testing.T) {
-	ctrl := true
-	tmpl, _ := json.Marshal(&fake.Managed{})
-	errBoom := errors.New("boom")
-
-	type args struct {
-		ctx context.Context
-		cp  resource.Composite
-		cd  resource.Composed
-		t   v1.ComposedTemplate
-	}
-	type want struct {
-		cd  resource.Composed
-		err error
-	}
-	cases := map[string]struct {
-		reason string
-		client client.Client
-		args
-		want
-	}{
-		"InvalidTemplate": {
-			reason: "Invalid template should not be accepted",
+		"ConnectionDetailsLastWins": {
+			reason: "When composed resources expose the same connection detail, the last composed resource in template order should win.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{
+					{
+						Template: v1.ComposedTemplate{
+							Name:              pointer.String("first"),
+							ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("url"), Value: pointer.String("https://first")}},
+						},
+					},
+					{
+						Template: v1.ComposedTemplate{
+							Name:              pointer.String("second"),
+							ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("url"), Value: pointer.String("https://second")}},
+						},
+					},
+				}),
+			},
 			args: args{
-				cd: &fake.Composed{},
-				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("olala")}},
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
 			},
 			want: want{
-				cd:  &fake.Composed{},
-				err: errors.Wrap(errors.New("invalid character 'o' looking for beginning of value"), errUnmarshal),
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "first", Ready: true},
+						{ResourceName: "second", Ready: true},
+					},
+					ConnectionDetails: managed.ConnectionDetails{"url": []byte("https://second")},
+				},
 			},
 		},
-		"NoLabel": {
-			reason: "The name prefix label has to be set",
+		"ConnectionDetailsWithheldUntilReady": {
+			reason: "When configured to publish connection details only when ready, we should withhold the connection details of composed resources that aren't ready.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name:              pointer.String("cool-resource"),
+						ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("url"), Value: pointer.String("https://cool")}},
+					},
+				}},
+					WithPublishConnectionDetailsWhenReady(),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						return false, nil
+					})),
+				),
+			},
 			args: args{
-				cp: &fake.Composite{},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
-				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
 			},
 			want: want{
-				cd:  &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
-				err: errors.New(errNamePrefix),
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName: "cool-resource",
+						Ready:        false,
+					}},
+					ConnectionDetails: managed.ConnectionDetails{},
+				},
 			},
 		},
-		"DryRunError": {
-			reason: "Errors dry-run creating the rendered resource to name it should be returned",
-			client: &test.MockClient{MockCreate: test.NewMockCreateFn(errBoom)},
+		"ConnectionDetailsConflict": {
+			reason: "When configured to fail on conflicting connection details, we should return an error if composed resources expose different values for the same connection detail.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{
+					{
+						Template: v1.ComposedTemplate{
+							Name:              pointer.String("first"),
+							ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("url"), Value: pointer.String("https://first")}},
+						},
+					},
+					{
+						Template: v1.ComposedTemplate{
+							Name:              pointer.String("second"),
+							ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("url"), Value: pointer.String("https://second")}},
+						},
+					},
+				},
+					WithConnectionDetailConflictPolicy(ConnectionDetailConflictPolicyFail),
+				),
+			},
 			args: args{
-				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
-					xcrd.LabelKeyNamePrefixForComposed: "ola",
-					xcrd.LabelKeyClaimName:             "rola",
-					xcrd.LabelKeyClaimNamespace:        "rolans",
-				}}},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{}},
-				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
 			},
 			want: want{
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
-					GenerateName: "ola-",
-					Labels: map[string]string{
-						xcrd.LabelKeyNamePrefixForComposed: "ola",
-						xcrd.LabelKeyClaimName:             "rola",
-						xcrd.LabelKeyClaimNamespace:        "rolans",
-					},
-					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
-				}},
-				err: errors.Wrap(errBoom, errName),
+				err: errors.Wrap(errors.Errorf(errFmtConnDetailConflict, "url", "second", "first"), errMergeDetails),
 			},
 		},
-		"ControllerError": {
-			reason: "External controller owner references should cause an exception",
-			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+		"CompositeConnectionDetails": {
+			reason: "We should include connection details derived from the XR, unless a composed resource exposes the same connection detail.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name:              pointer.String("first"),
+						ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("url"), Value: pointer.String("https://first")}},
+					},
+				}}),
+			},
 			args: args{
-				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
-					xcrd.LabelKeyNamePrefixForComposed: "ola",
-					xcrd.LabelKeyClaimName:             "rola",
-					xcrd.LabelKeyClaimNamespace:        "rolans",
-				}}},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd",
-					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl,
-						UID: "random_uid"}}}},
-				t: v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+				xr: func() resource.Composite {
+					xr := composite.New()
+					xr.Object["spec"] = map[string]any{"endpoint": "example.org"}
+					return xr
+				}(),
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							ConnectionDetails: []v1.ConnectionDetail{
+								{Name: pointer.String("endpoint"), FromFieldPath: pointer.String("spec.endpoint")},
+								{Name: pointer.String("url"), Value: pointer.String("https://xr")},
+							},
+						},
+					},
+				},
 			},
 			want: want{
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
-					Name:         "cd",
-					GenerateName: "ola-",
-					Labels: map[string]string{
-						xcrd.LabelKeyNamePrefixForComposed: "ola",
-						xcrd.LabelKeyClaimName:             "rola",
-						xcrd.LabelKeyClaimNamespace:        "rolans",
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName: "first",
+						Ready:        true,
+					}},
+					ConnectionDetails: managed.ConnectionDetails{
+						"endpoint": []byte("example.org"),
+						"url":      []byte("https://first"),
 					},
-					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl,
-						UID: "random_uid"}},
-				}},
-				err: errors.Wrap(errors.Errorf("cd is already controlled by   (UID random_uid)"), errSetControllerRef),
+					Events: []event.Event{},
+				},
 			},
 		},
-		"Success": {
-			reason: "Configuration should result in the right object with correct generateName",
-			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+		"CompositeConnectionDetailsConflict": {
+			reason: "When configured to fail on conflicting connection details, we should return an error if a composed resource exposes a different value for a connection detail derived from the XR.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name:              pointer.String("first"),
+						ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("url"), Value: pointer.String("https://first")}},
+					},
+				}},
+					WithConnectionDetailConflictPolicy(ConnectionDetailConflictPolicyFail),
+				),
+			},
 			args: args{
-				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
-					xcrd.LabelKeyNamePrefixForComposed: "ola",
-					xcrd.LabelKeyClaimName:             "rola",
-					xcrd.LabelKeyClaimNamespace:        "rolans",
-				}}},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
-				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+				xr: func() resource.Composite {
+					xr := composite.New()
+					xr.Object["spec"] = map[string]any{"endpoint": "example.org"}
+					return xr
+				}(),
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							ConnectionDetails: []v1.ConnectionDetail{
+								{Name: pointer.String("endpoint"), FromFieldPath: pointer.String("spec.endpoint")},
+								{Name: pointer.String("url"), Value: pointer.String("https://xr")},
+							},
+						},
+					},
+				},
 			},
 			want: want{
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
-					Name:         "cd",
-					GenerateName: "ola-",
-					Labels: map[string]string{
-						xcrd.LabelKeyNamePrefixForComposed: "ola",
-						xcrd.LabelKeyClaimName:             "rola",
-						xcrd.LabelKeyClaimNamespace:        "rolans",
-					},
-					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
-				}},
+				err: errors.Wrap(errors.Errorf(errFmtConnDetailConflictXR, "url", "first"), errMergeDetails),
 			},
 		},
-	}
-	for name, tc := range cases {
-		t.Run(name, func(t *testing.T) {
-			r := NewAPIDryRunRenderer(tc.client)
-			err := r.Render(tc.args.ctx, tc.args.cp, tc.args.cd, tc.args.t, nil)
-			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+		"ConnectionDetailsPrefixed": {
+			reason: "We should prefix the connection details of each composed resource before merging them, so that prefixed connection details of the same name don't conflict.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{
+					{
+						Template: v1.ComposedTemplate{
+							Name:                    pointer.String("first"),
+							ConnectionDetails:       []v1.ConnectionDetail{{Name: pointer.String("username"), Value: pointer.String("admin")}},
+							ConnectionDetailsPrefix: pointer.String("primary-db-"),
+						},
+					},
+					{
+						Template: v1.ComposedTemplate{
+							Name:                    pointer.String("second"),
+							ConnectionDetails:       []v1.ConnectionDetail{{Name: pointer.String("username"), Value: pointer.String("readonly")}},
+							ConnectionDetailsPrefix: pointer.String("replica-db-"),
+						},
+					},
+				},
+					WithConnectionDetailConflictPolicy(ConnectionDetailConflictPolicyFail),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "first", Ready: true},
+						{ResourceName: "second", Ready: true},
+					},
+					ConnectionDetails: managed.ConnectionDetails{
+						"primary-db-username": []byte("admin"),
+						"replica-db-username": []byte("readonly"),
+					},
+				},
+			},
+		},
+		"LastAppliedAnnotation": {
+			reason: "We should annotate each composed resource before we apply it with its rendered configuration.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch.
+					MockGet: test.NewMockGetFn(nil),
+					MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+						// We only annotate composed resources, not the XR.
+						if _, ok := obj.(resource.Composite); ok {
+							return nil
+						}
+						want := `{"apiVersion":"example.org/v1","kind":"Composed","metadata":{"annotations":{"cool":"very"},"name":"cool-composed"},"spec":{"replicas":3}}`
+						if diff := cmp.Diff(want, GetLastAppliedConfiguration(obj)); diff != "" {
+							return errors.Errorf("unexpected last applied configuration: -want, +got:\n%s", diff)
+						}
+						return nil
+					},
+				},
+				o: composing([]TemplateAssociation{{Template: v1.ComposedTemplate{Name: pointer.String("cool-resource")}}},
+					WithLastAppliedAnnotation(),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.(*composed.Unstructured).Object = checksummed().Object
+						cd.SetAnnotations(map[string]string{"cool": "very"})
+						return nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						GroupVersionKind: composedGVK,
+					}},
+					ConnectionDetails: managed.ConnectionDetails{},
+				},
+			},
+		},
+		"StandardAnnotations": {
+			reason: "We should annotate each composed resource before we apply it, without overwriting annotations set by its template.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch.
+					MockGet: test.NewMockGetFn(nil),
+					MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+						// We only annotate composed resources, not the XR.
+						if _, ok := obj.(resource.Composite); ok {
+							return nil
+						}
+						want := map[string]string{
+							AnnotationKeyCompositionName:         "cool-comp",
+							AnnotationKeyCompositionRevisionName: "cool-comp-abc123",
+							AnnotationKeyManagedBy:               "platform-team",
+						}
+						if obj.GetName() == "second" {
+							want[AnnotationKeyManagedBy] = "app-team"
+						}
+						if diff := cmp.Diff(want, obj.GetAnnotations()); diff != "" {
+							return errors.Errorf("unexpected annotations: -want, +got:\n%s", diff)
+						}
+						return nil
+					},
+				},
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("first")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("second")}},
+				},
+					WithStandardAnnotations("platform-team"),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName(*t.Name)
+						if *t.Name == "second" {
+							// This composed resource's base sets its own manager.
+							cd.SetAnnotations(map[string]string{AnnotationKeyManagedBy: "app-team"})
+						}
+						return nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:   "cool-comp-abc123",
+							Labels: map[string]string{v1.LabelCompositionName: "cool-comp"},
+						},
+					},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "first", Ready: true, Revision: "cool-comp-abc123"},
+						{ResourceName: "second", Ready: true, Revision: "cool-comp-abc123"},
+					},
+					ConnectionDetails: managed.ConnectionDetails{},
+				},
+			},
+		},
+		"ComposedResourceRevisionAndKind": {
+			reason: "We should record the revision and kind of each composed resource, but not the kind of those we couldn't render.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("rendered")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("unrendered")}},
+				},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.GetObjectKind().SetGroupVersionKind(composedGVK)
+						if *t.Name == "unrendered" {
+							return errBoom
+						}
+						return nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{ObjectMeta: metav1.ObjectMeta{Name: "cool-comp-abc123"}},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "rendered", Ready: true, Revision: "cool-comp-abc123", GroupVersionKind: composedGVK},
+						{ResourceName: "unrendered", Revision: "cool-comp-abc123"},
+					},
+					ConnectionDetails: managed.ConnectionDetails{},
+					Events: []event.Event{
+						event.Warning(reasonCompose, errors.Wrapf(errBoom, errFmtResourceName, "unrendered")),
+					},
+				},
+			},
+		},
+		"CompositeReadinessAggregated": {
+			reason: "When configured with a readiness aggregator we should use it to determine whether the XR is ready.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{
+					{
+						Template: v1.ComposedTemplate{
+							Name: pointer.String("first"),
+						},
+					},
+					{
+						Template: v1.ComposedTemplate{
+							Name: pointer.String("second"),
+						},
+					},
+				},
+					WithCompositeReadinessAggregator(AtLeastReady(1)),
+					WithComposedReadinessChecker(func() ReadinessChecker {
+						// Only the first composed resource is ready.
+						calls := 0
+						return ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+							calls++
+							return calls == 1, nil
+						})
+					}()),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "first", Ready: true},
+						{ResourceName: "second", Ready: false},
+					},
+					ConnectionDetails: managed.ConnectionDetails{},
+					CompositeReady:    pointer.Bool(true),
+				},
+			},
+		},
+		"RequeueHint": {
+			reason: "When configured with a requeue hinter we should suggest when the XR should be composed again.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{
+					{
+						Template: v1.ComposedTemplate{
+							Name: pointer.String("first"),
+						},
+					},
+					{
+						Template: v1.ComposedTemplate{
+							Name: pointer.String("second"),
+						},
+					},
+				},
+					WithRequeueHinter(ReadinessRequeueHint(10*time.Second, 10*time.Minute)),
+					WithComposedReadinessChecker(func() ReadinessChecker {
+						// Only the first composed resource is ready.
+						calls := 0
+						return ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+							calls++
+							return calls == 1, nil
+						})
+					}()),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "first", Ready: true},
+						{ResourceName: "second", Ready: false},
+					},
+					ConnectionDetails: managed.ConnectionDetails{},
+					RequeueAfter:      10 * time.Second,
+				},
+			},
+		},
+		"ReadinessTransitions": {
+			reason: "We should emit an event for each extant composed resource whose readiness changed since the XR was last composed.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("now-ready")}, Reference: corev1.ObjectReference{Name: "now-ready"}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("still-ready")}, Reference: corev1.ObjectReference{Name: "still-ready"}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("no-longer-ready")}, Reference: corev1.ObjectReference{Name: "no-longer-ready"}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("still-not-ready")}, Reference: corev1.ObjectReference{Name: "still-not-ready"}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("new")}},
+				},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName(*t.Name)
+						return nil
+					})),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						n := o.(metav1.Object).GetName()
+						return n == "now-ready" || n == "still-ready", nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision:           &v1.CompositionRevision{},
+					PreviouslyNotReady: map[string]bool{"now-ready": true, "still-not-ready": true, "new": true},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "now-ready", Ready: true},
+						{ResourceName: "still-ready", Ready: true},
+						{ResourceName: "no-longer-ready"},
+						{ResourceName: "still-not-ready"},
+						{ResourceName: "new"},
+					},
+					ConnectionDetails: managed.ConnectionDetails{},
+					Events: []event.Event{
+						event.Normal(reasonCompose, `Composed resource "now-ready" is now ready`),
+						event.Warning(reasonCompose, errors.Errorf(errFmtNoLongerReady, "no-longer-ready")),
+					},
+				},
+			},
+		},
+		"ObserveOnly": {
+			reason: "We should observe, but never apply, an observe-only composed resource.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch. Only the managed composed
+					// resource should be patched.
+					MockGet: test.NewMockGetFn(nil),
+					MockPatch: test.NewMockPatchFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "observed-composed" {
+							t.Errorf("Patch(...): unexpected patch of an observe-only composed resource")
+						}
+						return nil
+					}),
+				},
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("managed")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("observed"), ManagementPolicy: &observe}},
+				},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName(*t.Name + "-composed")
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return managed.ConnectionDetails{cd.GetName(): []byte("b")}, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "managed", Ready: true},
+						{ResourceName: "observed", Ready: true},
+					},
+					ConnectionDetails: managed.ConnectionDetails{
+						"managed-composed":  []byte("b"),
+						"observed-composed": []byte("b"),
+					},
+				},
+			},
+		},
+		"ObserveOnlyNotFound": {
+			reason: "We should report, but not return, that an observe-only composed resource doesn't exist.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "observed-composed" {
+							return kerrors.NewNotFound(schema.GroupResource{}, "observed-composed")
+						}
+						return nil
+					}),
+					MockPatch: test.NewMockPatchFn(nil),
+				},
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("observed"), ManagementPolicy: &observe}},
+				},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName(*t.Name + "-composed")
+						return nil
+					})),
+					WithComposedReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error) {
+						t.Errorf("IsReady(...): unexpected readiness check of a composed resource that does not exist")
+						return true, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "observed"},
+					},
+					ConnectionDetails: managed.ConnectionDetails{},
+					Events: []event.Event{
+						event.Warning(reasonCompose, errors.Errorf(errFmtObserveOnlyNotFound, "observed")),
+					},
+				},
+			},
+		},
+		"DriftDetected": {
+			reason: "We should record that an extant composed resource had drifted from its desired state.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch (or Create).
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						u, ok := obj.(*kunstructured.Unstructured)
+						if !ok {
+							return nil
+						}
+						u.Object["spec"] = map[string]any{"replicas": int64(1)}
+						return nil
+					}),
+					MockPatch:  test.NewMockPatchFn(nil),
+					MockCreate: test.NewMockCreateFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithComposedDriftDetection(),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind("Composed")
+						u.SetName("cool-composed")
+						u.Object["spec"] = map[string]any{"replicas": int64(3)}
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						Drifted:          true,
+						GroupVersionKind: composedGVK,
+					}},
+				},
+			},
+		},
+		"FeatureFlagEnabled": {
+			reason: "We should detect drift when the Composition's feature flag enables it, even if the composer doesn't.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch (or Create).
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						u, ok := obj.(*kunstructured.Unstructured)
+						if !ok {
+							return nil
+						}
+						u.Object["spec"] = map[string]any{"replicas": int64(1)}
+						return nil
+					}),
+					MockPatch:  test.NewMockPatchFn(nil),
+					MockCreate: test.NewMockCreateFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind("Composed")
+						u.SetName("cool-composed")
+						u.Object["spec"] = map[string]any{"replicas": int64(3)}
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							FeatureFlags: map[string]bool{v1.CompositionFeatureDriftDetection: true},
+						},
+					},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						Drifted:          true,
+						GroupVersionKind: composedGVK,
+					}},
+				},
+			},
+		},
+		"FeatureFlagDisabled": {
+			reason: "A Composition's feature flag should take precedence over the composer's configuration.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch (or Create).
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						u, ok := obj.(*kunstructured.Unstructured)
+						if !ok {
+							return nil
+						}
+						u.Object["spec"] = map[string]any{"replicas": int64(1)}
+						return nil
+					}),
+					MockPatch:  test.NewMockPatchFn(nil),
+					MockCreate: test.NewMockCreateFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithComposedDriftDetection(),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind("Composed")
+						u.SetName("cool-composed")
+						u.Object["spec"] = map[string]any{"replicas": int64(3)}
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							FeatureFlags: map[string]bool{v1.CompositionFeatureDriftDetection: false},
+						},
+					},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						Drifted:          false,
+						GroupVersionKind: composedGVK,
+					}},
+				},
+			},
+		},
+		"FeatureFlagUnset": {
+			reason: "We should not detect drift when neither the Composition nor the composer enable it.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch (or Create).
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						u, ok := obj.(*kunstructured.Unstructured)
+						if !ok {
+							return nil
+						}
+						u.Object["spec"] = map[string]any{"replicas": int64(1)}
+						return nil
+					}),
+					MockPatch:  test.NewMockPatchFn(nil),
+					MockCreate: test.NewMockCreateFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind("Composed")
+						u.SetName("cool-composed")
+						u.Object["spec"] = map[string]any{"replicas": int64(3)}
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							FeatureFlags: map[string]bool{},
+						},
+					},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						Drifted:          false,
+						GroupVersionKind: composedGVK,
+					}},
+				},
+			},
+		},
+		"UnknownFeatureFlag": {
+			reason: "We should ignore unknown feature flags, which Composition validation warns about.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch (or Create).
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						u, ok := obj.(*kunstructured.Unstructured)
+						if !ok {
+							return nil
+						}
+						u.Object["spec"] = map[string]any{"replicas": int64(1)}
+						return nil
+					}),
+					MockPatch:  test.NewMockPatchFn(nil),
+					MockCreate: test.NewMockCreateFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind("Composed")
+						u.SetName("cool-composed")
+						u.Object["spec"] = map[string]any{"replicas": int64(3)}
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							FeatureFlags: map[string]bool{"CoolNewFeature": true, v1.CompositionFeatureDriftDetection: true},
+						},
+					},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						Drifted:          true,
+						GroupVersionKind: composedGVK,
+					}},
+				},
+			},
+		},
+		"NoDrift": {
+			reason: "We should not record drift if applying the composed resource would be a no-op.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch (or Create).
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						u, ok := obj.(*kunstructured.Unstructured)
+						if !ok {
+							return nil
+						}
+						u.SetResourceVersion("42")
+						u.Object["spec"] = map[string]any{"replicas": int64(3)}
+						return nil
+					}),
+					MockPatch:  test.NewMockPatchFn(nil),
+					MockCreate: test.NewMockCreateFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithComposedDriftDetection(),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind("Composed")
+						u.SetName("cool-composed")
+						u.Object["spec"] = map[string]any{"replicas": int64(3)}
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						Drifted:          false,
+						GroupVersionKind: composedGVK,
+					}},
+				},
+			},
+		},
+		"NoDriftOnCreate": {
+			reason: "We should not record drift when a composed resource is first created.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch (or Create).
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						u, ok := obj.(*kunstructured.Unstructured)
+						if !ok {
+							return nil
+						}
+						return kerrors.NewNotFound(schema.GroupResource{}, u.GetName())
+					}),
+					MockPatch:  test.NewMockPatchFn(nil),
+					MockCreate: test.NewMockCreateFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithComposedDriftDetection(),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind("Composed")
+						u.SetName("cool-composed")
+						u.Object["spec"] = map[string]any{"replicas": int64(3)}
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						Drifted:          false,
+						GroupVersionKind: composedGVK,
+					}},
+				},
+			},
+		},
+		"ForceConflictPolicy": {
+			reason: "We should emit an event when we force ownership of fields that conflict with another field manager.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch.
+					MockGet: test.NewMockGetFn(nil),
+					MockPatch: func(ctx context.Context, obj client.Object, p client.Patch, opts ...client.PatchOption) error {
+						po := &client.PatchOptions{}
+						po.ApplyOptions(opts)
+						if p == client.Apply && (po.Force == nil || !*po.Force) {
+							return kerrors.NewApplyConflict([]metav1.StatusCause{{Type: metav1.CauseTypeFieldManagerConflict, Field: ".spec.replicas"}}, "conflict")
+						}
+						return nil
+					},
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name:           pointer.String("cool-resource"),
+						ConflictPolicy: &force,
+					},
+				}},
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName: "cool-resource",
+						Ready:        true,
+					}},
+					Events: []event.Event{
+						event.Normal(reasonCompose, `Forced ownership of conflicting fields of composed resource "cool-resource": .spec.replicas`),
+					},
+				},
+			},
+		},
+		"FieldManagers": {
+			reason: "We should apply composed resources as the field manager configured by their template, or the composer's field manager.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch.
+					MockGet: test.NewMockGetFn(nil),
+					MockPatch: func(ctx context.Context, obj client.Object, p client.Patch, opts ...client.PatchOption) error {
+						if p != client.Apply {
+							return nil
+						}
+						want := map[string]string{
+							"migrated": "cool-controller",
+							"composed": "cool-composer",
+						}[obj.GetName()]
+						po := &client.PatchOptions{}
+						po.ApplyOptions(opts)
+						if po.FieldManager != want {
+							return errors.Errorf("composed resource %q was applied as field manager %q, not %q", obj.GetName(), po.FieldManager, want)
+						}
+						return nil
+					},
+				},
+				o: composing([]TemplateAssociation{
+					{
+						Template: v1.ComposedTemplate{
+							Name:           pointer.String("migrated"),
+							ConflictPolicy: &force,
+							FieldManager:   pointer.String("cool-controller"),
+						},
+					},
+					{
+						Template: v1.ComposedTemplate{
+							Name:           pointer.String("composed"),
+							ConflictPolicy: &force,
+						},
+					},
+				},
+					WithFieldManager("cool-composer"),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName(*t.Name)
+						return nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "migrated", Ready: true},
+						{ResourceName: "composed", Ready: true},
+					},
+				},
+			},
+		},
+		"SubsetUnknownTemplateError": {
+			reason: "We should return an error if the requested subset references a template that does not exist.",
+			params: params{
+				o: []PTComposerOption{
+					WithTemplateAssociator(CompositionTemplateAssociatorFn(func(ctx context.Context, c resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+						// Associating templates may garbage collect composed
+						// resources, so we mustn't do it for an invalid subset.
+						t.Errorf("AssociateTemplates(...): unexpected association of templates for an invalid subset")
+						return nil, nil
+					})),
+				},
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							Resources: []v1.ComposedTemplate{{Name: pointer.String("cool-resource")}},
+						},
+					},
+					Subset: []string{"nonexistent-resource"},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtUnknownTemplate, "nonexistent-resource"),
+			},
+		},
+		"SkipWhenEqual": {
+			reason: "We should skip a template when the composite field equals the skip condition's value, leaving any existing composed resource untouched.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+						want := []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Monitor", Name: "cool-monitor"}}
+						xr := &composite.Unstructured{Unstructured: *obj.(*kunstructured.Unstructured)}
+						if diff := cmp.Diff(want, xr.GetResourceReferences()); diff != "" {
+							t.Errorf("Update(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+
+					// Apply uses Get and Patch.
+					MockGet:   test.NewMockGetFn(nil),
+					MockPatch: test.NewMockPatchFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("monitoring"),
+						SkipIf: &v1.SkipCondition{
+							FromFieldPath: "metadata.labels[monitoring]",
+							Equals:        extv1.JSON{Raw: []byte(`"disabled"`)},
+						},
+					},
+					Reference: corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Monitor", Name: "cool-monitor"},
+				}},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						// We should never render a skipped template.
+						return errBoom
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: func() resource.Composite {
+					xr := composite.New()
+					xr.SetLabels(map[string]string{"monitoring": "disabled"})
+					return xr
+				}(),
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{},
+			},
+		},
+		"RenderWhenAbsent": {
+			reason: "We should render a template when the composite field its skip condition refers to does not exist.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("monitoring"),
+						SkipIf: &v1.SkipCondition{
+							FromFieldPath: "metadata.labels[monitoring]",
+							Equals:        extv1.JSON{Raw: []byte(`"disabled"`)},
+						},
+					},
+				}},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind("Monitor")
+						u.SetName("cool-monitor")
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: composite.New(),
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "monitoring",
+						Ready:            true,
+						GroupVersionKind: schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Monitor"},
+					}},
+				},
+			},
+		},
+		"GarbageCollectOnToggle": {
+			reason: "We should delete the existing composed resource when its template is skipped and garbage collection is enabled.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+						want := []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Monitor"}}
+						xr := &composite.Unstructured{Unstructured: *obj.(*kunstructured.Unstructured)}
+						if diff := cmp.Diff(want, xr.GetResourceReferences()); diff != "" {
+							t.Errorf("Update(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+
+					// We get the composed resource to determine whether we
+					// control it. Apply uses Get and Patch.
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						obj.SetOwnerReferences([]metav1.OwnerReference{{UID: "cool-xr", Controller: pointer.Bool(true)}})
+						return nil
+					}),
+					MockPatch:  test.NewMockPatchFn(nil),
+					MockDelete: test.NewMockDeleteFn(nil),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("monitoring"),
+						SkipIf: &v1.SkipCondition{
+							FromFieldPath:  "metadata.labels[monitoring]",
+							Equals:         extv1.JSON{Raw: []byte(`"disabled"`)},
+							GarbageCollect: pointer.Bool(true),
+						},
+					},
+					Reference: corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Monitor", Name: "cool-monitor"},
+				}},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						// We should never render a skipped template.
+						return errBoom
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: func() resource.Composite {
+					xr := composite.New()
+					xr.SetUID("cool-xr")
+					xr.SetLabels(map[string]string{"monitoring": "disabled"})
+					return xr
+				}(),
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Events: []event.Event{
+						event.Normal(reasonCompose, "Deleted composed resource \"monitoring\" because its template was skipped"),
+					},
+					GarbageCollected: []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Monitor", Name: "cool-monitor"}},
+				},
+			},
+		},
+		"Interrupted": {
+			reason: "We should stop applying composed resources and return an error if our context is cancelled.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch.
+					MockGet: test.NewMockGetFn(nil),
+					MockPatch: test.NewMockPatchFn(nil, func(obj client.Object) error {
+						switch obj.GetName() {
+						case "first":
+							interrupt()
+						case "second":
+							return errors.New("the second composed resource should not be applied after the context was cancelled")
+						}
+						return nil
+					}),
+				},
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("first")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("second")}},
+				},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						cd.SetName(*t.Name)
+						return nil
+					})),
+				),
+			},
+			args: args{
+				ctx: interrupted,
+				xr:  &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				err: errors.Wrap(context.Canceled, errInterrupted),
+			},
+		},
+		"GarbageCollectedByAssociator": {
+			reason: "We should return references to composed resources that were garbage collected when we associated templates, but not include them in our composed resources.",
+			params: params{
+				kube: acceptingClient(),
+				o: composing(nil,
+					WithTemplateAssociator(reportingAssociator{
+						tas: []TemplateAssociation{{
+							Template:  v1.ComposedTemplate{Name: pointer.String("kept")},
+							Reference: corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Kept", Name: "kept"},
+						}},
+						// The composed resource named "gone" no longer
+						// corresponds to a template, so we garbage collected
+						// it. The composed resource named "released" was
+						// released rather than garbage collected.
+						report: AssociationReport{Collected: []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Gone", Name: "gone"}}},
+					}),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{
+						{APIVersion: "example.org/v1", Kind: "Kept", Name: "kept"},
+						{APIVersion: "example.org/v1", Kind: "Gone", Name: "gone"},
+						{APIVersion: "example.org/v1", Kind: "Released", Name: "released"},
+					}},
+				},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "kept", Ready: true, GroupVersionKind: schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Kept"}},
+					},
+					ConnectionDetails: managed.ConnectionDetails{},
+					GarbageCollected:  []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Gone", Name: "gone"}},
+				},
+			},
+		},
+		"WriteBudgetExhausted": {
+			reason: "We should stop applying composed resources once our write budget is exhausted, and signal that we should be requeued.",
+			params: params{
+				kube: func() client.Client {
+					writes := 0
+					return &test.MockClient{
+						MockUpdate: test.NewMockUpdateFn(nil),
+
+						// Apply uses Get and Patch. We also Get deferred
+						// composed resources in order to observe them.
+						MockGet: test.NewMockGetFn(nil),
+						MockPatch: test.NewMockPatchFn(nil, func(obj client.Object) error {
+							// The XR is patched too, but it's not included in
+							// the write budget.
+							if obj.GetName() == "" {
+								return nil
+							}
+							writes++
+							if writes > 2 {
+								t.Errorf("Patch(...): exceeded write budget of 2 with %d writes", writes)
+							}
+							return nil
+						}),
+					}
+				}(),
+				o: composing([]TemplateAssociation{
+					{Template: v1.ComposedTemplate{Name: pointer.String("a")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("b")}},
+					{Template: v1.ComposedTemplate{Name: pointer.String("c")}},
+				},
+					WithWriteBudget(2),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind("Composed")
+						u.SetName("cool-" + pointer.StringDeref(t.Name, ""))
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "a", Ready: true, GroupVersionKind: composedGVK},
+						{ResourceName: "b", Ready: true, GroupVersionKind: composedGVK},
+						{ResourceName: "c", Ready: true, GroupVersionKind: composedGVK},
+					},
+					Events: []event.Event{
+						event.Normal(reasonCompose, "Write budget exhausted; deferred applying composed resources: c"),
+					},
+					Requeue: true,
+				},
+			},
+		},
+		"ChecksumUnchanged": {
+			reason: "We should not patch a composed resource if its desired state is unchanged since it was last applied.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch.
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "cool-composed" {
+							obj.SetAnnotations(map[string]string{AnnotationKeyDesiredChecksum: checksum})
+						}
+						return nil
+					}),
+					MockPatch: test.NewMockPatchFn(nil, func(obj client.Object) error {
+						// The XR is patched too.
+						if obj.GetName() == "cool-composed" {
+							t.Errorf("Patch(...): unexpected patch of unchanged composed resource")
+						}
+						return nil
+					}),
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithDesiredChecksums(),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.Object = checksummed().Object
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						GroupVersionKind: composedGVK,
+					}},
+				},
+			},
+		},
+		"ChecksumChanged": {
+			reason: "We should patch a composed resource, annotated with its new checksum, if its desired state changed since it was last applied.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+
+					// Apply uses Get and Patch.
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "cool-composed" {
+							obj.SetAnnotations(map[string]string{AnnotationKeyDesiredChecksum: "outdated"})
+						}
+						return nil
+					}),
+					MockPatch: func(_ context.Context, obj client.Object, p client.Patch, _ ...client.PatchOption) error {
+						if obj.GetName() != "cool-composed" {
+							return nil
+						}
+						// The patch is the desired state of the composed resource.
+						data, _ := p.Data(obj)
+						if !strings.Contains(string(data), checksum) {
+							t.Errorf("Patch(...): want patch to include checksum annotation %q, got %s", checksum, data)
+						}
+						return nil
+					},
+				},
+				o: composing([]TemplateAssociation{{
+					Template: v1.ComposedTemplate{
+						Name: pointer.String("cool-resource"),
+					},
+				}},
+					WithDesiredChecksums(),
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						u := cd.(*composed.Unstructured)
+						u.Object = checksummed().Object
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{{
+						ResourceName:     "cool-resource",
+						Ready:            true,
+						GroupVersionKind: composedGVK,
+					}},
+				},
+			},
+		},
+		"Subset": {
+			reason: "We should only compose the requested subset of templates, observing but not applying the composed resources of other templates.",
+			params: params{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+						want := []corev1.ObjectReference{
+							{},
+							{APIVersion: "example.org/v1", Kind: "Untouched", Name: "untouched"},
+							{},
+						}
+						if diff := cmp.Diff(want, obj.(resource.Composite).GetResourceReferences()); diff != "" {
+							t.Errorf("Update(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+
+					// Apply uses Get and Patch.
+					MockGet: test.NewMockGetFn(nil),
+					MockPatch: test.NewMockPatchFn(nil, func(obj client.Object) error {
+						if obj.GetName() == "untouched" {
+							t.Errorf("Patch(...): unexpected apply of composed resource %q", obj.GetName())
+						}
+						return nil
+					}),
+				},
+				o: composing([]TemplateAssociation{
+					{
+						Template: v1.ComposedTemplate{Name: pointer.String("cool-resource")},
+					},
+					{
+						Template:  v1.ComposedTemplate{Name: pointer.String("untouched")},
+						Reference: corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Untouched", Name: "untouched"},
+					},
+					{
+						Template: v1.ComposedTemplate{Name: pointer.String("uncomposed")},
+					},
+				},
+					WithComposedRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+						if pointer.StringDeref(t.Name, "") != "cool-resource" {
+							return errBoom
+						}
+						return nil
+					})),
+					WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+						return details, nil
+					})),
+				),
+			},
+			args: args{
+				xr: &fake.Composite{},
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							Resources: []v1.ComposedTemplate{
+								{Name: pointer.String("cool-resource")},
+								{Name: pointer.String("untouched")},
+								{Name: pointer.String("uncomposed")},
+							},
+						},
+					},
+					Subset: []string{"cool-resource"},
+				},
+			},
+			want: want{
+				res: CompositionResult{
+					Composed: []ComposedResource{
+						{ResourceName: "cool-resource", Ready: true},
+						{ResourceName: "untouched", Ready: true, GroupVersionKind: schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Untouched"}},
+						{ResourceName: "uncomposed"},
+					},
+					ConnectionDetails: details,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			ctx := tc.args.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			c := NewPTComposer(tc.params.kube, tc.params.o...)
+			res, err := c.Compose(ctx, tc.args.xr, tc.args.req)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.res, res, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// A reportingAssociator associates templates, and reports on composed
+// resources, as it was configured to.
+type reportingAssociator struct {
+	tas    []TemplateAssociation
+	report AssociationReport
+}
+
+func (a reportingAssociator) AssociateTemplates(_ context.Context, _ resource.Composite, _ []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+	return a.tas, nil
+}
+
+func (a reportingAssociator) AssociateTemplatesWithReport(_ context.Context, _ resource.Composite, _ []v1.ComposedTemplate) ([]TemplateAssociation, AssociationReport, error) {
+	return a.tas, a.report, nil
+}
+
+func TestPTComposeRetainedReferences(t *testing.T) {
+	kept := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Kept", Name: "kept"}
+	retained := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Retained", Name: "retained"}
+
+	xr := &fake.Composite{ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{retained, kept}}}
+	c := NewPTComposer(acceptingClient(), composing(nil,
+		WithTemplateAssociator(reportingAssociator{
+			tas:    []TemplateAssociation{{Template: v1.ComposedTemplate{Name: pointer.String("kept")}, Reference: kept}},
+			report: AssociationReport{Retained: []corev1.ObjectReference{retained}},
+		}),
+	)...)
+
+	res, err := c.Compose(context.Background(), xr, CompositionRequest{Revision: &v1.CompositionRevision{}})
+	if err != nil {
+		t.Fatalf("Compose(...): %v", err)
+	}
+
+	// References to retained composed resources should be preserved after
+	// those of our templates, and they weren't garbage collected.
+	if diff := cmp.Diff([]corev1.ObjectReference{kept, retained}, xr.GetResourceReferences()); diff != "" {
+		t.Errorf("Compose(...): -want references, +got references:\n%s", diff)
+	}
+	if diff := cmp.Diff([]corev1.ObjectReference(nil), res.GarbageCollected); diff != "" {
+		t.Errorf("Compose(...): -want garbage collected, +got garbage collected:\n%s", diff)
+	}
+}
+
+func TestPTComposeSubsetGarbageCollection(t *testing.T) {
+	kept := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Kept", Name: "kept"}
+	orphan := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Orphan", Name: "orphan"}
+
+	xr := &fake.Composite{ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{kept, orphan}}}
+	xr.SetUID("cool-xr")
+
+	kube := acceptingClient()
+	kube.MockGet = test.NewMockGetFn(nil, func(obj client.Object) error {
+		// Both composed resources are controlled by the XR, but the orphan's
+		// template no longer exists.
+		SetCompositionResourceName(obj, obj.GetName())
+		ctrl := true
+		obj.SetOwnerReferences([]metav1.OwnerReference{{Controller: &ctrl, UID: xr.GetUID()}})
+		return nil
+	})
+	kube.MockDelete = func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+		t.Errorf("Delete(...): unexpected garbage collection of composed resource %q while composing a subset", obj.GetName())
+		return nil
+	}
+
+	c := NewPTComposer(kube, composing(nil, WithTemplateAssociator(NewGarbageCollectingAssociator(kube)))...)
+	rev := &v1.CompositionRevision{Spec: v1.CompositionRevisionSpec{Resources: []v1.ComposedTemplate{{Name: pointer.String("kept")}}}}
+	res, err := c.Compose(context.Background(), xr, CompositionRequest{Revision: rev, Subset: []string{"kept"}})
+	if err != nil {
+		t.Fatalf("Compose(...): %v", err)
+	}
+
+	// The orphan's reference should be retained so that it may be garbage
+	// collected once all templates are composed.
+	if diff := cmp.Diff([]corev1.ObjectReference{kept, orphan}, xr.GetResourceReferences()); diff != "" {
+		t.Errorf("Compose(...): -want references, +got references:\n%s", diff)
+	}
+	if diff := cmp.Diff([]corev1.ObjectReference(nil), res.GarbageCollected); diff != "" {
+		t.Errorf("Compose(...): -want garbage collected, +got garbage collected:\n%s", diff)
+	}
+}
+
+func TestPTComposeConnectionDetailsPrecedence(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		o      []PTComposerOption
+		want   managed.ConnectionDetails
+	}{
+		"ExtractedPrecedence": {
+			reason: "By default connection details extracted from a composed resource should take precedence over different values fetched from its connection secret.",
+			want:   managed.ConnectionDetails{"same": []byte("same"), "different": []byte("extracted")},
+		},
+		"FetchedPrecedence": {
+			reason: "When configured to, connection details fetched from a composed resource's connection secret should take precedence over different extracted values of the same key.",
+			o:      []PTComposerOption{WithComposedConnectionDetailsPrecedence(ConnectionDetailsPrecedenceFetched)},
+			want:   managed.ConnectionDetails{"same": []byte("same"), "different": []byte("fetched")},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := composing([]TemplateAssociation{{Template: v1.ComposedTemplate{Name: pointer.String("cool-resource")}}},
+				WithComposedConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(_ context.Context, _ resource.ConnectionSecretOwner) (managed.ConnectionDetails, error) {
+					return managed.ConnectionDetails{"same": []byte("same"), "different": []byte("fetched"), "unextracted": []byte("fetched")}, nil
+				})),
+				WithComposedConnectionDetailsExtractor(ConnectionDetailsExtractorFn(func(_ resource.Composed, _ managed.ConnectionDetails, _ ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+					return managed.ConnectionDetails{"same": []byte("same"), "different": []byte("extracted")}, nil
+				})),
+			)
+			c := NewPTComposer(acceptingClient(), append(o, tc.o...)...)
+
+			res, err := c.Compose(context.Background(), &fake.Composite{}, CompositionRequest{Revision: &v1.CompositionRevision{}})
+			if err != nil {
+				t.Fatalf("Compose(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want, res.ConnectionDetails); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPTComposeCompositeUpdateStrategy(t *testing.T) {
+	ref := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-bucket"}
+
+	updates := 0
+	var patch client.Patch
+	kube := &test.MockClient{
+		MockUpdate: func(_ context.Context, _ client.Object, _ ...client.UpdateOption) error {
+			updates++
+			return nil
+		},
+		MockGet: test.NewMockGetFn(nil),
+		MockPatch: func(_ context.Context, obj client.Object, p client.Patch, _ ...client.PatchOption) error {
+			// The first patch of the XR persists its references. The XR
+			// was updated since we read it, so a patch that requires the
+			// resource version we read would conflict.
+			if obj.GetObjectKind().GroupVersionKind().Kind == "XBucket" && patch == nil {
+				patch = p
+				data, _ := p.Data(obj)
+				if strings.Contains(string(data), "resourceVersion") {
+					return kerrors.NewConflict(schema.GroupResource{}, obj.GetName(), errors.New("the object has been modified"))
+				}
+				obj.SetResourceVersion("43")
+			}
+			return nil
+		},
+	}
+
+	c := NewPTComposer(kube, composing([]TemplateAssociation{{Template: v1.ComposedTemplate{Name: pointer.String("cool-resource")}}},
+		WithCompositeUpdateStrategy(CompositeUpdateStrategyPatch),
+		WithComposedRenderer(RendererFn(func(_ context.Context, _ resource.Composite, cd resource.Composed, _ v1.ComposedTemplate, _ *Environment) error {
+			cd.GetObjectKind().SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+			cd.SetName(ref.Name)
+			return nil
+		})),
+	)...)
+
+	xr := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XBucket"}))
+	xr.SetName("cool-xr")
+	xr.SetResourceVersion("42")
+	if _, err := c.Compose(context.Background(), xr, CompositionRequest{Revision: &v1.CompositionRevision{}}); err != nil {
+		t.Fatalf("Compose(...): %s", err)
+	}
+
+	if diff := cmp.Diff(0, updates); diff != "" {
+		t.Errorf("Compose(...): we should not update the XR when using the Patch strategy: -want updates, +got updates:\n%s", diff)
+	}
+	if patch == nil {
+		t.Fatal("Compose(...): we should patch the XR when using the Patch strategy")
+	}
+	if diff := cmp.Diff("43", xr.GetResourceVersion()); diff != "" {
+		t.Errorf("Compose(...): we should update the XR's resource version once it's patched: -want, +got:\n%s", diff)
+	}
+	data, err := patch.Data(xr)
+	if err != nil {
+		t.Fatalf("patch.Data(...): %s", err)
+	}
+	got := map[string]any{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal(...): %s", err)
+	}
+	want := map[string]any{
+		// Only the fields we own should be patched.
+		"spec": map[string]any{
+			"resourceRefs": []any{map[string]any{"apiVersion": ref.APIVersion, "kind": ref.Kind, "name": ref.Name}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Compose(...): -want patch, +got patch:\n%s", diff)
+	}
+}
+
+// A capturingLogger records the debug messages logged to it, along with their
+// key-value pairs.
+type capturingLogger struct {
+	values []any
+	lines  *[]string
+}
+
+func (l capturingLogger) Info(_ string, _ ...any) {}
+
+func (l capturingLogger) Debug(msg string, keysAndValues ...any) {
+	*l.lines = append(*l.lines, logLine(msg, append(append([]any{}, l.values...), keysAndValues...)...))
+}
+
+func (l capturingLogger) WithValues(keysAndValues ...any) logging.Logger {
+	return capturingLogger{values: append(append([]any{}, l.values...), keysAndValues...), lines: l.lines}
+}
+
+// logLine formats a log message and its key-value pairs.
+func logLine(msg string, keysAndValues ...any) string {
+	line := msg
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		line += fmt.Sprintf(" %v=%v", keysAndValues[i], keysAndValues[i+1])
+	}
+	return line
+}
+
+func TestPTComposeLogging(t *testing.T) {
+	ref := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-bucket"}
+	lines := make([]string, 0)
+
+	c := NewPTComposer(acceptingClient(), composing([]TemplateAssociation{{Template: v1.ComposedTemplate{Name: pointer.String("cool-resource")}, Reference: ref}},
+		WithComposerLogger(capturingLogger{lines: &lines}),
+	)...)
+
+	xr := composite.New()
+	xr.SetName("cool-xr")
+	rev := &v1.CompositionRevision{ObjectMeta: metav1.ObjectMeta{Name: "cool-rev"}}
+	if _, err := c.Compose(context.Background(), xr, CompositionRequest{Revision: rev}); err != nil {
+		t.Fatalf("Compose(...): %s", err)
+	}
+
+	want := []string{
+		logLine("Inlined composed resource templates", "composite", "cool-xr", "revision", "cool-rev", "templates", 0),
+		logLine("Associated composed resource templates with composed resources", "composite", "cool-xr", "associated", 1, "uncontrolled", 0, "retained", 0),
+		logLine("Rendered composed resource", "composite", "cool-xr", "resource-name", "cool-resource"),
+		logLine("Applied composed resource", "composite", "cool-xr", "resource-name", "cool-resource"),
+		logLine("Checked whether composed resource is ready", "composite", "cool-xr", "resource-name", "cool-resource", "ready", true),
+		logLine("Composed resources", "composite", "cool-xr", "ready", true, "rendered", 1, "deferred", 0),
+	}
+	if diff := cmp.Diff(want, lines); diff != "" {
+		t.Errorf("Compose(...): -want log lines, +got log lines:\n%s", diff)
+	}
+}
+
+func TestPTComposePostRenderMutator(t *testing.T) {
+	errBoom := errors.New("boom")
+	ref := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-bucket"}
+
+	type want struct {
+		seen        string
+		annotations map[string]string
+		events      []event.Event
+	}
+	cases := map[string]struct {
+		reason string
+		err    error
+		want   want
+	}{
+		"Mutated": {
+			reason: "The mutator should see the rendered composed resource, and its mutations should be applied.",
+			want: want{
+				seen:        "rendered",
+				annotations: map[string]string{"example.org/sidecar": "injected"},
+				events:      []event.Event{},
+			},
+		},
+		"MutatorError": {
+			reason: "A composed resource the mutator fails to mutate should not be applied, and the error should be reported as a warning.",
+			err:    errBoom,
+			want: want{
+				seen: "rendered",
+				events: []event.Event{
+					event.Warning(reasonCompose, errors.Wrapf(errors.Wrap(errBoom, errMutate), errFmtResourceName, "cool-resource")),
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var applied map[string]string
+			kube := &test.MockClient{
+				MockUpdate: test.NewMockUpdateFn(nil),
+				MockGet:    test.NewMockGetFn(nil),
+				MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+					if obj.GetObjectKind().GroupVersionKind().Kind == ref.Kind {
+						applied = obj.GetAnnotations()
+					}
+					return nil
+				},
+			}
+
+			var seen string
+			c := NewPTComposer(kube, composing([]TemplateAssociation{{Template: v1.ComposedTemplate{Name: pointer.String("cool-resource")}}},
+				WithComposedRenderer(RendererFn(func(_ context.Context, _ resource.Composite, cd resource.Composed, _ v1.ComposedTemplate, _ *Environment) error {
+					cd.GetObjectKind().SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+					cd.SetName(ref.Name)
+					cd.SetLabels(map[string]string{"state": "rendered"})
+					return nil
+				})),
+				WithPostRenderMutator(func(_ resource.Composite, cd resource.Composed, _ v1.ComposedTemplate) error {
+					seen = cd.GetLabels()["state"]
+					if tc.err != nil {
+						return tc.err
+					}
+					meta.AddAnnotations(cd, map[string]string{"example.org/sidecar": "injected"})
+					return nil
+				}),
+			)...)
+
+			res, err := c.Compose(context.Background(), &fake.Composite{}, CompositionRequest{Revision: &v1.CompositionRevision{}})
+			if err != nil {
+				t.Fatalf("Compose(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want.seen, seen); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want seen label, +got seen label:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.annotations, applied); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want applied annotations, +got applied annotations:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.events, res.Events, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want events, +got events:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPTComposeRenderWarning(t *testing.T) {
+	errBoom := errors.New("boom")
+	ref := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-bucket"}
+
+	type want struct {
+		applied bool
+		events  []event.Event
+	}
+	cases := map[string]struct {
+		reason string
+		err    error
+		want   want
+	}{
+		"RenderWarning": {
+			reason: "A composed resource rendered with a warning should be applied, and the warning should be reported.",
+			err:    NewRenderWarning(errBoom),
+			want: want{
+				applied: true,
+				events: []event.Event{
+					event.Warning(reasonCompose, errors.Wrapf(NewRenderWarning(errBoom), errFmtResourceName, "cool-resource")),
+				},
+			},
+		},
+		"RenderError": {
+			reason: "A composed resource that failed to render should not be applied, and the error should be reported.",
+			err:    errBoom,
+			want: want{
+				applied: false,
+				events: []event.Event{
+					event.Warning(reasonCompose, errors.Wrapf(errBoom, errFmtResourceName, "cool-resource")),
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			applied := false
+			kube := &test.MockClient{
+				MockUpdate: test.NewMockUpdateFn(nil),
+				MockGet:    test.NewMockGetFn(nil),
+				MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+					if obj.GetObjectKind().GroupVersionKind().Kind == ref.Kind {
+						applied = true
+					}
+					return nil
+				},
+			}
+
+			c := NewPTComposer(kube, composing([]TemplateAssociation{{Template: v1.ComposedTemplate{Name: pointer.String("cool-resource")}}},
+				WithComposedRenderer(RendererFn(func(_ context.Context, _ resource.Composite, cd resource.Composed, _ v1.ComposedTemplate, _ *Environment) error {
+					cd.GetObjectKind().SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+					cd.SetName(ref.Name)
+					return tc.err
+				})),
+			)...)
+
+			res, err := c.Compose(context.Background(), &fake.Composite{}, CompositionRequest{Revision: &v1.CompositionRevision{}})
+			if err != nil {
+				t.Fatalf("Compose(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want.applied, applied); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want applied, +got applied:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.events, res.Events, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want events, +got events:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestReadinessTransition(t *testing.T) {
+	type args struct {
+		cd                 ComposedResource
+		previouslyNotReady bool
+	}
+	type want struct {
+		e  event.Event
+		ok bool
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"StillReady": {
+			reason: "We should not emit an event for a composed resource that is still ready.",
+			args: args{
+				cd: ComposedResource{ResourceName: "cool-resource", Ready: true},
+			},
+		},
+		"StillNotReady": {
+			reason: "We should not emit an event for a composed resource that is still not ready.",
+			args: args{
+				cd:                 ComposedResource{ResourceName: "cool-resource"},
+				previouslyNotReady: true,
+			},
+		},
+		"NowReady": {
+			reason: "We should emit a normal event for a composed resource that became ready.",
+			args: args{
+				cd:                 ComposedResource{ResourceName: "cool-resource", Ready: true},
+				previouslyNotReady: true,
+			},
+			want: want{
+				e:  event.Normal(reasonCompose, `Composed resource "cool-resource" is now ready`),
+				ok: true,
+			},
+		},
+		"NoLongerReady": {
+			reason: "We should emit a warning event for a composed resource that is no longer ready.",
+			args: args{
+				cd: ComposedResource{ResourceName: "cool-resource"},
+			},
+			want: want{
+				e:  event.Warning(reasonCompose, errors.Errorf(errFmtNoLongerReady, "cool-resource")),
+				ok: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e, ok := readinessTransition(tc.args.cd, tc.args.previouslyNotReady)
+			if diff := cmp.Diff(tc.want.e, e); diff != "" {
+				t.Errorf("\n%s\nreadinessTransition(...): -want event, +got event:\n%s", tc.reason, diff)
+			}
+			if ok != tc.want.ok {
+				t.Errorf("\n%s\nreadinessTransition(...): want %t, got %t", tc.reason, tc.want.ok, ok)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	ctrl := true
+	tmpl, _ := json.Marshal(&fake.Managed{})
+	errBoom := errors.New("boom")
+	required := v1.FromFieldPathPolicyRequired
+	teamPatch := func(p *v1.PatchPolicy) []v1.Patch {
+		return []v1.Patch{{
+			Type:          v1.PatchTypeFromCompositeFieldPath,
+			FromFieldPath: pointer.String("objectMeta.labels[team]"),
+			Policy:        p,
+		}}
+	}
+
+	type args struct {
+		ctx context.Context
+		cp  resource.Composite
+		cd  resource.Composed
+		t   v1.ComposedTemplate
+	}
+	type want struct {
+		cd  resource.Composed
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		client client.Client
+		o      []APIDryRunRendererOption
+		labels []string
+		args
+		want
+	}{
+		"InvalidTemplate": {
+			reason: "Invalid template should not be accepted",
+			args: args{
+				cd: &fake.Composed{},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("olala")}},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.Wrap(errors.New("invalid character 'o' looking for beginning of value"), errUnmarshal),
+			},
+		},
+		"YAMLTemplate": {
+			reason: "A template whose base is authored as YAML should be rendered as if it were JSON",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+				}}},
+				cd: &fake.Composed{},
+				t: v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte(`
+annotations:
+  cool: very
+`)}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "ola-",
+					Annotations:  map[string]string{"cool": "very"},
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "",
+						xcrd.LabelKeyClaimNamespace:        "",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+			},
+		},
+		"MalformedYAMLTemplate": {
+			reason: "A template whose base is malformed YAML should not be accepted",
+			args: args{
+				cd: &fake.Composed{},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("annotations: [")}},
+			},
+			want: want{
+				cd: &fake.Composed{},
+				err: errors.Wrap(func() error {
+					_, err := yaml.YAMLToJSON([]byte("annotations: ["))
+					return err
+				}(), errUnmarshal),
+			},
+		},
+		"MultiDocYAMLTemplate": {
+			reason: "A template whose base is more than one YAML document should not be accepted",
+			args: args{
+				cd: &fake.Composed{},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("annotations: {}\n---\nannotations: {}\n")}},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.Wrap(errors.Errorf(errFmtMultiDocBase, 2), errUnmarshal),
+			},
+		},
+		"NoLabel": {
+			reason: "The name prefix label has to be set",
+			args: args{
+				cp: &fake.Composite{},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd:  &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				err: errors.New(errNamePrefix),
+			},
+		},
+		"DryRunError": {
+			reason: "Errors dry-run creating the rendered resource to name it should be returned",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(errBoom)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+				err: errors.Wrap(errBoom, errName),
+			},
+		},
+		"DryRunNameCollision": {
+			reason: "Dry-run creates that fail because the generated name is taken should be retried",
+			client: func() client.Client {
+				calls := 0
+				return &test.MockClient{MockCreate: func(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+					calls++
+					if calls == 1 {
+						return kerrors.NewAlreadyExists(schema.GroupResource{}, "ola-abcde")
+					}
+					obj.SetName("ola-fghij")
+					return nil
+				}}
+			}(),
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "ola-fghij",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+			},
+		},
+		"DryRunNameCollisionRetriesExhausted": {
+			reason: "Dry-run creates that keep failing because the generated name is taken should eventually return an error",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(kerrors.NewAlreadyExists(schema.GroupResource{}, "ola-abcde"))},
+			o:      []APIDryRunRendererOption{WithNameCollisionRetries(1)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+				err: errors.Wrap(kerrors.NewAlreadyExists(schema.GroupResource{}, "ola-abcde"), errName),
+			},
+		},
+		"ControllerError": {
+			reason: "External controller owner references should cause an exception",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd",
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl,
+						UID: "random_uid"}}}},
+				t: v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl,
+						UID: "random_uid"}},
+				}},
+				err: errors.Wrap(errors.Errorf("cd is already controlled by   (UID random_uid)"), errSetControllerRef),
+			},
+		},
+		"Success": {
+			reason: "Configuration should result in the right object with correct generateName",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+			},
+		},
+		"ObserveOnly": {
+			reason: "An observe-only composed resource should be named by its template, since it must already exist",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(errBoom)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+				}}},
+				cd: &fake.Composed{},
+				t: v1.ComposedTemplate{
+					Base: runtime.RawExtension{Raw: func() []byte {
+						b, _ := json.Marshal(&fake.Managed{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "default"}})
+						return b
+					}()},
+					ManagementPolicy: func() *v1.ManagementPolicy {
+						p := v1.ManagementPolicyObserveOnly
+						return &p
+					}(),
+				},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "existing",
+					Namespace:    "default",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "",
+						xcrd.LabelKeyClaimNamespace:        "",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+			},
+		},
+		"RequiredPatchSourcePresent": {
+			reason: "A patch whose source is required should be applied if its source exists",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					"team":                             "platform",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}, Patches: teamPatch(&v1.PatchPolicy{FromFieldPath: &required})},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "",
+						xcrd.LabelKeyClaimNamespace:        "",
+						"team":                             "platform",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+			},
+		},
+		"RequiredPatchSourceMissing": {
+			reason: "A patch whose source is required should return an error if its source doesn't exist, so that the composed resource isn't applied",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}, Patches: teamPatch(&v1.PatchPolicy{FromFieldPath: &required})},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+				}},
+				err: NewPatchError(0, teamPatch(&v1.PatchPolicy{FromFieldPath: &required})[0], func() error {
+					_, err := fieldpath.Pave(map[string]any{"objectMeta": map[string]any{"labels": map[string]any{}}}).GetValue("objectMeta.labels[team]")
+					return err
+				}()),
+			},
+		},
+		"OptionalPatchSourceMissing": {
+			reason: "A patch whose source is optional, which is the default, should be skipped if its source doesn't exist",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}, Patches: teamPatch(nil)},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "",
+						xcrd.LabelKeyClaimNamespace:        "",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+			},
+		},
+		"SuccessWithPropagatedLabels": {
+			reason: "Propagated labels of the composite resource should be copied to the composed resource, without overwriting its existing labels",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			labels: []string{"cost-center", "team", "environment"},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+					"cost-center":                      "1234",
+					"team":                             "platform",
+					"unpropagated":                     "value",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t: v1.ComposedTemplate{Base: runtime.RawExtension{Raw: func() []byte {
+					b, _ := json.Marshal(&fake.Managed{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "storage"}}})
+					return b
+				}()}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+						"cost-center":                      "1234",
+						"team":                             "storage",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+			},
+		},
+		"SuccessWithGarbageCollectionPolicy": {
+			reason: "The template's garbage collection policy should be recorded on the composed resource",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t: v1.ComposedTemplate{
+					Base: runtime.RawExtension{Raw: tmpl},
+					GarbageCollectionPolicy: func() *v1.GarbageCollectionPolicy {
+						p := v1.GarbageCollectionPolicyOrphan
+						return &p
+					}(),
+				},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "",
+						xcrd.LabelKeyClaimNamespace:        "",
+					},
+					Annotations:     map[string]string{AnnotationKeyGarbageCollectionPolicy: "Orphan"},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+			},
+		},
+		"SuccessWithOwnerTeam": {
+			reason: "The team that owns the composite resource should be propagated to the composed resource",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					LabelKeyOwnerTeam:                  "platform",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "",
+						xcrd.LabelKeyClaimNamespace:        "",
+						LabelKeyOwnerTeam:                  "platform",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl}},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var r Renderer = NewAPIDryRunRenderer(tc.client, tc.o...)
+			if len(tc.labels) > 0 {
+				r = NewLabelPropagatingRenderer(r, tc.labels...)
+			}
+			err := r.Render(tc.args.ctx, tc.args.cp, tc.args.cd, tc.args.t, nil)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// A mappingErrorRESTMapper returns an error for any mapping.
+type mappingErrorRESTMapper struct {
+	kmeta.RESTMapper
+	err error
+}
+
+func (m mappingErrorRESTMapper) RESTMapping(_ schema.GroupKind, _ ...string) (*kmeta.RESTMapping, error) {
+	return nil, m.err
+}
+
+func TestRenderKindValidation(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	known := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Bucket"}
+	m := kmeta.NewDefaultRESTMapper(nil)
+	m.Add(known, kmeta.RESTScopeRoot)
+
+	base := func(apiVersion, kind string) v1.ComposedTemplate {
+		raw, _ := json.Marshal(map[string]any{"apiVersion": apiVersion, "kind": kind})
+		return v1.ComposedTemplate{Base: runtime.RawExtension{Raw: raw}}
+	}
+	xr := &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{xcrd.LabelKeyNamePrefixForComposed: "ola"}}}
+
+	cases := map[string]struct {
+		reason string
+		mapper kmeta.RESTMapper
+		t      v1.ComposedTemplate
+		want   error
+	}{
+		"KnownKind": {
+			reason: "We should render a template whose base is of an installed kind.",
+			mapper: m,
+			t:      base("example.org/v1", "Bucket"),
+		},
+		"UnknownKind": {
+			reason: "We should return an error if a template's base is of a kind that isn't installed.",
+			mapper: m,
+			t:      base("example.org/v1", "Buckit"),
+			want:   errors.Errorf(errFmtUnknownKind, "example.org/v1", "Buckit"),
+		},
+		"UnknownVersion": {
+			reason: "We should return an error if a template's base is of an API version that isn't installed.",
+			mapper: m,
+			t:      base("example.org/v2", "Bucket"),
+			want:   errors.Errorf(errFmtUnknownKind, "example.org/v2", "Bucket"),
+		},
+		"MappingError": {
+			reason: "We should return any error encountered while determining whether a kind is installed.",
+			mapper: mappingErrorRESTMapper{err: errBoom},
+			t:      base("example.org/v1", "Bucket"),
+			want:   errors.Wrap(errBoom, errMapKind),
+		},
+		"NoValidation": {
+			reason: "We should not validate the kind of a template's base unless configured to.",
+			t:      base("example.org/v1", "Buckit"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := []APIDryRunRendererOption{WithRandomNames(NewSeededRandSource(42))}
+			if tc.mapper != nil {
+				o = append(o, WithKindValidation(tc.mapper))
+			}
+			r := NewAPIDryRunRenderer(nil, o...)
+			err := r.Render(context.Background(), xr, composed.New(), tc.t, nil)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderNamespaceDefaulting(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	m := kmeta.NewDefaultRESTMapper(nil)
+	m.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Bucket"}, kmeta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Queue"}, kmeta.RESTScopeNamespace)
+
+	base := func(kind string) v1.ComposedTemplate {
+		raw, _ := json.Marshal(map[string]any{"apiVersion": "example.org/v1", "kind": kind})
+		return v1.ComposedTemplate{Base: runtime.RawExtension{Raw: raw}}
+	}
+	xr := &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+		xcrd.LabelKeyNamePrefixForComposed: "ola",
+		xcrd.LabelKeyClaimNamespace:        "rolans",
+	}}}
+
+	type want struct {
+		namespace string
+		err       error
+	}
+
+	cases := map[string]struct {
+		reason string
+		mapper kmeta.RESTMapper
+		cd     *composed.Unstructured
+		t      v1.ComposedTemplate
+		want   want
+	}{
+		"NamespacedKind": {
+			reason: "We should render a composed resource of a namespaced kind into the namespace of the XR's claim.",
+			mapper: m,
+			t:      base("Queue"),
+			want: want{
+				namespace: "rolans",
+			},
+		},
+		"NamespacedKindWithNamespace": {
+			reason: "We should not change the namespace of a composed resource that already has one.",
+			mapper: m,
+			cd: func() *composed.Unstructured {
+				cd := composed.New()
+				cd.SetNamespace("cool-namespace")
+				return cd
+			}(),
+			t: base("Queue"),
+			want: want{
+				namespace: "cool-namespace",
+			},
+		},
+		"ClusterScopedKind": {
+			reason: "We should not set the namespace of a composed resource of a cluster scoped kind.",
+			mapper: m,
+			t:      base("Bucket"),
+		},
+		"UnknownKind": {
+			reason: "We should return an error if a template's base is of a kind that isn't installed.",
+			mapper: m,
+			t:      base("Topic"),
+			want: want{
+				err: errors.Errorf(errFmtUnknownKind, "example.org/v1", "Topic"),
+			},
+		},
+		"MappingError": {
+			reason: "We should return any error encountered while determining whether a kind is namespaced.",
+			mapper: mappingErrorRESTMapper{err: errBoom},
+			t:      base("Queue"),
+			want: want{
+				err: errors.Wrap(errBoom, errMapKind),
+			},
+		},
+		"NoDefaulting": {
+			reason: "We should not default the namespace of a composed resource unless configured to.",
+			t:      base("Queue"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := []APIDryRunRendererOption{WithRandomNames(NewSeededRandSource(42))}
+			if tc.mapper != nil {
+				o = append(o, WithNamespaceDefaulting(tc.mapper))
+			}
+			r := NewAPIDryRunRenderer(nil, o...)
+			cd := tc.cd
+			if cd == nil {
+				cd = composed.New()
+			}
+			err := r.Render(context.Background(), xr, cd, tc.t, nil)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if tc.want.err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.namespace, cd.GetNamespace()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want namespace, +got namespace:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderBlockOwnerDeletion(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{"apiVersion": "example.org/v1", "kind": "Bucket"})
+	tmpl := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: raw}}
+	xr := func() *composite.Unstructured {
+		cp := composite.New()
+		cp.SetAPIVersion("example.org/v1")
+		cp.SetKind("XBucket")
+		cp.SetLabels(map[string]string{xcrd.LabelKeyNamePrefixForComposed: "ola"})
+		cp.SetName("cool-xr")
+		cp.SetUID("cool-uid")
+		return cp
+	}
+
+	cases := map[string]struct {
+		reason string
+		o      []APIDryRunRendererOption
+		want   []metav1.OwnerReference
+	}{
+		"Default": {
+			reason: "By default the controller reference should block deletion of the composite resource.",
+			want: []metav1.OwnerReference{{
+				APIVersion:         "example.org/v1",
+				Kind:               "XBucket",
+				Name:               "cool-xr",
+				UID:                "cool-uid",
+				Controller:         pointer.Bool(true),
+				BlockOwnerDeletion: pointer.Bool(true),
+			}},
+		},
+		"DontBlockOwnerDeletion": {
+			reason: "The controller reference should not block deletion of the composite resource when configured not to.",
+			o:      []APIDryRunRendererOption{WithBlockOwnerDeletion(false)},
+			want: []metav1.OwnerReference{{
+				APIVersion:         "example.org/v1",
+				Kind:               "XBucket",
+				Name:               "cool-xr",
+				UID:                "cool-uid",
+				Controller:         pointer.Bool(true),
+				BlockOwnerDeletion: pointer.Bool(false),
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := append([]APIDryRunRendererOption{WithRandomNames(NewSeededRandSource(42))}, tc.o...)
+			r := NewAPIDryRunRenderer(nil, o...)
+			cd := composed.New()
+			if err := r.Render(context.Background(), xr(), cd, tmpl, nil); err != nil {
+				t.Fatalf("\n%s\nRender(...): unexpected error: %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, cd.GetOwnerReferences()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want owner references, +got owner references:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderStrictDecoding(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = corev1.AddToScheme(s)
+
+	configMap := []byte(`{"apiVersion":"v1","kind":"ConfigMap","dta":{"cool":"very"}}`)
+	unknown := []byte(`{"apiVersion":"example.org/v1","kind":"Bucket","dta":{"cool":"very"}}`)
+	strict, _ := kjson.UnmarshalStrict(configMap, &corev1.ConfigMap{}, kjson.DisallowUnknownFields)
+
+	xr := &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{xcrd.LabelKeyNamePrefixForComposed: "ola"}}}
+
+	cases := map[string]struct {
+		reason string
+		o      []APIDryRunRendererOption
+		base   []byte
+		want   error
+	}{
+		"StrictUnknownField": {
+			reason: "We should return an error naming a field that isn't part of the base's kind when decoding strictly.",
+			o:      []APIDryRunRendererOption{WithStrictDecoding(s)},
+			base:   configMap,
+			want:   errors.Wrap(errors.Join(strict...), errUnmarshal),
+		},
+		"LenientUnknownField": {
+			reason: "We should ignore fields that aren't part of the base's kind by default.",
+			base:   configMap,
+		},
+		"StrictKnownFields": {
+			reason: "We should render a base whose fields are all part of its kind when decoding strictly.",
+			o:      []APIDryRunRendererOption{WithStrictDecoding(s)},
+			base:   []byte(`{"apiVersion":"v1","kind":"ConfigMap","data":{"cool":"very"}}`),
+		},
+		"StrictUnknownKind": {
+			reason: "We should decode a base leniently if we don't know about its kind.",
+			o:      []APIDryRunRendererOption{WithStrictDecoding(s)},
+			base:   unknown,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := append([]APIDryRunRendererOption{WithRandomNames(NewSeededRandSource(42))}, tc.o...)
+			r := NewAPIDryRunRenderer(nil, o...)
+			err := r.Render(context.Background(), xr, composed.New(), v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tc.base}}, nil)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderConnectionSecretNamespace(t *testing.T) {
+	base := func(ref map[string]any) runtime.RawExtension {
+		spec := map[string]any{}
+		if ref != nil {
+			spec["writeConnectionSecretToRef"] = ref
+		}
+		raw, _ := json.Marshal(map[string]any{"apiVersion": "example.org/v1", "kind": "Bucket", "spec": spec})
+		return runtime.RawExtension{Raw: raw}
+	}
+	xr := func() *composite.Unstructured {
+		cp := composite.New()
+		cp.SetLabels(map[string]string{xcrd.LabelKeyNamePrefixForComposed: "ola"})
+		cp.SetName("cool-xr")
+		return cp
+	}
+
+	cases := map[string]struct {
+		reason string
+		t      v1.ComposedTemplate
+		want   *xpv1.SecretReference
+	}{
+		"NoOverride": {
+			reason: "We should not change the connection secret namespace if no override is set.",
+			t:      v1.ComposedTemplate{Base: base(map[string]any{"name": "cool-secret", "namespace": "default"})},
+			want:   &xpv1.SecretReference{Name: "cool-secret", Namespace: "default"},
+		},
+		"Override": {
+			reason: "We should override the connection secret namespace if an override is set.",
+			t: v1.ComposedTemplate{
+				Base:                             base(map[string]any{"name": "cool-secret", "namespace": "default"}),
+				WriteConnectionSecretToNamespace: pointer.String("secrets"),
+			},
+			want: &xpv1.SecretReference{Name: "cool-secret", Namespace: "secrets"},
+		},
+		"OverridePatchedNamespace": {
+			reason: "The connection secret namespace override should take precedence over patches.",
+			t: v1.ComposedTemplate{
+				Base: base(map[string]any{"name": "cool-secret", "namespace": "default"}),
+				Patches: []v1.Patch{{
+					Type:          v1.PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.String("metadata.name"),
+					ToFieldPath:   pointer.String("spec.writeConnectionSecretToRef.namespace"),
+				}},
+				WriteConnectionSecretToNamespace: pointer.String("secrets"),
+			},
+			want: &xpv1.SecretReference{Name: "cool-secret", Namespace: "secrets"},
+		},
+		"NoConnectionSecret": {
+			reason: "We should not add a connection secret reference to a composed resource that doesn't write one.",
+			t: v1.ComposedTemplate{
+				Base:                             base(nil),
+				WriteConnectionSecretToNamespace: pointer.String("secrets"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewAPIDryRunRenderer(nil, WithRandomNames(NewSeededRandSource(42)))
+			cd := composed.New()
+			if err := r.Render(context.Background(), xr(), cd, tc.t, nil); err != nil {
+				t.Fatalf("\n%s\nRender(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, cd.GetWriteConnectionSecretToReference()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderEnvironmentPatches(t *testing.T) {
+	base := func(spec map[string]any) runtime.RawExtension {
+		raw, _ := json.Marshal(map[string]any{
+			"apiVersion": "example.org/v1",
+			"kind":       "Composed",
+			"spec":       spec,
+		})
+		return runtime.RawExtension{Raw: raw}
+	}
+	xr := func() resource.Composite {
+		xr := composite.New()
+		xr.SetAPIVersion("example.org/v1")
+		xr.SetKind("Composite")
+		xr.SetName("cool-xr")
+		xr.SetLabels(map[string]string{xcrd.LabelKeyNamePrefixForComposed: "cool-xr"})
+		return xr
+	}
+	env := func(data map[string]any) *Environment {
+		e := &Environment{}
+		e.SetUnstructuredContent(data)
+		e.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   environmentGroup,
+			Version: environmentVersion,
+			Kind:    environmentKind,
+		})
+		return e
+	}
+	errNotFound := func(path string) error {
+		_, err := fieldpath.Pave(map[string]any{}).GetValue(path)
+		return err
+	}
+	required := func() *v1.PatchPolicy {
+		p := v1.FromFieldPathPolicyRequired
+		return &v1.PatchPolicy{FromFieldPath: &p}
+	}
+
+	// writer computes a value and writes it back to the environment.
+	writer := v1.ComposedTemplate{
+		Name: pointer.String("writer"),
+		Base: base(map[string]any{"endpoint": "https://example.org"}),
+		Patches: []v1.Patch{{
+			Type:          v1.PatchTypeToEnvironmentFieldPath,
+			FromFieldPath: pointer.String("spec.endpoint"),
+			ToFieldPath:   pointer.String("endpoint"),
+		}},
+	}
+
+	// reader copies values out of the environment into its spec.
+	reader := func(p *v1.PatchPolicy) v1.ComposedTemplate {
+		return v1.ComposedTemplate{
+			Name: pointer.String("reader"),
+			Base: base(map[string]any{"region": "us-west-2"}),
+			Patches: []v1.Patch{
+				{
+					Type:          v1.PatchTypeFromEnvironmentFieldPath,
+					FromFieldPath: pointer.String("endpoint"),
+					ToFieldPath:   pointer.String("spec.endpoint"),
+					Policy:        p,
+				},
+				{
+					Type:          v1.PatchTypeFromEnvironmentFieldPath,
+					FromFieldPath: pointer.String("region"),
+					ToFieldPath:   pointer.String("spec.region"),
+				},
+			},
+		}
+	}
+
+	type args struct {
+		env *Environment
+		ts  []v1.ComposedTemplate
+	}
+	type want struct {
+		env   *Environment
+		specs []any
+		err   error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"RoundTrip": {
+			reason: "A resource rendered after another should see the values that resource wrote to the environment.",
+			args: args{
+				env: env(map[string]any{"region": "eu-central-1"}),
+				ts:  []v1.ComposedTemplate{writer, reader(nil)},
+			},
+			want: want{
+				env: env(map[string]any{"region": "eu-central-1", "endpoint": "https://example.org"}),
+				specs: []any{
+					map[string]any{"endpoint": "https://example.org"},
+					map[string]any{"endpoint": "https://example.org", "region": "eu-central-1"},
+				},
+			},
+		},
+		"MissingOptionalFieldPath": {
+			reason: "A missing optional environment field path should leave the composed resource's field untouched.",
+			args: args{
+				env: env(map[string]any{}),
+				ts:  []v1.ComposedTemplate{reader(nil)},
+			},
+			want: want{
+				env: env(map[string]any{}),
+				specs: []any{
+					map[string]any{"region": "us-west-2"},
+				},
+			},
+		},
+		"MissingRequiredFieldPath": {
+			reason: "A missing required environment field path should return an error.",
+			args: args{
+				env: env(map[string]any{}),
+				ts:  []v1.ComposedTemplate{reader(required())},
+			},
+			want: want{
+				env: env(map[string]any{}),
+				err: NewPatchError(0, reader(required()).Patches[0], errNotFound("endpoint")),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewAPIDryRunRenderer(nil)
+			cp := xr()
+			var specs []any
+			var err error
+			for _, tmpl := range tc.args.ts {
+				cd := composed.New()
+				cd.SetName(*tmpl.Name)
+				if err = r.Render(context.Background(), cp, cd, tmpl, tc.args.env); err != nil {
+					break
+				}
+				specs = append(specs, cd.Object["spec"])
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.specs, specs); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want spec, +got spec:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.env, tc.args.env); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want environment, +got environment:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCanonicalizingRenderer(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	// render builds the same composed resource each time it's called, but
+	// using different Go types depending on the supplied flag. The two
+	// resources serialize identically, but aren't deeply equal.
+	render := func(typed bool) RendererFn {
+		return func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+			var replicas any = float64(3)
+			var zones any = []any{"us-west-2b", "us-west-2a"}
+			if typed {
+				replicas = 3
+				zones = []string{"us-west-2b", "us-west-2a"}
+			}
+			u := cd.(*composed.Unstructured)
+			u.SetAPIVersion("example.org/v1")
+			u.SetKind("Composed")
+			u.Object["spec"] = map[string]any{
+				"forProvider": map[string]any{"zulu": "zulu", "alpha": "alpha"},
+				"replicas":    replicas,
+				"zones":       zones,
+				"items": []any{
+					map[string]any{"name": "z"},
+					map[string]any{"name": "a"},
+				},
+			}
+			return nil
+		}
+	}
+
+	type args struct {
+		r Renderer
+	}
+	type want struct {
+		json string
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"RenderError": {
+			reason: "We should return errors encountered by the wrapped renderer unchanged.",
+			args: args{
+				r: RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+					return errBoom
+				}),
+			},
+			want: want{
+				json: `{}`,
+				err:  errBoom,
+			},
+		},
+		"Success": {
+			reason: "We should canonicalize the rendered resource, preserving the order of array elements.",
+			args: args{
+				r: render(false),
+			},
+			want: want{
+				json: `{"apiVersion":"example.org/v1","kind":"Composed","spec":{"forProvider":{"alpha":"alpha","zulu":"zulu"},"items":[{"name":"z"},{"name":"a"}],"replicas":3,"zones":["us-west-2b","us-west-2a"]}}`,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cd := composed.New()
+			err := NewCanonicalizingRenderer(tc.args.r).Render(context.Background(), &fake.Composite{}, cd, v1.ComposedTemplate{}, nil)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			got, _ := json.Marshal(cd)
+			if diff := cmp.Diff(tc.want.json, string(got)); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+
+	t.Run("StableAcrossRenders", func(t *testing.T) {
+		// Make sure the renders actually differ before they're canonicalized.
+		untyped, typed := composed.New(), composed.New()
+		_ = render(false)(context.Background(), &fake.Composite{}, untyped, v1.ComposedTemplate{}, nil)
+		_ = render(true)(context.Background(), &fake.Composite{}, typed, v1.ComposedTemplate{}, nil)
+		if cmp.Equal(untyped.Object, typed.Object) {
+			t.Fatalf("Render(...): renders should differ before they're canonicalized")
+		}
+
+		var first map[string]any
+		for i := 0; i < 10; i++ {
+			cd := composed.New()
+			if err := NewCanonicalizingRenderer(render(i%2 == 0)).Render(context.Background(), &fake.Composite{}, cd, v1.ComposedTemplate{}, nil); err != nil {
+				t.Fatalf("Render(...): %s", err)
+			}
+			if first == nil {
+				first = cd.Object
+				continue
+			}
+			if diff := cmp.Diff(first, cd.Object); diff != "" {
+				t.Errorf("Render(...): repeated renders of the same inputs should be identical: -first, +got:\n%s", diff)
+			}
+		}
+	})
+}
+
+func TestAssociateByOrder(t *testing.T) {
+	t0 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("zero")}}
+	t1 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("one")}}
+	t2 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("two")}}
+
+	r0 := corev1.ObjectReference{Name: "zero"}
+	r1 := corev1.ObjectReference{Name: "one"}
+	r2 := corev1.ObjectReference{Name: "two"}
+
+	cases := map[string]struct {
+		reason string
+		t      []v1.ComposedTemplate
+		r      []corev1.ObjectReference
+		want   []TemplateAssociation
+	}{
+		"NoReferences": {
+			reason: "When there are no references we should return templates associated with empty references.",
+			t:      []v1.ComposedTemplate{t0, t1, t2},
+			want: []TemplateAssociation{
+				{Template: t0},
+				{Template: t1},
+				{Template: t2},
+			},
+		},
+		"SomeReferences": {
+			reason: "We should return all templates when there are fewer references than templates.",
+			t:      []v1.ComposedTemplate{t0, t1, t2},
+			r:      []corev1.ObjectReference{r0, r1},
+			want: []TemplateAssociation{
+				{Template: t0, Reference: r0},
+				{Template: t1, Reference: r1},
+				{Template: t2},
+			},
+		},
+		"ExtraReferences": {
+			reason: "When there are more references than templates they should be truncated.",
+			t:      []v1.ComposedTemplate{t0, t1},
+			r:      []corev1.ObjectReference{r0, r1, r2},
+			want: []TemplateAssociation{
+				{Template: t0, Reference: r0},
+				{Template: t1, Reference: r1},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := AssociateByOrder(tc.t, tc.r)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nAssociateByOrder(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAssociateByOrderWithExtras(t *testing.T) {
+	t0 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("zero")}}
+	t1 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("one")}}
+
+	r0 := corev1.ObjectReference{Name: "zero"}
+	r1 := corev1.ObjectReference{Name: "one"}
+	r2 := corev1.ObjectReference{Name: "two"}
+
+	type want struct {
+		tas    []TemplateAssociation
+		extras []corev1.ObjectReference
+	}
+	cases := map[string]struct {
+		reason string
+		t      []v1.ComposedTemplate
+		r      []corev1.ObjectReference
+		want   want
+	}{
+		"NoExtraReferences": {
+			reason: "When there are no more references than templates we should return no extra references.",
+			t:      []v1.ComposedTemplate{t0, t1},
+			r:      []corev1.ObjectReference{r0},
+			want: want{
+				tas: []TemplateAssociation{
+					{Template: t0, Reference: r0},
+					{Template: t1},
+				},
+			},
+		},
+		"ExtraReferences": {
+			reason: "When there are more references than templates we should return the extra references.",
+			t:      []v1.ComposedTemplate{t0},
+			r:      []corev1.ObjectReference{r0, r1, r2},
+			want: want{
+				tas: []TemplateAssociation{
+					{Template: t0, Reference: r0},
+				},
+				extras: []corev1.ObjectReference{r1, r2},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tas, extras := AssociateByOrderWithExtras(tc.t, tc.r)
+			if diff := cmp.Diff(tc.want.tas, tas); diff != "" {
+				t.Errorf("\n%s\nAssociateByOrderWithExtras(...): -want associations, +got associations:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.extras, extras); diff != "" {
+				t.Errorf("\n%s\nAssociateByOrderWithExtras(...): -want extras, +got extras:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGarbageCollectingAssociator(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	n0 := "zero"
+	t0 := v1.ComposedTemplate{Name: &n0}
+
+	r0 := corev1.ObjectReference{Name: n0}
+
+	// A template and an orphaned composed resource of the same kind, e.g.
+	// after switching to a Composition with differently named templates.
+	nb := "bucket"
+	tb := v1.ComposedTemplate{Name: &nb, Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket"}`)}}
+	rb := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-bucket"}
+	orphan := test.NewMockGetFn(nil, func(obj client.Object) error {
+		// The template used to create this resource is no longer known to us.
+		SetCompositionResourceName(obj, "old-bucket")
+		return nil
+	})
+	observeOnlyBucket := tb.DeepCopy()
+	observeOnlyBucket.Base = runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket","metadata":{"name":"cool-bucket"}}`)}
+	observeOnlyBucket.ManagementPolicy = func() *v1.ManagementPolicy {
+		p := v1.ManagementPolicyObserveOnly
+		return &p
+	}()
+
+	type args struct {
+		ctx context.Context
+		cr  resource.Composite
+		ct  []v1.ComposedTemplate
+	}
+
+	type want struct {
+		tas []TemplateAssociation
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		c      client.Client
+		o      []GarbageCollectingAssociatorOption
+		args   args
+		want   want
+	}{
+		"LeaveOrphan": {
+			reason: "We should release rather than garbage collect an orphaned resource when our strategy is to leave it.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, "old-bucket")
+					obj.SetOwnerReferences([]metav1.OwnerReference{{Name: "xr", Controller: pointer.Bool(true)}})
+					return nil
+				}),
+				MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+					if len(obj.GetOwnerReferences()) != 0 {
+						t.Errorf("Update(...): orphaned resource should not be controlled by the XR")
+					}
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			o: []GarbageCollectingAssociatorOption{WithOrphanStrategy(OrphanStrategyLeave)},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{rb}},
+				},
+				ct: []v1.ComposedTemplate{tb},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: tb}},
+			},
+		},
+		"AdoptMatchingOrphan": {
+			reason: "We should associate an orphaned resource with an unassociated template of the same kind when our strategy is to adopt it.",
+			c: &test.MockClient{
+				MockGet:    orphan,
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			o: []GarbageCollectingAssociatorOption{WithOrphanStrategy(OrphanStrategyAdoptIfMatching)},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{rb}},
+				},
+				ct: []v1.ComposedTemplate{t0, tb},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}, {Template: tb, Reference: rb}},
+			},
+		},
+		"AdoptNonMatchingOrphan": {
+			reason: "We should garbage collect an orphaned resource that doesn't match any unassociated template when our strategy is to adopt it.",
+			c: &test.MockClient{
+				MockGet:    orphan,
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			o: []GarbageCollectingAssociatorOption{WithOrphanStrategy(OrphanStrategyAdoptIfMatching)},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{rb}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGCComposed),
+			},
+		},
+		"DeleteOrphanWithOrphanDeletionPolicy": {
+			reason: "We should garbage collect an orphaned resource whose deletion policy is Orphan, leaving its deletion policy to orphan its external resource.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, "old-bucket")
+					obj.(*composed.Unstructured).Object["spec"] = map[string]any{"deletionPolicy": "Orphan"}
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{rb}},
+				},
+				ct: []v1.ComposedTemplate{tb},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGCComposed),
+			},
+		},
+		"AnonymousTemplates": {
+			reason: "We should fall back to associating templates with references by order if any template is not named.",
+			args: args{
+				cr: &fake.Composite{},
+				ct: []v1.ComposedTemplate{t0, {Name: nil}},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}, {Template: v1.ComposedTemplate{Name: nil}}},
+			},
+		},
+		"DuplicateTemplateNames": {
+			reason: "We should return an error if more than one template has the same name.",
+			args: args{
+				cr: &fake.Composite{},
+				ct: []v1.ComposedTemplate{t0, tb, {Name: &n0}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtDuplicateTemplate, 0, 2, n0),
+			},
+		},
+		"AnonymousAndDuplicateTemplates": {
+			reason: "We should return an error if named templates share a name, even if some templates are not named.",
+			args: args{
+				cr: &fake.Composite{},
+				ct: []v1.ComposedTemplate{t0, t0, {Name: nil}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtDuplicateTemplate, 0, 1, n0),
+			},
+		},
+		"AnonymousTemplatesExtraReferences": {
+			reason: "We should drop references after the last anonymous template by default, without garbage collecting them.",
+			c: &test.MockClient{
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{UID: "cool-uid"},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0, {Name: "extra"}}},
+				},
+				ct: []v1.ComposedTemplate{{Name: nil}},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: v1.ComposedTemplate{Name: nil}, Reference: r0}},
+			},
+		},
+		"AnonymousTemplatesCollectExtraReferences": {
+			reason: "We should garbage collect composed resources we control that are referenced after the last anonymous template when configured to.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					meta.AddControllerReference(obj, metav1.OwnerReference{UID: "cool-uid", Controller: pointer.Bool(true)})
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			o: []GarbageCollectingAssociatorOption{WithExtraReferenceCollection()},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{UID: "cool-uid"},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0, {Name: "extra"}}},
+				},
+				ct: []v1.ComposedTemplate{{Name: nil}},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGCComposed),
+			},
+		},
+		"AnonymousTemplatesUncontrolledExtraReferences": {
+			reason: "We should not garbage collect composed resources we don't control that are referenced after the last anonymous template.",
+			c: &test.MockClient{
+				MockGet:    test.NewMockGetFn(nil),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			o: []GarbageCollectingAssociatorOption{WithExtraReferenceCollection()},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{UID: "cool-uid"},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0, {Name: "extra"}}},
+				},
+				ct: []v1.ComposedTemplate{{Name: nil}},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: v1.ComposedTemplate{Name: nil}, Reference: r0}},
+			},
+		},
+		"ResourceNotFoundError": {
+			reason: "Non-existent resources should be ignored.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"GetResourceError": {
+			reason: "Errors getting a referenced resource should be returned.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGetComposed),
+			},
+		},
+		"AnonymousResource": {
+			reason: "We should fall back to associating templates with references by order if any resource we control is not annotated with its template name.",
+			c: &test.MockClient{
+				// Return an unannotated composed resource that we control.
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					obj.SetOwnerReferences([]metav1.OwnerReference{{Controller: pointer.Bool(true), UID: types.UID("very-unique")}})
+					return nil
+				}),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{UID: types.UID("very-unique")},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+			},
+		},
+		"AssociatedResource": {
+			reason: "We should associate referenced resources by their template name annotation.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj.(metav1.Object), n0)
+					return nil
+				}),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+			},
+		},
+		"UncontrolledResource": {
+			reason: "We should not garbage collect a resource that we don't control.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+
+					// This resource is not controlled by us.
+					ctrl := true
+					obj.SetOwnerReferences([]metav1.OwnerReference{{
+						Controller:         &ctrl,
+						BlockOwnerDeletion: &ctrl,
+						UID:                types.UID("who-dat"),
+					}})
+					return nil
+				}),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{UID: types.UID("very-unique")},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"GarbageCollectionError": {
+			reason: "We should return errors encountered while garbage collecting a composed resource.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGCComposed),
+			},
+		},
+		"GarbageCollectedResource": {
+			reason: "We should not return a resource that we successfully garbage collect.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(nil),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"GarbageCollectionInProgress": {
+			reason: "We should not return, or try to delete, a resource that is already being deleted.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+					now := metav1.Now()
+					obj.SetDeletionTimestamp(&now)
+					return nil
+				}),
+				MockDelete: func(_ context.Context, _ client.Object, _ ...client.DeleteOption) error {
+					return errors.New("Delete should not be called for a resource that is already being deleted")
+				},
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"GarbageCollectionDisabled": {
+			reason: "We should never delete a resource that we would otherwise garbage collect when garbage collection is disabled.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+					return nil
+				}),
+				MockDelete: func(_ context.Context, _ client.Object, _ ...client.DeleteOption) error {
+					return errors.New("Delete should not be called when garbage collection is disabled")
+				},
+			},
+			o: []GarbageCollectingAssociatorOption{WithGarbageCollection(false)},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"GarbageCollectionDisabledExtraReference": {
+			reason: "We should never delete a resource referenced after the last anonymous template when garbage collection is disabled.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// This resource is controlled by us.
+					ctrl := true
+					obj.SetOwnerReferences([]metav1.OwnerReference{{Controller: &ctrl, UID: types.UID("very-unique")}})
+					return nil
+				}),
+				MockDelete: func(_ context.Context, _ client.Object, _ ...client.DeleteOption) error {
+					return errors.New("Delete should not be called when garbage collection is disabled")
+				},
+			},
+			o: []GarbageCollectingAssociatorOption{WithExtraReferenceCollection(), WithGarbageCollection(false)},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{UID: types.UID("very-unique")},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0, {Name: "extra"}}},
+				},
+				ct: []v1.ComposedTemplate{{}},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: v1.ComposedTemplate{}, Reference: r0}},
+			},
+		},
+		"ObserveOnlyResource": {
+			reason: "We should associate an observe-only resource with the resource its base names, and never garbage collect it.",
+			c: &test.MockClient{
+				MockGet:    test.NewMockGetFn(errBoom),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{rb}},
+				},
+				ct: []v1.ComposedTemplate{*observeOnlyBucket},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: *observeOnlyBucket, Reference: rb}},
+			},
+		},
+		"ObserveOnlyResourceReordered": {
+			reason: "We should associate an observe-only resource by the resource its base names, and other resources by name, when templates are reordered around it.",
+			c: &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					if key.Name != r0.Name {
+						t.Errorf("Get(...): unexpected get of %q", key.Name)
+					}
+					SetCompositionResourceName(obj, n0)
+					return nil
+				},
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{rb, r0}},
+				},
+				ct: []v1.ComposedTemplate{t0, *observeOnlyBucket},
+			},
+			want: want{
+				tas: []TemplateAssociation{
+					{Template: t0, Reference: r0},
+					{Template: *observeOnlyBucket, Reference: rb},
+				},
+			},
+		},
+		"ObserveOnlyResourceRenamed": {
+			reason: "We should neither associate nor garbage collect an unannotated resource we don't control, and shouldn't fall back to associating templates by order because of it.",
+			c: &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					if key.Name == r0.Name {
+						SetCompositionResourceName(obj, n0)
+					}
+					return nil
+				},
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{
+						{APIVersion: "example.org/v1", Kind: "Bucket", Name: "old-bucket"},
+						r0,
+					}},
+				},
+				ct: []v1.ComposedTemplate{t0, *observeOnlyBucket},
+			},
+			want: want{
+				tas: []TemplateAssociation{
+					{Template: t0, Reference: r0},
+					{Template: *observeOnlyBucket},
+				},
+			},
+		},
+		"ObserveOnlyResourceKindChanged": {
+			reason: "We should not associate an observe-only template with a resource of a different kind.",
+			c: &test.MockClient{
+				MockGet:    orphan,
+				MockDelete: test.NewMockDeleteFn(nil),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{*observeOnlyBucket},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: *observeOnlyBucket}},
+			},
+		},
+		"OrphanedResource": {
+			reason: "We should release rather than delete a resource whose garbage collection policy is Orphan.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+					SetGarbageCollectionPolicy(obj, v1.GarbageCollectionPolicyOrphan)
+					obj.SetOwnerReferences([]metav1.OwnerReference{
+						{Name: "xr", Controller: pointer.Bool(true)},
+						{Name: "other"},
+					})
+					return nil
+				}),
+				MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+					if GetCompositionResourceName(obj) != "" {
+						t.Errorf("Update(...): orphaned resource should not be associated with a template")
+					}
+					want := []metav1.OwnerReference{{Name: "other"}}
+					if diff := cmp.Diff(want, obj.GetOwnerReferences()); diff != "" {
+						t.Errorf("Update(...): -want owner references, +got owner references:\n%s", diff)
+					}
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"OrphanResourceError": {
+			reason: "We should return any error encountered while releasing a resource whose garbage collection policy is Orphan.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, "unknown")
+					SetGarbageCollectionPolicy(obj, v1.GarbageCollectionPolicyOrphan)
+					return nil
+				}),
+				MockUpdate: test.NewMockUpdateFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errOrphanComposed),
+			},
+		},
+		"OtherTeamResource": {
+			reason: "We should neither associate nor garbage collect a resource owned by a different team, even if its template name matches.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, n0)
+					SetOwnerTeam(obj, "payments")
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{Labels: map[string]string{LabelKeyOwnerTeam: "platform"}},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"OtherTeamUnknownResource": {
+			reason: "We should not garbage collect a resource owned by a different team.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+					SetOwnerTeam(obj, "payments")
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{Labels: map[string]string{LabelKeyOwnerTeam: "platform"}},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"SameTeamResource": {
+			reason: "We should associate a resource owned by the same team as the composite resource.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, n0)
+					SetOwnerTeam(obj, "platform")
+					return nil
+				}),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{Labels: map[string]string{LabelKeyOwnerTeam: "platform"}},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+			},
+		},
+		"UnownedResource": {
+			reason: "We should garbage collect a resource that isn't owned by any team.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, "unknown")
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{Labels: map[string]string{LabelKeyOwnerTeam: "platform"}},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGCComposed),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewGarbageCollectingAssociator(tc.c, tc.o...)
+			got, err := a.AssociateTemplates(tc.args.ctx, tc.args.cr, tc.args.ct)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplates(...): -want, +got:\n%s", tc.reason, diff)
 			}
-			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
-				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			if diff := cmp.Diff(tc.want.tas, got); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplates(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestAssociateByOrder(t *testing.T) {
-	t0 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("zero")}}
-	t1 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("one")}}
-	t2 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("two")}}
+func TestGarbageCollectingAssociatorReport(t *testing.T) {
+	n0 := "zero"
+	t0 := v1.ComposedTemplate{Name: &n0}
+	r0 := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-bucket"}
+	re := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "extra-bucket"}
 
-	r0 := corev1.ObjectReference{Name: "zero"}
-	r1 := corev1.ObjectReference{Name: "one"}
-	r2 := corev1.ObjectReference{Name: "two"}
+	// A composed resource created from a template that no longer exists, and
+	// that is controlled by the supplied resource.
+	controlledBy := func(uid types.UID) client.Client {
+		return &test.MockClient{
+			MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+				SetCompositionResourceName(obj, "unknown")
+				ctrl := true
+				obj.SetOwnerReferences([]metav1.OwnerReference{{
+					Controller: &ctrl,
+					UID:        uid,
+				}})
+				return nil
+			}),
+			MockDelete: func(_ context.Context, _ client.Object, _ ...client.DeleteOption) error {
+				return errors.New("Delete should not be called")
+			},
+		}
+	}
+	cr := &fake.Composite{
+		ObjectMeta:                  metav1.ObjectMeta{UID: types.UID("very-unique")},
+		ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+	}
+
+	type args struct {
+		cr resource.Composite
+		ct []v1.ComposedTemplate
+	}
+
+	type want struct {
+		tas    []TemplateAssociation
+		report AssociationReport
+		err    error
+	}
 
 	cases := map[string]struct {
 		reason string
-		t      []v1.ComposedTemplate
-		r      []corev1.ObjectReference
-		want   []TemplateAssociation
+		c      client.Client
+		o      []GarbageCollectingAssociatorOption
+		args   args
+		want   want
 	}{
-		"NoReferences": {
-			reason: "When there are no references we should return templates associated with empty references.",
-			t:      []v1.ComposedTemplate{t0, t1, t2},
-			want: []TemplateAssociation{
-				{Template: t0},
-				{Template: t1},
-				{Template: t2},
+		"UncontrolledNotReported": {
+			reason: "By default we should silently drop references to composed resources we don't control.",
+			c:      controlledBy(types.UID("who-dat")),
+			args: args{
+				cr: cr,
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"UncontrolledReported": {
+			reason: "When configured to, we should report references to composed resources we don't control.",
+			c:      controlledBy(types.UID("who-dat")),
+			o:      []GarbageCollectingAssociatorOption{WithUncontrolledResourceReporting()},
+			args: args{
+				cr: cr,
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas:    []TemplateAssociation{{Template: t0}},
+				report: AssociationReport{Uncontrolled: []corev1.ObjectReference{r0}},
+			},
+		},
+		"GarbageCollectionDisabled": {
+			reason: "We should retain references to composed resources we would otherwise garbage collect when garbage collection is disabled, rather than report them as uncontrolled.",
+			c:      controlledBy(types.UID("very-unique")),
+			o:      []GarbageCollectingAssociatorOption{WithUncontrolledResourceReporting(), WithGarbageCollection(false)},
+			args: args{
+				cr: cr,
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas:    []TemplateAssociation{{Template: t0}},
+				report: AssociationReport{Retained: []corev1.ObjectReference{r0}},
+			},
+		},
+		"Collected": {
+			reason: "We should report composed resources we deleted as collected.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, "unknown")
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(nil),
+			},
+			args: args{
+				cr: cr,
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas:    []TemplateAssociation{{Template: t0}},
+				report: AssociationReport{Collected: []corev1.ObjectReference{r0}},
+			},
+		},
+		"Released": {
+			reason: "We shouldn't report composed resources we released rather than deleted as collected.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, "unknown")
+					return nil
+				}),
+				MockUpdate: test.NewMockUpdateFn(nil),
+			},
+			o: []GarbageCollectingAssociatorOption{WithOrphanStrategy(OrphanStrategyLeave)},
+			args: args{
+				cr: cr,
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"AlreadyDeleted": {
+			reason: "We shouldn't report composed resources that were already being deleted as collected.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, "unknown")
+					now := metav1.Now()
+					obj.SetDeletionTimestamp(&now)
+					return nil
+				}),
+			},
+			args: args{
+				cr: cr,
+				ct: []v1.ComposedTemplate{t0},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"GarbageCollectionDisabledExtraReference": {
+			reason: "We should retain references to composed resources referenced after the last anonymous template when garbage collection is disabled.",
+			c:      controlledBy(types.UID("very-unique")),
+			o:      []GarbageCollectingAssociatorOption{WithExtraReferenceCollection(), WithGarbageCollection(false)},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{UID: types.UID("very-unique")},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0, re}},
+				},
+				ct: []v1.ComposedTemplate{{}},
+			},
+			want: want{
+				tas:    []TemplateAssociation{{Template: v1.ComposedTemplate{}, Reference: r0}},
+				report: AssociationReport{Retained: []corev1.ObjectReference{re}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewGarbageCollectingAssociator(tc.c, tc.o...)
+			tas, report, err := a.AssociateTemplatesWithReport(context.Background(), tc.args.cr, tc.args.ct)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplatesWithReport(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.tas, tas); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplatesWithReport(...): -want associations, +got associations:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.report, report); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplatesWithReport(...): -want report, +got report:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGarbageCollectingAssociatorListing(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	n0, n1 := "zero", "one"
+	t0 := v1.ComposedTemplate{Name: &n0}
+	t1 := v1.ComposedTemplate{Name: &n1}
+	r0 := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-zero"}
+	r1 := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-one"}
+	rq := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Queue", Name: "cool-one"}
+
+	cr := func(refs ...corev1.ObjectReference) *composite.Unstructured {
+		xr := composite.New()
+		xr.SetUID(types.UID("very-unique"))
+		xr.SetLabels(map[string]string{xcrd.LabelKeyNamePrefixForComposed: "cool-xr"})
+		xr.SetResourceReferences(refs)
+		return xr
+	}
+
+	// A composed resource controlled by the XR, created from the supplied
+	// template.
+	cd := func(name, template string) kunstructured.Unstructured {
+		u := composed.New()
+		u.SetName(name)
+		SetCompositionResourceName(u, template)
+		ctrl := true
+		u.SetOwnerReferences([]metav1.OwnerReference{{Controller: &ctrl, UID: types.UID("very-unique")}})
+		return u.Unstructured
+	}
+
+	// Associates any composed resource it gets with the template of the
+	// same name.
+	get := test.NewMockGetFn(nil, func(obj client.Object) error {
+		SetCompositionResourceName(obj, map[string]string{r0.Name: n0, r1.Name: n1}[obj.GetName()])
+		ctrl := true
+		obj.SetOwnerReferences([]metav1.OwnerReference{{Controller: &ctrl, UID: types.UID("very-unique")}})
+		return nil
+	})
+
+	type args struct {
+		cr resource.Composite
+		ct []v1.ComposedTemplate
+	}
+
+	type want struct {
+		tas []TemplateAssociation
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		c      client.Client
+		args   args
+		want   want
+	}{
+		"Listed": {
+			reason: "We should associate templates with composed resources we listed, without getting them.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(errBoom),
+				MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+					obj.(*composed.UnstructuredList).Items = []kunstructured.Unstructured{cd(r0.Name, n0), cd(r1.Name, n1)}
+					return nil
+				}),
+			},
+			args: args{
+				cr: cr(r0, r1),
+				ct: []v1.ComposedTemplate{t0, t1},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}, {Template: t1, Reference: r1}},
+			},
+		},
+		"NotListed": {
+			reason: "We should get any referenced composed resource we didn't list.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, r1.Name)),
+				MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+					obj.(*composed.UnstructuredList).Items = []kunstructured.Unstructured{cd(r0.Name, n0)}
+					return nil
+				}),
+			},
+			args: args{
+				cr: cr(r0, r1),
+				ct: []v1.ComposedTemplate{t0, t1},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}, {Template: t1}},
+			},
+		},
+		"DifferentKinds": {
+			reason: "We should get composed resources when they're not all of the same kind.",
+			c: &test.MockClient{
+				MockGet:  get,
+				MockList: test.NewMockListFn(errBoom),
+			},
+			args: args{
+				cr: cr(r0, rq),
+				ct: []v1.ComposedTemplate{t0, t1},
 			},
-		},
-		"SomeReferences": {
-			reason: "We should return all templates when there are fewer references than templates.",
-			t:      []v1.ComposedTemplate{t0, t1, t2},
-			r:      []corev1.ObjectReference{r0, r1},
-			want: []TemplateAssociation{
-				{Template: t0, Reference: r0},
-				{Template: t1, Reference: r1},
-				{Template: t2},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}, {Template: t1, Reference: rq}},
 			},
 		},
-		"ExtraReferences": {
-			reason: "When there are more references than templates they should be truncated.",
-			t:      []v1.ComposedTemplate{t0, t1},
-			r:      []corev1.ObjectReference{r0, r1, r2},
-			want: []TemplateAssociation{
-				{Template: t0, Reference: r0},
-				{Template: t1, Reference: r1},
+		"ListError": {
+			reason: "We should return any error encountered while listing composed resources.",
+			c: &test.MockClient{
+				MockGet:  get,
+				MockList: test.NewMockListFn(errBoom),
+			},
+			args: args{
+				cr: cr(r0, r1),
+				ct: []v1.ComposedTemplate{t0, t1},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errListComposed),
 			},
 		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got := AssociateByOrder(tc.t, tc.r)
-			if diff := cmp.Diff(tc.want, got); diff != "" {
-				t.Errorf("\n%s\nAssociateByOrder(...): -want, +got:\n%s", tc.reason, diff)
+			a := NewGarbageCollectingAssociator(tc.c, WithComposedResourceListing())
+			tas, _, err := a.AssociateTemplatesWithReport(context.Background(), tc.args.cr, tc.args.ct)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplatesWithReport(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.tas, tas); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplatesWithReport(...): -want associations, +got associations:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestGarbageCollectingAssociator(t *testing.T) {
+func TestGarbageCollectingAssociatorMixed(t *testing.T) {
 	errBoom := errors.New("boom")
 
-	n0 := "zero"
+	n0, n1 := "zero", "one"
 	t0 := v1.ComposedTemplate{Name: &n0}
+	t1 := v1.ComposedTemplate{Name: &n1}
+	ta := v1.ComposedTemplate{Name: nil}
+	r0 := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-zero"}
+	r1 := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-one"}
+	ra := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-anonymous"}
+	ru := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-unannotated"}
+	rg := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-gone"}
 
-	r0 := corev1.ObjectReference{Name: n0}
+	cr := func(refs ...corev1.ObjectReference) *composite.Unstructured {
+		xr := composite.New()
+		xr.SetUID(types.UID("very-unique"))
+		xr.SetResourceReferences(refs)
+		return xr
+	}
+
+	// Annotates any composed resource it gets with the name of the template
+	// it was created from, if any.
+	get := test.NewMockGetFn(nil, func(obj client.Object) error {
+		SetCompositionResourceName(obj, map[string]string{r0.Name: n0, r1.Name: n1, rg.Name: "gone"}[obj.GetName()])
+		ctrl := true
+		obj.SetOwnerReferences([]metav1.OwnerReference{{Controller: &ctrl, UID: types.UID("very-unique")}})
+		return nil
+	})
 
 	type args struct {
-		ctx context.Context
-		cr  resource.Composite
-		ct  []v1.ComposedTemplate
+		cr resource.Composite
+		ct []v1.ComposedTemplate
 	}
 
 	type want struct {
-		tas []TemplateAssociation
-		err error
+		tas    []TemplateAssociation
+		report AssociationReport
+		err    error
 	}
 
 	cases := map[string]struct {
@@ -705,146 +5257,120 @@ func TestGarbageCollectingAssociator(t *testing.T) {
 		args   args
 		want   want
 	}{
-		"AnonymousTemplates": {
-			reason: "We should fall back to associating templates with references by order if any template is not named.",
+		"NamesAndPositionsDisagree": {
+			reason: "We should associate named templates by name and anonymous templates by order.",
+			c:      &test.MockClient{MockGet: get},
 			args: args{
-				cr: &fake.Composite{},
-				ct: []v1.ComposedTemplate{t0, {Name: nil}},
+				cr: cr(r1, ra, r0),
+				ct: []v1.ComposedTemplate{t0, ta, t1},
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0}, {Template: v1.ComposedTemplate{Name: nil}}},
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}, {Template: ta, Reference: ra}, {Template: t1, Reference: r1}},
 			},
 		},
-		"ResourceNotFoundError": {
-			reason: "Non-existent resources should be ignored.",
-			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
-			},
+		"AnonymousPositionNamed": {
+			reason: "We shouldn't associate an anonymous template with a composed resource created from a named template.",
+			c:      &test.MockClient{MockGet: get},
 			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				ct: []v1.ComposedTemplate{t0},
+				cr: cr(r0),
+				ct: []v1.ComposedTemplate{ta, t0},
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0}},
+				tas: []TemplateAssociation{{Template: ta}, {Template: t0, Reference: r0}},
 			},
 		},
-		"GetResourceError": {
-			reason: "Errors getting a referenced resource should be returned.",
-			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(errBoom),
-			},
+		"NamedTemplateUnannotated": {
+			reason: "We should associate a named template by order if the composed resource at its position isn't annotated with a template name.",
+			c:      &test.MockClient{MockGet: get},
 			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				ct: []v1.ComposedTemplate{t0},
+				cr: cr(ra, ru),
+				ct: []v1.ComposedTemplate{ta, t0},
 			},
 			want: want{
-				err: errors.Wrap(errBoom, errGetComposed),
+				tas: []TemplateAssociation{{Template: ta, Reference: ra}, {Template: t0, Reference: ru}},
 			},
 		},
-		"AnonymousResource": {
-			reason: "We should fall back to associating templates with references by order if any resource is not annotated with its template name.",
+		"UnannotatedPositionTaken": {
+			reason: "We should retain, not garbage collect, an unannotated composed resource whose position is taken by a template associated by name.",
 			c: &test.MockClient{
-				// Return an empty (and thus unannotated) composed resource.
-				MockGet: test.NewMockGetFn(nil),
+				MockGet:    get,
+				MockDelete: test.NewMockDeleteFn(errBoom),
 			},
 			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				ct: []v1.ComposedTemplate{t0},
+				cr: cr(ru, r0),
+				ct: []v1.ComposedTemplate{t0, ta},
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+				tas:    []TemplateAssociation{{Template: t0, Reference: r0}, {Template: ta}},
+				report: AssociationReport{Retained: []corev1.ObjectReference{ru}},
 			},
 		},
-		"AssociatedResource": {
-			reason: "We should associate referenced resources by their template name annotation.",
+		"NotFound": {
+			reason: "We should preserve the reference to a composed resource that no longer exists at the position of an anonymous template.",
 			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-					SetCompositionResourceName(obj.(metav1.Object), n0)
-					return nil
-				}),
+				MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, ra.Name)),
 			},
 			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				ct: []v1.ComposedTemplate{t0},
+				cr: cr(ra),
+				ct: []v1.ComposedTemplate{ta, t0},
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+				tas: []TemplateAssociation{{Template: ta, Reference: ra}, {Template: t0}},
 			},
 		},
-		"UncontrolledResource": {
-			reason: "We should not garbage collect a resource that we don't control.",
+		"GarbageCollectOrphan": {
+			reason: "We should garbage collect composed resources created from a named template that no longer exists.",
 			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-					// The template used to create this resource is no longer known to us.
-					SetCompositionResourceName(obj, "unknown")
-
-					// This resource is not controlled by us.
-					ctrl := true
-					obj.SetOwnerReferences([]metav1.OwnerReference{{
-						Controller:         &ctrl,
-						BlockOwnerDeletion: &ctrl,
-						UID:                types.UID("who-dat"),
-					}})
+				MockGet: get,
+				MockDelete: test.NewMockDeleteFn(nil, func(obj client.Object) error {
+					if obj.GetName() != rg.Name {
+						return errBoom
+					}
 					return nil
 				}),
 			},
 			args: args{
-				cr: &fake.Composite{
-					ObjectMeta:                  metav1.ObjectMeta{UID: types.UID("very-unique")},
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				ct: []v1.ComposedTemplate{t0},
+				cr: cr(ra, rg),
+				ct: []v1.ComposedTemplate{ta, t0},
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0}},
+				tas:    []TemplateAssociation{{Template: ta, Reference: ra}, {Template: t0}},
+				report: AssociationReport{Collected: []corev1.ObjectReference{rg}},
 			},
 		},
-		"GarbageCollectionError": {
-			reason: "We should return errors encountered while garbage collecting a composed resource.",
+		"GarbageCollectOrphanError": {
+			reason: "We should return any error encountered while garbage collecting an orphaned composed resource.",
 			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-					// The template used to create this resource is no longer known to us.
-					SetCompositionResourceName(obj, "unknown")
-					return nil
-				}),
+				MockGet:    get,
 				MockDelete: test.NewMockDeleteFn(errBoom),
 			},
 			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				ct: []v1.ComposedTemplate{t0},
+				cr: cr(ra, rg),
+				ct: []v1.ComposedTemplate{ta, t0},
 			},
 			want: want{
 				err: errors.Wrap(errBoom, errGCComposed),
 			},
 		},
-		"GarbageCollectedResource": {
-			reason: "We should not return a resource that we successfully garbage collect.",
-			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-					// The template used to create this resource is no longer known to us.
-					SetCompositionResourceName(obj, "unknown")
-					return nil
-				}),
-				MockDelete: test.NewMockDeleteFn(nil),
+		"DuplicateTemplateNames": {
+			reason: "We should return an error if more than one named template has the same name.",
+			args: args{
+				cr: cr(),
+				ct: []v1.ComposedTemplate{t0, ta, t0},
+			},
+			want: want{
+				err: errors.Errorf(errFmtDuplicateTemplate, 0, 2, n0),
 			},
+		},
+		"GetError": {
+			reason: "We should return any error encountered while getting a composed resource.",
+			c:      &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
 			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				ct: []v1.ComposedTemplate{t0},
+				cr: cr(ra),
+				ct: []v1.ComposedTemplate{ta, t0},
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0}},
+				err: errors.Wrap(errBoom, errGetComposed),
 			},
 		},
 	}
@@ -852,13 +5378,324 @@ func TestGarbageCollectingAssociator(t *testing.T) {
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			a := NewGarbageCollectingAssociator(tc.c)
-			got, err := a.AssociateTemplates(tc.args.ctx, tc.args.cr, tc.args.ct)
+			tas, report, err := a.AssociateTemplatesWithReport(context.Background(), tc.args.cr, tc.args.ct)
 
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("\n%s\nAssociateTemplates(...): -want, +got:\n%s", tc.reason, diff)
+				t.Errorf("\n%s\nAssociateTemplatesWithReport(...): -want error, +got error:\n%s", tc.reason, diff)
 			}
-			if diff := cmp.Diff(tc.want.tas, got); diff != "" {
-				t.Errorf("\n%s\nAssociateTemplates(...): -want, +got:\n%s", tc.reason, diff)
+			if diff := cmp.Diff(tc.want.tas, tas); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplatesWithReport(...): -want associations, +got associations:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.report, report); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplatesWithReport(...): -want report, +got report:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestStaleConnectionDetails(t *testing.T) {
+	type args struct {
+		current managed.ConnectionDetails
+		ct      []v1.ComposedTemplate
+		conn    managed.ConnectionDetails
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []string
+	}{
+		"NoCurrentConnectionDetails": {
+			reason: "No connection details are stale if none have been published.",
+			args: args{
+				conn: managed.ConnectionDetails{"cool": {42}},
+			},
+		},
+		"SourceRemoved": {
+			reason: "Connection details are stale once the resource that contributed them is removed.",
+			args: args{
+				current: managed.ConnectionDetails{"cool": {42}, "removed": {41}, "alsoremoved": {40}},
+				ct: []v1.ComposedTemplate{{
+					ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("cool"), FromConnectionSecretKey: pointer.String("cool")}},
+				}},
+				conn: managed.ConnectionDetails{"cool": {42}},
+			},
+			want: []string{"alsoremoved", "removed"},
+		},
+		"MultipleSources": {
+			reason: "Connection details are not stale while any source remains.",
+			args: args{
+				current: managed.ConnectionDetails{"shared": {42}},
+				ct: []v1.ComposedTemplate{{
+					ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("shared"), FromConnectionSecretKey: pointer.String("shared")}},
+				}},
+			},
+		},
+		"ConstantValue": {
+			reason: "Connection details with constant values are not stale while a template declares them.",
+			args: args{
+				current: managed.ConnectionDetails{"constant": []byte("cool")},
+				ct: []v1.ComposedTemplate{{
+					ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.String("constant"), Value: pointer.String("cool")}},
+				}},
+			},
+		},
+		"Prefixed": {
+			reason: "Connection details a template declares are identified by their prefixed name.",
+			args: args{
+				current: managed.ConnectionDetails{"primary-db-constant": []byte("cool"), "constant": []byte("cool")},
+				ct: []v1.ComposedTemplate{{
+					ConnectionDetails:       []v1.ConnectionDetail{{Name: pointer.String("constant"), Value: pointer.String("cool")}},
+					ConnectionDetailsPrefix: pointer.String("primary-db-"),
+				}},
+			},
+			want: []string{"constant"},
+		},
+		"ExtractedOnly": {
+			reason: "Connection details that were extracted are not stale, even if no template declares them by name.",
+			args: args{
+				current: managed.ConnectionDetails{"extracted": {42}},
+				conn:    managed.ConnectionDetails{"extracted": {42}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := staleConnectionDetails(tc.args.current, tc.args.ct, tc.args.conn)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nstaleConnectionDetails(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWriteBudget(t *testing.T) {
+	xr := types.UID("cool-xr")
+
+	// A nil budget is unlimited.
+	var unlimited *WriteBudget
+	if unlimited.Exhausted(100) {
+		t.Errorf("Exhausted(100): a nil WriteBudget should never be exhausted")
+	}
+	if got := unlimited.Start(xr, 3); got != 0 {
+		t.Errorf("Start(...): a nil WriteBudget should start at 0, got %d", got)
+	}
+
+	b := NewWriteBudget(2)
+	if b.Exhausted(1) {
+		t.Errorf("Exhausted(1): a WriteBudget of 2 should not be exhausted by 1 write")
+	}
+	if !b.Exhausted(2) {
+		t.Errorf("Exhausted(2): a WriteBudget of 2 should be exhausted by 2 writes")
+	}
+
+	// We should resume from where we deferred last time, so that the same
+	// composed resources aren't deferred each time.
+	if got := b.Start(xr, 3); got != 0 {
+		t.Errorf("Start(...): want 0, got %d", got)
+	}
+	b.Resume(xr, 2)
+	if got := b.Start(xr, 3); got != 2 {
+		t.Errorf("Start(...): want 2 after resuming from 2, got %d", got)
+	}
+
+	// We should handle templates being removed between compositions.
+	if got := b.Start(xr, 2); got != 0 {
+		t.Errorf("Start(...): want 0 when fewer composed resources exist, got %d", got)
+	}
+
+	// We should forget XRs once nothing was deferred.
+	b.Resume(xr, -1)
+	if got := b.Start(xr, 3); got != 0 {
+		t.Errorf("Start(...): want 0 once nothing was deferred, got %d", got)
+	}
+
+	// We should forget XRs that aren't composed again within our TTL, e.g.
+	// because they were deleted.
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	b.Resume(xr, 2)
+	now = now.Add(DefaultWriteBudgetTTL + time.Second)
+	if got := b.Start(xr, 3); got != 0 {
+		t.Errorf("Start(...): want 0 once our TTL has passed, got %d", got)
+	}
+	b.Resume(types.UID("other-xr"), 1)
+	if _, ok := b.next[xr]; ok {
+		t.Errorf("Resume(...): want XRs not resumed within our TTL to be evicted")
+	}
+}
+
+func TestForEach(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		c      int
+		n      int
+	}{
+		"Sequential": {
+			reason: "We should call fn for every index when we're not concurrent.",
+			c:      1,
+			n:      10,
+		},
+		"Concurrent": {
+			reason: "We should call fn for every index, never with more than c concurrent calls.",
+			c:      3,
+			n:      50,
+		},
+		"None": {
+			reason: "We should not call fn when there are no indices.",
+			c:      3,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			running, peak := 0, 0
+			called := make([]bool, tc.n)
+
+			forEach(tc.c, tc.n, func(i int) {
+				mu.Lock()
+				running++
+				if running > peak {
+					peak = running
+				}
+				called[i] = true
+				mu.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+			})
+
+			for i, ok := range called {
+				if !ok {
+					t.Errorf("\n%s\nforEach(...): fn was not called with index %d", tc.reason, i)
+				}
+			}
+			if peak > tc.c {
+				t.Errorf("\n%s\nforEach(...): %d concurrent calls exceeds limit of %d", tc.reason, peak, tc.c)
+			}
+		})
+	}
+}
+
+func TestRenderConcurrency(t *testing.T) {
+	type args struct {
+		flags      map[string]bool
+		configured int
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   int
+	}{
+		"FlagUnset": {
+			reason: "We should use the configured concurrency if the feature flag isn't set.",
+			args: args{
+				configured: 3,
+			},
+			want: 3,
+		},
+		"FlagDisabled": {
+			reason: "We should render sequentially if the feature flag is disabled, regardless of the configured concurrency.",
+			args: args{
+				flags:      map[string]bool{v1.CompositionFeatureConcurrentRendering: false},
+				configured: 3,
+			},
+			want: 1,
+		},
+		"FlagEnabled": {
+			reason: "We should render with the default concurrency if the feature flag is enabled and less concurrency is configured.",
+			args: args{
+				flags:      map[string]bool{v1.CompositionFeatureConcurrentRendering: true},
+				configured: 1,
+			},
+			want: defaultRenderConcurrency,
+		},
+		"FlagEnabledMoreConfigured": {
+			reason: "We should use the configured concurrency if the feature flag is enabled and more concurrency is configured.",
+			args: args{
+				flags:      map[string]bool{v1.CompositionFeatureConcurrentRendering: true},
+				configured: defaultRenderConcurrency + 1,
+			},
+			want: defaultRenderConcurrency + 1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := renderConcurrency(tc.args.flags, tc.args.configured)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nrenderConcurrency(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPTComposeConcurrentRenderingFlag(t *testing.T) {
+	cases := map[string]struct {
+		reason     string
+		flags      map[string]bool
+		o          []PTComposerOption
+		concurrent bool
+	}{
+		"FlagUnset": {
+			reason:     "We should render sequentially by default.",
+			concurrent: false,
+		},
+		"FlagEnabled": {
+			reason:     "We should render concurrently if the Composition enables concurrent rendering.",
+			flags:      map[string]bool{v1.CompositionFeatureConcurrentRendering: true},
+			concurrent: true,
+		},
+		"FlagDisabled": {
+			reason:     "We should render sequentially if the Composition disables concurrent rendering, even if the composer is configured to render concurrently.",
+			flags:      map[string]bool{v1.CompositionFeatureConcurrentRendering: false},
+			o:          []PTComposerOption{WithRenderConcurrency(4)},
+			concurrent: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Each render waits briefly for the other. Both only arrive while
+			// the other is waiting if they're rendered concurrently.
+			var mx sync.Mutex
+			rendering := 0
+			concurrent := false
+			both := make(chan struct{})
+			render := RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+				mx.Lock()
+				rendering++
+				if rendering == 2 {
+					concurrent = true
+					close(both)
+				}
+				mx.Unlock()
+				select {
+				case <-both:
+				case <-time.After(100 * time.Millisecond):
+				}
+				mx.Lock()
+				rendering--
+				mx.Unlock()
+				cd.SetName(*t.Name)
+				return nil
+			})
+
+			o := append([]PTComposerOption{WithComposedRenderer(render)}, tc.o...)
+			c := NewPTComposer(acceptingClient(), composing([]TemplateAssociation{
+				{Template: v1.ComposedTemplate{Name: pointer.String("a")}},
+				{Template: v1.ComposedTemplate{Name: pointer.String("b")}},
+			}, o...)...)
+
+			rev := &v1.CompositionRevision{Spec: v1.CompositionRevisionSpec{FeatureFlags: tc.flags}}
+			if _, err := c.Compose(context.Background(), &fake.Composite{}, CompositionRequest{Revision: rev}); err != nil {
+				t.Fatalf("Compose(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.concurrent, concurrent); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want concurrent, +got concurrent:\n%s", tc.reason, diff)
 			}
 		})
 	}
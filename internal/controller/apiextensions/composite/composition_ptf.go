@@ -16,6 +16,7 @@ specific language governing permissions and limitations under the License.
 package composite
 
 import (
+	"bytes"
 	"context"
 	"sort"
 
@@ -1047,22 +1048,85 @@ func (o *ReadinessObserver) ObserveComposedResources(ctx context.Context, s *PTF
 	return nil
 }
 
+// A ConnectionDetailsPrecedence determines whether the connection details
+// extracted from a composed resource take precedence over those fetched from
+// the composed resource's connection secret when both have the same key.
+type ConnectionDetailsPrecedence string
+
+const (
+	// ConnectionDetailsPrecedenceExtracted uses the value extracted from a
+	// composed resource, rather than a different fetched value.
+	ConnectionDetailsPrecedenceExtracted ConnectionDetailsPrecedence = "Extracted"
+
+	// ConnectionDetailsPrecedenceFetched uses the value fetched from a
+	// composed resource's connection secret, rather than a different
+	// extracted value.
+	ConnectionDetailsPrecedenceFetched ConnectionDetailsPrecedence = "Fetched"
+)
+
+// resolveConnectionDetails returns the supplied connection details extracted
+// from a composed resource, resolving any key that was also fetched from the
+// composed resource's connection secret with a different value according to
+// the supplied precedence.
+func resolveConnectionDetails(fetched, extracted managed.ConnectionDetails, p ConnectionDetailsPrecedence) managed.ConnectionDetails {
+	if p != ConnectionDetailsPrecedenceFetched {
+		return extracted
+	}
+	out := make(managed.ConnectionDetails, len(extracted))
+	for k, v := range extracted {
+		if f, ok := fetched[k]; ok {
+			v = f
+		}
+		out[k] = v
+	}
+	return out
+}
+
 // A ConnectionDetailsObserver extracts XR connection details from composed
 // resource state. The details to extract are derived from each composed
 // resource's P&T resource template and/or Composition Function desired state.
 type ConnectionDetailsObserver struct {
-	details ConnectionDetailsExtractor
+	details    ConnectionDetailsExtractor
+	precedence ConnectionDetailsPrecedence
+}
+
+// A ConnectionDetailsObserverOption configures a ConnectionDetailsObserver.
+type ConnectionDetailsObserverOption func(o *ConnectionDetailsObserver)
+
+// WithConnectionDetailsPrecedence configures whether connection details
+// extracted from a composed resource take precedence over those fetched from
+// its connection secret. Extracted connection details take precedence by
+// default.
+func WithConnectionDetailsPrecedence(p ConnectionDetailsPrecedence) ConnectionDetailsObserverOption {
+	return func(o *ConnectionDetailsObserver) {
+		o.precedence = p
+	}
 }
 
 // NewConnectionDetailsObserver returns a ComposedResourceObserver that observes
 // composed resources in order to extract XR connection details.
-func NewConnectionDetailsObserver(e ConnectionDetailsExtractor) *ConnectionDetailsObserver {
-	return &ConnectionDetailsObserver{details: e}
+func NewConnectionDetailsObserver(e ConnectionDetailsExtractor, o ...ConnectionDetailsObserverOption) *ConnectionDetailsObserver {
+	obs := &ConnectionDetailsObserver{details: e, precedence: ConnectionDetailsPrecedenceExtracted}
+	for _, fn := range o {
+		fn(obs)
+	}
+	return obs
 }
 
-// ObserveComposedResources to extract XR connection details.
+// ObserveComposedResources to extract XR connection details. Composed
+// resources are observed in order of their resource name, so that the same
+// connection detail extracted from more than one composed resource is
+// resolved deterministically. Extracted values identical to existing values
+// are not rewritten.
 func (o *ConnectionDetailsObserver) ObserveComposedResources(_ context.Context, s *PTFCompositionState) error {
-	for _, cd := range s.ComposedResources {
+	names := make([]string, 0, len(s.ComposedResources))
+	for name := range s.ComposedResources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cd := s.ComposedResources[name]
 		ecfgs := append(ExtractConfigsFromTemplate(cd.Template), ExtractConfigsFromDesired(cd.Desired)...)
 		e, err := o.details.ExtractConnection(cd.Resource, cd.ConnectionDetails, ecfgs...)
 		if err != nil {
@@ -1073,7 +1137,10 @@ func (o *ConnectionDetailsObserver) ObserveComposedResources(_ context.Context,
 			s.ConnectionDetails = managed.ConnectionDetails{}
 		}
 
-		for key, val := range e {
+		for key, val := range resolveConnectionDetails(cd.ConnectionDetails, e, o.precedence) {
+			if existing, ok := s.ConnectionDetails[key]; ok && bytes.Equal(existing, val) {
+				continue
+			}
 			s.ConnectionDetails[key] = val
 		}
 	}
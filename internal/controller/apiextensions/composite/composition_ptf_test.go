@@ -821,6 +821,15 @@ func TestFunctionIOObserved(t *testing.T) {
 func TestPatchAndTransform(t *testing.T) {
 	errBoom := errors.New("boom")
 
+	// This environment patch fails because its required field path doesn't
+	// exist.
+	envPatchRequiredMissing := v1.EnvironmentPatch{
+		Type:          v1.PatchTypeFromCompositeFieldPath,
+		FromFieldPath: pointer.String("spec.missing"),
+		ToFieldPath:   pointer.String("data.missing"),
+		Policy:        &v1.PatchPolicy{FromFieldPath: func() *v1.FromFieldPathPolicy { p := v1.FromFieldPathPolicyRequired; return &p }()},
+	}
+
 	type params struct {
 		composite Renderer
 		composed  Renderer
@@ -865,7 +874,30 @@ func TestPatchAndTransform(t *testing.T) {
 				err: errors.Wrap(errors.Errorf(errFmtUndefinedPatchSet, "nonexistent-patchset"), errInline),
 			},
 		},
-		// TODO(negz): Test handling of ApplyEnvironmentPatch errors.
+		"ApplyEnvironmentPatchError": {
+			reason: "We should return any error encountered while applying an environment patch.",
+			args: args{
+				req: CompositionRequest{
+					Revision: &v1.CompositionRevision{
+						Spec: v1.CompositionRevisionSpec{
+							Environment: &v1.EnvironmentConfiguration{
+								Patches: []v1.EnvironmentPatch{envPatchRequiredMissing},
+							},
+						},
+					},
+					Environment: &Environment{},
+				},
+				s: &PTFCompositionState{
+					Composite: &fake.Composite{},
+				},
+			},
+			want: want{
+				s: &PTFCompositionState{
+					Composite: &fake.Composite{},
+				},
+				err: errors.Wrapf(ApplyEnvironmentPatch(envPatchRequiredMissing, &fake.Composite{}, &Environment{}), errFmtPatchEnvironment, 0),
+			},
+		},
 		"CompositeRenderError": {
 			reason: "We should return any error encountered while rendering an XR.",
 			params: params{
@@ -2386,6 +2418,7 @@ func TestConnectionDetailsObserver(t *testing.T) {
 
 	type params struct {
 		e ConnectionDetailsExtractor
+		o []ConnectionDetailsObserverOption
 	}
 
 	type args struct {
@@ -2478,12 +2511,133 @@ func TestConnectionDetailsObserver(t *testing.T) {
 				},
 			},
 		},
+		"ExistingSameValue": {
+			reason: "We should not rewrite an existing connection detail when the same value is extracted.",
+			params: params{
+				e: ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+					return managed.ConnectionDetails{"a": []byte("b")}, nil
+				}),
+			},
+			args: args{
+				s: &PTFCompositionState{
+					ConnectionDetails: managed.ConnectionDetails{"a": []byte("b")},
+					ComposedResources: ComposedResourceStates{
+						"cool-resource": ComposedResourceState{Resource: &fake.Composed{}},
+					},
+				},
+			},
+			want: want{
+				s: &PTFCompositionState{
+					ConnectionDetails: managed.ConnectionDetails{"a": []byte("b")},
+					ComposedResources: ComposedResourceStates{
+						"cool-resource": ComposedResourceState{Resource: &fake.Composed{}},
+					},
+				},
+			},
+		},
+		"FetchedAndExtractedSameValue": {
+			reason: "A connection detail fetched from and extracted from a composed resource with the same value should be recorded once, regardless of precedence.",
+			params: params{
+				e: ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+					return managed.ConnectionDetails{"a": []byte("b")}, nil
+				}),
+				o: []ConnectionDetailsObserverOption{WithConnectionDetailsPrecedence(ConnectionDetailsPrecedenceFetched)},
+			},
+			args: args{
+				s: &PTFCompositionState{
+					ComposedResources: ComposedResourceStates{
+						"cool-resource": ComposedResourceState{Resource: &fake.Composed{}, ConnectionDetails: managed.ConnectionDetails{"a": []byte("b")}},
+					},
+				},
+			},
+			want: want{
+				s: &PTFCompositionState{
+					ConnectionDetails: managed.ConnectionDetails{"a": []byte("b")},
+					ComposedResources: ComposedResourceStates{
+						"cool-resource": ComposedResourceState{Resource: &fake.Composed{}, ConnectionDetails: managed.ConnectionDetails{"a": []byte("b")}},
+					},
+				},
+			},
+		},
+		"FetchedAndExtractedDifferentValues": {
+			reason: "By default a connection detail extracted from a composed resource should take precedence over a different value fetched from its connection secret.",
+			params: params{
+				e: ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+					return managed.ConnectionDetails{"a": []byte("extracted")}, nil
+				}),
+			},
+			args: args{
+				s: &PTFCompositionState{
+					ComposedResources: ComposedResourceStates{
+						"cool-resource": ComposedResourceState{Resource: &fake.Composed{}, ConnectionDetails: managed.ConnectionDetails{"a": []byte("fetched"), "b": []byte("fetched")}},
+					},
+				},
+			},
+			want: want{
+				s: &PTFCompositionState{
+					ConnectionDetails: managed.ConnectionDetails{"a": []byte("extracted")},
+					ComposedResources: ComposedResourceStates{
+						"cool-resource": ComposedResourceState{Resource: &fake.Composed{}, ConnectionDetails: managed.ConnectionDetails{"a": []byte("fetched"), "b": []byte("fetched")}},
+					},
+				},
+			},
+		},
+		"FetchedPrecedence": {
+			reason: "When configured to, we should use the value fetched from a composed resource's connection secret rather than a different extracted value.",
+			params: params{
+				e: ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+					return managed.ConnectionDetails{"a": []byte("extracted"), "c": []byte("extracted")}, nil
+				}),
+				o: []ConnectionDetailsObserverOption{WithConnectionDetailsPrecedence(ConnectionDetailsPrecedenceFetched)},
+			},
+			args: args{
+				s: &PTFCompositionState{
+					ConnectionDetails: managed.ConnectionDetails{"a": []byte("published")},
+					ComposedResources: ComposedResourceStates{
+						"cool-resource": ComposedResourceState{Resource: &fake.Composed{}, ConnectionDetails: managed.ConnectionDetails{"a": []byte("fetched")}},
+					},
+				},
+			},
+			want: want{
+				s: &PTFCompositionState{
+					ConnectionDetails: managed.ConnectionDetails{"a": []byte("fetched"), "c": []byte("extracted")},
+					ComposedResources: ComposedResourceStates{
+						"cool-resource": ComposedResourceState{Resource: &fake.Composed{}, ConnectionDetails: managed.ConnectionDetails{"a": []byte("fetched")}},
+					},
+				},
+			},
+		},
+		"ExtractedInResourceNameOrder": {
+			reason: "A connection detail extracted from more than one composed resource should take the value extracted from the last, in resource name order.",
+			params: params{
+				e: ConnectionDetailsExtractorFn(func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
+					return managed.ConnectionDetails{"a": []byte(cd.GetName())}, nil
+				}),
+			},
+			args: args{
+				s: &PTFCompositionState{
+					ComposedResources: ComposedResourceStates{
+						"b-resource": ComposedResourceState{Resource: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "b"}}},
+						"a-resource": ComposedResourceState{Resource: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "a"}}},
+					},
+				},
+			},
+			want: want{
+				s: &PTFCompositionState{
+					ConnectionDetails: managed.ConnectionDetails{"a": []byte("b")},
+					ComposedResources: ComposedResourceStates{
+						"b-resource": ComposedResourceState{Resource: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "b"}}},
+						"a-resource": ComposedResourceState{Resource: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "a"}}},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 
-			o := NewConnectionDetailsObserver(tc.params.e)
+			o := NewConnectionDetailsObserver(tc.params.e, tc.params.o...)
 			err := o.ObserveComposedResources(tc.args.ctx, tc.args.s)
 
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
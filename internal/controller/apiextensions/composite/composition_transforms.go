@@ -21,30 +21,45 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"hash/adler32"
+	"math"
+	"net"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
+	"golang.org/x/text/unicode/norm"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	verrors "github.com/crossplane/crossplane/internal/validation/errors"
 )
 
 const (
 	errMathTransformTypeFailed = "type %s is not supported for math transform type"
 	errFmtMathInputNonNumber   = "input is required to be a number for math transformer, got %T"
+	errFmtMathOverflow         = "math transform of input %d overflows a 64-bit integer"
 
 	errFmtRequiredField                 = "%s is required by type %s"
 	errFmtConvertInputTypeNotSupported  = "invalid input type %T"
 	errFmtConvertFormatPairNotSupported = "conversion from %s to %s is not supported with format %s"
+	errFmtConvertDurationInvalid        = "cannot parse %q as a duration"
+	errFmtConvertDurationOverflow       = "%d seconds is too long to format as a duration"
+	errFmtConvertJSONInvalid            = "cannot parse %q as a JSON %s"
+	errFmtConvertJSONMarshal            = "cannot format %s as JSON"
+	errFmtConvertIntInvalid             = "cannot parse %q as a base %d integer"
 	errFmtTransformAtIndex              = "transform at index %d returned error"
 	errFmtTypeNotSupported              = "transform type %s is not supported"
 	errFmtTransformConfigMissing        = "given transform type %s requires configuration"
@@ -52,6 +67,14 @@ const (
 	errFmtMapTypeNotSupported           = "type %s is not supported for map transform"
 	errFmtMapNotFound                   = "key %s is not found in map"
 	errFmtMapInvalidJSON                = "value for key %s is not valid JSON"
+	errMapInvalidDefaultJSON            = "default value is not valid JSON"
+	errFmtHashRingInputTypeInvalid      = "input is required to be a string for hashRing transform, got %T"
+	errHashRingNoBuckets                = "hashRing transform requires at least one bucket"
+	errFmtConnStringInputTypeInvalid    = "input is required to be an object for connectionString transform, got %T"
+	errFmtConnStringComponentInvalid    = "connectionString component %q must be a string, got %T"
+	errFmtConnStringParamInvalid        = "connectionString query parameter %q must be a string, number, or boolean, got %T"
+	errConnStringParamsInvalid          = "connectionString component \"params\" must be an object"
+	errConnStringNoHost                 = "connectionString transform requires a host"
 
 	errFmtMatchPattern            = "cannot match pattern at index %d"
 	errFmtMatchParseResult        = "cannot parse result of pattern at index %d"
@@ -68,14 +91,56 @@ const (
 	errStringTransformTypeRegexp        = "string transform of type %s regexp is not set"
 	errStringTransformTypeRegexpFailed  = "could not compile regexp"
 	errStringTransformTypeRegexpNoMatch = "regexp %q had no matches for group %d"
+	errStringTransformTypeRegexpNoGroup = "regexp %q has no capture group %d"
+	errStringTransformTypeEnum          = "string transform of type %s enum is not set"
+	errStringTransformTypeEnumNotFound  = "%q is not one of the allowed values %q"
 	errStringConvertTypeFailed          = "type %s is not supported for string convert"
 
 	errDecodeString = "string is not valid base64"
 	errMarshalJSON  = "cannot marshal to JSON"
 	errHash         = "cannot generate hash"
 	errAdler        = "unable to generate Adler checksum"
+
+	errInvalidTransforms = "composed resource templates have invalid transforms"
 )
 
+// ValidateTransforms validates the transforms of the patches and connection
+// details of the supplied composed templates. It returns a single error that
+// describes every invalid transform, or nil if all of them are valid.
+func ValidateTransforms(ct []v1.ComposedTemplate) error {
+	errs := field.ErrorList{}
+	for i := range ct {
+		rp := field.NewPath("resources").Index(i)
+		for j, p := range ct[i].Patches {
+			pp := rp.Child("patches").Index(j)
+			errs = append(errs, validateTransforms(p.Transforms, pp.Child("transforms"))...)
+			if p.EachElement == nil {
+				continue
+			}
+			for k, ep := range p.EachElement.Patches {
+				errs = append(errs, validateTransforms(ep.Transforms, pp.Child("eachElement", "patches").Index(k).Child("transforms"))...)
+			}
+		}
+		for j, cd := range ct[i].ConnectionDetails {
+			errs = append(errs, validateTransforms(cd.Transforms, rp.Child("connectionDetails").Index(j).Child("transforms"))...)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.Wrap(errs.ToAggregate(), errInvalidTransforms)
+}
+
+func validateTransforms(ts []v1.Transform, path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	for i := range ts {
+		if err := ts[i].Validate(); err != nil {
+			errs = append(errs, verrors.WrapFieldError(err, path.Index(i)))
+		}
+	}
+	return errs
+}
+
 // Resolve the supplied Transform.
 func Resolve(t v1.Transform, input any) (any, error) { //nolint:gocyclo // This is a long but simple/same-y switch.
 	var out any
@@ -91,7 +156,7 @@ func Resolve(t v1.Transform, input any) (any, error) { //nolint:gocyclo // This
 		if t.Map == nil {
 			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
 		}
-		out, err = ResolveMap(*t.Map, input)
+		out, err = ResolveMapOrDefault(*t.Map, t.MapDefault, input)
 	case v1.TransformTypeMatch:
 		if t.Match == nil {
 			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
@@ -107,6 +172,16 @@ func Resolve(t v1.Transform, input any) (any, error) { //nolint:gocyclo // This
 			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
 		}
 		out, err = ResolveConvert(*t.Convert, input)
+	case v1.TransformTypeHashRing:
+		if t.HashRing == nil {
+			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+		}
+		out, err = ResolveHashRing(*t.HashRing, input)
+	case v1.TransformTypeConnectionString:
+		if t.ConnectionString == nil {
+			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+		}
+		out, err = ResolveConnectionString(*t.ConnectionString, input)
 	default:
 		return nil, errors.Errorf(errFmtTypeNotSupported, string(t.Type))
 	}
@@ -136,20 +211,48 @@ func ResolveMath(t v1.MathTransform, input any) (any, error) {
 
 // resolveMathMultiply resolves a multiply transform, returning an error if the
 // input is not a number. If the input is a float, the result will be a float64, otherwise
-// it will be an int64.
+// it will be an int64. The input is multiplied, then offset, then clamped.
 func resolveMathMultiply(t v1.MathTransform, input any) (any, error) {
 	switch i := input.(type) {
 	case int:
-		return int64(i) * *t.Multiply, nil
+		return resolveMathMultiplyInt(t, int64(i))
 	case int64:
-		return i * *t.Multiply, nil
+		return resolveMathMultiplyInt(t, i)
 	case float64:
-		return i * float64(*t.Multiply), nil
+		out := i*float64(*t.Multiply) + float64(pointer.Int64Deref(t.Offset, 0))
+		if t.ClampMin != nil && out < float64(*t.ClampMin) {
+			out = float64(*t.ClampMin)
+		}
+		if t.ClampMax != nil && out > float64(*t.ClampMax) {
+			out = float64(*t.ClampMax)
+		}
+		return out, nil
 	default:
 		return nil, errors.Errorf(errFmtMathInputNonNumber, input)
 	}
 }
 
+// resolveMathMultiplyInt resolves a multiply transform of an integer input,
+// returning an error if the result overflows an int64.
+func resolveMathMultiplyInt(t v1.MathTransform, in int64) (any, error) {
+	m, o := *t.Multiply, pointer.Int64Deref(t.Offset, 0)
+	out := in * m
+	if in != 0 && (out/in != m || (in == -1 && m == math.MinInt64)) {
+		return nil, errors.Errorf(errFmtMathOverflow, in)
+	}
+	if (o > 0 && out > math.MaxInt64-o) || (o < 0 && out < math.MinInt64-o) {
+		return nil, errors.Errorf(errFmtMathOverflow, in)
+	}
+	out += o
+	if t.ClampMin != nil && out < *t.ClampMin {
+		out = *t.ClampMin
+	}
+	if t.ClampMax != nil && out > *t.ClampMax {
+		out = *t.ClampMax
+	}
+	return out, nil
+}
+
 // resolveMathClamp resolves a clamp transform, returning an error if the input
 // is not a number. depending on the type of clamp, the result will be either
 // the input or the clamp value, preserving their original types.
@@ -183,12 +286,23 @@ func resolveMathClamp(t v1.MathTransform, input any) (any, error) {
 
 // ResolveMap resolves a Map transform.
 func ResolveMap(t v1.MapTransform, input any) (any, error) {
+	return ResolveMapOrDefault(t, nil, input)
+}
+
+// ResolveMapOrDefault resolves a Map transform, returning the supplied default
+// value if the input is not a key in the map. It returns an error if the input
+// is not a key in the map and the default is nil.
+func ResolveMapOrDefault(t v1.MapTransform, def *extv1.JSON, input any) (any, error) {
 	switch i := input.(type) {
 	case string:
 		p, ok := t.Pairs[i]
-		if !ok {
+		if !ok && def == nil {
 			return nil, errors.Errorf(errFmtMapNotFound, i)
 		}
+		if !ok {
+			var val any
+			return val, errors.Wrap(json.Unmarshal(def.Raw, &val), errMapInvalidDefaultJSON)
+		}
 		var val interface{}
 		if err := json.Unmarshal(p.Raw, &val); err != nil {
 			return nil, errors.Wrapf(err, errFmtMapInvalidJSON, i)
@@ -303,6 +417,11 @@ func ResolveString(t v1.StringTransform, input any) (string, error) {
 			return "", errors.Errorf(errStringTransformTypeRegexp, string(t.Type))
 		}
 		return stringRegexpTransform(input, *t.Regexp)
+	case v1.StringTransformTypeEnum:
+		if len(t.Enum) == 0 {
+			return "", errors.Errorf(errStringTransformTypeEnum, string(t.Type))
+		}
+		return stringEnumTransform(input, t.Enum)
 	default:
 		return "", errors.Errorf(errStringTransformTypeFailed, string(t.Type))
 	}
@@ -335,11 +454,39 @@ func stringConvertTransform(t *v1.StringConversionType, input any) (string, erro
 	case v1.StringConversionTypeToAdler32:
 		checksum, err := stringGenerateHash(input, adler32.Checksum)
 		return strconv.FormatUint(uint64(checksum), 10), errors.Wrap(err, errAdler)
+	case v1.StringConversionTypeToSlug:
+		return slugify(str), nil
 	default:
 		return "", errors.Errorf(errStringConvertTypeFailed, *t)
 	}
 }
 
+// slugify lowercases the supplied string, removes accents from its letters,
+// and replaces each run of characters other than ASCII letters and digits with
+// a single hyphen, trimming any leading or trailing hyphens. Slugifying a slug
+// returns it unchanged.
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFKD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Drop the combining marks that accented letters decompose to,
+			// e.g. 'é' decomposes to 'e' followed by a combining accent.
+			continue
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		default:
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
 func stringGenerateHash[THash any](input any, hashFunc func([]byte) THash) (THash, error) {
 	var b []byte
 	var err error
@@ -373,17 +520,30 @@ func stringRegexpTransform(input any, r v1.StringTransformRegexp) (string, error
 		return "", errors.Wrap(err, errStringTransformTypeRegexpFailed)
 	}
 
-	groups := re.FindStringSubmatch(fmt.Sprintf("%v", input))
-
 	// Return the entire match (group zero) by default.
 	g := pointer.IntDeref(r.Group, 0)
-	if len(groups) == 0 || g >= len(groups) {
+	if g < 0 || g > re.NumSubexp() {
+		return "", errors.Errorf(errStringTransformTypeRegexpNoGroup, r.Match, g)
+	}
+
+	groups := re.FindStringSubmatch(fmt.Sprintf("%v", input))
+	if len(groups) == 0 {
 		return "", errors.Errorf(errStringTransformTypeRegexpNoMatch, r.Match, g)
 	}
 
 	return groups[g], nil
 }
 
+func stringEnumTransform(input any, allowed []string) (string, error) {
+	str := fmt.Sprintf("%v", input)
+	for _, v := range allowed {
+		if str == v {
+			return str, nil
+		}
+	}
+	return "", errors.Errorf(errStringTransformTypeEnumNotFound, str, allowed)
+}
+
 // ResolveConvert resolves a Convert transform by looking up the appropriate
 // conversion function for the given input type and invoking it.
 func ResolveConvert(t v1.ConvertTransform, input any) (any, error) {
@@ -391,7 +551,7 @@ func ResolveConvert(t v1.ConvertTransform, input any) (any, error) {
 		return nil, err
 	}
 
-	from := v1.TransformIOType(fmt.Sprintf("%T", input))
+	from := convertInputType(input)
 	if !from.IsValid() {
 		return nil, errors.Errorf(errFmtConvertInputTypeNotSupported, input)
 	}
@@ -402,6 +562,17 @@ func ResolveConvert(t v1.ConvertTransform, input any) (any, error) {
 	return f(input)
 }
 
+// convertInputType returns the TransformIOType of the supplied input.
+func convertInputType(input any) v1.TransformIOType {
+	switch input.(type) {
+	case map[string]any:
+		return v1.TransformIOTypeObject
+	case []any:
+		return v1.TransformIOTypeArray
+	}
+	return v1.TransformIOType(fmt.Sprintf("%T", input))
+}
+
 type conversionPair struct {
 	from   v1.TransformIOType
 	to     v1.TransformIOType
@@ -428,6 +599,12 @@ func GetConversionFunc(t *v1.ConvertTransform, from v1.TransformIOType) (func(an
 	if !ok {
 		return nil, errors.Errorf(v1.ErrFmtConvertFormatPairNotSupported, originalFrom, to, t.GetFormat())
 	}
+	if originalFrom == v1.TransformIOTypeInt {
+		// Conversions from int64 assert that their input is an int64.
+		return func(input any) (any, error) {
+			return f(int64(input.(int)))
+		}, nil
+	}
 	return f, nil
 }
 
@@ -488,12 +665,175 @@ var conversions = map[conversionPair]func(any) (any, error){
 	{from: v1.TransformIOTypeFloat64, to: v1.TransformIOTypeBool, format: v1.ConvertTransformFormatNone}: func(i any) (any, error) { //nolint:unparam // See note above.
 		return i.(float64) == float64(1), nil
 	},
+	{from: v1.TransformIOTypeString, to: v1.TransformIOTypeInt64, format: v1.ConvertTransformFormatDuration}: func(i any) (any, error) {
+		d, err := time.ParseDuration(i.(string))
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConvertDurationInvalid, i)
+		}
+		return int64(d / time.Second), nil
+	},
+	{from: v1.TransformIOTypeInt64, to: v1.TransformIOTypeString, format: v1.ConvertTransformFormatDuration}: func(i any) (any, error) {
+		s := i.(int64)
+		if s > math.MaxInt64/int64(time.Second) || s < math.MinInt64/int64(time.Second) {
+			return nil, errors.Errorf(errFmtConvertDurationOverflow, s)
+		}
+		return (time.Duration(s) * time.Second).String(), nil
+	},
+	{from: v1.TransformIOTypeString, to: v1.TransformIOTypeInt64, format: v1.ConvertTransformFormatHex}:   parseIntBase(16),
+	{from: v1.TransformIOTypeString, to: v1.TransformIOTypeInt64, format: v1.ConvertTransformFormatOctal}: parseIntBase(8),
+	{from: v1.TransformIOTypeInt64, to: v1.TransformIOTypeString, format: v1.ConvertTransformFormatHex}:   formatIntBase(16),
+	{from: v1.TransformIOTypeInt64, to: v1.TransformIOTypeString, format: v1.ConvertTransformFormatOctal}: formatIntBase(8),
 	{from: v1.TransformIOTypeString, to: v1.TransformIOTypeObject, format: v1.ConvertTransformFormatJSON}: func(i any) (any, error) {
 		o := map[string]any{}
-		return o, json.Unmarshal([]byte(i.(string)), &o)
+		if err := json.Unmarshal([]byte(i.(string)), &o); err != nil {
+			return nil, errors.Wrapf(err, errFmtConvertJSONInvalid, i, v1.TransformIOTypeObject)
+		}
+		return o, nil
 	},
 	{from: v1.TransformIOTypeString, to: v1.TransformIOTypeArray, format: v1.ConvertTransformFormatJSON}: func(i any) (any, error) {
 		var o []any
-		return o, json.Unmarshal([]byte(i.(string)), &o)
+		if err := json.Unmarshal([]byte(i.(string)), &o); err != nil {
+			return nil, errors.Wrapf(err, errFmtConvertJSONInvalid, i, v1.TransformIOTypeArray)
+		}
+		return o, nil
+	},
+	{from: v1.TransformIOTypeObject, to: v1.TransformIOTypeString, format: v1.ConvertTransformFormatJSON}: func(i any) (any, error) {
+		b, err := json.Marshal(i)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConvertJSONMarshal, v1.TransformIOTypeObject)
+		}
+		return string(b), nil
+	},
+	{from: v1.TransformIOTypeArray, to: v1.TransformIOTypeString, format: v1.ConvertTransformFormatJSON}: func(i any) (any, error) {
+		b, err := json.Marshal(i)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConvertJSONMarshal, v1.TransformIOTypeArray)
+		}
+		return string(b), nil
 	},
 }
+
+// parseIntBase returns a conversion that parses a string as an integer of the
+// supplied base.
+func parseIntBase(base int) func(any) (any, error) {
+	return func(i any) (any, error) {
+		n, err := strconv.ParseInt(i.(string), base, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConvertIntInvalid, i, base)
+		}
+		return n, nil
+	}
+}
+
+// formatIntBase returns a conversion that formats an integer as a string in
+// the supplied base.
+func formatIntBase(base int) func(any) (any, error) {
+	return func(i any) (any, error) { //nolint:unparam // See note above the conversions map.
+		return strconv.FormatInt(i.(int64), base), nil
+	}
+}
+
+// ResolveHashRing resolves a HashRing transform. It uses weighted rendezvous
+// hashing to assign the input to a bucket, so that adding or removing a
+// bucket only affects the inputs assigned to that bucket.
+func ResolveHashRing(t v1.HashRingTransform, input any) (any, error) {
+	key, ok := input.(string)
+	if !ok {
+		return nil, errors.Errorf(errFmtHashRingInputTypeInvalid, input)
+	}
+	if len(t.Buckets) == 0 {
+		return nil, errors.New(errHashRingNoBuckets)
+	}
+
+	best, bestScore := "", math.Inf(-1)
+	for _, b := range t.Buckets {
+		h := sha256.Sum256([]byte(key + "/" + b.Name))
+
+		// Map the hash to a float in the open interval (0, 1).
+		u := (float64(binary.BigEndian.Uint64(h[:8])>>11) + 0.5) / (1 << 53)
+		score := float64(b.GetWeight()) / -math.Log(u)
+
+		// Break (unlikely) ties by name so that assignment is deterministic
+		// regardless of bucket order.
+		if score > bestScore || (score == bestScore && b.Name < best) {
+			best, bestScore = b.Name, score
+		}
+	}
+	return best, nil
+}
+
+// Components of a connection string.
+const (
+	connStringUsername = "username"
+	connStringPassword = "password"
+	connStringHost     = "host"
+	connStringPort     = "port"
+	connStringDatabase = "database"
+	connStringParams   = "params"
+)
+
+// ResolveConnectionString resolves a ConnectionString transform. It builds a
+// connection string URL from the components of the input object, omitting any
+// optional components that are missing or empty.
+func ResolveConnectionString(t v1.ConnectionStringTransform, input any) (any, error) { //nolint:gocyclo // Mostly simple checks of each component.
+	in, ok := input.(map[string]any)
+	if !ok {
+		return nil, errors.Errorf(errFmtConnStringInputTypeInvalid, input)
+	}
+
+	c := map[string]string{}
+	for _, k := range []string{connStringUsername, connStringPassword, connStringHost, connStringPort, connStringDatabase} {
+		switch v := in[k].(type) {
+		case nil:
+		case string:
+			c[k] = v
+		case int64:
+			c[k] = strconv.FormatInt(v, 10)
+		case float64:
+			c[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, errors.Errorf(errFmtConnStringComponentInvalid, k, v)
+		}
+	}
+	if c[connStringHost] == "" {
+		return nil, errors.New(errConnStringNoHost)
+	}
+
+	u := &url.URL{Scheme: t.Scheme, Host: c[connStringHost]}
+	switch {
+	case c[connStringPort] != "":
+		u.Host = net.JoinHostPort(c[connStringHost], c[connStringPort])
+	case strings.Contains(c[connStringHost], ":") && !strings.HasPrefix(c[connStringHost], "["):
+		// An IPv6 address must be bracketed even without a port.
+		u.Host = "[" + c[connStringHost] + "]"
+	}
+	switch {
+	case c[connStringPassword] != "":
+		u.User = url.UserPassword(c[connStringUsername], c[connStringPassword])
+	case c[connStringUsername] != "":
+		u.User = url.User(c[connStringUsername])
+	}
+	if c[connStringDatabase] != "" {
+		u.Path = "/" + c[connStringDatabase]
+	}
+
+	if p, ok := in[connStringParams]; ok && p != nil {
+		params, ok := p.(map[string]any)
+		if !ok {
+			return nil, errors.New(errConnStringParamsInvalid)
+		}
+		q := url.Values{}
+		for k, v := range params {
+			switch v.(type) {
+			case string, int64, float64, bool:
+				q.Set(k, fmt.Sprint(v))
+			default:
+				return nil, errors.Errorf(errFmtConnStringParamInvalid, k, v)
+			}
+		}
+		// Encode sorts parameters by key, so the result is deterministic.
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String(), nil
+}
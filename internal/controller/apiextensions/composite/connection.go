@@ -17,12 +17,15 @@ limitations under the License.
 package composite
 
 import (
+	"bytes"
 	"context"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
@@ -42,6 +45,13 @@ const (
 	errFmtConnDetailKey  = "connection detail of type %q key is not set"
 	errFmtConnDetailVal  = "connection detail of type %q value is not set"
 	errFmtConnDetailPath = "connection detail of type %q fromFieldPath is not set"
+	errFmtConnDetailAnno = "connection detail of type %q fromAnnotation is not set"
+
+	errFmtConnDetailRequiredPath = "cannot extract connection detail %q from required field path %q"
+	errFmtConnDetailRequiredAnno = "cannot extract connection detail %q: composed resource has no required annotation %q"
+	errFmtConnDetailTransform    = "cannot transform connection detail %q"
+	errFmtConnDetailConflict     = "connection detail %q exposed by composed resource %q conflicts with the value exposed by composed resource %q"
+	errFmtConnDetailConflictXR   = "connection detail %q exposed by composed resource %q conflicts with the value derived from the composite resource"
 )
 
 // A ConnectionDetailsFetcherFn fetches the connection details of the supplied
@@ -88,12 +98,18 @@ func NewSecretConnectionDetailsFetcher(c client.Client) *SecretConnectionDetails
 func (cdf *SecretConnectionDetailsFetcher) FetchConnection(ctx context.Context, o resource.ConnectionSecretOwner) (managed.ConnectionDetails, error) {
 	sref := o.GetWriteConnectionSecretToReference()
 	if sref == nil {
-		// secret but has not yet. We presume this isn't an issue and that we'll
-		// propagate any connection details during a future iteration.
+		// Either this resource does not write a connection secret (which would
+		// be typical) or it is supposed to write a connection secret but has
+		// not yet. We presume this isn't an issue and that we'll propagate any
+		// connection details during a future iteration.
 		return nil, nil
 	}
 	s := &corev1.Secret{}
 	nn := types.NamespacedName{Namespace: sref.Namespace, Name: sref.Name}
+
+	// The secret won't exist until the composed resource has written it,
+	// which is typical early in its lifecycle. It has no connection details
+	// yet.
 	if err := cdf.client.Get(ctx, nn, s); client.IgnoreNotFound(err) != nil {
 		return nil, errors.Wrap(err, errGetSecret)
 	}
@@ -195,42 +211,181 @@ func (fn ConnectionDetailsExtractorFn) ExtractConnection(cd resource.Composed, c
 
 // ExtractConnectionDetails extracts XR connection details from the supplied
 // composed resource. If no ExtractConfigs are supplied no connection details
-// will be returned.
-func ExtractConnectionDetails(cd resource.Composed, data managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) { //nolint:gocyclo // TODO(negz): Break extraction out from validation, like we do with readiness.
+// will be returned. Optional connection details that can't be extracted are
+// omitted, rather than returning an error.
+func ExtractConnectionDetails(cd resource.Composed, data managed.ConnectionDetails, cfg ...ConnectionDetailExtractConfig) (managed.ConnectionDetails, error) {
 	out := map[string][]byte{}
 	for _, cfg := range cfg {
-		if cfg.Name == "" {
-			return nil, errors.Errorf(errConnDetailName)
-		}
-		switch tp := cfg.Type; tp {
-		case ConnectionDetailTypeFromValue:
-			if cfg.Value == nil {
-				return nil, errors.Errorf(errFmtConnDetailVal, tp)
-			}
-			out[cfg.Name] = []byte(*cfg.Value)
-		case ConnectionDetailTypeFromConnectionSecretKey:
-			if cfg.FromConnectionSecretKey == nil {
-				return nil, errors.Errorf(errFmtConnDetailKey, tp)
-			}
-			if data[*cfg.FromConnectionSecretKey] == nil {
-				// We don't consider this an error because it's possible the
-				// key will still be written at some point in the future.
+		v, ok, err := extractConnectionDetail(cd, data, cfg)
+		if err != nil {
+			if cfg.Optional {
 				continue
 			}
-			out[cfg.Name] = data[*cfg.FromConnectionSecretKey]
-		case ConnectionDetailTypeFromFieldPath:
-			if cfg.FromFieldPath == nil {
-				return nil, errors.Errorf(errFmtConnDetailPath, tp)
+			return nil, err
+		}
+		if ok {
+			out[cfg.Name] = v
+		}
+	}
+	return out, nil
+}
+
+// extractConnectionDetail extracts the supplied connection detail from the
+// supplied composed resource. It returns false if the connection detail
+// doesn't exist (yet).
+func extractConnectionDetail(cd resource.Composed, data managed.ConnectionDetails, cfg ConnectionDetailExtractConfig) ([]byte, bool, error) { //nolint:gocyclo // TODO(negz): Break extraction out from validation, like we do with readiness.
+	if cfg.Name == "" {
+		return nil, false, errors.Errorf(errConnDetailName)
+	}
+	var v []byte
+	switch tp := cfg.Type; tp {
+	case ConnectionDetailTypeFromValue:
+		if cfg.Value == nil {
+			return nil, false, errors.Errorf(errFmtConnDetailVal, tp)
+		}
+		v = []byte(*cfg.Value)
+	case ConnectionDetailTypeFromConnectionSecretKey:
+		if cfg.FromConnectionSecretKey == nil {
+			return nil, false, errors.Errorf(errFmtConnDetailKey, tp)
+		}
+		if data[*cfg.FromConnectionSecretKey] == nil {
+			// We don't consider this an error because it's possible the
+			// key will still be written at some point in the future.
+			return nil, false, nil
+		}
+		v = data[*cfg.FromConnectionSecretKey]
+	case ConnectionDetailTypeFromFieldPath:
+		if cfg.FromFieldPath == nil {
+			return nil, false, errors.Errorf(errFmtConnDetailPath, tp)
+		}
+		// Unless the field path is required we silently avoid including
+		// this connection secret if we hit an error. It's possible the
+		// path will start existing with a valid value in future.
+		b, err := fromFieldPath(cd, *cfg.FromFieldPath)
+		if err != nil {
+			if cfg.Required {
+				return nil, false, errors.Wrapf(err, errFmtConnDetailRequiredPath, cfg.Name, *cfg.FromFieldPath)
 			}
-			// Note we're checking that the error _is_ nil. If we hit an error
-			// we silently avoid including this connection secret. It's possible
-			// the path will start existing with a valid value in future.
-			if b, err := fromFieldPath(cd, *cfg.FromFieldPath); err == nil {
-				out[cfg.Name] = b
+			return nil, false, nil
+		}
+		v = b
+	case ConnectionDetailTypeFromAnnotation:
+		if cfg.FromAnnotation == nil {
+			return nil, false, errors.Errorf(errFmtConnDetailAnno, tp)
+		}
+		a, ok := cd.GetAnnotations()[*cfg.FromAnnotation]
+		if !ok {
+			// Like a field path, it's possible the annotation will be
+			// set at some point in the future.
+			if cfg.Required {
+				return nil, false, errors.Errorf(errFmtConnDetailRequiredAnno, cfg.Name, *cfg.FromAnnotation)
 			}
+			return nil, false, nil
 		}
+		v = []byte(a)
+	default:
+		return nil, false, nil
 	}
-	return out, nil
+
+	if len(cfg.Transforms) == 0 {
+		return v, true, nil
+	}
+	t, err := transformConnectionDetail(v, cfg.Transforms)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, errFmtConnDetailTransform, cfg.Name)
+	}
+	return t, true, nil
+}
+
+// transformConnectionDetail applies the supplied transforms to the supplied
+// connection detail value, in order. The value is transformed as a string.
+// Transformed values that aren't strings are returned as JSON.
+func transformConnectionDetail(v []byte, ts []v1.Transform) ([]byte, error) {
+	var out any = string(v)
+	for i, t := range ts {
+		var err error
+		if out, err = Resolve(t, out); err != nil {
+			return nil, errors.Wrapf(err, errFmtTransformAtIndex, i)
+		}
+	}
+	if s, ok := out.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(out)
+}
+
+// A ConnectionDetailConflictPolicy determines how connection details exposed
+// by more than one composed resource are merged.
+type ConnectionDetailConflictPolicy string
+
+const (
+	// ConnectionDetailConflictPolicyLastWins uses the value exposed by the
+	// last composed resource, in template order.
+	ConnectionDetailConflictPolicyLastWins ConnectionDetailConflictPolicy = "LastWins"
+
+	// ConnectionDetailConflictPolicyFail returns an error if composed
+	// resources expose different values for the same connection detail.
+	ConnectionDetailConflictPolicyFail ConnectionDetailConflictPolicy = "Fail"
+)
+
+// prefixConnectionDetails returns the supplied connection details with the
+// supplied prefix prepended to each key.
+func prefixConnectionDetails(e managed.ConnectionDetails, prefix string) managed.ConnectionDetails {
+	if prefix == "" || e == nil {
+		return e
+	}
+	out := make(managed.ConnectionDetails, len(e))
+	for k, v := range e {
+		out[prefix+k] = v
+	}
+	return out
+}
+
+// mergeConnectionDetails merges the connection details extracted from the named
+// composed resource into the supplied connection details, according to the
+// supplied conflict policy. The exposedBy map records which composed resource
+// exposed each connection detail, and is updated as details are merged.
+func mergeConnectionDetails(conn managed.ConnectionDetails, exposedBy map[string]string, name string, e managed.ConnectionDetails, p ConnectionDetailConflictPolicy) error {
+	// Iterate in key order so we return the same error for the same conflicts.
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if existing, ok := conn[k]; ok && p == ConnectionDetailConflictPolicyFail && !bytes.Equal(existing, e[k]) {
+			return errors.Errorf(errFmtConnDetailConflict, k, name, exposedBy[k])
+		}
+		conn[k] = e[k]
+		exposedBy[k] = name
+	}
+	return nil
+}
+
+// mergeCompositeConnectionDetails merges the connection details derived from
+// the composite resource into the supplied connection details, which were
+// exposed by composed resources. Connection details exposed by composed
+// resources take precedence, unless the supplied conflict policy requires
+// their values to be identical.
+func mergeCompositeConnectionDetails(conn managed.ConnectionDetails, exposedBy map[string]string, e managed.ConnectionDetails, p ConnectionDetailConflictPolicy) error {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		existing, ok := conn[k]
+		if !ok {
+			conn[k] = e[k]
+			continue
+		}
+		if p == ConnectionDetailConflictPolicyFail && !bytes.Equal(existing, e[k]) {
+			return errors.Errorf(errFmtConnDetailConflictXR, k, exposedBy[k])
+		}
+	}
+	return nil
 }
 
 // A ConnectionDetailType is a type of connection detail.
@@ -241,6 +396,7 @@ const (
 	ConnectionDetailTypeFromConnectionSecretKey ConnectionDetailType = "FromConnectionSecretKey"
 	ConnectionDetailTypeFromFieldPath           ConnectionDetailType = "FromFieldPath"
 	ConnectionDetailTypeFromValue               ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeFromAnnotation          ConnectionDetailType = "FromAnnotation"
 )
 
 // A ConnectionDetailExtractConfig configures how an XR connection detail should
@@ -264,6 +420,23 @@ type ConnectionDetailExtractConfig struct {
 	// FromFieldPath is specified.
 	FromFieldPath *string
 
+	// FromAnnotation is the key of the annotation on the composed resource
+	// whose value to be used as input. Name must be specified if the type is
+	// FromAnnotation.
+	FromAnnotation *string
+
+	// Required causes extraction to fail if the field path or annotation of
+	// a FromFieldPath or FromAnnotation connection detail does not exist,
+	// rather than omitting the connection detail.
+	Required bool
+
+	// Optional causes the connection detail to be omitted, rather than
+	// failing extraction, if it can't be extracted or transformed.
+	Optional bool
+
+	// Transforms are applied, in order, to the extracted value.
+	Transforms []v1.Transform
+
 	// Value that will be propagated to the connection secret of the composition
 	// instance. Typically you should use FromConnectionSecretKey instead, but
 	// an explicit value may be set to inject a fixed, non-sensitive connection
@@ -277,17 +450,30 @@ func ExtractConfigsFromTemplate(t *v1.ComposedTemplate) []ConnectionDetailExtrac
 	if t == nil {
 		return nil
 	}
-	out := make([]ConnectionDetailExtractConfig, len(t.ConnectionDetails))
-	for i := range t.ConnectionDetails {
+	return ExtractConfigsFromConnectionDetails(t.ConnectionDetails)
+}
+
+// ExtractConfigsFromConnectionDetails builds extract configs for the supplied
+// P&T style connection details.
+func ExtractConfigsFromConnectionDetails(cds []v1.ConnectionDetail) []ConnectionDetailExtractConfig {
+	if len(cds) == 0 {
+		return nil
+	}
+	out := make([]ConnectionDetailExtractConfig, len(cds))
+	for i := range cds {
 		out[i] = ConnectionDetailExtractConfig{
-			Type:                    connectionDetailType(t.ConnectionDetails[i]),
-			Value:                   t.ConnectionDetails[i].Value,
-			FromConnectionSecretKey: t.ConnectionDetails[i].FromConnectionSecretKey,
-			FromFieldPath:           t.ConnectionDetails[i].FromFieldPath,
+			Type:                    connectionDetailType(cds[i]),
+			Value:                   cds[i].Value,
+			FromConnectionSecretKey: cds[i].FromConnectionSecretKey,
+			FromFieldPath:           cds[i].FromFieldPath,
+			FromAnnotation:          cds[i].FromAnnotation,
+			Required:                cds[i].Policy != nil && *cds[i].Policy == v1.FromFieldPathPolicyRequired,
+			Optional:                pointer.BoolDeref(cds[i].Optional, false),
+			Transforms:              cds[i].Transforms,
 		}
 
-		if t.ConnectionDetails[i].Name != nil {
-			out[i].Name = *t.ConnectionDetails[i].Name
+		if cds[i].Name != nil {
+			out[i].Name = *cds[i].Name
 			continue
 		}
 
@@ -311,6 +497,7 @@ func ExtractConfigsFromDesired(dr *iov1alpha1.DesiredResource) []ConnectionDetai
 			Value:                   dr.ConnectionDetails[i].Value,
 			FromConnectionSecretKey: dr.ConnectionDetails[i].FromConnectionSecretKey,
 			FromFieldPath:           dr.ConnectionDetails[i].FromFieldPath,
+			FromAnnotation:          dr.ConnectionDetails[i].FromAnnotation,
 		}
 
 		if dr.ConnectionDetails[i].Name != nil {
@@ -338,6 +525,8 @@ func connectionDetailType(d v1.ConnectionDetail) ConnectionDetailType {
 		return ConnectionDetailTypeFromConnectionSecretKey
 	case d.FromFieldPath != nil:
 		return ConnectionDetailTypeFromFieldPath
+	case d.FromAnnotation != nil:
+		return ConnectionDetailTypeFromAnnotation
 	default:
 		// If nothing was specified, assume FromConnectionSecretKey, which was
 		// the only value we originally supported. We don't have enough
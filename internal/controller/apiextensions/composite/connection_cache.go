@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// DefaultConnectionDetailsCacheTTL is the default time for which fetched
+// connection details are cached.
+const DefaultConnectionDetailsCacheTTL = 5 * time.Second
+
+// A CachingConnectionDetailsFetcher caches the connection details fetched by
+// another ConnectionDetailsFetcher, keyed by the connection secret they were
+// fetched from. Concurrent fetches of the same connection secret are collapsed
+// into one. Errors are never cached. It is safe for concurrent use.
+type CachingConnectionDetailsFetcher struct {
+	wrapped managed.ConnectionDetailsFetcher
+	ttl     time.Duration
+	now     func() time.Time
+
+	flight singleflight.Group
+
+	mu        sync.Mutex
+	entries   map[string]connectionDetailsCacheEntry
+	lastSweep time.Time
+}
+
+type connectionDetailsCacheEntry struct {
+	conn    managed.ConnectionDetails
+	expires time.Time
+}
+
+// NewCachingConnectionDetailsFetcher returns a ConnectionDetailsFetcher that
+// caches the connection details fetched by the supplied fetcher for the
+// supplied duration.
+func NewCachingConnectionDetailsFetcher(wrapped managed.ConnectionDetailsFetcher, ttl time.Duration) *CachingConnectionDetailsFetcher {
+	return &CachingConnectionDetailsFetcher{
+		wrapped: wrapped,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]connectionDetailsCacheEntry),
+	}
+}
+
+// FetchConnection details of the supplied resource. Details are fetched from
+// the cache unless they aren't cached or have expired. Resources that don't
+// write a connection secret can't be cached, and are always fetched.
+func (f *CachingConnectionDetailsFetcher) FetchConnection(ctx context.Context, o resource.ConnectionSecretOwner) (managed.ConnectionDetails, error) {
+	sref := o.GetWriteConnectionSecretToReference()
+	if sref == nil {
+		return f.wrapped.FetchConnection(ctx, o)
+	}
+	key := sref.Namespace + "/" + sref.Name
+
+	if conn, ok := f.get(key); ok {
+		return conn, nil
+	}
+
+	v, err, _ := f.flight.Do(key, func() (any, error) {
+		conn, err := f.wrapped.FetchConnection(ctx, o)
+		if err != nil {
+			return nil, err
+		}
+		f.set(key, conn)
+		return conn, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyConnectionDetails(v.(managed.ConnectionDetails)), nil
+}
+
+func (f *CachingConnectionDetailsFetcher) get(key string) (managed.ConnectionDetails, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, ok := f.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !f.now().Before(e.expires) {
+		delete(f.entries, key)
+		return nil, false
+	}
+	return copyConnectionDetails(e.conn), true
+}
+
+func (f *CachingConnectionDetailsFetcher) set(key string, conn managed.ConnectionDetails) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+
+	// Evict expired entries at most once per TTL, so that the cache doesn't
+	// grow without bound as connection secrets come and go.
+	if now.Sub(f.lastSweep) >= f.ttl {
+		for k, e := range f.entries {
+			if !now.Before(e.expires) {
+				delete(f.entries, k)
+			}
+		}
+		f.lastSweep = now
+	}
+
+	f.entries[key] = connectionDetailsCacheEntry{conn: copyConnectionDetails(conn), expires: now.Add(f.ttl)}
+}
+
+// copyConnectionDetails returns a copy of the supplied connection details, so
+// that callers can't modify cached details.
+func copyConnectionDetails(conn managed.ConnectionDetails) managed.ConnectionDetails {
+	if conn == nil {
+		return nil
+	}
+	out := make(managed.ConnectionDetails, len(conn))
+	for k, v := range conn {
+		out[k] = append([]byte(nil), v...)
+	}
+	return out
+}
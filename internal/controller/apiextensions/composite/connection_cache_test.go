@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestCachingConnectionDetailsFetcher(t *testing.T) {
+	errBoom := errors.New("boom")
+	details := managed.ConnectionDetails{"password": []byte("hunter2")}
+
+	withSecret := &fake.Composed{
+		ConnectionSecretWriterTo: fake.ConnectionSecretWriterTo{
+			Ref: &xpv1.SecretReference{Namespace: "cool-ns", Name: "cool-secret"},
+		},
+	}
+
+	// A fetch happens after the supplied time has elapsed since the
+	// previous fetch.
+	type fetch struct {
+		after time.Duration
+		fail  bool
+	}
+	type want struct {
+		conn    managed.ConnectionDetails
+		err     error
+		fetches int
+	}
+	cases := map[string]struct {
+		reason  string
+		o       resource.ConnectionSecretOwner
+		fetches []fetch
+		want    want
+	}{
+		"Hit": {
+			reason:  "We should only fetch connection details once while they are cached.",
+			o:       withSecret,
+			fetches: []fetch{{}, {after: time.Second}, {after: time.Second}},
+			want:    want{conn: details, fetches: 1},
+		},
+		"Expired": {
+			reason:  "We should fetch connection details again once they have expired.",
+			o:       withSecret,
+			fetches: []fetch{{}, {after: DefaultConnectionDetailsCacheTTL}, {after: time.Second}},
+			want:    want{conn: details, fetches: 2},
+		},
+		"ErrorNotCached": {
+			reason:  "We should not cache errors encountered fetching connection details.",
+			o:       withSecret,
+			fetches: []fetch{{fail: true}, {after: time.Second}},
+			want:    want{conn: details, fetches: 2},
+		},
+		"Error": {
+			reason:  "We should return errors encountered fetching connection details.",
+			o:       withSecret,
+			fetches: []fetch{{}, {after: DefaultConnectionDetailsCacheTTL, fail: true}},
+			want:    want{err: errBoom, fetches: 2},
+		},
+		"NoConnectionSecret": {
+			reason:  "We should always fetch the connection details of a resource that doesn't write a connection secret.",
+			o:       &fake.Composed{},
+			fetches: []fetch{{}, {after: time.Second}},
+			want:    want{conn: details, fetches: 2},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fetches := 0
+			fail := false
+			inner := ConnectionDetailsFetcherFn(func(_ context.Context, _ resource.ConnectionSecretOwner) (managed.ConnectionDetails, error) {
+				fetches++
+				if fail {
+					return nil, errBoom
+				}
+				return managed.ConnectionDetails{"password": []byte("hunter2")}, nil
+			})
+
+			now := time.Now()
+			f := NewCachingConnectionDetailsFetcher(inner, DefaultConnectionDetailsCacheTTL)
+			f.now = func() time.Time { return now }
+
+			var conn managed.ConnectionDetails
+			var err error
+			for _, fe := range tc.fetches {
+				now = now.Add(fe.after)
+				fail = fe.fail
+				conn, err = f.FetchConnection(context.Background(), tc.o)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFetchConnection(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.conn, conn); diff != "" {
+				t.Errorf("\n%s\nFetchConnection(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.fetches, fetches); diff != "" {
+				t.Errorf("\n%s\nFetchConnection(...): -want fetches, +got fetches:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -31,9 +31,11 @@ import (
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	iov1alpha1 "github.com/crossplane/crossplane/apis/apiextensions/fn/io/v1alpha1"
@@ -246,6 +248,19 @@ func TestConnectionDetailsFetcherChain(t *testing.T) {
 
 func TestExtractConnectionDetails(t *testing.T) {
 	// errBoom := errors.New("boom")
+	frombase64 := v1.StringConversionTypeFromBase64
+	fromBase64 := v1.Transform{
+		Type: v1.TransformTypeString,
+		String: &v1.StringTransform{
+			Type:    v1.StringTransformTypeConvert,
+			Convert: &frombase64,
+		},
+	}
+	toInt64 := v1.Transform{
+		Type:    v1.TransformTypeConvert,
+		Convert: &v1.ConvertTransform{ToType: v1.TransformIOTypeInt64},
+	}
+	_, errToInt64 := Resolve(toInt64, "http")
 
 	type args struct {
 		cd   resource.Composed
@@ -317,6 +332,138 @@ func TestExtractConnectionDetails(t *testing.T) {
 				err: errors.Errorf(errFmtConnDetailPath, v1.ConnectionDetailTypeFromFieldPath),
 			},
 		},
+		"MissingAnnotationError": {
+			reason: "We should return an error if the annotation is missing.",
+			args: args{
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Name: "cool-detail",
+						Type: ConnectionDetailTypeFromAnnotation,
+					},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtConnDetailAnno, ConnectionDetailTypeFromAnnotation),
+			},
+		},
+		"FromAnnotation": {
+			reason: "We should extract the value of the annotation.",
+			args: args{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{"example.org/endpoint": "https://example.org"},
+					},
+				},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:           ConnectionDetailTypeFromAnnotation,
+						Name:           "endpoint",
+						FromAnnotation: pointer.String("example.org/endpoint"),
+						Required:       true,
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"endpoint": []byte("https://example.org"),
+				},
+			},
+		},
+		"FromAnnotationOptionalMissing": {
+			reason: "We should omit a connection detail if its optional annotation does not exist.",
+			args: args{
+				cd: &fake.Composed{},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:           ConnectionDetailTypeFromAnnotation,
+						Name:           "endpoint",
+						FromAnnotation: pointer.String("example.org/endpoint"),
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{},
+			},
+		},
+		"FromAnnotationRequiredMissing": {
+			reason: "We should return an error if a required annotation does not exist.",
+			args: args{
+				cd: &fake.Composed{},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:           ConnectionDetailTypeFromAnnotation,
+						Name:           "endpoint",
+						FromAnnotation: pointer.String("example.org/endpoint"),
+						Required:       true,
+					},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtConnDetailRequiredAnno, "endpoint", "example.org/endpoint"),
+			},
+		},
+		"FromFieldPathStatus": {
+			reason: "We should extract a connection detail from the composed resource's status, rather than its connection secret.",
+			args: args{
+				cd: func() resource.Composed {
+					cd := composed.New()
+					_ = fieldpath.Pave(cd.UnstructuredContent()).SetValue("status.atProvider.endpoint", "example.org")
+					return cd
+				}(),
+				data: managed.ConnectionDetails{
+					"status.atProvider.endpoint": []byte("secret.example.org"),
+				},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:          ConnectionDetailTypeFromFieldPath,
+						Name:          "endpoint",
+						FromFieldPath: pointer.String("status.atProvider.endpoint"),
+						Required:      true,
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"endpoint": []byte("example.org"),
+				},
+			},
+		},
+		"FromFieldPathOptionalMissing": {
+			reason: "We should omit a connection detail if its optional field path does not exist.",
+			args: args{
+				cd: &fake.Composed{},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:          ConnectionDetailTypeFromFieldPath,
+						Name:          "endpoint",
+						FromFieldPath: pointer.String("status.endpoint"),
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{},
+			},
+		},
+		"FromFieldPathRequiredMissing": {
+			reason: "We should return an error if a required field path does not exist.",
+			args: args{
+				cd: &fake.Composed{},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:          ConnectionDetailTypeFromFieldPath,
+						Name:          "endpoint",
+						FromFieldPath: pointer.String("status.endpoint"),
+						Required:      true,
+					},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(func() error {
+					_, err := fromFieldPath(&fake.Composed{}, "status.endpoint")
+					return err
+				}(), errFmtConnDetailRequiredPath, "endpoint", "status.endpoint"),
+			},
+		},
 		"FetchConfigSuccess": {
 			reason: "Should extract only the selected set of secret keys",
 			args: args{
@@ -373,6 +520,120 @@ func TestExtractConnectionDetails(t *testing.T) {
 				},
 			},
 		},
+		"TransformedValue": {
+			reason: "We should apply a connection detail's transforms to its extracted value, propagating values that aren't strings as JSON.",
+			args: args{
+				data: managed.ConnectionDetails{
+					"port": []byte("ODA4MA=="),
+				},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:                    ConnectionDetailTypeFromConnectionSecretKey,
+						Name:                    "port",
+						FromConnectionSecretKey: pointer.String("port"),
+						Transforms:              []v1.Transform{fromBase64, toInt64},
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"port": []byte("8080"),
+				},
+			},
+		},
+		"TransformedMissingValue": {
+			reason: "We should not transform a connection detail that could not be extracted.",
+			args: args{
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:                    ConnectionDetailTypeFromConnectionSecretKey,
+						Name:                    "port",
+						FromConnectionSecretKey: pointer.String("port"),
+						Transforms:              []v1.Transform{fromBase64, toInt64},
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{},
+			},
+		},
+		"TransformError": {
+			reason: "We should return an error if a connection detail's transforms fail.",
+			args: args{
+				data: managed.ConnectionDetails{
+					"port": []byte("http"),
+				},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:                    ConnectionDetailTypeFromConnectionSecretKey,
+						Name:                    "port",
+						FromConnectionSecretKey: pointer.String("port"),
+						Transforms:              []v1.Transform{toInt64},
+					},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrapf(errToInt64, errFmtTransformAtIndex, 0), errFmtConnDetailTransform, "port"),
+			},
+		},
+		"OptionalTransformError": {
+			reason: "We should omit an optional connection detail whose transforms fail, while still extracting required connection details.",
+			args: args{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{"example.org/endpoint": "example.org"},
+					},
+				},
+				data: managed.ConnectionDetails{
+					"port": []byte("http"),
+				},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:                    ConnectionDetailTypeFromConnectionSecretKey,
+						Name:                    "port",
+						FromConnectionSecretKey: pointer.String("port"),
+						Optional:                true,
+						Transforms:              []v1.Transform{toInt64},
+					},
+					{
+						Type:           ConnectionDetailTypeFromAnnotation,
+						Name:           "endpoint",
+						FromAnnotation: pointer.String("example.org/endpoint"),
+						Required:       true,
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"endpoint": []byte("example.org"),
+				},
+			},
+		},
+		"OptionalRequiredMissing": {
+			reason: "We should omit an optional connection detail whose required field path does not exist, while still extracting other connection details.",
+			args: args{
+				cd: &fake.Composed{},
+				cfg: []ConnectionDetailExtractConfig{
+					{
+						Type:          ConnectionDetailTypeFromFieldPath,
+						Name:          "endpoint",
+						FromFieldPath: pointer.String("status.endpoint"),
+						Required:      true,
+						Optional:      true,
+					},
+					{
+						Type:  ConnectionDetailTypeFromValue,
+						Name:  "port",
+						Value: pointer.String("5432"),
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"port": []byte("5432"),
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -391,6 +652,7 @@ func TestExtractConnectionDetails(t *testing.T) {
 
 func TestExtractConfigsFromTemplate(t *testing.T) {
 	tfk := v1.ConnectionDetailTypeFromConnectionSecretKey
+	required := v1.FromFieldPathPolicyRequired
 
 	type args struct {
 		t *v1.ComposedTemplate
@@ -450,6 +712,66 @@ func TestExtractConfigsFromTemplate(t *testing.T) {
 				}},
 			},
 		},
+		"RequiredPolicy": {
+			reason: "When a template's connection detail has a Required policy, its extract config should be required.",
+			args: args{
+				t: &v1.ComposedTemplate{
+					ConnectionDetails: []v1.ConnectionDetail{{
+						Name:           pointer.String("cool-detail"),
+						FromAnnotation: pointer.String("cool-annotation"),
+						Policy:         &required,
+					}},
+				},
+			},
+			want: want{
+				cfgs: []ConnectionDetailExtractConfig{{
+					Name:           "cool-detail",
+					Type:           ConnectionDetailTypeFromAnnotation,
+					FromAnnotation: pointer.String("cool-annotation"),
+					Required:       true,
+				}},
+			},
+		},
+		"Optional": {
+			reason: "An optional connection detail should result in an optional extract config.",
+			args: args{
+				t: &v1.ComposedTemplate{
+					ConnectionDetails: []v1.ConnectionDetail{{
+						Name:          pointer.String("cool-detail"),
+						FromFieldPath: pointer.String("status.coolness"),
+						Optional:      pointer.Bool(true),
+					}},
+				},
+			},
+			want: want{
+				cfgs: []ConnectionDetailExtractConfig{{
+					Name:          "cool-detail",
+					Type:          ConnectionDetailTypeFromFieldPath,
+					FromFieldPath: pointer.String("status.coolness"),
+					Optional:      true,
+				}},
+			},
+		},
+		"Transforms": {
+			reason: "A template's connection detail transforms should be included in its extract config.",
+			args: args{
+				t: &v1.ComposedTemplate{
+					ConnectionDetails: []v1.ConnectionDetail{{
+						Name:                    pointer.String("cool-detail"),
+						FromConnectionSecretKey: pointer.String("cool-key"),
+						Transforms:              []v1.Transform{{Type: v1.TransformTypeConvert, Convert: &v1.ConvertTransform{ToType: v1.TransformIOTypeInt64}}},
+					}},
+				},
+			},
+			want: want{
+				cfgs: []ConnectionDetailExtractConfig{{
+					Name:                    "cool-detail",
+					Type:                    ConnectionDetailTypeFromConnectionSecretKey,
+					FromConnectionSecretKey: pointer.String("cool-key"),
+					Transforms:              []v1.Transform{{Type: v1.TransformTypeConvert, Convert: &v1.ConvertTransform{ToType: v1.TransformIOTypeInt64}}},
+				}},
+			},
+		},
 	}
 
 	for name, tc := range cases {
@@ -581,6 +903,13 @@ func TestConnectionDetailType(t *testing.T) {
 			},
 			want: ConnectionDetailTypeFromFieldPath,
 		},
+		"FromAnnotationInferred": {
+			d: v1.ConnectionDetail{
+				Name:           &name,
+				FromAnnotation: &key,
+			},
+			want: ConnectionDetailTypeFromAnnotation,
+		},
 		"DefaultToFromConnectionSecretKey": {
 			d: v1.ConnectionDetail{
 				Name: &name,
@@ -0,0 +1,255 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Error strings.
+const (
+	errDeleteComposed    = "cannot delete composed resource"
+	errUnorderedDeletion = "leaving composed resources to be garbage collected in no particular order"
+
+	errFmtDependencyCycle = "cannot order composed resource templates for deletion: templates %s are part of, or depended on by, a dependency cycle"
+)
+
+// DeletionOrder returns the names of the supplied templates in the order
+// their composed resources should be deleted. Each stage of the returned
+// order lists the templates whose composed resources may be deleted once the
+// composed resources of all earlier stages are gone; i.e. once nothing that
+// depends on them remains. Anonymous templates can't be depended on, and are
+// omitted. It returns an error if the templates' dependencies are cyclic.
+func DeletionOrder(ct []v1.ComposedTemplate) ([][]string, error) {
+	dependsOn := map[string][]string{}
+	dependents := map[string]int{}
+	for _, t := range ct {
+		if t.Name == nil {
+			continue
+		}
+		dependsOn[*t.Name] = nil
+		dependents[*t.Name] += 0
+	}
+	for _, t := range ct {
+		if t.Name == nil {
+			continue
+		}
+		for _, d := range t.DependsOn {
+			// Dependencies on templates that don't exist can't affect the
+			// order in which extant templates are deleted.
+			if _, ok := dependsOn[d]; !ok {
+				continue
+			}
+			dependsOn[*t.Name] = append(dependsOn[*t.Name], d)
+			dependents[d]++
+		}
+	}
+
+	stages := make([][]string, 0)
+	for len(dependents) > 0 {
+		stage := make([]string, 0)
+		for name, n := range dependents {
+			if n == 0 {
+				stage = append(stage, name)
+			}
+		}
+
+		// Everything that remains is depended on by something else that
+		// remains. There must be a cycle.
+		if len(stage) == 0 {
+			remaining := make([]string, 0, len(dependents))
+			for name := range dependents {
+				remaining = append(remaining, name)
+			}
+			sort.Strings(remaining)
+			return nil, errors.Errorf(errFmtDependencyCycle, strings.Join(remaining, ", "))
+		}
+
+		sort.Strings(stage)
+		for _, name := range stage {
+			delete(dependents, name)
+			for _, d := range dependsOn[name] {
+				dependents[d]--
+			}
+		}
+		stages = append(stages, stage)
+	}
+
+	return stages, nil
+}
+
+// A DeletionSequencer deletes the composed resources of a composite
+// resource that is being deleted.
+type DeletionSequencer interface {
+	// SequenceDeletion deletes the supplied composite resource's
+	// composed resources. It returns true once they're all gone.
+	SequenceDeletion(ctx context.Context, xr resource.Composite) (bool, error)
+}
+
+// A DeletionSequencerFn deletes the composed resources of a composite
+// resource that is being deleted.
+type DeletionSequencerFn func(ctx context.Context, xr resource.Composite) (bool, error)
+
+// SequenceDeletion deletes the supplied composite resource's composed
+// resources.
+func (fn DeletionSequencerFn) SequenceDeletion(ctx context.Context, xr resource.Composite) (bool, error) {
+	return fn(ctx, xr)
+}
+
+// A DependencyDeletionSequencer deletes composed resources in the order
+// declared by the dependencies of the templates they were created from,
+// deleting dependents before the composed resources they depend on.
+type DependencyDeletionSequencer struct {
+	client client.Client
+	log    logging.Logger
+	record event.Recorder
+}
+
+// A DependencyDeletionSequencerOption configures a
+// DependencyDeletionSequencer.
+type DependencyDeletionSequencerOption func(d *DependencyDeletionSequencer)
+
+// WithDeletionSequencerLogger configures the logger a
+// DependencyDeletionSequencer uses to report templates it can't order.
+func WithDeletionSequencerLogger(l logging.Logger) DependencyDeletionSequencerOption {
+	return func(d *DependencyDeletionSequencer) {
+		d.log = l
+	}
+}
+
+// WithDeletionSequencerRecorder configures the event recorder a
+// DependencyDeletionSequencer uses to warn that it can't order templates.
+func WithDeletionSequencerRecorder(er event.Recorder) DependencyDeletionSequencerOption {
+	return func(d *DependencyDeletionSequencer) {
+		d.record = er
+	}
+}
+
+// NewDependencyDeletionSequencer returns a DeletionSequencer that
+// deletes composed resources in dependency order.
+func NewDependencyDeletionSequencer(c client.Client, o ...DependencyDeletionSequencerOption) *DependencyDeletionSequencer {
+	d := &DependencyDeletionSequencer{client: c, log: logging.NewNopLogger(), record: event.NewNopRecorder()}
+	for _, fn := range o {
+		fn(d)
+	}
+	return d
+}
+
+// SequenceDeletion deletes the supplied composite resource's composed
+// resources in dependency order. Each call deletes the composed resources of
+// the earliest stage of the deletion order that still has composed
+// resources, and returns false until all of them are gone. Composed resources
+// are otherwise left to be garbage collected by Kubernetes, so it returns
+// true immediately if the composite resource's CompositionRevision doesn't
+// declare any dependencies, or no longer exists. Composition validation
+// rejects cyclic dependencies, but if a CompositionRevision nonetheless has
+// them it records a warning event on the composite resource and returns true,
+// rather than blocking deletion of the composite resource.
+func (d *DependencyDeletionSequencer) SequenceDeletion(ctx context.Context, xr resource.Composite) (bool, error) {
+	ref := xr.GetCompositionRevisionReference()
+	if ref == nil {
+		return true, nil
+	}
+	rev := &v1.CompositionRevision{}
+	if err := d.client.Get(ctx, types.NamespacedName{Name: ref.Name}, rev); err != nil {
+		return kerrors.IsNotFound(err), errors.Wrap(resource.IgnoreNotFound(err), errGetCompositionRevision)
+	}
+	if !hasDependencies(rev.Spec.Resources) {
+		return true, nil
+	}
+
+	order, err := DeletionOrder(rev.Spec.Resources)
+	if err != nil {
+		d.log.Info("Cannot delete composed resources in dependency order; leaving them to be garbage collected in no particular order", "composite", xr.GetName(), "revision", rev.GetName(), "error", err)
+		d.record.Event(xr, event.Warning(reasonDelete, errors.Wrap(err, errUnorderedDeletion)))
+		return true, nil
+	}
+	stage := map[string]int{}
+	for i, names := range order {
+		for _, name := range names {
+			stage[name] = i
+		}
+	}
+
+	// Composed resources that weren't created from a named template have no
+	// dependents, so they're deleted in the first stage.
+	existing := make([][]*composed.Unstructured, len(order))
+	for _, ref := range xr.GetResourceReferences() {
+		if ref.Name == "" {
+			continue
+		}
+		cd := composed.New(composed.FromReference(ref))
+		err := d.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, errors.Wrap(err, errGetComposed)
+		}
+
+		// We only delete composed resources we control.
+		if c := metav1.GetControllerOf(cd); c == nil || c.UID != xr.GetUID() {
+			continue
+		}
+		i := stage[GetCompositionResourceName(cd)]
+		existing[i] = append(existing[i], cd)
+	}
+
+	for _, cds := range existing {
+		if len(cds) == 0 {
+			continue
+		}
+		for _, cd := range cds {
+			if meta.WasDeleted(cd) {
+				continue
+			}
+			if err := d.client.Delete(ctx, cd); resource.IgnoreNotFound(err) != nil {
+				return false, errors.Wrap(err, errDeleteComposed)
+			}
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// hasDependencies returns true if any of the supplied templates depends on
+// another.
+func hasDependencies(ct []v1.ComposedTemplate) bool {
+	for _, t := range ct {
+		if len(t.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestDeletionOrder(t *testing.T) {
+	type want struct {
+		order [][]string
+		err   error
+	}
+
+	cases := map[string]struct {
+		reason string
+		ct     []v1.ComposedTemplate
+		want   want
+	}{
+		"NoDependencies": {
+			reason: "Templates without dependencies may all be deleted at once.",
+			ct: []v1.ComposedTemplate{
+				{Name: pointer.String("subnet")},
+				{Name: pointer.String("database")},
+			},
+			want: want{
+				order: [][]string{{"database", "subnet"}},
+			},
+		},
+		"TwoNodes": {
+			reason: "A template should be deleted before the template it depends on.",
+			ct: []v1.ComposedTemplate{
+				{Name: pointer.String("subnet")},
+				{Name: pointer.String("database"), DependsOn: []string{"subnet"}},
+			},
+			want: want{
+				order: [][]string{{"database"}, {"subnet"}},
+			},
+		},
+		"AnonymousAndUnknown": {
+			reason: "Anonymous templates and dependencies on templates that don't exist should be ignored.",
+			ct: []v1.ComposedTemplate{
+				{Name: nil},
+				{Name: pointer.String("database"), DependsOn: []string{"network"}},
+			},
+			want: want{
+				order: [][]string{{"database"}},
+			},
+		},
+		"Cycle": {
+			reason: "We should return an error if templates depend on each other.",
+			ct: []v1.ComposedTemplate{
+				{Name: pointer.String("subnet"), DependsOn: []string{"database"}},
+				{Name: pointer.String("database"), DependsOn: []string{"subnet"}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtDependencyCycle, "database, subnet"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			order, err := DeletionOrder(tc.ct)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nDeletionOrder(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.order, order); diff != "" {
+				t.Errorf("\n%s\nDeletionOrder(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDependencyDeletionSequencer(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	subnet := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Subnet", Name: "cool-subnet"}
+	database := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Database", Name: "cool-database"}
+
+	xr := func() *composite.Unstructured {
+		xr := composite.New()
+		xr.SetUID(types.UID("very-unique"))
+		xr.SetCompositionRevisionReference(&corev1.ObjectReference{Name: "cool-rev"})
+		xr.SetResourceReferences([]corev1.ObjectReference{subnet, database})
+		return xr
+	}
+
+	withTemplates := func(ct ...v1.ComposedTemplate) func(obj client.Object) error {
+		return func(obj client.Object) error {
+			switch o := obj.(type) {
+			case *v1.CompositionRevision:
+				o.Spec.Resources = ct
+			default:
+				SetCompositionResourceName(obj, map[string]string{subnet.Name: "subnet", database.Name: "database"}[obj.GetName()])
+				ctrl := true
+				obj.SetOwnerReferences([]metav1.OwnerReference{{Controller: &ctrl, UID: types.UID("very-unique")}})
+			}
+			return nil
+		}
+	}
+
+	ordered := []v1.ComposedTemplate{
+		{Name: pointer.String("subnet")},
+		{Name: pointer.String("database"), DependsOn: []string{"subnet"}},
+	}
+
+	type want struct {
+		deleted []string
+		events  []event.Event
+		done    bool
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		c      *test.MockClient
+		xr     resource.Composite
+		want   want
+	}{
+		"NoRevision": {
+			reason: "We should leave composed resources to be garbage collected if the XR never selected a revision.",
+			c:      &test.MockClient{},
+			xr:     composite.New(),
+			want: want{
+				done: true,
+			},
+		},
+		"RevisionNotFound": {
+			reason: "We should leave composed resources to be garbage collected if the XR's revision no longer exists.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "cool-rev")),
+			},
+			xr: xr(),
+			want: want{
+				done: true,
+			},
+		},
+		"GetRevisionError": {
+			reason: "We should return any error encountered getting the XR's revision.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(errBoom),
+			},
+			xr: xr(),
+			want: want{
+				err: errors.Wrap(errBoom, errGetCompositionRevision),
+			},
+		},
+		"NoDependencies": {
+			reason: "We should leave composed resources to be garbage collected if no template declares dependencies.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, withTemplates(v1.ComposedTemplate{Name: pointer.String("subnet")}, v1.ComposedTemplate{Name: pointer.String("database")})),
+			},
+			xr: xr(),
+			want: want{
+				done: true,
+			},
+		},
+		"DeleteDependentsFirst": {
+			reason: "We should delete a composed resource before the composed resource it depends on.",
+			c: &test.MockClient{
+				MockGet:    test.NewMockGetFn(nil, withTemplates(ordered...)),
+				MockDelete: test.NewMockDeleteFn(nil),
+			},
+			xr: xr(),
+			want: want{
+				deleted: []string{database.Name},
+			},
+		},
+		"DeleteDependencies": {
+			reason: "We should delete a composed resource once the composed resources that depend on it are gone.",
+			c: &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					if key.Name == database.Name {
+						return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+					}
+					return withTemplates(ordered...)(obj)
+				},
+				MockDelete: test.NewMockDeleteFn(nil),
+			},
+			xr: xr(),
+			want: want{
+				deleted: []string{subnet.Name},
+			},
+		},
+		"Deleted": {
+			reason: "We should return true once all composed resources are gone.",
+			c: &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					if key.Name != "cool-rev" {
+						return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+					}
+					return withTemplates(ordered...)(obj)
+				},
+			},
+			xr: xr(),
+			want: want{
+				done: true,
+			},
+		},
+		"Cycle": {
+			reason: "We should warn that we're leaving composed resources to be garbage collected in no particular order if templates depend on each other.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, withTemplates(
+					v1.ComposedTemplate{Name: pointer.String("subnet"), DependsOn: []string{"database"}},
+					v1.ComposedTemplate{Name: pointer.String("database"), DependsOn: []string{"subnet"}},
+				)),
+			},
+			xr: xr(),
+			want: want{
+				events: []event.Event{
+					event.Warning(reasonDelete, errors.Wrap(errors.Errorf(errFmtDependencyCycle, "database, subnet"), errUnorderedDeletion)),
+				},
+				done: true,
+			},
+		},
+		"DeleteError": {
+			reason: "We should return any error encountered deleting a composed resource.",
+			c: &test.MockClient{
+				MockGet:    test.NewMockGetFn(nil, withTemplates(ordered...)),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			xr: xr(),
+			want: want{
+				err: errors.Wrap(errBoom, errDeleteComposed),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var deleted []string
+			if tc.c.MockDelete != nil {
+				del := tc.c.MockDelete
+				tc.c.MockDelete = func(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+					deleted = append(deleted, obj.GetName())
+					return del(ctx, obj, opts...)
+				}
+			}
+
+			rec := &capturingRecorder{}
+			s := NewDependencyDeletionSequencer(tc.c, WithDeletionSequencerRecorder(rec))
+			done, err := s.SequenceDeletion(context.Background(), tc.xr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nSequenceDeletion(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.done, done); diff != "" {
+				t.Errorf("\n%s\nSequenceDeletion(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if tc.want.err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.deleted, deleted); diff != "" {
+				t.Errorf("\n%s\nSequenceDeletion(...): -want deleted, +got deleted:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.events, rec.events, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nSequenceDeletion(...): -want events, +got events:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// A capturingRecorder captures the events it records.
+type capturingRecorder struct {
+	events []event.Event
+}
+
+func (r *capturingRecorder) Event(_ runtime.Object, e event.Event) {
+	r.events = append(r.events, e)
+}
+
+func (r *capturingRecorder) WithAnnotations(_ ...string) event.Recorder {
+	return r
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+const (
+	errDriftCurrent = "cannot convert current composed resource to unstructured"
+	errDriftDesired = "cannot convert desired composed resource to unstructured"
+	errChecksum     = "cannot marshal desired composed resource"
+	errLastApplied  = "cannot marshal last applied configuration of composed resource"
+	errUnchanged    = "desired state is unchanged since it was last applied"
+)
+
+// serverManagedMetadata are the metadata fields that are set by the API
+// server, and thus never considered when detecting drift.
+var serverManagedMetadata = []string{
+	"resourceVersion",
+	"uid",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"managedFields",
+	"selfLink",
+}
+
+// StripServerManagedFields removes fields that are managed by the API server
+// (e.g. metadata.resourceVersion and status) from the supplied unstructured
+// object.
+func StripServerManagedFields(obj map[string]any) {
+	delete(obj, "status")
+	md, ok := obj["metadata"].(map[string]any)
+	if !ok {
+		return
+	}
+	for _, f := range serverManagedMetadata {
+		delete(md, f)
+	}
+}
+
+// HasDrifted returns true if applying the desired object to the current
+// object would change any field of the current object, ignoring fields that
+// are managed by the API server.
+func HasDrifted(current, desired runtime.Object) (bool, error) {
+	cm, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return false, errors.Wrap(err, errDriftCurrent)
+	}
+	dm, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return false, errors.Wrap(err, errDriftDesired)
+	}
+	StripServerManagedFields(cm)
+	StripServerManagedFields(dm)
+	return !containsFields(cm, dm), nil
+}
+
+// containsFields returns true if every field of the desired object is set to
+// the same value in the current object. Objects are compared field by field,
+// while all other values (including arrays) must be equal, mirroring the
+// semantics of a JSON merge patch.
+func containsFields(current, desired map[string]any) bool {
+	for k, dv := range desired {
+		cv, ok := current[k]
+		if !ok {
+			if dv == nil {
+				// A null in a merge patch deletes the field, which is a
+				// no-op if it doesn't exist.
+				continue
+			}
+			return false
+		}
+		dvm, dok := dv.(map[string]any)
+		cvm, cok := cv.(map[string]any)
+		if dok && cok {
+			if !containsFields(cvm, dvm) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(cv, dv) {
+			return false
+		}
+	}
+	return true
+}
+
+// detectDrift returns an ApplyOption that records whether the current object
+// has drifted from the desired object. ApplyOptions are not called when the
+// object is being created, so newly created objects are never considered to
+// have drifted.
+func detectDrift(drifted *bool) resource.ApplyOption {
+	return func(_ context.Context, current, desired runtime.Object) error {
+		d, err := HasDrifted(current, desired)
+		if err != nil {
+			return err
+		}
+		*drifted = d
+		return nil
+	}
+}
+
+// DesiredChecksum returns a checksum of the supplied desired object, ignoring
+// fields that are managed by the API server and any existing checksum or
+// last applied configuration annotation.
+func DesiredChecksum(desired runtime.Object) (string, error) {
+	dm, err := desiredFields(desired)
+	if err != nil {
+		return "", err
+	}
+
+	// Object keys are always marshaled in sorted order.
+	b, err := json.Marshal(dm)
+	if err != nil {
+		return "", errors.Wrap(err, errChecksum)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// LastAppliedConfiguration returns the supplied desired object as compact
+// JSON, ignoring fields that are managed by the API server and any existing
+// checksum or last applied configuration annotation.
+func LastAppliedConfiguration(desired runtime.Object) (string, error) {
+	dm, err := desiredFields(desired)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(dm)
+	if err != nil {
+		return "", errors.Wrap(err, errLastApplied)
+	}
+	return string(b), nil
+}
+
+// desiredFields returns the supplied desired object as unstructured data,
+// without fields that are managed by the API server or annotations that are
+// derived from the desired object itself.
+func desiredFields(desired runtime.Object) (map[string]any, error) {
+	dm, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, errors.Wrap(err, errDriftDesired)
+	}
+	dm = runtime.DeepCopyJSON(dm)
+	StripServerManagedFields(dm)
+	if md, ok := dm["metadata"].(map[string]any); ok {
+		if a, ok := md["annotations"].(map[string]any); ok {
+			delete(a, AnnotationKeyDesiredChecksum)
+			delete(a, AnnotationKeyLastAppliedConfiguration)
+			if len(a) == 0 {
+				delete(md, "annotations")
+			}
+		}
+	}
+	return dm, nil
+}
+
+// skipUnchanged returns an ApplyOption that prevents the current object from
+// being updated if it was last applied with the supplied desired checksum.
+// ApplyOptions are not called when the object is being created.
+func skipUnchanged(sum string) resource.ApplyOption {
+	return func(_ context.Context, current, _ runtime.Object) error {
+		if o, ok := current.(metav1.Object); ok && GetDesiredChecksum(o) == sum {
+			return resource.NewNotAllowed(errUnchanged)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestHasDrifted(t *testing.T) {
+	type args struct {
+		current map[string]any
+		desired map[string]any
+	}
+	type want struct {
+		drifted bool
+		err     error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoOp": {
+			reason: "An object should not be considered to have drifted if applying the desired state would not change it.",
+			args: args{
+				current: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "Composed",
+					"metadata": map[string]any{
+						"name":            "cool-composed",
+						"resourceVersion": "42",
+						"uid":             "some-uid",
+						"labels":          map[string]any{"cool": "very", "extra": "label"},
+					},
+					"spec": map[string]any{
+						"replicas":   int64(3),
+						"defaulted":  "by-the-api-server",
+						"containers": []any{map[string]any{"name": "c"}},
+					},
+					"status": map[string]any{"ready": true},
+				},
+				desired: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "Composed",
+					"metadata": map[string]any{
+						"name":   "cool-composed",
+						"labels": map[string]any{"cool": "very"},
+					},
+					"spec": map[string]any{
+						"replicas":   int64(3),
+						"containers": []any{map[string]any{"name": "c"}},
+					},
+				},
+			},
+			want: want{
+				drifted: false,
+			},
+		},
+		"IgnoreServerManagedFields": {
+			reason: "Fields managed by the API server should never be considered drift.",
+			args: args{
+				current: map[string]any{
+					"metadata": map[string]any{"resourceVersion": "42", "generation": int64(2)},
+					"status":   map[string]any{"ready": true},
+				},
+				desired: map[string]any{
+					"metadata": map[string]any{"resourceVersion": "41", "generation": int64(1)},
+					"status":   map[string]any{"ready": false},
+				},
+			},
+			want: want{
+				drifted: false,
+			},
+		},
+		"ChangedField": {
+			reason: "An object should be considered to have drifted if a desired field has a different value.",
+			args: args{
+				current: map[string]any{"spec": map[string]any{"replicas": int64(1)}},
+				desired: map[string]any{"spec": map[string]any{"replicas": int64(3)}},
+			},
+			want: want{
+				drifted: true,
+			},
+		},
+		"MissingField": {
+			reason: "An object should be considered to have drifted if a desired field is missing.",
+			args: args{
+				current: map[string]any{"spec": map[string]any{}},
+				desired: map[string]any{"spec": map[string]any{"replicas": int64(3)}},
+			},
+			want: want{
+				drifted: true,
+			},
+		},
+		"ChangedArray": {
+			reason: "Arrays are replaced by a merge patch, so any difference should be considered drift.",
+			args: args{
+				current: map[string]any{"spec": map[string]any{"containers": []any{"a", "b"}}},
+				desired: map[string]any{"spec": map[string]any{"containers": []any{"a"}}},
+			},
+			want: want{
+				drifted: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := HasDrifted(&unstructured.Unstructured{Object: tc.args.current}, &unstructured.Unstructured{Object: tc.args.desired})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nHasDrifted(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.drifted, got); diff != "" {
+				t.Errorf("\n%s\nHasDrifted(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDesiredChecksum(t *testing.T) {
+	desired := func() map[string]any {
+		return map[string]any{
+			"apiVersion": "example.org/v1",
+			"kind":       "Composed",
+			"metadata":   map[string]any{"name": "cool-composed"},
+			"spec":       map[string]any{"replicas": int64(3)},
+		}
+	}
+	sum := func(o map[string]any) string {
+		s, err := DesiredChecksum(&unstructured.Unstructured{Object: o})
+		if err != nil {
+			t.Fatalf("DesiredChecksum(...): %v", err)
+		}
+		return s
+	}
+
+	cases := map[string]struct {
+		reason  string
+		desired map[string]any
+		same    bool
+	}{
+		"Unchanged": {
+			reason:  "The checksum of identical desired state should be identical.",
+			desired: desired(),
+			same:    true,
+		},
+		"ServerManagedFields": {
+			reason: "Fields managed by the API server should not affect the checksum.",
+			desired: func() map[string]any {
+				o := desired()
+				o["metadata"].(map[string]any)["resourceVersion"] = "42"
+				o["status"] = map[string]any{"ready": true}
+				return o
+			}(),
+			same: true,
+		},
+		"ChecksumAnnotation": {
+			reason: "An existing checksum annotation should not affect the checksum.",
+			desired: func() map[string]any {
+				o := desired()
+				o["metadata"].(map[string]any)["annotations"] = map[string]any{AnnotationKeyDesiredChecksum: "old"}
+				return o
+			}(),
+			same: true,
+		},
+		"LastAppliedAnnotation": {
+			reason: "An existing last applied configuration annotation should not affect the checksum.",
+			desired: func() map[string]any {
+				o := desired()
+				o["metadata"].(map[string]any)["annotations"] = map[string]any{AnnotationKeyLastAppliedConfiguration: "{}"}
+				return o
+			}(),
+			same: true,
+		},
+		"SpecChanged": {
+			reason: "Changes to the desired spec should change the checksum.",
+			desired: func() map[string]any {
+				o := desired()
+				o["spec"] = map[string]any{"replicas": int64(4)}
+				return o
+			}(),
+			same: false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := sum(tc.desired) == sum(desired()); got != tc.same {
+				t.Errorf("\n%s\nDesiredChecksum(...): want same checksum %t, got %t", tc.reason, tc.same, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	errFmtGetConfigMap       = "cannot get ConfigMap %s/%s"
+	errFmtConfigMapNamespace = "cannot get ConfigMap %s/%s: ConfigMaps may only be read from namespace %q"
+)
+
+// A ConfigMapEnvironmentFetcher merges values from the ConfigMaps referenced by
+// a composition's environment configuration into the Environment fetched by
+// another EnvironmentFetcher. ConfigMaps are only read from a single namespace,
+// so that a Composition can't be used to read arbitrary ConfigMaps using
+// Crossplane's privileges.
+type ConfigMapEnvironmentFetcher struct {
+	wrapped   EnvironmentFetcher
+	kube      client.Reader
+	namespace string
+}
+
+// NewConfigMapEnvironmentFetcher returns an EnvironmentFetcher that merges
+// values from referenced ConfigMaps in the supplied namespace into the
+// Environment fetched by the supplied EnvironmentFetcher.
+func NewConfigMapEnvironmentFetcher(kube client.Reader, namespace string, wrapped EnvironmentFetcher) *ConfigMapEnvironmentFetcher {
+	return &ConfigMapEnvironmentFetcher{wrapped: wrapped, kube: kube, namespace: namespace}
+}
+
+// Fetch an Environment using the wrapped EnvironmentFetcher, then merge the
+// data of any referenced ConfigMaps into it. String values that are exactly
+// the formatted representation of a boolean or number are coerced to that
+// type. It returns an error if any
+// ConfigMap is referenced in a namespace other than the allowed one.
+func (f *ConfigMapEnvironmentFetcher) Fetch(ctx context.Context, req EnvironmentFetcherRequest) (*Environment, error) {
+	env, err := f.wrapped.Fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Revision == nil || req.Revision.Spec.Environment == nil || len(req.Revision.Spec.Environment.ConfigMaps) == 0 {
+		return env, nil
+	}
+
+	if env == nil {
+		env = &Environment{unstructured.Unstructured{Object: map[string]interface{}{}}}
+
+		// GVK is necessary for patching because it uses unstructured conversion
+		env.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   environmentGroup,
+			Version: environmentVersion,
+			Kind:    environmentKind,
+		})
+	}
+
+	for _, src := range req.Revision.Spec.Environment.ConfigMaps {
+		if src.Namespace != f.namespace {
+			return nil, errors.Errorf(errFmtConfigMapNamespace, src.Namespace, src.Name, f.namespace)
+		}
+		cm := &corev1.ConfigMap{}
+		if err := f.kube.Get(ctx, types.NamespacedName{Namespace: src.Namespace, Name: src.Name}, cm); err != nil {
+			if kerrors.IsNotFound(err) && src.IsOptional() {
+				continue
+			}
+			return nil, errors.Wrapf(err, errFmtGetConfigMap, src.Namespace, src.Name)
+		}
+		env.Object = mergeMaps(env.Object, configMapData(cm, src.Keys))
+	}
+
+	return env, nil
+}
+
+// configMapData returns the supplied keys of the ConfigMap's data, or all of
+// its data if no keys are supplied. Keys that don't exist are ignored.
+func configMapData(cm *corev1.ConfigMap, keys []string) map[string]interface{} {
+	out := make(map[string]interface{}, len(cm.Data))
+	if len(keys) == 0 {
+		for k, v := range cm.Data {
+			out[k] = coerceString(v)
+		}
+		return out
+	}
+	for _, k := range keys {
+		if v, ok := cm.Data[k]; ok {
+			out[k] = coerceString(v)
+		}
+	}
+	return out
+}
+
+// coerceString returns the supplied string as an int64, float64 or bool if it
+// can be parsed as one, or unchanged otherwise. Numbers are only coerced if
+// they format back to exactly the supplied string, so that values like
+// "01234", "1.10" or "1e3" aren't changed by coercion.
+func coerceString(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		return i
+	}
+	// ParseFloat accepts strings like "Inf" and "NaN", which we don't want to
+	// treat as numbers. They don't format back to the supplied string anyway.
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == s {
+		return f
+	}
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return s
+}
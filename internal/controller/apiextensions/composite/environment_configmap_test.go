@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestConfigMapEnvironmentFetcher(t *testing.T) {
+	errBoom := errors.New("boom")
+	optional := xpv1.ResolutionPolicyOptional
+
+	makeEnvironment := func(m map[string]interface{}) *Environment {
+		env := &Environment{Unstructured: unstructured.Unstructured{Object: m}}
+		env.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   environmentGroup,
+			Version: environmentVersion,
+			Kind:    environmentKind,
+		})
+		return env
+	}
+
+	revision := func(cms ...v1.EnvironmentConfigMapSource) *v1.CompositionRevision {
+		return &v1.CompositionRevision{
+			Spec: v1.CompositionRevisionSpec{
+				Environment: &v1.EnvironmentConfiguration{ConfigMaps: cms},
+			},
+		}
+	}
+
+	getConfigMap := test.NewMockGetFn(nil, func(obj client.Object) error {
+		cm := obj.(*corev1.ConfigMap)
+		cm.Data = map[string]string{
+			"int":    "42",
+			"float":  "4.2",
+			"bool":   "true",
+			"str":    "cool",
+			"inf":    "Inf",
+			"zip":    "01234",
+			"semver": "1.10",
+			"exp":    "1e3",
+		}
+		return nil
+	})
+
+	type args struct {
+		kube    client.Reader
+		wrapped EnvironmentFetcher
+		req     EnvironmentFetcherRequest
+	}
+	type want struct {
+		env *Environment
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoConfigMaps": {
+			reason: "We should return the wrapped fetcher's Environment unchanged if no ConfigMaps are referenced.",
+			args: args{
+				wrapped: EnvironmentFetcherFn(func(_ context.Context, _ EnvironmentFetcherRequest) (*Environment, error) {
+					return nil, nil
+				}),
+				req: EnvironmentFetcherRequest{Revision: revision()},
+			},
+			want: want{},
+		},
+		"WrappedError": {
+			reason: "We should return errors encountered by the wrapped fetcher.",
+			args: args{
+				wrapped: EnvironmentFetcherFn(func(_ context.Context, _ EnvironmentFetcherRequest) (*Environment, error) {
+					return nil, errBoom
+				}),
+				req: EnvironmentFetcherRequest{Revision: revision(v1.EnvironmentConfigMapSource{Namespace: "ns", Name: "cm"})},
+			},
+			want: want{err: errBoom},
+		},
+		"AllKeys": {
+			reason: "We should merge all of a ConfigMap's data into a new Environment, coercing values to their types only where doing so is lossless.",
+			args: args{
+				kube: &test.MockClient{MockGet: getConfigMap},
+				wrapped: EnvironmentFetcherFn(func(_ context.Context, _ EnvironmentFetcherRequest) (*Environment, error) {
+					return nil, nil
+				}),
+				req: EnvironmentFetcherRequest{Revision: revision(v1.EnvironmentConfigMapSource{Namespace: "ns", Name: "cm"})},
+			},
+			want: want{
+				env: makeEnvironment(map[string]interface{}{
+					"int":    int64(42),
+					"float":  float64(4.2),
+					"bool":   true,
+					"str":    "cool",
+					"inf":    "Inf",
+					"zip":    "01234",
+					"semver": "1.10",
+					"exp":    "1e3",
+				}),
+			},
+		},
+		"SomeKeys": {
+			reason: "We should merge only the supplied keys of a ConfigMap's data into the wrapped fetcher's Environment.",
+			args: args{
+				kube: &test.MockClient{MockGet: getConfigMap},
+				wrapped: EnvironmentFetcherFn(func(_ context.Context, _ EnvironmentFetcherRequest) (*Environment, error) {
+					return makeEnvironment(map[string]interface{}{"int": int64(1), "existing": "value"}), nil
+				}),
+				req: EnvironmentFetcherRequest{Revision: revision(v1.EnvironmentConfigMapSource{Namespace: "ns", Name: "cm", Keys: []string{"int", "missing"}})},
+			},
+			want: want{
+				env: makeEnvironment(map[string]interface{}{
+					"int":      int64(42),
+					"existing": "value",
+				}),
+			},
+		},
+		"MissingOptional": {
+			reason: "We should ignore an optional ConfigMap that doesn't exist.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "cm"))},
+				wrapped: EnvironmentFetcherFn(func(_ context.Context, _ EnvironmentFetcherRequest) (*Environment, error) {
+					return nil, nil
+				}),
+				req: EnvironmentFetcherRequest{Revision: revision(v1.EnvironmentConfigMapSource{Namespace: "ns", Name: "cm", Resolution: &optional})},
+			},
+			want: want{
+				env: makeEnvironment(map[string]interface{}{}),
+			},
+		},
+		"MissingRequired": {
+			reason: "We should return an error if a required ConfigMap doesn't exist.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "cm"))},
+				wrapped: EnvironmentFetcherFn(func(_ context.Context, _ EnvironmentFetcherRequest) (*Environment, error) {
+					return nil, nil
+				}),
+				req: EnvironmentFetcherRequest{Revision: revision(v1.EnvironmentConfigMapSource{Namespace: "ns", Name: "cm"})},
+			},
+			want: want{
+				err: errors.Wrapf(kerrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "cm"), errFmtGetConfigMap, "ns", "cm"),
+			},
+		},
+		"GetError": {
+			reason: "We should return an error if we can't get an optional ConfigMap for a reason other than it not existing.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				wrapped: EnvironmentFetcherFn(func(_ context.Context, _ EnvironmentFetcherRequest) (*Environment, error) {
+					return nil, nil
+				}),
+				req: EnvironmentFetcherRequest{Revision: revision(v1.EnvironmentConfigMapSource{Namespace: "ns", Name: "cm", Resolution: &optional})},
+			},
+			want: want{
+				err: errors.Wrapf(errBoom, errFmtGetConfigMap, "ns", "cm"),
+			},
+		},
+		"NamespaceNotAllowed": {
+			reason: "We should return an error rather than read a ConfigMap from a namespace other than the allowed one.",
+			args: args{
+				kube: &test.MockClient{MockGet: getConfigMap},
+				wrapped: EnvironmentFetcherFn(func(_ context.Context, _ EnvironmentFetcherRequest) (*Environment, error) {
+					return nil, nil
+				}),
+				req: EnvironmentFetcherRequest{Revision: revision(v1.EnvironmentConfigMapSource{Namespace: "kube-system", Name: "cm", Resolution: &optional})},
+			},
+			want: want{
+				err: errors.Errorf(errFmtConfigMapNamespace, "kube-system", "cm", "ns"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := NewConfigMapEnvironmentFetcher(tc.args.kube, "ns", tc.args.wrapped)
+			env, err := f.Fetch(context.Background(), tc.args.req)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFetch(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.env, env); diff != "" {
+				t.Errorf("\n%s\nFetch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -18,10 +18,14 @@ package composite
 
 import (
 	"context"
+	"reflect"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
@@ -78,11 +82,50 @@ func mergeOptions(pas []v1.Patch) []resource.ApplyOption {
 		if p.Policy == nil || p.ToFieldPath == nil {
 			continue
 		}
-		opts = append(opts, withMergeOptions(*p.ToFieldPath, p.Policy.MergeOptions))
+		path, _ := appendFieldPath(*p.ToFieldPath)
+		opts = append(opts, withMergeOptions(path, p.Policy.MergeOptions))
 	}
 	return opts
 }
 
+// appendFieldPath returns the path of the array to append to if the supplied
+// field path ends in [], e.g. spec.forProvider.tags[].
+func appendFieldPath(fieldPath string) (string, bool) {
+	return strings.CutSuffix(fieldPath, "[]")
+}
+
+// appendValue appends the supplied value to the array at the supplied path,
+// creating the array if it doesn't exist. Like merging with the AppendSlice
+// option, the value isn't appended if the array already contains it. This
+// keeps patches that append idempotent.
+func appendValue(p *fieldpath.Paved, path string, value any) error {
+	v, err := p.GetValue(path)
+	if fieldpath.IsNotFound(err) {
+		return p.SetValue(path, []any{value})
+	}
+	if err != nil {
+		return err
+	}
+	a, ok := v.([]any)
+	if !ok {
+		return errors.Errorf(errFmtAppendNotArray, path, v)
+	}
+
+	// Compare the value as it will be stored, e.g. with int rather than
+	// int64 values converted to JSON numbers.
+	stored := fieldpath.Pave(map[string]any{})
+	if err := stored.SetValue("v", value); err != nil {
+		return err
+	}
+	sv, _ := stored.GetValue("v")
+	for _, e := range a {
+		if reflect.DeepEqual(e, sv) {
+			return nil
+		}
+	}
+	return p.SetValue(path, append(a, value))
+}
+
 // patchFieldValueToObject applies the value to the "to" object at the given
 // path with the given merge options, returning any errors as they occur.
 // If no merge options is supplied, then destination field is replaced
@@ -93,9 +136,55 @@ func patchFieldValueToObject(fieldPath string, value any, to runtime.Object, mo
 		return err
 	}
 
+	if path, ok := appendFieldPath(fieldPath); ok {
+		if err := appendValue(paved, path, value); err != nil {
+			return err
+		}
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(paved.UnstructuredContent(), to)
+	}
+
 	if err := paved.MergeValue(fieldPath, value, mo); err != nil {
 		return err
 	}
 
 	return runtime.DefaultUnstructuredConverter.FromUnstructured(paved.UnstructuredContent(), to)
 }
+
+// A SliceMergeStrategy determines how a slice patched into a composite
+// resource is merged with any existing slice at the same field path.
+type SliceMergeStrategy string
+
+const (
+	// SliceMergeStrategyReplace replaces any existing slice.
+	SliceMergeStrategyReplace SliceMergeStrategy = "Replace"
+
+	// SliceMergeStrategyAppend appends to any existing slice the elements
+	// that it doesn't already contain.
+	SliceMergeStrategyAppend SliceMergeStrategy = "Append"
+)
+
+// NewMergingCompositeRenderer returns a Renderer that renders the supplied
+// composite resource like RenderComposite, except that patches are merged into
+// the composite resource rather than replacing the value at their field path.
+// Maps are deep-merged, so patches from different composed resources may each
+// contribute keys to the same map. Slices are merged according to the supplied
+// strategy. Patches that specify their own merge options are merged per those
+// options.
+func NewMergingCompositeRenderer(s SliceMergeStrategy) RendererFn {
+	mo := &xpv1.MergeOptions{AppendSlice: pointer.Bool(s == SliceMergeStrategyAppend)}
+	return func(_ context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ *Environment) error {
+		for i, p := range t.Patches {
+			if p.Policy == nil || p.Policy.MergeOptions == nil {
+				pp := &v1.PatchPolicy{MergeOptions: mo}
+				if p.Policy != nil {
+					pp.FromFieldPath = p.Policy.FromFieldPath
+				}
+				p.Policy = pp
+			}
+			if err := Apply(p, cp, cd, patchTypesToXR()...); err != nil {
+				return NewPatchError(i, p, err)
+			}
+		}
+		return nil
+	}
+}
@@ -17,15 +17,19 @@ limitations under the License.
 package composite
 
 import (
+	"context"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
 	k8s "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
@@ -423,3 +427,143 @@ func TestMergeOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestMergingCompositeRenderer(t *testing.T) {
+	xr := func(status map[string]any) *composite.Unstructured {
+		xr := composite.New()
+		xr.SetAPIVersion("example.org/v1")
+		xr.SetKind("XR")
+		if status != nil {
+			xr.Object["status"] = status
+		}
+		return xr
+	}
+	cd := func(status map[string]any) *composed.Unstructured {
+		return composed.New(func(cd *composed.Unstructured) {
+			cd.SetAPIVersion("example.org/v1")
+			cd.SetKind("Composed")
+			cd.Object["status"] = status
+		})
+	}
+	toXR := func(path string) v1.Patch {
+		return v1.Patch{
+			Type:          v1.PatchTypeToCompositeFieldPath,
+			FromFieldPath: pointer.String(path),
+			ToFieldPath:   pointer.String(path),
+		}
+	}
+
+	type args struct {
+		s   SliceMergeStrategy
+		xr  *composite.Unstructured
+		cds []*composed.Unstructured
+		p   v1.Patch
+	}
+	type want struct {
+		xr  *composite.Unstructured
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"DeepMergeMaps": {
+			reason: "Patches from different composed resources into the same map should each contribute their keys.",
+			args: args{
+				s:  SliceMergeStrategyReplace,
+				xr: xr(map[string]any{"endpoints": map[string]any{"existing": map[string]any{"host": "c"}}}),
+				cds: []*composed.Unstructured{
+					cd(map[string]any{"endpoints": map[string]any{"first": map[string]any{"host": "a"}}}),
+					cd(map[string]any{"endpoints": map[string]any{"second": map[string]any{"host": "b"}}}),
+				},
+				p: toXR("status.endpoints"),
+			},
+			want: want{
+				xr: xr(map[string]any{"endpoints": map[string]any{
+					"existing": map[string]any{"host": "c"},
+					"first":    map[string]any{"host": "a"},
+					"second":   map[string]any{"host": "b"},
+				}}),
+			},
+		},
+		"AppendSlices": {
+			reason: "Patches into the same slice should be appended when our strategy is Append.",
+			args: args{
+				s:  SliceMergeStrategyAppend,
+				xr: xr(nil),
+				cds: []*composed.Unstructured{
+					cd(map[string]any{"zones": []any{"a"}}),
+					cd(map[string]any{"zones": []any{"a", "b"}}),
+				},
+				p: toXR("status.zones"),
+			},
+			want: want{
+				xr: xr(map[string]any{"zones": []any{"a", "b"}}),
+			},
+		},
+		"ReplaceSlices": {
+			reason: "Patches into the same slice should replace it when our strategy is Replace.",
+			args: args{
+				s:  SliceMergeStrategyReplace,
+				xr: xr(nil),
+				cds: []*composed.Unstructured{
+					cd(map[string]any{"zones": []any{"a"}}),
+					cd(map[string]any{"zones": []any{"b"}}),
+				},
+				p: toXR("status.zones"),
+			},
+			want: want{
+				xr: xr(map[string]any{"zones": []any{"b"}}),
+			},
+		},
+		"PatchMergeOptions": {
+			reason: "A patch's own merge options should take precedence over our merge semantics.",
+			args: args{
+				s:  SliceMergeStrategyReplace,
+				xr: xr(map[string]any{"endpoints": map[string]any{"first": "old"}}),
+				cds: []*composed.Unstructured{
+					cd(map[string]any{"endpoints": map[string]any{"first": "new", "second": "b"}}),
+				},
+				p: func() v1.Patch {
+					p := toXR("status.endpoints")
+					p.Policy = &v1.PatchPolicy{MergeOptions: &xpv1.MergeOptions{KeepMapValues: pointer.Bool(true)}}
+					return p
+				}(),
+			},
+			want: want{
+				xr: xr(map[string]any{"endpoints": map[string]any{"first": "old", "second": "b"}}),
+			},
+		},
+		"PatchError": {
+			reason: "We should return any error encountered applying a patch.",
+			args: args{
+				s:   SliceMergeStrategyReplace,
+				xr:  xr(nil),
+				cds: []*composed.Unstructured{cd(nil)},
+				p:   v1.Patch{Type: v1.PatchTypeToCompositeFieldPath},
+			},
+			want: want{
+				xr:  xr(nil),
+				err: NewPatchError(0, v1.Patch{Type: v1.PatchTypeToCompositeFieldPath}, errors.Errorf(errFmtRequiredField, "FromFieldPath", v1.PatchTypeToCompositeFieldPath)),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewMergingCompositeRenderer(tc.args.s)
+			var err error
+			for _, cd := range tc.args.cds {
+				if err = r.Render(context.Background(), tc.args.xr, cd, v1.ComposedTemplate{Patches: []v1.Patch{tc.args.p}}, nil); err != nil {
+					break
+				}
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.xr, tc.args.xr); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	metricsNamespace = "crossplane"
+	metricsSubsystem = "composition"
+
+	// metricsLabelRevision labels metrics with the CompositionRevision used
+	// to compose a composite resource.
+	metricsLabelRevision = "composition_revision"
+)
+
+// ComposerMetrics records metrics about the composition of composite
+// resources. Its collectors are safe for concurrent use. They're labelled by
+// the name of the CompositionRevision used to compose, not by composite
+// resource. Their cardinality is therefore bounded by the number of
+// CompositionRevisions that have been used to compose since Crossplane
+// started, which grows as Compositions are updated. Series for revisions that
+// are no longer used are kept until Crossplane restarts.
+type ComposerMetrics struct {
+	duration  *prometheus.HistogramVec
+	rendered  *prometheus.GaugeVec
+	collected *prometheus.GaugeVec
+}
+
+// NewComposerMetrics returns ComposerMetrics registered with the supplied
+// registerer. Collectors that are already registered, for example by another
+// composer, are reused rather than registered again.
+func NewComposerMetrics(r prometheus.Registerer) (*ComposerMetrics, error) {
+	m := &ComposerMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "compose_duration_seconds",
+			Help:      "The time taken to compose a composite resource's composed resources.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{metricsLabelRevision}),
+		rendered: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "composed_resources_rendered",
+			Help:      "The number of composed resources rendered by the most recent composition.",
+		}, []string{metricsLabelRevision}),
+		collected: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "composed_resources_garbage_collected",
+			Help:      "The number of composed resources garbage collected by the most recent composition.",
+		}, []string{metricsLabelRevision}),
+	}
+
+	var err error
+	if m.duration, err = register(r, m.duration); err != nil {
+		return nil, err
+	}
+	if m.rendered, err = register(r, m.rendered); err != nil {
+		return nil, err
+	}
+	if m.collected, err = register(r, m.collected); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register the supplied collector, or return the equivalent collector that is
+// already registered.
+func register[T prometheus.Collector](r prometheus.Registerer, c T) (T, error) {
+	err := r.Register(c)
+	are := prometheus.AlreadyRegisteredError{}
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+	}
+	return c, err
+}
+
+// ObserveDuration records the time elapsed since the supplied start time as
+// the duration of a composition using the supplied CompositionRevision.
+func (m *ComposerMetrics) ObserveDuration(rev string, start, now time.Time) {
+	m.duration.WithLabelValues(rev).Observe(now.Sub(start).Seconds())
+}
+
+// SetComposed records the number of composed resources rendered and garbage
+// collected by a composition using the supplied CompositionRevision.
+func (m *ComposerMetrics) SetComposed(rev string, rendered, collected int) {
+	m.rendered.WithLabelValues(rev).Set(float64(rendered))
+	m.collected.WithLabelValues(rev).Set(float64(collected))
+}
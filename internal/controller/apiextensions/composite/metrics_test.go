@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestNewComposerMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	a, err := NewComposerMetrics(reg)
+	if err != nil {
+		t.Fatalf("NewComposerMetrics(...): %s", err)
+	}
+	b, err := NewComposerMetrics(reg)
+	if err != nil {
+		t.Fatalf("NewComposerMetrics(...): registering metrics a second time should reuse the registered collectors, but returned %s", err)
+	}
+
+	a.SetComposed("cool-revision", 2, 1)
+	if diff := cmp.Diff(float64(2), testutil.ToFloat64(b.rendered.WithLabelValues("cool-revision"))); diff != "" {
+		t.Errorf("NewComposerMetrics(...): metrics registered twice should share collectors: -want, +got:\n%s", diff)
+	}
+}
+
+func TestPTComposeMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	now := time.Now()
+	clock := func() time.Time {
+		now = now.Add(2 * time.Second)
+		return now
+	}
+
+	m, err := NewComposerMetrics(reg)
+	if err != nil {
+		t.Fatalf("NewComposerMetrics(...): %s", err)
+	}
+	c := NewPTComposer(acceptingClient(), composing([]TemplateAssociation{{Template: v1.ComposedTemplate{Name: pointer.String("cool-resource")}}},
+		WithMetrics(m),
+		WithClock(clock),
+	)...)
+
+	rev := &v1.CompositionRevision{ObjectMeta: metav1.ObjectMeta{Name: "cool-revision"}}
+	if _, err := c.Compose(context.Background(), &fake.Composite{}, CompositionRequest{Revision: rev}); err != nil {
+		t.Fatalf("Compose(...): %s", err)
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather(): %s", err)
+	}
+	samples := map[string]uint64{}
+	gauges := map[string]float64{}
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			rev := ""
+			for _, l := range metric.GetLabel() {
+				if l.GetName() == metricsLabelRevision {
+					rev = l.GetValue()
+				}
+			}
+			switch mf.GetName() {
+			case "crossplane_composition_compose_duration_seconds":
+				samples[rev] += metric.GetHistogram().GetSampleCount()
+			default:
+				gauges[mf.GetName()+"/"+rev] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	if diff := cmp.Diff(map[string]uint64{"cool-revision": 1}, samples); diff != "" {
+		t.Errorf("Compose(...): the duration histogram should observe one sample per successful Compose, labelled by revision: -want, +got:\n%s", diff)
+	}
+	want := map[string]float64{
+		"crossplane_composition_composed_resources_rendered/cool-revision":          1,
+		"crossplane_composition_composed_resources_garbage_collected/cool-revision": 0,
+	}
+	if diff := cmp.Diff(want, gauges); diff != "" {
+		t.Errorf("Compose(...): composed resource gauges: -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+// Error strings.
+const (
+	errNameAnonymousTemplate = "cannot deterministically name a composed resource rendered from an anonymous template"
+	errNameCompositeUID      = "cannot deterministically name a composed resource of a composite resource without a UID"
+)
+
+const (
+	// The length of the hash suffix of a deterministic name.
+	deterministicSuffixLength = 10
+
+	// The maximum length of a deterministic name's prefix, allowing for the
+	// hyphen that separates it from its suffix.
+	maxDeterministicNamePrefixLength = 63 - deterministicSuffixLength - 1
+)
+
+// A DeterministicNameRenderer renders composed resources exactly like an
+// APIDryRunRenderer, except that it names them itself. A composed resource's
+// name is derived from the UID of its composite resource and the name of its
+// template, so the same template always renders a composed resource with the
+// same name. It never submits composed resources to an API server.
+type DeterministicNameRenderer struct {
+	wrapped *APIDryRunRenderer
+}
+
+// NewDeterministicNameRenderer returns a Renderer of composed resources that
+// names them deterministically.
+func NewDeterministicNameRenderer() *DeterministicNameRenderer {
+	// The APIDryRunRenderer never calls its client when the composed resource
+	// is already named.
+	return &DeterministicNameRenderer{wrapped: NewAPIDryRunRenderer(nil)}
+}
+
+// Render the supplied composed resource using the supplied composite resource
+// and template. Composed resources that aren't yet named are named after the
+// composite resource's UID and the template's name.
+func (r *DeterministicNameRenderer) Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+	if cd.GetName() == "" {
+		prefix := cp.GetLabels()[xcrd.LabelKeyNamePrefixForComposed]
+		if prefix == "" {
+			return errors.New(errNamePrefix)
+		}
+		if t.Name == nil || *t.Name == "" {
+			return errors.New(errNameAnonymousTemplate)
+		}
+		if cp.GetUID() == "" {
+			return errors.New(errNameCompositeUID)
+		}
+		cd.SetName(DeterministicName(prefix, string(cp.GetUID()), *t.Name))
+	}
+	return r.wrapped.Render(ctx, cp, cd, t, env)
+}
+
+// DeterministicName returns a name derived from the supplied prefix, composite
+// resource UID, and template name. The prefix is truncated if necessary so
+// that the name is at most 63 characters long.
+func DeterministicName(prefix, uid, template string) string {
+	if len(prefix) > maxDeterministicNamePrefixLength {
+		prefix = prefix[:maxDeterministicNamePrefixLength]
+	}
+	h := sha256.Sum256([]byte(uid + "/" + template))
+	return prefix + "-" + hex.EncodeToString(h[:])[:deterministicSuffixLength]
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+func TestDeterministicNameRendererRender(t *testing.T) {
+	ctrl := true
+	tmpl, _ := json.Marshal(&fake.Managed{})
+	labels := map[string]string{
+		xcrd.LabelKeyNamePrefixForComposed: "cool-xr",
+		xcrd.LabelKeyClaimName:             "cool-claim",
+		xcrd.LabelKeyClaimNamespace:        "default",
+	}
+
+	type args struct {
+		cp resource.Composite
+		cd resource.Composed
+		t  v1.ComposedTemplate
+	}
+	type want struct {
+		cd  resource.Composed
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoNamePrefix": {
+			reason: "We should return an error if the composite resource has no name prefix label.",
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{UID: "xr-uid"}},
+				cd: &fake.Composed{},
+				t:  v1.ComposedTemplate{Name: pointer.String("cool-resource"), Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.New(errNamePrefix),
+			},
+		},
+		"AnonymousTemplate": {
+			reason: "We should return an error if the composed resource's template has no name.",
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{UID: "xr-uid", Labels: labels}},
+				cd: &fake.Composed{},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.New(errNameAnonymousTemplate),
+			},
+		},
+		"NoCompositeUID": {
+			reason: "We should return an error if the composite resource has no UID.",
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+				cd: &fake.Composed{},
+				t:  v1.ComposedTemplate{Name: pointer.String("cool-resource"), Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.New(errNameCompositeUID),
+			},
+		},
+		"AlreadyNamed": {
+			reason: "We should not rename a composed resource that is already named.",
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{UID: "xr-uid", Labels: labels}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Name: pointer.String("cool-resource"), Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:            "cd",
+					GenerateName:    "cool-xr-",
+					Labels:          labels,
+					Annotations:     map[string]string{AnnotationKeyCompositionResourceName: "cool-resource"},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl, UID: "xr-uid"}},
+				}},
+			},
+		},
+		"Success": {
+			reason: "We should name a composed resource after its composite resource's UID and its template's name.",
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{UID: "xr-uid", Labels: labels}},
+				cd: &fake.Composed{},
+				t:  v1.ComposedTemplate{Name: pointer.String("cool-resource"), Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:            DeterministicName("cool-xr", "xr-uid", "cool-resource"),
+					GenerateName:    "cool-xr-",
+					Labels:          labels,
+					Annotations:     map[string]string{AnnotationKeyCompositionResourceName: "cool-resource"},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl, BlockOwnerDeletion: &ctrl, UID: "xr-uid"}},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewDeterministicNameRenderer()
+			err := r.Render(context.Background(), tc.args.cp, tc.args.cd, tc.args.t, nil)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDeterministicNameRendererIsStable(t *testing.T) {
+	tmpl, _ := json.Marshal(&fake.Managed{})
+	xr := &fake.Composite{ObjectMeta: metav1.ObjectMeta{
+		UID:    "xr-uid",
+		Labels: map[string]string{xcrd.LabelKeyNamePrefixForComposed: "cool-xr"},
+	}}
+
+	// render renders a new composed resource from the named template, and
+	// returns its name.
+	render := func(name string) string {
+		cd := &fake.Composed{}
+		t := v1.ComposedTemplate{Name: pointer.String(name), Base: runtime.RawExtension{Raw: tmpl}}
+		if err := NewDeterministicNameRenderer().Render(context.Background(), xr, cd, t, nil); err != nil {
+			return err.Error()
+		}
+		return cd.GetName()
+	}
+
+	first, second := render("cool-resource"), render("cool-resource")
+	if first != second {
+		t.Errorf("Render(...): rendering the same template twice should produce the same name, got %q and %q", first, second)
+	}
+	if other := render("other-resource"); other == first {
+		t.Errorf("Render(...): rendering different templates should produce different names, got %q for both", other)
+	}
+}
+
+func TestDeterministicName(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		prefix string
+		want   string
+	}{
+		"Short": {
+			reason: "We should append a hash suffix to the prefix.",
+			prefix: "cool-xr",
+			want:   "cool-xr-",
+		},
+		"Long": {
+			reason: "We should truncate prefixes so that the name is a valid name.",
+			prefix: strings.Repeat("a", 70),
+			want:   strings.Repeat("a", maxDeterministicNamePrefixLength) + "-",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := DeterministicName(tc.prefix, "xr-uid", "cool-resource")
+			suffix := strings.TrimPrefix(got, tc.want)
+			if !strings.HasPrefix(got, tc.want) || len(suffix) != deterministicSuffixLength {
+				t.Errorf("\n%s\nDeterministicName(...): want %q followed by a %d character suffix, got %q", tc.reason, tc.want, deterministicSuffixLength, got)
+			}
+			if len(got) > 63 {
+				t.Errorf("\n%s\nDeterministicName(...): name %q is longer than 63 characters", tc.reason, got)
+			}
+		})
+	}
+}
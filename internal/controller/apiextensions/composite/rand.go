@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+const (
+	// The API server appends a random suffix of this length to generateName.
+	generatedSuffixLength = 5
+
+	// The maximum length of a generated name's prefix. Names may be at most
+	// 63 characters long.
+	maxGeneratedNamePrefixLength = 63 - generatedSuffixLength
+
+	// The characters used by the API server to generate names. Vowels are
+	// omitted to avoid accidentally generating words.
+	generatedNameAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+)
+
+// A RandSource is a source of randomness used to render composed resources.
+type RandSource interface {
+	// Intn returns a non-negative random number in [0,n).
+	Intn(n int) int
+}
+
+// A SeededRandSource is a deterministic, pseudo-random RandSource that is safe
+// for concurrent use.
+type SeededRandSource struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewSeededRandSource returns a pseudo-random RandSource seeded with the
+// supplied seed. It is not cryptographically secure, and is intended for
+// testing.
+func NewSeededRandSource(seed int64) *SeededRandSource {
+	return &SeededRandSource{rand: rand.New(rand.NewSource(seed))} //nolint:gosec // Determinism is the point.
+}
+
+// Intn returns a non-negative pseudo-random number in [0,n).
+func (s *SeededRandSource) Intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Intn(n)
+}
+
+// GenerateName returns a name derived from the supplied prefix, the same way
+// the API server derives names from generateName.
+func GenerateName(prefix string, r RandSource) string {
+	if len(prefix) > maxGeneratedNamePrefixLength {
+		prefix = prefix[:maxGeneratedNamePrefixLength]
+	}
+	b := make([]byte, generatedSuffixLength)
+	for i := range b {
+		b[i] = generatedNameAlphabet[r.Intn(len(generatedNameAlphabet))]
+	}
+	return prefix + string(b)
+}
+
+// TemplateSeed derives a seed for the supplied composed resource template from
+// the supplied seed. Named templates are identified by their name, and
+// anonymous templates by their base.
+func TemplateSeed(seed int64, t v1.ComposedTemplate) int64 {
+	h := fnv.New64a()
+	if t.Name != nil {
+		_, _ = h.Write([]byte(*t.Name))
+	} else {
+		_, _ = h.Write(t.Base.Raw)
+	}
+	return seed ^ int64(h.Sum64())
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+func TestGenerateName(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		prefix string
+		want   string
+	}{
+		"Short": {
+			reason: "We should append a random suffix to the prefix.",
+			prefix: "cool-xr-",
+			want:   "cool-xr-",
+		},
+		"Long": {
+			reason: "We should truncate prefixes so that the generated name is a valid name.",
+			prefix: strings.Repeat("a", 70),
+			want:   strings.Repeat("a", maxGeneratedNamePrefixLength),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := GenerateName(tc.prefix, NewSeededRandSource(42))
+			suffix := strings.TrimPrefix(got, tc.want)
+			if !strings.HasPrefix(got, tc.want) || len(suffix) != generatedSuffixLength {
+				t.Errorf("\n%s\nGenerateName(...): want %q followed by a %d character suffix, got %q", tc.reason, tc.want, generatedSuffixLength, got)
+			}
+			if strings.Trim(suffix, generatedNameAlphabet) != "" {
+				t.Errorf("\n%s\nGenerateName(...): suffix %q contains characters not in %q", tc.reason, suffix, generatedNameAlphabet)
+			}
+		})
+	}
+}
+
+func TestWithSeededRandomNames(t *testing.T) {
+	tmpl, _ := json.Marshal(map[string]any{"apiVersion": "example.org/v1", "kind": "Composed"})
+	xr := &fake.Composite{}
+	xr.SetLabels(map[string]string{xcrd.LabelKeyNamePrefixForComposed: "cool-xr"})
+
+	// render renders a composed resource for each of the supplied templates
+	// using a renderer configured with the supplied seed, and returns their
+	// names keyed by template name.
+	render := func(seed int64, templates ...string) map[string]string {
+		kube := &test.MockClient{
+			MockCreate: test.NewMockCreateFn(errors.New("we should not ask the API server to name composed resources")),
+		}
+		r := NewAPIDryRunRenderer(kube, WithSeededRandomNames(seed))
+		names := make(map[string]string, len(templates))
+		for _, name := range templates {
+			cd := composed.New()
+			if err := r.Render(context.Background(), xr, cd, v1.ComposedTemplate{Name: pointer.String(name), Base: runtime.RawExtension{Raw: tmpl}}, nil); err != nil {
+				t.Fatalf("Render(...): %s", err)
+			}
+			names[name] = cd.GetName()
+		}
+		return names
+	}
+
+	t.Run("SameSeed", func(t *testing.T) {
+		if diff := cmp.Diff(render(42, "a", "b", "c"), render(42, "a", "b", "c")); diff != "" {
+			t.Errorf("Render(...): the same seed should produce the same names: -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("DifferentSeeds", func(t *testing.T) {
+		if diff := cmp.Diff(render(42, "a", "b", "c"), render(24, "a", "b", "c")); diff == "" {
+			t.Errorf("Render(...): different seeds should produce different names")
+		}
+	})
+
+	t.Run("DifferentTemplates", func(t *testing.T) {
+		names := render(42, "a", "b")
+		if names["a"] == names["b"] {
+			t.Errorf("Render(...): different templates should produce different names, got %q for both", names["a"])
+		}
+	})
+
+	t.Run("OrderIndependent", func(t *testing.T) {
+		if diff := cmp.Diff(render(42, "a", "b", "c"), render(42, "c", "b", "a")); diff != "" {
+			t.Errorf("Render(...): a template's name should not depend on the order templates are rendered in: -want, +got:\n%s", diff)
+		}
+	})
+}
+
+func TestSeededRandSource(t *testing.T) {
+	t.Run("Concurrent", func(t *testing.T) {
+		r := NewSeededRandSource(42)
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if n := r.Intn(10); n < 0 || n >= 10 {
+						t.Errorf("Intn(10): got %d", n)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -18,8 +18,10 @@ package composite
 
 import (
 	"context"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
@@ -40,6 +42,8 @@ const (
 	errFmtRequiresMatchString     = "type %q requires a match string"
 	errFmtRequiresMatchConditions = "type %q requires a valid match condition"
 	errFmtRequiresMatchInteger    = "type %q requires a match integer"
+	errFmtRequiresCompareToPath   = "type %q requires a field path to compare to"
+	errFmtRequiresSubChecks       = "type %q requires at least one readiness check"
 	errFmtUnknownCheck            = "unknown type %q"
 	errFmtRunCheck                = "cannot run readiness check at index %d"
 )
@@ -58,6 +62,10 @@ const (
 	ReadinessCheckTypeMatchFalse     ReadinessCheckType = "MatchFalse"
 	ReadinessCheckTypeMatchCondition ReadinessCheckType = "MatchCondition"
 	ReadinessCheckTypeNone           ReadinessCheckType = "None"
+	ReadinessCheckTypeAnyOf          ReadinessCheckType = "AnyOf"
+	ReadinessCheckTypeAllOf          ReadinessCheckType = "AllOf"
+
+	ReadinessCheckTypeGreaterThanOrEqual ReadinessCheckType = "GreaterThanOrEqual"
 )
 
 // ReadinessCheck is used to indicate how to tell whether a resource is ready
@@ -75,8 +83,21 @@ type ReadinessCheck struct {
 	// MatchInt is the value you'd like to match if you're using "MatchInt" type.
 	MatchInteger *int64
 
+	// CompareToFieldPath is the path of the integer field that the integer
+	// field at FieldPath must be greater than or equal to if you're using
+	// "GreaterThanOrEqual" type.
+	CompareToFieldPath *string
+
 	// MatchCondition is the condition you'd like to match if you're using "MatchCondition" type.
 	MatchCondition *MatchConditionReadinessCheck
+
+	// AnyOf is the group of readiness checks of which at least one must pass
+	// if you're using "AnyOf" type.
+	AnyOf []ReadinessCheck
+
+	// AllOf is the group of readiness checks of which all must pass if you're
+	// using "AllOf" type.
+	AllOf []ReadinessCheck
 }
 
 // MatchConditionReadinessCheck is used to indicate how to tell whether a resource is ready
@@ -87,6 +108,10 @@ type MatchConditionReadinessCheck struct {
 
 	// Status is the status of the condition you'd like to match.
 	Status corev1.ConditionStatus
+
+	// Reason is the reason of the condition you'd like to match. The reason
+	// is not matched if it is empty.
+	Reason xpv1.ConditionReason
 }
 
 // ReadinessCheckFromV1 derives a ReadinessCheck from the supplied v1.ReadinessCheck.
@@ -110,12 +135,29 @@ func ReadinessCheckFromV1(in *v1.ReadinessCheck) ReadinessCheck {
 	if in.MatchInteger != 0 {
 		out.MatchInteger = pointer.Int64(in.MatchInteger)
 	}
+	if in.CompareToFieldPath != "" {
+		out.CompareToFieldPath = pointer.String(in.CompareToFieldPath)
+	}
 	if in.MatchCondition != nil {
 		out.MatchCondition = &MatchConditionReadinessCheck{
 			Type:   in.MatchCondition.Type,
 			Status: in.MatchCondition.Status,
+			Reason: in.MatchCondition.Reason,
 		}
 	}
+	out.AnyOf = readinessSubChecksFromV1(in.AnyOf)
+	out.AllOf = readinessSubChecksFromV1(in.AllOf)
+	return out
+}
+
+func readinessSubChecksFromV1(in []v1.ReadinessSubCheck) []ReadinessCheck {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]ReadinessCheck, len(in))
+	for i := range in {
+		out[i] = ReadinessCheckFromV1(in[i].ReadinessCheck())
+	}
 	return out
 }
 
@@ -185,11 +227,25 @@ func (c ReadinessCheck) Validate() error {
 		if c.MatchInteger == nil {
 			return errors.Errorf(errFmtRequiresMatchInteger, c.Type)
 		}
+	case ReadinessCheckTypeGreaterThanOrEqual:
+		if c.CompareToFieldPath == nil {
+			return errors.Errorf(errFmtRequiresCompareToPath, c.Type)
+		}
 	case ReadinessCheckTypeMatchCondition:
-		if c.MatchCondition == nil {
+		if c.MatchCondition == nil || c.MatchCondition.Type == "" {
 			return errors.Errorf(errFmtRequiresMatchConditions, c.Type)
 		}
 		return nil
+	case ReadinessCheckTypeAnyOf:
+		if len(c.AnyOf) == 0 {
+			return errors.Errorf(errFmtRequiresSubChecks, c.Type)
+		}
+		return nil
+	case ReadinessCheckTypeAllOf:
+		if len(c.AllOf) == 0 {
+			return errors.Errorf(errFmtRequiresSubChecks, c.Type)
+		}
+		return nil
 	default:
 		return errors.Errorf(errFmtUnknownCheck, c.Type)
 	}
@@ -228,8 +284,21 @@ func (c ReadinessCheck) IsReady(p *fieldpath.Paved, o ConditionedObject) (bool,
 			return false, resource.Ignore(fieldpath.IsNotFound, err)
 		}
 		return val == *c.MatchInteger, nil
+	case ReadinessCheckTypeGreaterThanOrEqual:
+		val, err := p.GetInteger(*c.FieldPath)
+		if err != nil {
+			return false, resource.Ignore(fieldpath.IsNotFound, err)
+		}
+		cmp, err := p.GetInteger(*c.CompareToFieldPath)
+		if err != nil {
+			return false, resource.Ignore(fieldpath.IsNotFound, err)
+		}
+		return val >= cmp, nil
 	case ReadinessCheckTypeMatchCondition:
 		val := o.GetCondition(c.MatchCondition.Type)
+		if c.MatchCondition.Reason != "" && val.Reason != c.MatchCondition.Reason {
+			return false, nil
+		}
 		return val.Status == c.MatchCondition.Status, nil
 	case ReadinessCheckTypeMatchFalse:
 		val, err := p.GetBool(*c.FieldPath)
@@ -243,12 +312,35 @@ func (c ReadinessCheck) IsReady(p *fieldpath.Paved, o ConditionedObject) (bool,
 			return false, resource.Ignore(fieldpath.IsNotFound, err)
 		}
 		return val == true, nil //nolint:gosimple // returning 'val' here as suggested hurts readability
+	case ReadinessCheckTypeAnyOf:
+		for i := range c.AnyOf {
+			ready, err := c.AnyOf[i].IsReady(p, o)
+			if err != nil {
+				return false, errors.Wrapf(err, errFmtRunCheck, i)
+			}
+			if ready {
+				return true, nil
+			}
+		}
+		return false, nil
+	case ReadinessCheckTypeAllOf:
+		for i := range c.AllOf {
+			ready, err := c.AllOf[i].IsReady(p, o)
+			if err != nil {
+				return false, errors.Wrapf(err, errFmtRunCheck, i)
+			}
+			if !ready {
+				return false, nil
+			}
+		}
+		return true, nil
 	}
 
 	return false, nil
 }
 
-// A ReadinessChecker checks whether a composed resource is ready or not.
+// A ReadinessChecker checks whether a composed resource is ready or not. A
+// composed resource is ready only if all of the supplied readiness checks pass.
 type ReadinessChecker interface {
 	IsReady(ctx context.Context, o ConditionedObject, rc ...ReadinessCheck) (ready bool, err error)
 }
@@ -261,13 +353,29 @@ func (fn ReadinessCheckerFn) IsReady(ctx context.Context, o ConditionedObject, r
 	return fn(ctx, o, rc...)
 }
 
+// A ReadinessErrorPolicy determines how an error checking whether a composed
+// resource is ready is handled.
+type ReadinessErrorPolicy string
+
+const (
+	// ReadinessErrorPolicyFail returns the error, failing composition.
+	ReadinessErrorPolicyFail ReadinessErrorPolicy = "Fail"
+
+	// ReadinessErrorPolicyRetry treats the composed resource as not ready
+	// yet, and reports the error as a warning event. Readiness will be
+	// checked again the next time the XR is composed.
+	ReadinessErrorPolicyRetry ReadinessErrorPolicy = "Retry"
+)
+
 // A ConditionedObject is a runtime object with conditions.
 type ConditionedObject interface {
 	resource.Object
 	resource.Conditioned
 }
 
-// IsReady returns whether the composed resource is ready.
+// IsReady returns whether the composed resource is ready. The supplied checks
+// are evaluated in order, and must all pass. Evaluation stops at the first
+// check that fails or returns an error, so later checks are not evaluated.
 func IsReady(_ context.Context, o ConditionedObject, rc ...ReadinessCheck) (bool, error) {
 	// kept as a safety net, but defaulting should ensure this is never hit
 	if len(rc) == 0 {
@@ -289,3 +397,104 @@ func IsReady(_ context.Context, o ConditionedObject, rc ...ReadinessCheck) (bool
 	}
 	return true, nil
 }
+
+// CreationDeadlineExceeded returns true if the supplied creation deadline,
+// relative to the supplied creation time, has passed. A nil deadline or a zero
+// creation time never exceeds its deadline.
+func CreationDeadlineExceeded(created metav1.Time, deadline *metav1.Duration, now time.Time) bool {
+	if deadline == nil || created.IsZero() {
+		return false
+	}
+	return now.After(created.Add(deadline.Duration))
+}
+
+// A CompositeReadinessAggregator determines whether a composite resource is
+// ready, given the readiness of its composed resources.
+type CompositeReadinessAggregator interface {
+	AggregateReadiness(cds []ComposedResource) bool
+}
+
+// A CompositeReadinessAggregatorFn determines whether a composite resource is
+// ready, given the readiness of its composed resources.
+type CompositeReadinessAggregatorFn func(cds []ComposedResource) bool
+
+// AggregateReadiness of the supplied composed resources.
+func (fn CompositeReadinessAggregatorFn) AggregateReadiness(cds []ComposedResource) bool {
+	return fn(cds)
+}
+
+// AllReady returns true if all of the supplied composed resources that are
+// required for the composite resource to be ready are ready. Optional composed
+// resources are ignored. This is the default readiness aggregation.
+func AllReady(cds []ComposedResource) bool {
+	for _, cd := range cds {
+		if !cd.Optional && !cd.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// AtLeastReady returns a CompositeReadinessAggregatorFn that is ready if at
+// least n of the supplied required composed resources are ready, or if all of
+// them are ready when there are fewer than n. Optional composed resources are
+// ignored.
+func AtLeastReady(n int) CompositeReadinessAggregatorFn {
+	return func(cds []ComposedResource) bool {
+		required, ready := countReady(cds)
+		return ready >= n || ready == required
+	}
+}
+
+// QuorumReady returns true if a majority of the supplied required composed
+// resources are ready. Optional composed resources are ignored, so a composite
+// resource with no required composed resources is ready.
+func QuorumReady(cds []ComposedResource) bool {
+	required, ready := countReady(cds)
+	return required == 0 || ready > required/2
+}
+
+// A RequeueHinter suggests how long to wait before composing a composite
+// resource again, given the result of composing it.
+type RequeueHinter interface {
+	RequeueHint(res CompositionResult) time.Duration
+}
+
+// A RequeueHinterFn suggests how long to wait before composing a composite
+// resource again, given the result of composing it.
+type RequeueHinterFn func(res CompositionResult) time.Duration
+
+// RequeueHint for the supplied composition result.
+func (fn RequeueHinterFn) RequeueHint(res CompositionResult) time.Duration {
+	return fn(res)
+}
+
+// ReadinessRequeueHint returns a RequeueHinterFn that suggests composing a
+// composite resource again after the supplied notReady duration while any of
+// its composed resources, including optional ones, is not ready, and after
+// the supplied ready duration once all of them are ready.
+func ReadinessRequeueHint(notReady, ready time.Duration) RequeueHinterFn {
+	return func(res CompositionResult) time.Duration {
+		for _, cd := range res.Composed {
+			if !cd.Ready {
+				return notReady
+			}
+		}
+		return ready
+	}
+}
+
+// countReady returns the number of the supplied composed resources that are
+// required, and the number of those that are ready.
+func countReady(cds []ComposedResource) (required, ready int) {
+	for _, cd := range cds {
+		if cd.Optional {
+			continue
+		}
+		required++
+		if cd.Ready {
+			ready++
+		}
+	}
+	return required, ready
+}
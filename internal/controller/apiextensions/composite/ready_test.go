@@ -19,9 +19,11 @@ package composite
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
@@ -34,6 +36,12 @@ import (
 var _ ReadinessChecker = ReadinessCheckerFn(IsReady)
 
 func TestIsReady(t *testing.T) {
+	// notNumeric returns the error we expect reading a non-numeric field as
+	// an integer.
+	notNumeric := func(path string) error {
+		return errors.Errorf("%s: not a (int64) number", path)
+	}
+
 	type args struct {
 		ctx context.Context
 		o   ConditionedObject
@@ -98,6 +106,55 @@ func TestIsReady(t *testing.T) {
 				ready: false,
 			},
 		},
+		"MatchConditionReasonReady": {
+			reason: "If a match condition specifies a reason it should match both the condition's status and reason",
+			args: args{
+				o: composed.New(composed.WithConditions(xpv1.Condition{Type: "Healthy", Status: corev1.ConditionTrue, Reason: "Provisioned"})),
+				rc: []ReadinessCheck{{
+					Type: ReadinessCheckTypeMatchCondition,
+					MatchCondition: &MatchConditionReadinessCheck{
+						Type:   "Healthy",
+						Status: corev1.ConditionTrue,
+						Reason: "Provisioned",
+					},
+				}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchConditionReasonNotReady": {
+			reason: "If a match condition specifies a reason it should not match a condition with a different reason",
+			args: args{
+				o: composed.New(composed.WithConditions(xpv1.Condition{Type: "Healthy", Status: corev1.ConditionTrue, Reason: "Provisioned"})),
+				rc: []ReadinessCheck{{
+					Type: ReadinessCheckTypeMatchCondition,
+					MatchCondition: &MatchConditionReadinessCheck{
+						Type:   "Healthy",
+						Status: corev1.ConditionTrue,
+						Reason: "Degraded",
+					},
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchConditionMissingType": {
+			reason: "A match condition without a condition type should be invalid",
+			args: args{
+				o: composed.New(composed.WithConditions(xpv1.Condition{Type: "Healthy", Status: corev1.ConditionTrue, Reason: "Provisioned"})),
+				rc: []ReadinessCheck{{
+					Type: ReadinessCheckTypeMatchCondition,
+					MatchCondition: &MatchConditionReadinessCheck{
+						Status: corev1.ConditionTrue,
+					},
+				}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrap(errors.Errorf(errFmtRequiresMatchConditions, ReadinessCheckTypeMatchCondition), errInvalidCheck), errFmtRunCheck, 0),
+			},
+		},
 		"ExplictNone": {
 			reason: "If the only readiness check is explicitly 'None' the resource is always ready.",
 			args: args{
@@ -146,6 +203,45 @@ func TestIsReady(t *testing.T) {
 				ready: false,
 			},
 		},
+		"AllChecksMustPass": {
+			reason: "A resource should not be ready unless all of its checks pass.",
+			args: args{
+				o: composed.New(),
+				rc: []ReadinessCheck{
+					{Type: ReadinessCheckTypeNone},
+					{Type: ReadinessCheckTypeNonEmpty, FieldPath: pointer.String("metadata.uid")},
+				},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"ShortCircuitOnNotReady": {
+			reason: "Checks after the first failing check should not be evaluated, so a later check that would return an error should not.",
+			args: args{
+				o: composed.New(),
+				rc: []ReadinessCheck{
+					{Type: ReadinessCheckTypeNonEmpty, FieldPath: pointer.String("metadata.uid")},
+					{Type: ReadinessCheckTypeNonEmpty, FieldPath: pointer.String("metadata..uid")},
+				},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"ShortCircuitOnError": {
+			reason: "Checks after the first erroring check should not be evaluated, so the first error should be returned.",
+			args: args{
+				o: composed.New(),
+				rc: []ReadinessCheck{
+					{Type: ReadinessCheckTypeNonEmpty, FieldPath: pointer.String("metadata..uid")},
+					{Type: ReadinessCheckTypeNonEmpty},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(fieldpath.Pave(nil).GetValueInto("metadata..uid", nil), errFmtRunCheck, 0),
+			},
+		},
 		"NonEmptyTrue": {
 			reason: "If the field does have a value, NonEmpty check should return true",
 			args: args{
@@ -285,6 +381,136 @@ func TestIsReady(t *testing.T) {
 				ready: true,
 			},
 		},
+		"MatchIntegerFieldMissing": {
+			reason: "If the field is missing, it should return false",
+			args: args{
+				o: composed.New(),
+				rc: []ReadinessCheck{{
+					Type:         ReadinessCheckTypeMatchInteger,
+					FieldPath:    pointer.String("status.phase"),
+					MatchInteger: pointer.Int64(2),
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchIntegerNotNumeric": {
+			reason: "If the value of the field is not a number, we should return an error",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"phase": "Running",
+						},
+					}
+				}),
+				rc: []ReadinessCheck{{
+					Type:         ReadinessCheckTypeMatchInteger,
+					FieldPath:    pointer.String("status.phase"),
+					MatchInteger: pointer.Int64(2),
+				}},
+			},
+			want: want{
+				err: errors.Wrapf(notNumeric("status.phase"), errFmtRunCheck, 0),
+			},
+		},
+		"GreaterThanOrEqualMissingCompareTo": {
+			reason: "If the field path to compare to is missing, we should return an error",
+			args: args{
+				o: composed.New(),
+				rc: []ReadinessCheck{{
+					Type:      ReadinessCheckTypeGreaterThanOrEqual,
+					FieldPath: pointer.String("status.readyReplicas"),
+				}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrap(errors.Errorf(errFmtRequiresCompareToPath, ReadinessCheckTypeGreaterThanOrEqual), errInvalidCheck), errFmtRunCheck, 0),
+			},
+		},
+		"GreaterThanOrEqualTrue": {
+			reason: "If the value of the field is equal to the value of the field to compare to, it should return true",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"readyReplicas":   int64(3),
+							"desiredReplicas": int64(3),
+						},
+					}
+				}),
+				rc: []ReadinessCheck{{
+					Type:               ReadinessCheckTypeGreaterThanOrEqual,
+					FieldPath:          pointer.String("status.readyReplicas"),
+					CompareToFieldPath: pointer.String("status.desiredReplicas"),
+				}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"GreaterThanOrEqualFalse": {
+			reason: "If the value of the field is less than the value of the field to compare to, it should return false",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"readyReplicas":   int64(2),
+							"desiredReplicas": int64(3),
+						},
+					}
+				}),
+				rc: []ReadinessCheck{{
+					Type:               ReadinessCheckTypeGreaterThanOrEqual,
+					FieldPath:          pointer.String("status.readyReplicas"),
+					CompareToFieldPath: pointer.String("status.desiredReplicas"),
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"GreaterThanOrEqualFieldMissing": {
+			reason: "If either field is missing, it should return false",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"readyReplicas": int64(3),
+						},
+					}
+				}),
+				rc: []ReadinessCheck{{
+					Type:               ReadinessCheckTypeGreaterThanOrEqual,
+					FieldPath:          pointer.String("status.readyReplicas"),
+					CompareToFieldPath: pointer.String("status.desiredReplicas"),
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"GreaterThanOrEqualNotNumeric": {
+			reason: "If the value of the field to compare to is not a number, we should return an error",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"readyReplicas":   int64(3),
+							"desiredReplicas": "three",
+						},
+					}
+				}),
+				rc: []ReadinessCheck{{
+					Type:               ReadinessCheckTypeGreaterThanOrEqual,
+					FieldPath:          pointer.String("status.readyReplicas"),
+					CompareToFieldPath: pointer.String("status.desiredReplicas"),
+				}},
+			},
+			want: want{
+				err: errors.Wrapf(notNumeric("status.desiredReplicas"), errFmtRunCheck, 0),
+			},
+		},
 		"MatchTrueMissing": {
 			reason: "If the field is missing, it should return false",
 			args: args{
@@ -395,6 +621,173 @@ func TestIsReady(t *testing.T) {
 				ready: false,
 			},
 		},
+		"MatchFalseNotBool": {
+			reason: "If the value of the field is not a boolean, it should return an error",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"spec": map[string]any{
+							"someBool": "false",
+						},
+					}
+				}),
+				rc: []ReadinessCheck{{
+					Type:      ReadinessCheckTypeMatchFalse,
+					FieldPath: pointer.String("spec.someBool"),
+				}},
+			},
+			want: want{
+				ready: false,
+				err:   errors.Wrapf(errors.New("spec.someBool: not a bool"), errFmtRunCheck, 0),
+			},
+		},
+		"AnyOfReady": {
+			reason: "If the second check of an AnyOf group passes, it should return true",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"ready": true,
+						},
+					}
+					r.SetConditions(xpv1.Unavailable())
+				}),
+				rc: []ReadinessCheck{{
+					Type: ReadinessCheckTypeAnyOf,
+					AnyOf: []ReadinessCheck{
+						{
+							Type: ReadinessCheckTypeMatchCondition,
+							MatchCondition: &MatchConditionReadinessCheck{
+								Type:   xpv1.TypeReady,
+								Status: corev1.ConditionTrue,
+							},
+						},
+						{
+							Type:      ReadinessCheckTypeMatchTrue,
+							FieldPath: pointer.String("status.ready"),
+						},
+					},
+				}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"AnyOfNotReady": {
+			reason: "If no check of an AnyOf group passes, it should return false",
+			args: args{
+				o: composed.New(composed.WithConditions(xpv1.Unavailable())),
+				rc: []ReadinessCheck{{
+					Type: ReadinessCheckTypeAnyOf,
+					AnyOf: []ReadinessCheck{
+						{
+							Type: ReadinessCheckTypeMatchCondition,
+							MatchCondition: &MatchConditionReadinessCheck{
+								Type:   xpv1.TypeReady,
+								Status: corev1.ConditionTrue,
+							},
+						},
+						{
+							Type:      ReadinessCheckTypeMatchTrue,
+							FieldPath: pointer.String("status.ready"),
+						},
+					},
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"AllOfReady": {
+			reason: "If every check of an AllOf group passes, it should return true",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"ready": true,
+						},
+					}
+					r.SetConditions(xpv1.Available())
+				}),
+				rc: []ReadinessCheck{{
+					Type: ReadinessCheckTypeAllOf,
+					AllOf: []ReadinessCheck{
+						{
+							Type: ReadinessCheckTypeMatchCondition,
+							MatchCondition: &MatchConditionReadinessCheck{
+								Type:   xpv1.TypeReady,
+								Status: corev1.ConditionTrue,
+							},
+						},
+						{
+							Type:      ReadinessCheckTypeMatchTrue,
+							FieldPath: pointer.String("status.ready"),
+						},
+					},
+				}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"AllOfNotReady": {
+			reason: "If the first check of an AllOf group fails, it should return false",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"ready": true,
+						},
+					}
+					r.SetConditions(xpv1.Unavailable())
+				}),
+				rc: []ReadinessCheck{{
+					Type: ReadinessCheckTypeAllOf,
+					AllOf: []ReadinessCheck{
+						{
+							Type: ReadinessCheckTypeMatchCondition,
+							MatchCondition: &MatchConditionReadinessCheck{
+								Type:   xpv1.TypeReady,
+								Status: corev1.ConditionTrue,
+							},
+						},
+						{
+							Type:      ReadinessCheckTypeMatchTrue,
+							FieldPath: pointer.String("status.ready"),
+						},
+					},
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"EmptyGroup": {
+			reason: "If an AnyOf group has no checks, it should return an error",
+			args: args{
+				o:  composed.New(),
+				rc: []ReadinessCheck{{Type: ReadinessCheckTypeAnyOf}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrap(errors.Errorf(errFmtRequiresSubChecks, ReadinessCheckTypeAnyOf), errInvalidCheck), errFmtRunCheck, 0),
+			},
+		},
+		"SubCheckError": {
+			reason: "If a check of a group returns an error, it should return the error",
+			args: args{
+				o: composed.New(),
+				rc: []ReadinessCheck{{
+					Type: ReadinessCheckTypeAllOf,
+					AllOf: []ReadinessCheck{
+						{Type: ReadinessCheckTypeNone},
+						{Type: "Olala"},
+					},
+				}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrapf(errors.Wrap(errors.Errorf(errFmtUnknownCheck, "Olala"), errInvalidCheck), errFmtRunCheck, 1), errFmtRunCheck, 0),
+			},
+		},
 		"UnknownType": {
 			reason: "If unknown type is chosen, it should return an error",
 			args: args{
@@ -418,3 +811,199 @@ func TestIsReady(t *testing.T) {
 		})
 	}
 }
+
+func TestCreationDeadlineExceeded(t *testing.T) {
+	created := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	type args struct {
+		created  metav1.Time
+		deadline *metav1.Duration
+		now      time.Time
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   bool
+	}{
+		"NoDeadline": {
+			reason: "A resource without a deadline should never exceed it.",
+			args: args{
+				created: created,
+				now:     created.Add(24 * time.Hour),
+			},
+			want: false,
+		},
+		"NotYetCreated": {
+			reason: "A deadline should never be exceeded if we don't know when the composite resource was created.",
+			args: args{
+				deadline: &metav1.Duration{Duration: time.Minute},
+				now:      created.Add(24 * time.Hour),
+			},
+			want: false,
+		},
+		"WithinDeadline": {
+			reason: "A deadline should not be exceeded before it has passed.",
+			args: args{
+				created:  created,
+				deadline: &metav1.Duration{Duration: 10 * time.Minute},
+				now:      created.Add(5 * time.Minute),
+			},
+			want: false,
+		},
+		"PastDeadline": {
+			reason: "A deadline should be exceeded once it has passed.",
+			args: args{
+				created:  created,
+				deadline: &metav1.Duration{Duration: 10 * time.Minute},
+				now:      created.Add(15 * time.Minute),
+			},
+			want: true,
+		},
+		"ClockSkew": {
+			reason: "A deadline should not be exceeded if the current time is before the composite resource was created.",
+			args: args{
+				created:  created,
+				deadline: &metav1.Duration{Duration: 10 * time.Minute},
+				now:      created.Add(-time.Hour),
+			},
+			want: false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := CreationDeadlineExceeded(tc.args.created, tc.args.deadline, tc.args.now)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nCreationDeadlineExceeded(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCompositeReadinessAggregators(t *testing.T) {
+	type args struct {
+		a   CompositeReadinessAggregator
+		cds []ComposedResource
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   bool
+	}{
+		"AllReady": {
+			reason: "The XR should be ready if all of its composed resources are ready.",
+			args: args{
+				a:   CompositeReadinessAggregatorFn(AllReady),
+				cds: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b", Ready: true}},
+			},
+			want: true,
+		},
+		"OneNotReady": {
+			reason: "The XR should not be ready if any of its required composed resources isn't ready.",
+			args: args{
+				a:   CompositeReadinessAggregatorFn(AllReady),
+				cds: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b"}},
+			},
+			want: false,
+		},
+		"OptionalNotReady": {
+			reason: "The XR should be ready if only optional composed resources aren't ready.",
+			args: args{
+				a:   CompositeReadinessAggregatorFn(AllReady),
+				cds: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b", Optional: true}},
+			},
+			want: true,
+		},
+		"AtLeastReady": {
+			reason: "The XR should be ready if at least n of its required composed resources are ready.",
+			args: args{
+				a:   AtLeastReady(2),
+				cds: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b", Ready: true}, {ResourceName: "c"}},
+			},
+			want: true,
+		},
+		"FewerThanAtLeastReady": {
+			reason: "The XR should not be ready if fewer than n of its required composed resources are ready.",
+			args: args{
+				a:   AtLeastReady(2),
+				cds: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b"}, {ResourceName: "c", Optional: true, Ready: true}},
+			},
+			want: false,
+		},
+		"AtLeastMoreThanRequired": {
+			reason: "The XR should be ready if all of its required composed resources are ready, even if there are fewer than n.",
+			args: args{
+				a:   AtLeastReady(3),
+				cds: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b", Ready: true}},
+			},
+			want: true,
+		},
+		"Quorum": {
+			reason: "The XR should be ready if a majority of its required composed resources are ready.",
+			args: args{
+				a:   CompositeReadinessAggregatorFn(QuorumReady),
+				cds: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b", Ready: true}, {ResourceName: "c"}},
+			},
+			want: true,
+		},
+		"NoQuorum": {
+			reason: "The XR should not be ready if only half of its required composed resources are ready.",
+			args: args{
+				a:   CompositeReadinessAggregatorFn(QuorumReady),
+				cds: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b"}},
+			},
+			want: false,
+		},
+		"QuorumNoRequired": {
+			reason: "The XR should be ready if it has no required composed resources.",
+			args: args{
+				a:   CompositeReadinessAggregatorFn(QuorumReady),
+				cds: []ComposedResource{{ResourceName: "a", Optional: true}},
+			},
+			want: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.args.a.AggregateReadiness(tc.args.cds)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nAggregateReadiness(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestReadinessRequeueHint(t *testing.T) {
+	short, long := 10*time.Second, 10*time.Minute
+
+	cases := map[string]struct {
+		reason string
+		res    CompositionResult
+		want   time.Duration
+	}{
+		"NotReady": {
+			reason: "We should suggest a short requeue while any composed resource is not ready.",
+			res:    CompositionResult{Composed: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b"}}},
+			want:   short,
+		},
+		"OptionalNotReady": {
+			reason: "We should suggest a short requeue while an optional composed resource is not ready.",
+			res:    CompositionResult{Composed: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b", Optional: true}}},
+			want:   short,
+		},
+		"AllReady": {
+			reason: "We should suggest a long requeue once all composed resources are ready.",
+			res:    CompositionResult{Composed: []ComposedResource{{ResourceName: "a", Ready: true}, {ResourceName: "b", Ready: true}}},
+			want:   long,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ReadinessRequeueHint(short, long).RequeueHint(tc.res)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nRequeueHint(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
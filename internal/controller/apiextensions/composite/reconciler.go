@@ -21,9 +21,12 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -32,6 +35,7 @@ import (
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
@@ -61,16 +65,35 @@ const (
 	errConfigure              = "cannot configure composite resource"
 	errPublish                = "cannot publish connection details"
 	errUnpublish              = "cannot unpublish connection details"
+	errDeleteComposedRes      = "cannot delete composed resources"
 	errValidate               = "refusing to use invalid Composition"
 	errAssociate              = "cannot associate composed resources with Composition resource templates"
 	errFetchEnvironment       = "cannot fetch environment"
 	errSelectEnvironment      = "cannot select environment"
 	errCompose                = "cannot compose resources"
 	errRenderCD               = "cannot render composed resource"
+	errRecordNotReady         = "cannot record composed resources that are not ready"
 
 	errFmtPatchEnvironment = "cannot apply environment patch at index %d"
+	errFmtCreationDeadline = "composed resource %q is not ready and its creation deadline has passed"
 )
 
+// ReasonCreationDeadlineExceeded indicates that one or more composed resources
+// were not ready by their creation deadline.
+const ReasonCreationDeadlineExceeded xpv1.ConditionReason = "CreationDeadlineExceeded"
+
+// degraded returns a condition that indicates the XR is degraded because the
+// supplied composed resources were not ready by their creation deadline.
+func degraded(ids []string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               xpv1.TypeReady,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonCreationDeadlineExceeded,
+		Message:            fmt.Sprintf("Composed resources are not ready and their creation deadline has passed: %s", strings.Join(ids, ", ")),
+	}
+}
+
 // Event reasons.
 const (
 	reasonResolve event.Reason = "SelectComposition"
@@ -187,6 +210,29 @@ type Renderer interface {
 	Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error
 }
 
+// A RenderWarning is returned by a Renderer that rendered a composed resource,
+// but wants to warn about how it did so. Unlike other errors, a RenderWarning
+// doesn't prevent the composed resource from being applied.
+type RenderWarning struct {
+	error
+}
+
+// NewRenderWarning returns a RenderWarning wrapping the supplied error.
+func NewRenderWarning(err error) error {
+	return RenderWarning{error: err}
+}
+
+// Unwrap returns the error wrapped by this RenderWarning.
+func (w RenderWarning) Unwrap() error {
+	return w.error
+}
+
+// IsRenderWarning returns true if the supplied error is or wraps a
+// RenderWarning.
+func IsRenderWarning(err error) bool {
+	return errors.As(err, &RenderWarning{})
+}
+
 // A RendererFn may be used to render a composed resource.
 type RendererFn func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error
 
@@ -201,6 +247,21 @@ func (fn RendererFn) Render(ctx context.Context, cp resource.Composite, cd resou
 type CompositionRequest struct {
 	Revision    *v1.CompositionRevision
 	Environment *Environment
+
+	// Subset optionally limits composition to the named composed templates.
+	// Resources composed from any other template are observed, so that they
+	// still contribute connection details and readiness, but are otherwise
+	// left untouched. No composed resources are garbage collected when
+	// composing a subset. All templates are composed when Subset is empty.
+	Subset []string
+
+	// PreviouslyNotReady optionally records which composed resources, keyed
+	// by resource name, were not ready when the XR was last composed. Any
+	// other composed resource that existed then is assumed to have been
+	// ready. Events are emitted for composed resources whose readiness has
+	// changed since. No such events are emitted when PreviouslyNotReady is
+	// nil, i.e. when the previous readiness is unknown.
+	PreviouslyNotReady map[string]bool
 }
 
 // A CompositionResult is the result of the composition process.
@@ -208,6 +269,163 @@ type CompositionResult struct {
 	Composed          []ComposedResource
 	ConnectionDetails managed.ConnectionDetails
 	Events            []event.Event
+
+	// Requeue indicates that some composition work was deferred, and that
+	// the XR should be composed again as soon as possible.
+	Requeue bool
+
+	// RequeueAfter optionally suggests how long to wait before the XR is
+	// composed again. No duration is suggested when it is zero.
+	RequeueAfter time.Duration
+
+	// StaleConnectionDetails are the keys of connection details that were
+	// previously published for the XR, but that no longer have a source. They
+	// should be removed from the XR's published connection details.
+	StaleConnectionDetails []string
+
+	// GarbageCollected are references to the composed resources that the
+	// composer deleted while composing the XR. They aren't included in
+	// Composed.
+	GarbageCollected []corev1.ObjectReference
+
+	// CompositeReady is whether the composer determined that the XR is
+	// ready, by aggregating the readiness of its composed resources. It is
+	// nil if the composer didn't aggregate their readiness.
+	CompositeReady *bool
+}
+
+// Ready returns true if the composite resource is ready. Unless the composer
+// aggregated the readiness of the composed resources it is ready if all of the
+// composed resources that are required for the composite resource to be ready
+// are ready. Optional composed resources are ignored, so a result with no
+// required composed resources is ready.
+func (r CompositionResult) Ready() bool {
+	if r.CompositeReady != nil {
+		return *r.CompositeReady
+	}
+	return AllReady(r.Composed)
+}
+
+// TypeComposedResources is the type of the condition that summarizes the state
+// of an XR's composed resources.
+const TypeComposedResources xpv1.ConditionType = "ComposedResources"
+
+// Reasons a ComposedResources condition may be set.
+const (
+	ReasonAllComposedResourcesReady xpv1.ConditionReason = "AllReady"
+	ReasonComposedResourcesNotReady xpv1.ConditionReason = "NotAllReady"
+)
+
+// maxSummaryLength is the maximum length of the message of a ComposedResources
+// condition. Messages listing many resources that are not ready are truncated.
+const maxSummaryLength = 512
+
+// FieldPathComposedResourcesNotReady is the field path at which an XR records
+// which of its composed resources were not ready when it was last composed.
+//
+// This field is an addition to the status schema of every XR. No existing
+// status field records the readiness of each composed resource: resource
+// references don't, and the ComposedResources condition's message is meant
+// for humans and may be truncated. An annotation couldn't be written by the
+// status update that records readiness. The field is owned by Crossplane, like
+// status.connectionDetails, and XRs that predate it simply don't emit
+// readiness transition events until they're next composed.
+const FieldPathComposedResourcesNotReady = "status.composedResources.notReady"
+
+// SummaryCondition returns a condition that summarizes the state of the
+// composed resources, e.g. "3 ready, 1 creating, 1 degraded".
+func (r CompositionResult) SummaryCondition() xpv1.Condition {
+	ready, creating, degraded := 0, 0, 0
+	notReady := make([]string, 0)
+	for i, cd := range r.Composed {
+		switch {
+		case cd.DeadlineExceeded:
+			degraded++
+		case cd.Ready:
+			ready++
+			continue
+		default:
+			creating++
+		}
+		notReady = append(notReady, strconv.Quote(resourceID(i, cd)))
+	}
+
+	c := xpv1.Condition{
+		Type:               TypeComposedResources,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAllComposedResourcesReady,
+		Message:            fmt.Sprintf("%d ready, %d creating, %d degraded", ready, creating, degraded),
+	}
+	if len(notReady) == 0 {
+		return c
+	}
+
+	c.Status = corev1.ConditionFalse
+	c.Reason = ReasonComposedResourcesNotReady
+	c.Message = fmt.Sprintf("%s. Not ready: %s", c.Message, strings.Join(notReady, ", "))
+	if len(c.Message) > maxSummaryLength {
+		c.Message = c.Message[:maxSummaryLength-3] + "..."
+	}
+	return c
+}
+
+// NotReady returns the names of the composed resources that aren't ready.
+// Anonymous composed resources are named by their index.
+func (r CompositionResult) NotReady() []string {
+	notReady := make([]string, 0)
+	for i, cd := range r.Composed {
+		if cd.Ready && !cd.DeadlineExceeded {
+			continue
+		}
+		notReady = append(notReady, resourceID(i, cd))
+	}
+	return notReady
+}
+
+// resourceID returns the name of the supplied composed resource, or its index
+// if it's anonymous.
+func resourceID(i int, cd ComposedResource) string {
+	if cd.ResourceName == "" {
+		return strconv.Itoa(i)
+	}
+	return cd.ResourceName
+}
+
+// previouslyNotReady returns the composed resources that the supplied XR
+// recorded as not ready when it was last composed. It returns nil if the XR
+// didn't record them, for example because it was never composed.
+func previouslyNotReady(xr resource.Composite) map[string]bool {
+	p, err := fieldpath.PaveObject(xr)
+	if err != nil {
+		return nil
+	}
+	ids, err := p.GetStringArray(FieldPathComposedResourcesNotReady)
+	if err != nil {
+		return nil
+	}
+	out := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		out[id] = true
+	}
+	return out
+}
+
+// setNotReady records the supplied composed resources as not ready in the
+// supplied XR's status.
+func setNotReady(xr resource.Composite, ids []string) error {
+	// Unstructured XRs can be written in place.
+	if u, ok := xr.(runtime.Unstructured); ok {
+		return fieldpath.Pave(u.UnstructuredContent()).SetValue(FieldPathComposedResourcesNotReady, ids)
+	}
+	p, err := fieldpath.PaveObject(xr)
+	if err != nil {
+		return errors.Wrap(err, errPaveComposite)
+	}
+	if err := p.SetValue(FieldPathComposedResourcesNotReady, ids); err != nil {
+		return err
+	}
+	return errors.Wrap(runtime.DefaultUnstructuredConverter.FromUnstructured(p.UnstructuredContent(), xr), errConvertComposite)
 }
 
 // A Composer composes (i.e. creates, updates, or deletes) resources given the
@@ -334,6 +552,14 @@ func WithConnectionPublishers(p ...managed.ConnectionPublisher) ReconcilerOption
 	}
 }
 
+// WithDeletionSequencer specifies how the Reconciler should delete
+// composed resources when their composite resource is deleted.
+func WithDeletionSequencer(d DeletionSequencer) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.DeletionSequencer = d
+	}
+}
+
 // WithComposer specifies how the Reconciler should compose resources.
 func WithComposer(c Composer) ReconcilerOption {
 	return func(r *Reconciler) {
@@ -371,6 +597,7 @@ type compositeResource struct {
 	EnvironmentSelector
 	Configurator
 	managed.ConnectionPublisher
+	DeletionSequencer
 }
 
 // NewReconciler returns a new Reconciler of composite resources.
@@ -413,9 +640,11 @@ func NewReconciler(mgr manager.Manager, of resource.CompositeKind, opts ...Recon
 			// never filter any keys. Is there an unfiltered variant we could
 			// use by default instead?
 			ConnectionPublisher: NewAPIFilteredSecretPublisher(kube, []string{}),
+
+			DeletionSequencer: NewDependencyDeletionSequencer(kube),
 		},
 
-		resource: NewPTComposer(kube),
+		resource: NewSelectingComposer(NewModeComposerSelector(NewPTComposer(kube))),
 
 		log:    logging.NewNopLogger(),
 		record: event.NewNopRecorder(),
@@ -490,6 +719,23 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 			return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
 		}
 
+		deleted, err := r.composite.SequenceDeletion(ctx, xr)
+		if err != nil {
+			log.Debug(errDeleteComposedRes, "error", err)
+			err = errors.Wrap(err, errDeleteComposedRes)
+			r.record.Event(xr, event.Warning(reasonDelete, err))
+			xr.SetConditions(xpv1.ReconcileError(err))
+			return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
+		}
+
+		// Composed resources that must be deleted before others are still
+		// being deleted. We keep our finalizer until they're gone.
+		if !deleted {
+			log.Debug("Waiting for composed resources to be deleted")
+			xr.SetConditions(xpv1.ReconcileSuccess())
+			return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
+		}
+
 		if err := r.composite.RemoveFinalizer(ctx, xr); err != nil {
 			log.Debug(errRemoveFinalizer, "error", err)
 			err = errors.Wrap(err, errRemoveFinalizer)
@@ -583,7 +829,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 
 	// TODO(negz): Pass this method a copy of xr, to make very clear that
 	// anything it does won't be reflected in the state of xr?
-	res, err := r.resource.Compose(ctx, xr, CompositionRequest{Revision: rev, Environment: env})
+	res, err := r.resource.Compose(ctx, xr, CompositionRequest{
+		Revision:           rev,
+		Environment:        env,
+		PreviouslyNotReady: previouslyNotReady(xr),
+	})
 	if err != nil {
 		log.Debug(errCompose, "error", err)
 		err = errors.Wrap(err, errCompose)
@@ -606,6 +856,31 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		r.record.Event(xr, event.Normal(reasonPublish, "Successfully published connection details"))
 	}
 
+	if len(res.StaleConnectionDetails) > 0 {
+		stale := make(managed.ConnectionDetails, len(res.StaleConnectionDetails))
+		for _, key := range res.StaleConnectionDetails {
+			stale[key] = nil
+		}
+		if err := r.composite.UnpublishConnection(ctx, xr, stale); err != nil {
+			log.Debug(errUnpublish, "error", err)
+			err = errors.Wrap(err, errUnpublish)
+			r.record.Event(xr, event.Warning(reasonPublish, err))
+			xr.SetConditions(xpv1.ReconcileError(err))
+			return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
+		}
+		log.Debug("Removed stale connection details", "keys", res.StaleConnectionDetails)
+		r.record.Event(xr, event.Normal(reasonPublish, fmt.Sprintf("Removed stale connection details: %s", strings.Join(res.StaleConnectionDetails, ", "))))
+	}
+
+	if len(res.GarbageCollected) > 0 {
+		collected := make([]string, len(res.GarbageCollected))
+		for i, ref := range res.GarbageCollected {
+			collected[i] = fmt.Sprintf("%s %q", ref.Kind, ref.Name)
+		}
+		log.Debug("Garbage collected composed resources", "resources", collected)
+		r.record.Event(xr, event.Normal(reasonCompose, fmt.Sprintf("Garbage collected composed resources: %s", strings.Join(collected, ", "))))
+	}
+
 	warnings := 0
 	for _, e := range res.Events {
 		if e.Type == event.TypeWarning {
@@ -623,7 +898,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		r.record.Event(xr, event.Normal(reasonCompose, "Successfully composed resources"))
 	}
 
-	ready := 0
+	exceeded := make([]string, 0)
 	for i, cd := range res.Composed {
 		// Specifying a name for P&T templates is optional but encouraged.
 		// If there was no name, fall back to using the index.
@@ -632,23 +907,59 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 			id = strconv.Itoa(i)
 		}
 
-		if !cd.Ready {
-			log.Debug("Composed resource is not yet ready", "id", id)
-			r.record.Event(xr, event.Normal(reasonCompose, fmt.Sprintf("Composed resource %q is not yet ready", id)))
+		if cd.Drifted {
+			log.Debug("Composed resource had drifted from its desired state", "id", id)
+			r.record.Event(xr, event.Normal(reasonCompose, fmt.Sprintf("Composed resource %q had drifted from its desired state", id)))
+		}
+
+		if cd.DeadlineExceeded {
+			log.Debug("Composed resource is not ready and its creation deadline has passed", "id", id, "optional", cd.Optional)
+			r.record.Event(xr, event.Warning(reasonCompose, errors.Errorf(errFmtCreationDeadline, id)))
+
+			// Optional composed resources don't degrade the XR.
+			if !cd.Optional {
+				exceeded = append(exceeded, id)
+			}
 			continue
 		}
 
-		ready++
+		if !cd.Ready {
+			log.Debug("Composed resource is not yet ready", "id", id, "optional", cd.Optional)
+			r.record.Event(xr, event.Normal(reasonCompose, fmt.Sprintf("Composed resource %q is not yet ready", id)))
+		}
 	}
 
 	xr.SetConditions(xpv1.ReconcileSuccess())
 
+	// Summarize the state of our composed resources. This condition's
+	// transition time only changes when the summary does.
+	if len(res.Composed) > 0 {
+		xr.SetConditions(res.SummaryCondition())
+		if err := setNotReady(xr, res.NotReady()); err != nil {
+			log.Debug(errRecordNotReady, "error", err)
+			err = errors.Wrap(err, errRecordNotReady)
+			r.record.Event(xr, event.Warning(reasonCompose, err))
+			xr.SetConditions(xpv1.ReconcileError(err))
+			return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
+		}
+	}
+
+	// Composed resources that missed their creation deadline degrade the XR.
+	// We keep requeueing, since they may yet become ready.
+	if len(exceeded) > 0 {
+		xr.SetConditions(degraded(exceeded))
+		return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
+	}
+
 	// TODO(muvaf): If a resource becomes Unavailable at some point, should we
 	// still report it as Creating?
-	if ready != len(res.Composed) {
+	if !res.Ready() {
 		// We want to requeue to wait for our composed resources to
 		// become ready, since we can't watch them.
 		xr.SetConditions(xpv1.Creating())
+		if res.RequeueAfter > 0 {
+			return reconcile.Result{RequeueAfter: res.RequeueAfter}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
+		}
 		return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
 	}
 
@@ -656,5 +967,17 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	// resources - we can't know what type of resources we might compose
 	// when this controller is started.
 	xr.SetConditions(xpv1.Available())
+
+	// The composer deferred some work, for example because it hit its write
+	// budget. Requeue immediately rather than waiting for our poll interval.
+	if res.Requeue {
+		return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
+	}
+
+	// The composer suggested when to compose the XR again.
+	if res.RequeueAfter > 0 {
+		return reconcile.Result{RequeueAfter: res.RequeueAfter}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
+	}
+
 	return reconcile.Result{RequeueAfter: r.pollInterval}, errors.Wrap(r.client.Status().Update(ctx, xr), errUpdateStatus)
 }
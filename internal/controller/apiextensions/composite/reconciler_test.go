@@ -18,6 +18,8 @@ package composite
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -119,6 +122,67 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{Requeue: true},
 			},
 		},
+		"SequenceDeletionError": {
+			reason: "We should return any error encountered while deleting composed resources.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: WithComposite(t, NewComposite(func(cr resource.Composite) {
+							cr.SetDeletionTimestamp(&now)
+						})),
+						MockStatusUpdate: WantComposite(t, NewComposite(func(cr resource.Composite) {
+							cr.SetDeletionTimestamp(&now)
+							cr.SetConditions(xpv1.Deleting(), xpv1.ReconcileError(errors.Wrap(errBoom, errDeleteComposedRes)))
+						})),
+					}),
+					WithConnectionPublishers(managed.ConnectionPublisherFns{
+						UnpublishConnectionFn: func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) error {
+							return nil
+						},
+					}),
+					WithDeletionSequencer(DeletionSequencerFn(func(ctx context.Context, xr resource.Composite) (bool, error) {
+						return false, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: true},
+			},
+		},
+		"WaitForComposedResourceDeletion": {
+			reason: "We should keep our finalizer until composed resources that must be deleted first are gone.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: WithComposite(t, NewComposite(func(cr resource.Composite) {
+							cr.SetDeletionTimestamp(&now)
+						})),
+						MockStatusUpdate: WantComposite(t, NewComposite(func(cr resource.Composite) {
+							cr.SetDeletionTimestamp(&now)
+							cr.SetConditions(xpv1.Deleting(), xpv1.ReconcileSuccess())
+						})),
+					}),
+					WithCompositeFinalizer(resource.FinalizerFns{
+						RemoveFinalizerFn: func(ctx context.Context, obj resource.Object) error {
+							return errBoom
+						},
+					}),
+					WithConnectionPublishers(managed.ConnectionPublisherFns{
+						UnpublishConnectionFn: func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) error {
+							return nil
+						},
+					}),
+					WithDeletionSequencer(DeletionSequencerFn(func(ctx context.Context, xr resource.Composite) (bool, error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: true},
+			},
+		},
 		"RemoveFinalizerError": {
 			reason: "We should return any error encountered while removing finalizer.",
 			args: args{
@@ -516,7 +580,14 @@ func TestReconcile(t *testing.T) {
 						MockGet: test.NewMockGetFn(nil),
 						MockStatusUpdate: WantComposite(t, NewComposite(func(cr resource.Composite) {
 							cr.SetCompositionReference(&corev1.ObjectReference{})
-							cr.SetConditions(xpv1.ReconcileSuccess(), xpv1.Creating())
+							cr.SetConditions(xpv1.ReconcileSuccess(), xpv1.Condition{
+								Type:               TypeComposedResources,
+								Status:             corev1.ConditionFalse,
+								LastTransitionTime: metav1.Now(),
+								Reason:             ReasonComposedResourcesNotReady,
+								Message:            `0 ready, 1 creating, 0 degraded. Not ready: "0"`,
+							}, xpv1.Creating())
+							_ = setNotReady(cr, []string{"0"})
 						})),
 					}),
 					WithCompositeFinalizer(resource.NewNopFinalizer()),
@@ -553,6 +624,131 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{Requeue: true},
 			},
 		},
+		"OptionalComposedResourcesNotReady": {
+			reason: "We should not wait for optional composed resources to become ready, or be degraded by them.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(nil),
+						MockStatusUpdate: WantComposite(t, NewComposite(func(cr resource.Composite) {
+							cr.SetCompositionReference(&corev1.ObjectReference{})
+							cr.SetConditions(xpv1.ReconcileSuccess(), xpv1.Condition{
+								Type:               TypeComposedResources,
+								Status:             corev1.ConditionFalse,
+								LastTransitionTime: metav1.Now(),
+								Reason:             ReasonComposedResourcesNotReady,
+								Message:            `1 ready, 1 creating, 1 degraded. Not ready: "dashboard", "alerts"`,
+							}, xpv1.Available())
+							_ = setNotReady(cr, []string{"dashboard", "alerts"})
+						})),
+					}),
+					WithCompositeFinalizer(resource.NewNopFinalizer()),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithCompositionRevisionFetcher(CompositionRevisionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.CompositionRevision, error) {
+						c := &v1.CompositionRevision{Spec: v1.CompositionRevisionSpec{
+							Resources: []v1.ComposedTemplate{{}},
+						}}
+						return c, nil
+					})),
+					WithCompositionRevisionValidator(CompositionRevisionValidatorFn(func(_ *v1.CompositionRevision) error { return nil })),
+					WithConfigurator(ConfiguratorFn(func(_ context.Context, _ resource.Composite, _ *v1.CompositionRevision) error {
+						return nil
+					})),
+					WithComposer(ComposerFn(func(ctx context.Context, xr resource.Composite, req CompositionRequest) (CompositionResult, error) {
+						return CompositionResult{
+							Composed: []ComposedResource{
+								{
+									ResourceName: "database",
+									Ready:        true,
+								},
+								{
+									ResourceName: "dashboard",
+									Optional:     true,
+								},
+								{
+									ResourceName:     "alerts",
+									Optional:         true,
+									DeadlineExceeded: true,
+								},
+							},
+						}, nil
+					})),
+					WithConnectionPublishers(managed.ConnectionPublisherFns{
+						PublishConnectionFn: func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+							return false, nil
+						},
+					}),
+					WithCompositionUpdatePolicySelector(CompositionUpdatePolicySelectorFn(func(ctx context.Context, cr resource.Composite) error { return nil })),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: defaultPollInterval},
+			},
+		},
+		"ComposedResourcesCreationDeadlineExceeded": {
+			reason: "We should mark the XR degraded and requeue if any of our composed resources are not ready by their creation deadline.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(nil),
+						MockStatusUpdate: WantComposite(t, NewComposite(func(cr resource.Composite) {
+							cr.SetCompositionReference(&corev1.ObjectReference{})
+							cr.SetConditions(xpv1.ReconcileSuccess(), xpv1.Condition{
+								Type:               TypeComposedResources,
+								Status:             corev1.ConditionFalse,
+								LastTransitionTime: metav1.Now(),
+								Reason:             ReasonComposedResourcesNotReady,
+								Message:            `0 ready, 1 creating, 1 degraded. Not ready: "cool-resource", "later-resource"`,
+							}, degraded([]string{"cool-resource"}))
+							_ = setNotReady(cr, []string{"cool-resource", "later-resource"})
+						})),
+					}),
+					WithCompositeFinalizer(resource.NewNopFinalizer()),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithCompositionRevisionFetcher(CompositionRevisionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.CompositionRevision, error) {
+						c := &v1.CompositionRevision{Spec: v1.CompositionRevisionSpec{
+							Resources: []v1.ComposedTemplate{{}},
+						}}
+						return c, nil
+					})),
+					WithCompositionRevisionValidator(CompositionRevisionValidatorFn(func(_ *v1.CompositionRevision) error { return nil })),
+					WithConfigurator(ConfiguratorFn(func(_ context.Context, _ resource.Composite, _ *v1.CompositionRevision) error {
+						return nil
+					})),
+					WithComposer(ComposerFn(func(ctx context.Context, xr resource.Composite, req CompositionRequest) (CompositionResult, error) {
+						return CompositionResult{
+							Composed: []ComposedResource{
+								{
+									ResourceName:     "cool-resource",
+									DeadlineExceeded: true,
+								},
+								{
+									ResourceName: "later-resource",
+									Ready:        false,
+								},
+							},
+						}, nil
+					})),
+					WithConnectionPublishers(managed.ConnectionPublisherFns{
+						PublishConnectionFn: func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+							return false, nil
+						},
+					}),
+					WithCompositionUpdatePolicySelector(CompositionUpdatePolicySelectorFn(func(ctx context.Context, cr resource.Composite) error { return nil })),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: true},
+			},
+		},
 		"ComposedResourcesReady": {
 			reason: "We should requeue after our poll interval if all of our composed resources are ready.",
 			args: args{
@@ -731,6 +927,92 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: defaultPollInterval},
 			},
 		},
+		"RequeueHintNotReady": {
+			reason: "We should requeue after the duration suggested by the composer while the composite resource is not ready.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: WithComposite(t, NewComposite()),
+						MockStatusUpdate: WantComposite(t, NewComposite(func(cr resource.Composite) {
+							cr.SetConditions(xpv1.ReconcileSuccess(), xpv1.Creating())
+							cr.SetConnectionDetailsLastPublishedTime(&now)
+							cr.SetCompositionReference(&corev1.ObjectReference{})
+						})),
+					}),
+					WithCompositeFinalizer(resource.NewNopFinalizer()),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithCompositionRevisionFetcher(CompositionRevisionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.CompositionRevision, error) {
+						c := &v1.CompositionRevision{Spec: v1.CompositionRevisionSpec{
+							Resources: []v1.ComposedTemplate{{}},
+						}}
+						return c, nil
+					})),
+					WithCompositionRevisionValidator(CompositionRevisionValidatorFn(func(_ *v1.CompositionRevision) error { return nil })),
+					WithConfigurator(ConfiguratorFn(func(_ context.Context, _ resource.Composite, _ *v1.CompositionRevision) error {
+						return nil
+					})),
+					WithComposer(ComposerFn(func(ctx context.Context, xr resource.Composite, req CompositionRequest) (CompositionResult, error) {
+						return CompositionResult{CompositeReady: pointer.Bool(false), RequeueAfter: 10 * time.Second}, nil
+					})),
+					WithConnectionPublishers(managed.ConnectionPublisherFns{
+						PublishConnectionFn: func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+							return true, nil
+						},
+					}),
+					WithCompositionUpdatePolicySelector(CompositionUpdatePolicySelectorFn(func(ctx context.Context, cr resource.Composite) error { return nil })),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: 10 * time.Second},
+			},
+		},
+		"RequeueHintReady": {
+			reason: "We should requeue after the duration suggested by the composer once the composite resource is ready.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: WithComposite(t, NewComposite()),
+						MockStatusUpdate: WantComposite(t, NewComposite(func(cr resource.Composite) {
+							cr.SetConditions(xpv1.ReconcileSuccess(), xpv1.Available())
+							cr.SetConnectionDetailsLastPublishedTime(&now)
+							cr.SetCompositionReference(&corev1.ObjectReference{})
+						})),
+					}),
+					WithCompositeFinalizer(resource.NewNopFinalizer()),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithCompositionRevisionFetcher(CompositionRevisionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.CompositionRevision, error) {
+						c := &v1.CompositionRevision{Spec: v1.CompositionRevisionSpec{
+							Resources: []v1.ComposedTemplate{{}},
+						}}
+						return c, nil
+					})),
+					WithCompositionRevisionValidator(CompositionRevisionValidatorFn(func(_ *v1.CompositionRevision) error { return nil })),
+					WithConfigurator(ConfiguratorFn(func(_ context.Context, _ resource.Composite, _ *v1.CompositionRevision) error {
+						return nil
+					})),
+					WithComposer(ComposerFn(func(ctx context.Context, xr resource.Composite, req CompositionRequest) (CompositionResult, error) {
+						return CompositionResult{RequeueAfter: 10 * time.Minute}, nil
+					})),
+					WithConnectionPublishers(managed.ConnectionPublisherFns{
+						PublishConnectionFn: func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+							return true, nil
+						},
+					}),
+					WithCompositionUpdatePolicySelector(CompositionUpdatePolicySelectorFn(func(ctx context.Context, cr resource.Composite) error { return nil })),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: 10 * time.Minute},
+			},
+		},
 	}
 
 	for name, tc := range cases {
@@ -845,3 +1127,198 @@ func TestFilterToXRPatches(t *testing.T) {
 		})
 	}
 }
+
+func TestCompositionResultReady(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		res    CompositionResult
+		want   bool
+	}{
+		"NoComposedResources": {
+			reason: "A result without composed resources should be ready.",
+			want:   true,
+		},
+		"RequiredNotReady": {
+			reason: "A result should not be ready if a required composed resource is not ready.",
+			res: CompositionResult{Composed: []ComposedResource{
+				{ResourceName: "a", Ready: true},
+				{ResourceName: "b"},
+			}},
+			want: false,
+		},
+		"OptionalNotReady": {
+			reason: "A result should be ready if only optional composed resources are not ready.",
+			res: CompositionResult{Composed: []ComposedResource{
+				{ResourceName: "a", Ready: true},
+				{ResourceName: "b", Optional: true},
+			}},
+			want: true,
+		},
+		"MixedNotReady": {
+			reason: "A result should not be ready if a required composed resource is not ready, even if optional ones are.",
+			res: CompositionResult{Composed: []ComposedResource{
+				{ResourceName: "a"},
+				{ResourceName: "b", Optional: true, Ready: true},
+			}},
+			want: false,
+		},
+		"Aggregated": {
+			reason: "A result should be ready if the composer aggregated the readiness of its composed resources and determined it is.",
+			res: CompositionResult{
+				Composed: []ComposedResource{
+					{ResourceName: "a", Ready: true},
+					{ResourceName: "b"},
+				},
+				CompositeReady: pointer.Bool(true),
+			},
+			want: true,
+		},
+		"AllOptional": {
+			reason: "A result whose composed resources are all optional should be ready, regardless of their readiness.",
+			res: CompositionResult{Composed: []ComposedResource{
+				{ResourceName: "a", Optional: true},
+				{ResourceName: "b", Optional: true, DeadlineExceeded: true},
+			}},
+			want: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.res.Ready()); diff != "" {
+				t.Errorf("\n%s\nReady(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCompositionResultSummaryCondition(t *testing.T) {
+	many := make([]ComposedResource, 100)
+	for i := range many {
+		many[i] = ComposedResource{ResourceName: fmt.Sprintf("resource-with-a-long-name-%d", i)}
+	}
+
+	cases := map[string]struct {
+		reason string
+		res    CompositionResult
+		want   xpv1.Condition
+	}{
+		"AllReady": {
+			reason: "The condition should be true when all composed resources are ready.",
+			res: CompositionResult{Composed: []ComposedResource{
+				{ResourceName: "a", Ready: true},
+				{ResourceName: "b", Ready: true},
+			}},
+			want: xpv1.Condition{
+				Type:    TypeComposedResources,
+				Status:  corev1.ConditionTrue,
+				Reason:  ReasonAllComposedResourcesReady,
+				Message: "2 ready, 0 creating, 0 degraded",
+			},
+		},
+		"MixedStates": {
+			reason: "The condition should summarize a mix of ready, creating, and degraded resources.",
+			res: CompositionResult{Composed: []ComposedResource{
+				{ResourceName: "a", Ready: true},
+				{ResourceName: "b", Ready: true},
+				{ResourceName: "c", Ready: true},
+				{ResourceName: "d"},
+				{ResourceName: "e", DeadlineExceeded: true},
+				{},
+			}},
+			want: xpv1.Condition{
+				Type:    TypeComposedResources,
+				Status:  corev1.ConditionFalse,
+				Reason:  ReasonComposedResourcesNotReady,
+				Message: `3 ready, 2 creating, 1 degraded. Not ready: "d", "e", "5"`,
+			},
+		},
+		"Truncated": {
+			reason: "The condition message should be truncated when many resources are not ready.",
+			res:    CompositionResult{Composed: many},
+			want: xpv1.Condition{
+				Type:   TypeComposedResources,
+				Status: corev1.ConditionFalse,
+				Reason: ReasonComposedResourcesNotReady,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.res.SummaryCondition()
+			if len(got.Message) > maxSummaryLength {
+				t.Errorf("\n%s\nSummaryCondition(): message length %d exceeds %d", tc.reason, len(got.Message), maxSummaryLength)
+			}
+			if tc.want.Message == "" {
+				if !strings.HasSuffix(got.Message, "...") {
+					t.Errorf("\n%s\nSummaryCondition(): message %q should be truncated", tc.reason, got.Message)
+				}
+				got.Message = ""
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nSummaryCondition(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+
+	t.Run("OnlyUpdatedOnChange", func(t *testing.T) {
+		res := CompositionResult{Composed: []ComposedResource{{ResourceName: "a", Ready: true}}}
+
+		first := res.SummaryCondition()
+		first.LastTransitionTime = metav1.NewTime(time.Unix(0, 0))
+		s := &xpv1.ConditionedStatus{}
+		s.SetConditions(first)
+		s.SetConditions(res.SummaryCondition())
+
+		if diff := cmp.Diff(first.LastTransitionTime, s.GetCondition(TypeComposedResources).LastTransitionTime); diff != "" {
+			t.Errorf("SetConditions(SummaryCondition()): an unchanged summary should not change its transition time: -want, +got:\n%s", diff)
+		}
+	})
+}
+
+func TestPreviouslyNotReady(t *testing.T) {
+	recorded := func(res CompositionResult) *composite.Unstructured {
+		xr := composite.New()
+		if err := setNotReady(xr, res.NotReady()); err != nil {
+			t.Fatalf("setNotReady(...): %v", err)
+		}
+		return xr
+	}
+
+	cases := map[string]struct {
+		reason string
+		xr     resource.Composite
+		want   map[string]bool
+	}{
+		"NeverRecorded": {
+			reason: "We should not know which composed resources were not ready if the XR never recorded them.",
+			xr:     composite.New(),
+			want:   nil,
+		},
+		"AllReady": {
+			reason: "No composed resources were not ready if the XR recorded that they were all ready.",
+			xr: recorded(CompositionResult{Composed: []ComposedResource{
+				{ResourceName: "a", Ready: true},
+			}}),
+			want: map[string]bool{},
+		},
+		"NotAllReady": {
+			reason: "We should return the composed resources the XR recorded as not ready.",
+			xr: recorded(CompositionResult{Composed: []ComposedResource{
+				{ResourceName: "a", Ready: true},
+				{ResourceName: "b"},
+				{ResourceName: "c", DeadlineExceeded: true},
+				{ResourceName: `d, "e"`},
+				{},
+			}}),
+			want: map[string]bool{"b": true, "c": true, `d, "e"`: true, "4": true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := previouslyNotReady(tc.xr)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\npreviouslyNotReady(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
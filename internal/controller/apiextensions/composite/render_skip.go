@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+const (
+	errRenderChecksum = "cannot compute checksum of composed resource render inputs"
+)
+
+// renderInputs are the inputs used to render a composed resource.
+type renderInputs struct {
+	Template    v1.ComposedTemplate `json:"template"`
+	Name        string              `json:"name"`
+	UID         string              `json:"uid"`
+	Labels      map[string]string   `json:"labels,omitempty"`
+	Composite   map[string]any      `json:"composite,omitempty"`
+	Environment map[string]any      `json:"environment,omitempty"`
+}
+
+// RenderChecksum returns a checksum of the inputs used to render a composed
+// resource from the supplied template: the template itself, the identity and
+// labels of the supplied XR, and the values of the XR and environment fields
+// read by the template's patches. Any supplied additional XR field paths are
+// also read, for example because a Renderer that wraps the composed resource
+// renderer reads them. A composed resource rendered from the same inputs will
+// be rendered the same way.
+func RenderChecksum(xr resource.Composite, t v1.ComposedTemplate, env *Environment, paths ...string) (string, error) {
+	in := renderInputs{
+		Template:  t,
+		Name:      xr.GetName(),
+		UID:       string(xr.GetUID()),
+		Labels:    xr.GetLabels(),
+		Composite: map[string]any{},
+	}
+
+	xp, err := fieldpath.PaveObject(xr)
+	if err != nil {
+		return "", errors.Wrap(err, errRenderChecksum)
+	}
+	var ep *fieldpath.Paved
+	if env != nil {
+		in.Environment = map[string]any{}
+		ep = fieldpath.Pave(env.UnstructuredContent())
+	}
+
+	readFieldPaths(xp, in.Composite, paths...)
+	for _, p := range t.Patches {
+		switch p.Type {
+		case v1.PatchTypeFromCompositeFieldPath, "":
+			readFieldPaths(xp, in.Composite, pointer.StringDeref(p.FromFieldPath, ""))
+		case v1.PatchTypeCombineFromComposite:
+			readFieldPaths(xp, in.Composite, combineFieldPaths(p.Combine)...)
+		case v1.PatchTypeFromEnvironmentFieldPath:
+			readFieldPaths(ep, in.Environment, pointer.StringDeref(p.FromFieldPath, ""))
+		case v1.PatchTypeCombineFromEnvironment:
+			readFieldPaths(ep, in.Environment, combineFieldPaths(p.Combine)...)
+		case v1.PatchTypePatchSet, v1.PatchTypeToCompositeFieldPath, v1.PatchTypeCombineToComposite, v1.PatchTypeToEnvironmentFieldPath, v1.PatchTypeCombineToEnvironment:
+			// These patches don't read from the XR or environment.
+		}
+	}
+
+	// Object keys are always marshaled in sorted order.
+	b, err := json.Marshal(in)
+	if err != nil {
+		return "", errors.Wrap(err, errRenderChecksum)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// rendersEnvironment returns true if rendering the supplied template patches
+// the environment. Such templates must always be rendered, because later
+// templates may read the environment.
+func rendersEnvironment(t v1.ComposedTemplate) bool {
+	for _, p := range t.Patches {
+		if p.Type == v1.PatchTypeToEnvironmentFieldPath || p.Type == v1.PatchTypeCombineToEnvironment {
+			return true
+		}
+	}
+	return false
+}
+
+// readFieldPaths reads the values of the supplied field paths, which may
+// contain wildcards, into the supplied map. Fields that don't exist are read
+// as nil.
+func readFieldPaths(p *fieldpath.Paved, into map[string]any, paths ...string) {
+	if p == nil {
+		return
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		expanded, err := p.ExpandWildcards(path)
+		if err != nil {
+			into[path] = nil
+			continue
+		}
+		for _, e := range expanded {
+			v, _ := p.GetValue(e)
+			into[e] = v
+		}
+		if len(expanded) == 0 {
+			into[path] = nil
+		}
+	}
+}
+
+// combineFieldPaths returns the field paths read by the supplied combine.
+func combineFieldPaths(c *v1.Combine) []string {
+	if c == nil {
+		return nil
+	}
+	paths := make([]string, len(c.Variables))
+	for i := range c.Variables {
+		paths[i] = c.Variables[i].FromFieldPath
+	}
+	return paths
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestRenderChecksum(t *testing.T) {
+	tmpl := v1.ComposedTemplate{
+		Name: pointer.String("bucket"),
+		Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket"}`)},
+		Patches: []v1.Patch{{
+			Type:          v1.PatchTypeFromCompositeFieldPath,
+			FromFieldPath: pointer.String("objectMeta.annotations[size]"),
+			ToFieldPath:   pointer.String("spec.size"),
+		}},
+	}
+	xr := func(annotations map[string]string) *fake.Composite {
+		return &fake.Composite{ObjectMeta: metav1.ObjectMeta{Name: "cool-xr", Annotations: annotations}}
+	}
+
+	type args struct {
+		a, b resource.Composite
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   bool
+	}{
+		"SameInputs": {
+			reason: "The checksum should be the same if the template's inputs are unchanged.",
+			args: args{
+				a: xr(map[string]string{"size": "small"}),
+				b: xr(map[string]string{"size": "small"}),
+			},
+			want: true,
+		},
+		"UnreadFieldChanged": {
+			reason: "The checksum should be the same if an XR field the template doesn't read has changed.",
+			args: args{
+				a: xr(map[string]string{"size": "small"}),
+				b: xr(map[string]string{"size": "small", "colour": "blue"}),
+			},
+			want: true,
+		},
+		"ReadFieldChanged": {
+			reason: "The checksum should change if an XR field the template reads has changed.",
+			args: args{
+				a: xr(map[string]string{"size": "small"}),
+				b: xr(map[string]string{"size": "large"}),
+			},
+			want: false,
+		},
+		"WrapperFieldChanged": {
+			reason: "The checksum should change if an XR field read by a wrapping renderer has changed.",
+			args: args{
+				a: xr(map[string]string{"size": "small", "zone": "a"}),
+				b: xr(map[string]string{"size": "small", "zone": "b"}),
+			},
+			want: false,
+		},
+		"ReadFieldRemoved": {
+			reason: "The checksum should change if an XR field the template reads no longer exists.",
+			args: args{
+				a: xr(map[string]string{"size": "small"}),
+				b: xr(nil),
+			},
+			want: false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a, err := RenderChecksum(tc.args.a, tmpl, nil, "objectMeta.annotations[zone]")
+			if err != nil {
+				t.Fatalf("RenderChecksum(...): %s", err)
+			}
+			b, err := RenderChecksum(tc.args.b, tmpl, nil, "objectMeta.annotations[zone]")
+			if err != nil {
+				t.Fatalf("RenderChecksum(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want, a == b); diff != "" {
+				t.Errorf("\n%s\nRenderChecksum(...): -want equal, +got equal:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPTComposeRenderSkipping(t *testing.T) {
+	ref := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-bucket"}
+	tmpl := v1.ComposedTemplate{
+		Name: pointer.String("bucket"),
+		Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket"}`)},
+		Patches: []v1.Patch{{
+			Type:          v1.PatchTypeFromCompositeFieldPath,
+			FromFieldPath: pointer.String("objectMeta.annotations[size]"),
+			ToFieldPath:   pointer.String("spec.size"),
+		}},
+	}
+
+	type args struct {
+		// The size the composed resource was last rendered with.
+		renderedSize string
+		// The size the XR currently specifies.
+		size string
+		// Additional composer options.
+		o []PTComposerOption
+	}
+	type want struct {
+		renders  int
+		applies  int
+		observes int
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Unchanged": {
+			reason: "We should neither render nor apply a composed resource whose render inputs are unchanged, but should still observe it.",
+			args:   args{renderedSize: "small", size: "small"},
+			want:   want{renders: 0, applies: 0, observes: 1},
+		},
+		"Changed": {
+			reason: "We should render and apply a composed resource whose render inputs have changed.",
+			args:   args{renderedSize: "small", size: "large"},
+			want:   want{renders: 1, applies: 1, observes: 1},
+		},
+		"PostRenderMutator": {
+			reason: "We should always render and apply composed resources when a post-render mutator, which may read anything, is configured.",
+			args: args{
+				renderedSize: "small",
+				size:         "small",
+				o: []PTComposerOption{WithPostRenderMutator(func(_ resource.Composite, _ resource.Composed, _ v1.ComposedTemplate) error {
+					return nil
+				})},
+			},
+			want: want{renders: 1, applies: 1, observes: 1},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			xr := &fake.Composite{
+				ObjectMeta:                  metav1.ObjectMeta{Name: "cool-xr", Annotations: map[string]string{"size": tc.args.size}},
+				ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{ref}},
+			}
+			previous := &fake.Composite{ObjectMeta: metav1.ObjectMeta{Name: "cool-xr", Annotations: map[string]string{"size": tc.args.renderedSize}}}
+			sum, err := RenderChecksum(previous, tmpl, nil)
+			if err != nil {
+				t.Fatalf("RenderChecksum(...): %s", err)
+			}
+
+			renders, applies, observes := 0, 0, 0
+			kube := &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					if u, ok := obj.(*kunstructured.Unstructured); ok {
+						u.SetAnnotations(map[string]string{AnnotationKeyRenderChecksum: sum})
+					}
+					return nil
+				}),
+				MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+					if _, ok := obj.(*kunstructured.Unstructured); ok {
+						applies++
+					}
+					return nil
+				},
+				MockUpdate: test.NewMockUpdateFn(nil),
+			}
+
+			o := composing([]TemplateAssociation{{Template: tmpl, Reference: ref}},
+				WithRenderSkipping(),
+				WithComposedRenderer(RendererFn(func(_ context.Context, _ resource.Composite, _ resource.Composed, _ v1.ComposedTemplate, _ *Environment) error {
+					renders++
+					return nil
+				})),
+				WithComposedReadinessChecker(ReadinessCheckerFn(func(_ context.Context, _ ConditionedObject, _ ...ReadinessCheck) (bool, error) {
+					observes++
+					return true, nil
+				})),
+			)
+			c := NewPTComposer(kube, append(o, tc.args.o...)...)
+
+			if _, err := c.Compose(context.Background(), xr, CompositionRequest{Revision: &v1.CompositionRevision{}}); err != nil {
+				t.Fatalf("Compose(...): %s", err)
+			}
+			got := want{renders: renders, applies: applies, observes: observes}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nCompose(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Error strings.
+const (
+	errFmtGetScheduling     = "cannot get scheduling constraints from composite resource field path %q"
+	errFmtInvalidScheduling = "scheduling constraints at composite resource field path %q must be an object"
+	errFmtGetPodSpec        = "cannot get pod spec of composed resource at field path %q"
+	errFmtSetPodSpec        = "cannot set pod spec of composed resource at field path %q"
+	errFmtNoPodTemplate     = "not projecting inherited scheduling constraints: composed resource of kind %q in %q has no pod template"
+	errPaveComposed         = "cannot pave composed resource"
+	errPaveComposite        = "cannot pave composite resource"
+	errConvertComposed      = "cannot convert composed resource"
+)
+
+// DefaultSchedulingFieldPath is the composite resource field path from which
+// scheduling constraints are inherited by default.
+const DefaultSchedulingFieldPath = "spec.scheduling"
+
+// A SchedulingPolicy determines how scheduling constraints inherited from a
+// composite resource are combined with those declared by a composed resource.
+type SchedulingPolicy string
+
+// Scheduling policies.
+const (
+	// SchedulingPolicyMerge adds inherited scheduling constraints to those
+	// declared by the composed resource. Constraints declared by the composed
+	// resource take precedence.
+	SchedulingPolicyMerge SchedulingPolicy = "Merge"
+
+	// SchedulingPolicyOverride replaces any scheduling constraints declared by
+	// the composed resource with those inherited from the composite resource.
+	SchedulingPolicyOverride SchedulingPolicy = "Override"
+)
+
+// Scheduling constraints that may be inherited from a composite resource.
+const (
+	schedulingTolerations  = "tolerations"
+	schedulingNodeSelector = "nodeSelector"
+	schedulingAffinity     = "affinity"
+)
+
+// podSpecPaths are the field paths at which composed workloads commonly embed
+// a pod spec. A ReplicaSet, Deployment, StatefulSet, DaemonSet, or Job embeds
+// one at spec.template.spec, and a CronJob at
+// spec.jobTemplate.spec.template.spec. A core Pod is its own pod spec.
+var podSpecPaths = []string{"spec.template.spec", "spec.jobTemplate.spec.template.spec"}
+
+// A SchedulingRenderer renders composed resources using another Renderer, then
+// projects the scheduling constraints (tolerations, node selector, and
+// affinity) declared by the composite resource into the pod spec of any
+// composed workload. Only a core Pod, and kinds that embed a pod template at
+// one of the podSpecPaths, are supported. Other kinds (including managed
+// resources that wrap a workload in spec.forProvider) are rendered unchanged,
+// with a RenderWarning.
+type SchedulingRenderer struct {
+	wrapped Renderer
+	path    string
+	policy  SchedulingPolicy
+}
+
+// A SchedulingRendererOption configures a SchedulingRenderer.
+type SchedulingRendererOption func(r *SchedulingRenderer)
+
+// WithSchedulingFieldPath configures the composite resource field path from
+// which a SchedulingRenderer inherits scheduling constraints.
+func WithSchedulingFieldPath(path string) SchedulingRendererOption {
+	return func(r *SchedulingRenderer) {
+		r.path = path
+	}
+}
+
+// WithSchedulingPolicy configures how a SchedulingRenderer combines inherited
+// scheduling constraints with those declared by a composed resource.
+func WithSchedulingPolicy(p SchedulingPolicy) SchedulingRendererOption {
+	return func(r *SchedulingRenderer) {
+		r.policy = p
+	}
+}
+
+// NewSchedulingRenderer returns a Renderer that projects the composite
+// resource's scheduling constraints into the composed resources rendered by
+// the supplied Renderer.
+func NewSchedulingRenderer(wrapped Renderer, o ...SchedulingRendererOption) *SchedulingRenderer {
+	r := &SchedulingRenderer{
+		wrapped: wrapped,
+		path:    DefaultSchedulingFieldPath,
+		policy:  SchedulingPolicyMerge,
+	}
+	for _, fn := range o {
+		fn(r)
+	}
+	return r
+}
+
+// Render the supplied composed resource using the wrapped Renderer, then
+// project the composite resource's scheduling constraints into it. Composed
+// resources that don't embed a pod spec are not modified, nor are any composed
+// resources if the composite resource declares no scheduling constraints. A
+// RenderWarning is returned if the composite resource declares scheduling
+// constraints that can't be projected into the composed resource.
+func (r *SchedulingRenderer) Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, env *Environment) error {
+	if err := r.wrapped.Render(ctx, cp, cd, t, env); err != nil {
+		return err
+	}
+
+	xp, err := fieldpath.PaveObject(cp)
+	if err != nil {
+		return errors.Wrap(err, errPaveComposite)
+	}
+	in, err := xp.GetValue(r.path)
+	if fieldpath.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, errFmtGetScheduling, r.path)
+	}
+	scheduling, ok := in.(map[string]any)
+	if !ok {
+		return errors.Errorf(errFmtInvalidScheduling, r.path)
+	}
+
+	cdp, err := fieldpath.PaveObject(cd)
+	if err != nil {
+		return errors.Wrap(err, errPaveComposed)
+	}
+	path, ok := podSpecPath(cd, cdp)
+	if !ok {
+		gvk := cd.GetObjectKind().GroupVersionKind()
+		return NewRenderWarning(errors.Errorf(errFmtNoPodTemplate, gvk.Kind, gvk.GroupVersion().String()))
+	}
+	spec, err := cdp.GetValue(path)
+	if err != nil {
+		return errors.Wrapf(err, errFmtGetPodSpec, path)
+	}
+	ps, ok := spec.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if err := cdp.SetValue(path, MergeScheduling(ps, scheduling, r.policy)); err != nil {
+		return errors.Wrapf(err, errFmtSetPodSpec, path)
+	}
+	return errors.Wrap(runtime.DefaultUnstructuredConverter.FromUnstructured(cdp.UnstructuredContent(), cd), errConvertComposed)
+}
+
+// podSpecPath returns the field path at which the supplied composed resource
+// embeds a pod spec, if any.
+func podSpecPath(cd resource.Composed, p *fieldpath.Paved) (string, bool) {
+	gvk := cd.GetObjectKind().GroupVersionKind()
+	if gvk.Group == "" && gvk.Kind == "Pod" {
+		return "spec", true
+	}
+	for _, path := range podSpecPaths {
+		if _, err := p.GetValue(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// MergeScheduling combines the supplied inherited scheduling constraints with
+// the supplied pod spec according to the supplied policy, and returns the
+// pod spec. Only tolerations, node selectors, and affinity are inherited.
+//
+// When merging, inherited tolerations are appended to the pod spec's unless
+// they're already present. Inherited node selector labels and affinity types
+// (e.g. nodeAffinity) are added unless the pod spec already declares them.
+func MergeScheduling(spec, inherited map[string]any, p SchedulingPolicy) map[string]any {
+	// Don't share inherited values with the object we inherited them from.
+	inherited = runtime.DeepCopyJSON(inherited)
+	for _, key := range []string{schedulingTolerations, schedulingNodeSelector, schedulingAffinity} {
+		in, ok := inherited[key]
+		if !ok {
+			continue
+		}
+		existing, ok := spec[key]
+		if !ok || p == SchedulingPolicyOverride {
+			spec[key] = in
+			continue
+		}
+		switch key {
+		case schedulingTolerations:
+			spec[key] = mergeTolerations(existing, in)
+		case schedulingNodeSelector, schedulingAffinity:
+			spec[key] = mergeMissingKeys(existing, in)
+		}
+	}
+	return spec
+}
+
+// mergeTolerations appends any inherited tolerations that aren't already
+// present to the existing tolerations.
+func mergeTolerations(existing, inherited any) any {
+	e, eok := existing.([]any)
+	in, iok := inherited.([]any)
+	if !eok || !iok {
+		return existing
+	}
+	out := append(make([]any, 0, len(e)+len(in)), e...)
+	for _, t := range in {
+		present := false
+		for _, et := range e {
+			if reflect.DeepEqual(t, et) {
+				present = true
+				break
+			}
+		}
+		if !present {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// mergeMissingKeys adds any inherited keys that aren't already present to the
+// existing object.
+func mergeMissingKeys(existing, inherited any) any {
+	e, eok := existing.(map[string]any)
+	in, iok := inherited.(map[string]any)
+	if !eok || !iok {
+		return existing
+	}
+	for k, v := range in {
+		if _, ok := e[k]; !ok {
+			e[k] = v
+		}
+	}
+	return e
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestSchedulingRendererRender(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	xr := func(scheduling any) *composite.Unstructured {
+		xr := composite.New()
+		if scheduling != nil {
+			xr.Object["spec"] = map[string]any{"scheduling": scheduling}
+		}
+		return xr
+	}
+	deployment := func(podSpec map[string]any) *composed.Unstructured {
+		return composed.New(func(cd *composed.Unstructured) {
+			cd.SetAPIVersion("apps/v1")
+			cd.SetKind("Deployment")
+			cd.Object["spec"] = map[string]any{
+				"template": map[string]any{"spec": podSpec},
+			}
+		})
+	}
+	scheduling := map[string]any{
+		"tolerations": []any{
+			map[string]any{"key": "dedicated", "operator": "Exists"},
+		},
+		"nodeSelector": map[string]any{"zone": "us-west-2a", "disk": "ssd"},
+		"affinity": map[string]any{
+			"nodeAffinity": map[string]any{"from": "composite"},
+		},
+	}
+	renderer := RendererFn(func(_ context.Context, _ resource.Composite, _ resource.Composed, _ v1.ComposedTemplate, _ *Environment) error {
+		return nil
+	})
+
+	type params struct {
+		wrapped Renderer
+		o       []SchedulingRendererOption
+	}
+	type args struct {
+		cp resource.Composite
+		cd resource.Composed
+	}
+	type want struct {
+		cd  resource.Composed
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		params params
+		args   args
+		want   want
+	}{
+		"RenderError": {
+			reason: "We should return any error encountered by the wrapped Renderer.",
+			params: params{
+				wrapped: RendererFn(func(_ context.Context, _ resource.Composite, _ resource.Composed, _ v1.ComposedTemplate, _ *Environment) error {
+					return errBoom
+				}),
+			},
+			args: args{
+				cp: xr(scheduling),
+				cd: deployment(map[string]any{}),
+			},
+			want: want{
+				cd:  deployment(map[string]any{}),
+				err: errBoom,
+			},
+		},
+		"NoSchedulingConstraints": {
+			reason: "We should not modify a composed resource if the composite resource declares no scheduling constraints.",
+			params: params{wrapped: renderer},
+			args: args{
+				cp: xr(nil),
+				cd: deployment(map[string]any{"nodeSelector": map[string]any{"disk": "hdd"}}),
+			},
+			want: want{
+				cd: deployment(map[string]any{"nodeSelector": map[string]any{"disk": "hdd"}}),
+			},
+		},
+		"InvalidSchedulingConstraints": {
+			reason: "We should return an error if the composite resource's scheduling constraints are not an object.",
+			params: params{wrapped: renderer},
+			args: args{
+				cp: xr("wat"),
+				cd: deployment(map[string]any{}),
+			},
+			want: want{
+				cd:  deployment(map[string]any{}),
+				err: errors.Errorf(errFmtInvalidScheduling, DefaultSchedulingFieldPath),
+			},
+		},
+		"NotAWorkload": {
+			reason: "We should not modify a composed resource that doesn't embed a pod spec, but should return a warning.",
+			params: params{wrapped: renderer},
+			args: args{
+				cp: xr(scheduling),
+				cd: composed.New(func(cd *composed.Unstructured) {
+					cd.SetAPIVersion("example.org/v1")
+					cd.SetKind("Bucket")
+					cd.Object["spec"] = map[string]any{"region": "us-west-2"}
+				}),
+			},
+			want: want{
+				cd: composed.New(func(cd *composed.Unstructured) {
+					cd.SetAPIVersion("example.org/v1")
+					cd.SetKind("Bucket")
+					cd.Object["spec"] = map[string]any{"region": "us-west-2"}
+				}),
+				err: NewRenderWarning(errors.Errorf(errFmtNoPodTemplate, "Bucket", "example.org/v1")),
+			},
+		},
+		"ProjectIntoDeployment": {
+			reason: "We should project the composite resource's scheduling constraints into a Deployment that declares none.",
+			params: params{wrapped: renderer},
+			args: args{
+				cp: xr(scheduling),
+				cd: deployment(map[string]any{"containers": []any{}}),
+			},
+			want: want{
+				cd: deployment(map[string]any{
+					"containers": []any{},
+					"tolerations": []any{
+						map[string]any{"key": "dedicated", "operator": "Exists"},
+					},
+					"nodeSelector": map[string]any{"zone": "us-west-2a", "disk": "ssd"},
+					"affinity": map[string]any{
+						"nodeAffinity": map[string]any{"from": "composite"},
+					},
+				}),
+			},
+		},
+		"MergeIntoDeployment": {
+			reason: "We should merge the composite resource's scheduling constraints into a Deployment, preferring constraints declared by the Deployment.",
+			params: params{wrapped: renderer},
+			args: args{
+				cp: xr(scheduling),
+				cd: deployment(map[string]any{
+					"tolerations": []any{
+						map[string]any{"key": "dedicated", "operator": "Exists"},
+						map[string]any{"key": "gpu", "operator": "Exists"},
+					},
+					"nodeSelector": map[string]any{"disk": "hdd"},
+					"affinity": map[string]any{
+						"nodeAffinity":    map[string]any{"from": "base"},
+						"podAntiAffinity": map[string]any{"from": "base"},
+					},
+				}),
+			},
+			want: want{
+				cd: deployment(map[string]any{
+					"tolerations": []any{
+						map[string]any{"key": "dedicated", "operator": "Exists"},
+						map[string]any{"key": "gpu", "operator": "Exists"},
+					},
+					"nodeSelector": map[string]any{"zone": "us-west-2a", "disk": "hdd"},
+					"affinity": map[string]any{
+						"nodeAffinity":    map[string]any{"from": "base"},
+						"podAntiAffinity": map[string]any{"from": "base"},
+					},
+				}),
+			},
+		},
+		"OverrideDeployment": {
+			reason: "We should replace a Deployment's scheduling constraints with the composite resource's when our policy is Override.",
+			params: params{
+				wrapped: renderer,
+				o:       []SchedulingRendererOption{WithSchedulingPolicy(SchedulingPolicyOverride)},
+			},
+			args: args{
+				cp: xr(scheduling),
+				cd: deployment(map[string]any{
+					"tolerations": []any{
+						map[string]any{"key": "gpu", "operator": "Exists"},
+					},
+					"nodeSelector": map[string]any{"disk": "hdd"},
+				}),
+			},
+			want: want{
+				cd: deployment(map[string]any{
+					"tolerations": []any{
+						map[string]any{"key": "dedicated", "operator": "Exists"},
+					},
+					"nodeSelector": map[string]any{"zone": "us-west-2a", "disk": "ssd"},
+					"affinity": map[string]any{
+						"nodeAffinity": map[string]any{"from": "composite"},
+					},
+				}),
+			},
+		},
+		"CustomFieldPath": {
+			reason: "We should inherit scheduling constraints from the configured composite resource field path.",
+			params: params{
+				wrapped: renderer,
+				o:       []SchedulingRendererOption{WithSchedulingFieldPath("spec.placement")},
+			},
+			args: args{
+				cp: func() resource.Composite {
+					xr := composite.New()
+					xr.Object["spec"] = map[string]any{"placement": map[string]any{"nodeSelector": map[string]any{"disk": "ssd"}}}
+					return xr
+				}(),
+				cd: deployment(map[string]any{}),
+			},
+			want: want{
+				cd: deployment(map[string]any{"nodeSelector": map[string]any{"disk": "ssd"}}),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewSchedulingRenderer(tc.params.wrapped, tc.params.o...)
+			err := r.Render(context.Background(), tc.args.cp, tc.args.cd, v1.ComposedTemplate{}, nil)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
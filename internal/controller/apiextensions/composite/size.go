@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"encoding/json"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// Error strings.
+const (
+	errEstimateSize          = "cannot estimate size of composed resource"
+	errFmtComposedTooLarge   = "composed resource %q is too large to apply: its estimated size of %d bytes exceeds the limit of %d bytes"
+	errFmtComposedNearlyFull = "composed resource %q is nearly too large to apply: its estimated size of %d bytes is %d%% of the limit of %d bytes"
+)
+
+const (
+	// DefaultComposedSizeLimit is the default maximum size, in bytes, of a
+	// serialized composed resource. It's etcd's default request size limit.
+	DefaultComposedSizeLimit = 1536 * 1024
+
+	// DefaultComposedSizeWarningThreshold is the default fraction of the
+	// size limit beyond which a composed resource is reported as nearly too
+	// large to apply.
+	DefaultComposedSizeWarningThreshold = 0.8
+)
+
+// A SizeLimit limits the size of the composed resources that are applied.
+type SizeLimit struct {
+	// Limit is the maximum estimated size, in bytes, of a composed resource.
+	Limit int
+
+	// WarningThreshold is the fraction of the limit beyond which a composed
+	// resource is reported as nearly too large to apply.
+	WarningThreshold float64
+}
+
+// Check the estimated size of the supplied composed resource. It returns an
+// error if the composed resource is too large to apply, or a warning if it is
+// nearly too large to apply.
+func (l SizeLimit) Check(name string, cd resource.Composed) (warning error, err error) {
+	size, err := EstimateSize(cd)
+	if err != nil {
+		return nil, errors.Wrap(err, errEstimateSize)
+	}
+	if size > l.Limit {
+		return nil, errors.Errorf(errFmtComposedTooLarge, name, size, l.Limit)
+	}
+	if float64(size) >= l.WarningThreshold*float64(l.Limit) {
+		return errors.Errorf(errFmtComposedNearlyFull, name, size, size*100/l.Limit, l.Limit), nil
+	}
+	return nil, nil
+}
+
+// EstimateSize estimates the size, in bytes, of the supplied composed resource
+// once stored. It's the size of the composed resource serialized as JSON,
+// including all of its annotations - e.g. any last-applied configuration. It
+// doesn't include fields populated by the API server, such as managed fields,
+// so a warning threshold should leave some headroom.
+func EstimateSize(cd resource.Composed) (int, error) {
+	b, err := json.Marshal(cd)
+	return len(b), err
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestSizeLimitCheck(t *testing.T) {
+	// sized returns a composed resource whose estimated size is exactly n
+	// bytes, assuming n is large enough for an empty resource.
+	sized := func(n int, annotations map[string]string) *composed.Unstructured {
+		cd := composed.New()
+		cd.SetAnnotations(annotations)
+		cd.Object["spec"] = map[string]any{"config": ""}
+		base, _ := EstimateSize(cd)
+		cd.Object["spec"] = map[string]any{"config": strings.Repeat("a", n-base)}
+		return cd
+	}
+
+	type args struct {
+		l  SizeLimit
+		cd resource.Composed
+	}
+	type want struct {
+		warning error
+		err     error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"WellWithinLimit": {
+			reason: "We should neither warn nor error if a composed resource is well within the limit.",
+			args: args{
+				l:  SizeLimit{Limit: 1000, WarningThreshold: 0.8},
+				cd: sized(500, nil),
+			},
+		},
+		"NearlyTooLarge": {
+			reason: "We should warn if a composed resource exceeds the warning threshold.",
+			args: args{
+				l:  SizeLimit{Limit: 1000, WarningThreshold: 0.8},
+				cd: sized(900, nil),
+			},
+			want: want{
+				warning: errors.Errorf(errFmtComposedNearlyFull, "cool-resource", 900, 90, 1000),
+			},
+		},
+		"TooLarge": {
+			reason: "We should return an error if a composed resource exceeds the limit.",
+			args: args{
+				l:  SizeLimit{Limit: 1000, WarningThreshold: 0.8},
+				cd: sized(1001, nil),
+			},
+			want: want{
+				err: errors.Errorf(errFmtComposedTooLarge, "cool-resource", 1001, 1000),
+			},
+		},
+		"AnnotationsCount": {
+			reason: "Annotations, such as a last-applied configuration, should count toward a composed resource's size.",
+			args: args{
+				l: SizeLimit{Limit: 1000, WarningThreshold: 0.8},
+				cd: func() resource.Composed {
+					cd := sized(500, nil)
+					cd.SetAnnotations(map[string]string{"kubectl.kubernetes.io/last-applied-configuration": strings.Repeat("a", 600)})
+					return cd
+				}(),
+			},
+			want: want{
+				// 500 bytes, plus the serialized metadata.annotations field.
+				err: errors.Errorf(errFmtComposedTooLarge, "cool-resource", 500+len(`,"metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":""}}`)+600, 1000),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			warning, err := tc.args.l.Check("cool-resource", tc.args.cd)
+			if diff := cmp.Diff(tc.want.warning, warning, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCheck(...): -want warning, +got warning:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCheck(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
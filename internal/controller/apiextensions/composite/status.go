@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"reflect"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+const (
+	errFmtStatusFromComposed = "cannot copy status field %q of composed resource %q"
+	errFmtStatusConflict     = "status field %q copied from composed resource %q conflicts with the value copied from composed resource %q"
+)
+
+// copyStatusFromComposed copies the supplied status fields of the named
+// composed resource to the status of the supplied XR, according to the supplied
+// conflict policy. Fields that don't exist aren't copied. The copiedBy map
+// records which composed resource each XR status field was copied from, and is
+// updated as fields are copied.
+func copyStatusFromComposed(xr resource.Composite, cd resource.Composed, name string, sfc []v1.StatusFromComposed, copiedBy map[string]string, p ConnectionDetailConflictPolicy) error {
+	if len(sfc) == 0 {
+		return nil
+	}
+
+	from, err := fieldpath.PaveObject(cd)
+	if err != nil {
+		return errors.Wrapf(err, errFmtStatusFromComposed, "", name)
+	}
+
+	for _, s := range sfc {
+		v, err := from.GetValue("status." + s.FromFieldPath)
+		if fieldpath.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, errFmtStatusFromComposed, s.FromFieldPath, name)
+		}
+
+		to := "status." + s.GetToFieldPath()
+		if by, ok := copiedBy[to]; ok && p == ConnectionDetailConflictPolicyFail {
+			xp, err := fieldpath.PaveObject(xr)
+			if err != nil {
+				return errors.Wrapf(err, errFmtStatusFromComposed, s.FromFieldPath, name)
+			}
+			existing, err := xp.GetValue(to)
+			if err != nil || !reflect.DeepEqual(existing, v) {
+				return errors.Errorf(errFmtStatusConflict, to, name, by)
+			}
+		}
+
+		if err := patchFieldValueToObject(to, v, xr, nil); err != nil {
+			return errors.Wrapf(err, errFmtStatusFromComposed, s.FromFieldPath, name)
+		}
+		copiedBy[to] = name
+	}
+	return nil
+}
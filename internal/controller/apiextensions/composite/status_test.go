@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestCopyStatusFromComposed(t *testing.T) {
+	// A composed resource from which status fields are copied.
+	type source struct {
+		name   string
+		status map[string]any
+		sfc    []v1.StatusFromComposed
+	}
+	type args struct {
+		sources []source
+		p       ConnectionDetailConflictPolicy
+	}
+	type want struct {
+		status map[string]any
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Copy": {
+			reason: "We should copy status fields of a composed resource to the XR's status.",
+			args: args{
+				sources: []source{{
+					name:   "instance",
+					status: map[string]any{"atProvider": map[string]any{"ip": "10.0.0.1", "port": int64(5432)}},
+					sfc: []v1.StatusFromComposed{
+						{FromFieldPath: "atProvider.ip"},
+						{FromFieldPath: "atProvider.port", ToFieldPath: pointer.String("database.port")},
+					},
+				}},
+			},
+			want: want{
+				status: map[string]any{
+					"atProvider": map[string]any{"ip": "10.0.0.1"},
+					"database":   map[string]any{"port": int64(5432)},
+				},
+			},
+		},
+		"MissingField": {
+			reason: "We should not copy status fields that don't exist.",
+			args: args{
+				sources: []source{{
+					name:   "instance",
+					status: map[string]any{"atProvider": map[string]any{"ip": "10.0.0.1"}},
+					sfc: []v1.StatusFromComposed{
+						{FromFieldPath: "atProvider.ip", ToFieldPath: pointer.String("ip")},
+						{FromFieldPath: "atProvider.port", ToFieldPath: pointer.String("port")},
+					},
+				}},
+			},
+			want: want{
+				status: map[string]any{"ip": "10.0.0.1"},
+			},
+		},
+		"ConflictLastWins": {
+			reason: "The last composed resource should win when composed resources copy different values to the same status field.",
+			args: args{
+				sources: []source{
+					{name: "a", status: map[string]any{"ip": "10.0.0.1"}, sfc: []v1.StatusFromComposed{{FromFieldPath: "ip"}}},
+					{name: "b", status: map[string]any{"ip": "10.0.0.2"}, sfc: []v1.StatusFromComposed{{FromFieldPath: "ip"}}},
+				},
+				p: ConnectionDetailConflictPolicyLastWins,
+			},
+			want: want{
+				status: map[string]any{"ip": "10.0.0.2"},
+			},
+		},
+		"ConflictFail": {
+			reason: "We should return an error when composed resources copy different values to the same status field and our policy is to fail.",
+			args: args{
+				sources: []source{
+					{name: "a", status: map[string]any{"ip": "10.0.0.1"}, sfc: []v1.StatusFromComposed{{FromFieldPath: "ip"}}},
+					{name: "b", status: map[string]any{"ip": "10.0.0.2"}, sfc: []v1.StatusFromComposed{{FromFieldPath: "ip"}}},
+				},
+				p: ConnectionDetailConflictPolicyFail,
+			},
+			want: want{
+				status: map[string]any{"ip": "10.0.0.1"},
+				err:    errors.Errorf(errFmtStatusConflict, "status.ip", "b", "a"),
+			},
+		},
+		"SameValueNoConflict": {
+			reason: "Composed resources that copy the same value to the same status field should not conflict.",
+			args: args{
+				sources: []source{
+					{name: "a", status: map[string]any{"ip": "10.0.0.1"}, sfc: []v1.StatusFromComposed{{FromFieldPath: "ip"}}},
+					{name: "b", status: map[string]any{"ip": "10.0.0.1"}, sfc: []v1.StatusFromComposed{{FromFieldPath: "ip"}}},
+				},
+				p: ConnectionDetailConflictPolicyFail,
+			},
+			want: want{
+				status: map[string]any{"ip": "10.0.0.1"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			xr := composite.New()
+			xr.SetAPIVersion("example.org/v1")
+			xr.SetKind("XDatabase")
+			copiedBy := map[string]string{}
+
+			var err error
+			for _, s := range tc.args.sources {
+				cd := composed.New()
+				cd.SetAPIVersion("example.org/v1")
+				cd.SetKind("Instance")
+				cd.Object["status"] = s.status
+				if err = copyStatusFromComposed(xr, cd, s.name, s.sfc, copiedBy, tc.args.p); err != nil {
+					break
+				}
+			}
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ncopyStatusFromComposed(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.status, xr.Object["status"]); diff != "" {
+				t.Errorf("\n%s\ncopyStatusFromComposed(...): -want status, +got status:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"container/list"
+	"sync"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// DefaultComposedTemplateCacheSize is the default number of CompositionRevisions
+// whose composed templates are cached.
+const DefaultComposedTemplateCacheSize = 256
+
+// A ComposedTemplateCache caches the composed templates of CompositionRevisions
+// with their patch sets inlined and their transforms validated, so that they
+// needn't be inlined and validated each time an XR is composed. It evicts the
+// least recently used revision once it's full. It is safe for concurrent use.
+type ComposedTemplateCache struct {
+	inline func(pss []v1.PatchSet, cts []v1.ComposedTemplate) ([]v1.ComposedTemplate, error)
+	size   int
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type composedTemplateCacheEntry struct {
+	revision   string
+	generation int64
+	templates  []v1.ComposedTemplate
+}
+
+// NewComposedTemplateCache returns a ComposedTemplateCache that caches the
+// composed templates of up to the supplied number of CompositionRevisions.
+func NewComposedTemplateCache(size int) *ComposedTemplateCache {
+	return &ComposedTemplateCache{
+		inline:  ComposedTemplates,
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// ComposedTemplates returns the composed templates of the supplied
+// CompositionRevision with any patch sets inlined. It returns an error if any
+// of their transforms are invalid. Templates are inlined and validated only if
+// the revision isn't cached, or if it was cached at a different generation.
+// Callers may safely modify the returned templates.
+func (c *ComposedTemplateCache) ComposedTemplates(rev *v1.CompositionRevision) ([]v1.ComposedTemplate, error) {
+	if c == nil {
+		return inlineAndValidate(ComposedTemplates, rev)
+	}
+
+	// We can't key anonymous revisions.
+	if rev.GetName() == "" {
+		return inlineAndValidate(c.inline, rev)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[rev.GetName()]; ok {
+		entry := e.Value.(*composedTemplateCacheEntry)
+		if entry.generation == rev.GetGeneration() {
+			c.lru.MoveToFront(e)
+			return deepCopyTemplates(entry.templates), nil
+		}
+		c.lru.Remove(e)
+		delete(c.entries, rev.GetName())
+	}
+
+	ct, err := inlineAndValidate(c.inline, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	c.entries[rev.GetName()] = c.lru.PushFront(&composedTemplateCacheEntry{revision: rev.GetName(), generation: rev.GetGeneration(), templates: ct})
+	for c.size > 0 && c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*composedTemplateCacheEntry).revision)
+	}
+
+	return deepCopyTemplates(ct), nil
+}
+
+// inlineAndValidate inlines the patch sets of the supplied revision's composed
+// templates using the supplied function, then validates their transforms.
+func inlineAndValidate(inline func(pss []v1.PatchSet, cts []v1.ComposedTemplate) ([]v1.ComposedTemplate, error), rev *v1.CompositionRevision) ([]v1.ComposedTemplate, error) {
+	ct, err := inline(rev.Spec.PatchSets, rev.Spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateTransforms(ct); err != nil {
+		return nil, err
+	}
+	return ct, nil
+}
+
+func deepCopyTemplates(ct []v1.ComposedTemplate) []v1.ComposedTemplate {
+	out := make([]v1.ComposedTemplate, len(ct))
+	for i := range ct {
+		ct[i].DeepCopyInto(&out[i])
+	}
+	return out
+}
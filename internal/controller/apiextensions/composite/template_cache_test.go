@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestComposedTemplateCache(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	rev := func(name string, generation int64) *v1.CompositionRevision {
+		return &v1.CompositionRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Generation: generation},
+			Spec: v1.CompositionRevisionSpec{
+				PatchSets: []v1.PatchSet{{
+					Name:    "common",
+					Patches: []v1.Patch{{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.String("spec.region")}},
+				}},
+				Resources: []v1.ComposedTemplate{{
+					Name:    pointer.String("cool-resource"),
+					Patches: []v1.Patch{{Type: v1.PatchTypePatchSet, PatchSetName: pointer.String("common")}},
+				}},
+			},
+		}
+	}
+	invalid := func(name string, generation int64) *v1.CompositionRevision {
+		r := rev(name, generation)
+		r.Spec.Resources[0].Patches = append(r.Spec.Resources[0].Patches, v1.Patch{
+			Type:       v1.PatchTypeFromCompositeFieldPath,
+			Transforms: []v1.Transform{{Type: v1.TransformTypeMath}},
+		})
+		return r
+	}
+	inlined := []v1.ComposedTemplate{{
+		Name:    pointer.String("cool-resource"),
+		Patches: []v1.Patch{{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.String("spec.region")}},
+	}}
+
+	type want struct {
+		ct      []v1.ComposedTemplate
+		err     error
+		inlines int
+	}
+	cases := map[string]struct {
+		reason string
+		size   int
+		fail   bool
+		revs   []*v1.CompositionRevision
+		want   want
+	}{
+		"SameRevision": {
+			reason: "We should only inline a revision once.",
+			revs:   []*v1.CompositionRevision{rev("cool-rev", 1), rev("cool-rev", 1), rev("cool-rev", 1)},
+			want:   want{ct: inlined, inlines: 1},
+		},
+		"NewGeneration": {
+			reason: "We should inline a revision again when we observe a new generation.",
+			revs:   []*v1.CompositionRevision{rev("cool-rev", 1), rev("cool-rev", 2), rev("cool-rev", 2)},
+			want:   want{ct: inlined, inlines: 2},
+		},
+		"AnonymousRevision": {
+			reason: "We should not cache a revision without a name.",
+			revs:   []*v1.CompositionRevision{rev("", 1), rev("", 1)},
+			want:   want{ct: inlined, inlines: 2},
+		},
+		"Evicted": {
+			reason: "We should inline a revision again once it has been evicted.",
+			size:   1,
+			revs:   []*v1.CompositionRevision{rev("cool-rev", 1), rev("other-rev", 1), rev("cool-rev", 1)},
+			want:   want{ct: inlined, inlines: 3},
+		},
+		"InvalidTransforms": {
+			reason: "We should return, and not cache, revisions with invalid transforms.",
+			revs:   []*v1.CompositionRevision{invalid("cool-rev", 1), invalid("cool-rev", 1)},
+			want: want{
+				err: errors.Wrap(field.ErrorList{
+					field.Required(field.NewPath("resources").Index(0).Child("patches").Index(1).Child("transforms").Index(0).Child("math"), "given transform type math requires configuration"),
+				}.ToAggregate(), errInvalidTransforms),
+				inlines: 2,
+			},
+		},
+		"InlineError": {
+			reason: "We should return, and not cache, errors encountered inlining a revision.",
+			fail:   true,
+			revs:   []*v1.CompositionRevision{rev("cool-rev", 1), rev("cool-rev", 1)},
+			want:   want{err: errBoom, inlines: 2},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := NewComposedTemplateCache(tc.size)
+			inlines := 0
+			c.inline = func(pss []v1.PatchSet, cts []v1.ComposedTemplate) ([]v1.ComposedTemplate, error) {
+				inlines++
+				if tc.fail {
+					return nil, errBoom
+				}
+				return ComposedTemplates(pss, cts)
+			}
+
+			var ct []v1.ComposedTemplate
+			var err error
+			for _, r := range tc.revs {
+				ct, err = c.ComposedTemplates(r)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nComposedTemplates(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ct, ct); diff != "" {
+				t.Errorf("\n%s\nComposedTemplates(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if inlines != tc.want.inlines {
+				t.Errorf("\n%s\nComposedTemplates(...): want %d inlines, got %d", tc.reason, tc.want.inlines, inlines)
+			}
+		})
+	}
+}
+
+func TestComposedTemplateCacheReturnsCopies(t *testing.T) {
+	rev := &v1.CompositionRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "cool-rev"},
+		Spec: v1.CompositionRevisionSpec{
+			Resources: []v1.ComposedTemplate{{Name: pointer.String("cool-resource")}},
+		},
+	}
+	c := NewComposedTemplateCache(DefaultComposedTemplateCacheSize)
+
+	first, _ := c.ComposedTemplates(rev)
+	*first[0].Name = "modified"
+
+	second, _ := c.ComposedTemplates(rev)
+	if got := pointer.StringDeref(second[0].Name, ""); got != "cool-resource" {
+		t.Errorf("ComposedTemplates(...): modifying returned templates should not modify the cache: want %q, got %q", "cool-resource", got)
+	}
+}
+
+func TestPTComposeWithComposedTemplateCache(t *testing.T) {
+	rev := &v1.CompositionRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "cool-rev", Generation: 1},
+	}
+
+	var mu sync.Mutex
+	inlines := 0
+	tc := NewComposedTemplateCache(DefaultComposedTemplateCacheSize)
+	tc.inline = func(pss []v1.PatchSet, cts []v1.ComposedTemplate) ([]v1.ComposedTemplate, error) {
+		mu.Lock()
+		inlines++
+		mu.Unlock()
+		return ComposedTemplates(pss, cts)
+	}
+
+	kube := &test.MockClient{
+		MockUpdate: test.NewMockUpdateFn(nil),
+
+		// Apply uses Get and Patch.
+		MockGet:   test.NewMockGetFn(nil),
+		MockPatch: test.NewMockPatchFn(nil),
+	}
+	c := NewPTComposer(kube,
+		WithComposedTemplateCache(tc),
+		WithTemplateAssociator(CompositionTemplateAssociatorFn(func(ctx context.Context, _ resource.Composite, ct []v1.ComposedTemplate) ([]TemplateAssociation, error) {
+			return AssociateByOrder(ct, nil), nil
+		})),
+	)
+
+	// Concurrently compose several XRs that use the same revision.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Compose(context.Background(), &fake.Composite{}, CompositionRequest{Revision: rev}); err != nil {
+				t.Errorf("Compose(...): %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if inlines != 1 {
+		t.Errorf("Compose(...): composing XRs that use the same revision should inline its templates once, got %d", inlines)
+	}
+}
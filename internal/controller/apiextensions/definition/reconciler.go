@@ -34,6 +34,7 @@ import (
 	kcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/crossplane/crossplane-runtime/pkg/connection"
@@ -435,6 +436,10 @@ func CompositeReconcilerOptions(co apiextensionscontroller.Options, d *v1.Compos
 			composite.NewAPILabelSelectorResolver(c),
 		)),
 		composite.WithCompositionUpdatePolicySelector(composite.NewAPIDefaultCompositionUpdatePolicySelector(c, *meta.ReferenceTo(d, v1.CompositeResourceDefinitionGroupVersionKind), e)),
+		composite.WithDeletionSequencer(composite.NewDependencyDeletionSequencer(c,
+			composite.WithDeletionSequencerLogger(l.WithValues("controller", composite.ControllerName(d.GetName()))),
+			composite.WithDeletionSequencerRecorder(e.WithAnnotations("controller", composite.ControllerName(d.GetName()))),
+		)),
 		composite.WithLogger(l.WithValues("controller", composite.ControllerName(d.GetName()))),
 		composite.WithRecorder(e.WithAnnotations("controller", composite.ControllerName(d.GetName()))),
 		composite.WithPollInterval(co.PollInterval),
@@ -447,7 +452,7 @@ func CompositeReconcilerOptions(co apiextensionscontroller.Options, d *v1.Compos
 	if co.Features.Enabled(features.EnableAlphaEnvironmentConfigs) {
 		o = append(o,
 			composite.WithEnvironmentSelector(composite.NewAPIEnvironmentSelector(c)),
-			composite.WithEnvironmentFetcher(composite.NewAPIEnvironmentFetcher(c)))
+			composite.WithEnvironmentFetcher(composite.NewConfigMapEnvironmentFetcher(c, co.Namespace, composite.NewAPIEnvironmentFetcher(c))))
 	}
 
 	// If external secret stores aren't enabled we just fetch connection details
@@ -482,37 +487,67 @@ func CompositeReconcilerOptions(co apiextensionscontroller.Options, d *v1.Compos
 
 		o = append(o,
 			composite.WithConnectionPublishers(pc...),
-			composite.WithConfigurator(cc),
-			composite.WithComposer(composite.NewPTComposer(c, composite.WithComposedConnectionDetailsFetcher(fetcher))))
+			composite.WithConfigurator(cc))
+	}
+
+	pto := []composite.PTComposerOption{
+		composite.WithComposedConnectionDetailsFetcher(fetcher),
+	}
+
+	// Composition metrics are served with the controller manager's metrics.
+	// They're registered once, and shared by every XR controller. We'd rather
+	// compose without metrics than not compose at all.
+	if m, err := composite.NewComposerMetrics(metrics.Registry); err != nil {
+		l.Info("Cannot register composition metrics", "error", err)
+	} else {
+		pto = append(pto, composite.WithMetrics(m))
+	}
+
+	// We only canonicalize rendered composed resources if the canonical
+	// rendering feature flag is enabled.
+	if co.Features.Enabled(features.EnableAlphaCanonicalRendering) {
+		pto = append(pto, composite.WithCanonicalRendering())
+	}
+
+	// We only adopt existing resources by external name if the external name
+	// adoption feature flag is enabled.
+	if co.Features.Enabled(features.EnableAlphaExternalNameAdoption) {
+		pto = append(pto, composite.WithTemplateAssociator(composite.NewExternalNameAssociator(c, composite.NewGarbageCollectingAssociator(c))))
 	}
 
-	// If Composition Functions are enabled we want to try to use the
-	// PTFComposer. This Composer supports using P&T Composition alone,
-	// Functions alone, or mixing both. It does not support anonymous resource
-	// templates - resource templates with a nil name - because it needs the
-	// name to match entries in the resource templates array to entries in the
-	// FunctionIO used by the templates array. We therefore 'fall back' to the
-	// PTComposer if we encounter a Composition with anonymous templates.
-	// Composition validation ensures that a Composition that uses functions
-	// must have named resources templates.
+	pt := composite.NewPTComposer(c, pto...)
+
+	// Each Composition is composed by the Composer configured for its mode.
+	// Compositions in PatchAndTransform mode, or in any mode that isn't
+	// configured, are composed by the PTComposer.
+	modes := []composite.ModeComposerSelectorOption{}
+
+	// If Composition Functions are enabled, Compositions that use functions
+	// are composed by the PTFComposer. Composition validation ensures that a
+	// Composition that uses functions has only named resource templates,
+	// which the PTFComposer requires, but an XR may still reference composed
+	// resources created by anonymous templates before its Composition started
+	// using functions. We 'fall back' to the PTComposer for such XRs. If
+	// Composition Functions aren't enabled such Compositions are composed by
+	// the PTComposer, without their functions.
 	if co.Features.Enabled(features.EnableAlphaCompositionFunctions) {
-		fb := composite.NewFallBackComposer(
-			composite.NewPTFComposer(c,
-				composite.WithComposedResourceGetter(composite.NewExistingComposedResourceGetter(c, fetcher)),
-				composite.WithCompositeConnectionDetailsFetcher(fetcher),
-				composite.WithFunctionPipelineRunner(composite.NewFunctionPipeline(
-					composite.ContainerFunctionRunnerFn(composite.RunFunction),
-					composite.WithKubernetesAuthentication(c, co.Namespace, co.ServiceAccount, co.Registry),
-				)),
+		modes = append(modes, composite.WithModeComposer(composite.CompositionModeFunctions,
+			composite.NewFallBackComposer(
+				composite.NewPTFComposer(c,
+					composite.WithComposedResourceGetter(composite.NewExistingComposedResourceGetter(c, fetcher)),
+					composite.WithCompositeConnectionDetailsFetcher(fetcher),
+					composite.WithFunctionPipelineRunner(composite.NewFunctionPipeline(
+						composite.ContainerFunctionRunnerFn(composite.RunFunction),
+						composite.WithKubernetesAuthentication(c, co.Namespace, co.ServiceAccount, co.Registry),
+					)),
+				),
+				pt,
+				composite.FallBackForAnonymousTemplates(c),
 			),
-			composite.NewPTComposer(c, composite.WithComposedConnectionDetailsFetcher(fetcher)),
-			composite.FallBackForAnonymousTemplates(c),
-		)
-
-		// Note that if external secret stores are enabled this will supercede
-		// the WithComposer option specified in that block.
-		o = append(o, composite.WithComposer(fb))
+		))
 	}
 
+	o = append(o, composite.WithComposer(composite.NewSelectingComposer(composite.NewModeComposerSelector(pt, modes...))))
+
 	return o
 }
@@ -40,4 +40,14 @@ const (
 	// details.
 	// https://github.com/crossplane/crossplane/blob/f32496bed53a393c8239376fd8266ddf2ef84d61/design/design-doc-composition-validating-webhook.md
 	EnableAlphaCompositionWebhookSchemaValidation feature.Flag = "EnableAlphaCompositionWebhookSchemaValidation"
+
+	// EnableAlphaExternalNameAdoption enables alpha support for composite
+	// resources adopting existing resources whose external name matches the
+	// one declared by a composed resource template.
+	EnableAlphaExternalNameAdoption feature.Flag = "EnableAlphaExternalNameAdoption"
+
+	// EnableAlphaCanonicalRendering enables alpha support for canonicalizing
+	// rendered composed resources, so that semantically equal renders don't
+	// cause needless updates.
+	EnableAlphaCanonicalRendering feature.Flag = "EnableAlphaCanonicalRendering"
 )
@@ -428,6 +428,18 @@ func TestForCompositeResource(t *testing.T) {
 														"lastPublishedTime": {Type: "string", Format: "date-time"},
 													},
 												},
+												"composedResources": {
+													Type: "object",
+													Properties: map[string]extv1.JSONSchemaProps{
+														"notReady": {
+															Description: "NotReady lists the composed resources that were not ready when the XR was last composed. It is managed by Crossplane.",
+															Type:        "array",
+															Items: &extv1.JSONSchemaPropsOrArray{
+																Schema: &extv1.JSONSchemaProps{Type: "string"},
+															},
+														},
+													},
+												},
 											},
 											XValidations: extv1.ValidationRules{
 												{
@@ -683,6 +695,18 @@ func TestForCompositeResource(t *testing.T) {
 														"lastPublishedTime": {Type: "string", Format: "date-time"},
 													},
 												},
+												"composedResources": {
+													Type: "object",
+													Properties: map[string]extv1.JSONSchemaProps{
+														"notReady": {
+															Description: "NotReady lists the composed resources that were not ready when the XR was last composed. It is managed by Crossplane.",
+															Type:        "array",
+															Items: &extv1.JSONSchemaPropsOrArray{
+																Schema: &extv1.JSONSchemaProps{Type: "string"},
+															},
+														},
+													},
+												},
 											},
 										},
 									},
@@ -1142,6 +1166,18 @@ func TestForCompositeResourceClaim(t *testing.T) {
 												"lastPublishedTime": {Type: "string", Format: "date-time"},
 											},
 										},
+										"composedResources": {
+											Type: "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"notReady": {
+													Description: "NotReady lists the composed resources that were not ready when the XR was last composed. It is managed by Crossplane.",
+													Type:        "array",
+													Items: &extv1.JSONSchemaPropsOrArray{
+														Schema: &extv1.JSONSchemaProps{Type: "string"},
+													},
+												},
+											},
+										},
 									},
 									XValidations: extv1.ValidationRules{
 										{
@@ -1426,6 +1462,18 @@ func TestForCompositeResourceClaimEmptyXrd(t *testing.T) {
 												"lastPublishedTime": {Type: "string", Format: "date-time"},
 											},
 										},
+										"composedResources": {
+											Type: "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"notReady": {
+													Description: "NotReady lists the composed resources that were not ready when the XR was last composed. It is managed by Crossplane.",
+													Type:        "array",
+													Items: &extv1.JSONSchemaPropsOrArray{
+														Schema: &extv1.JSONSchemaProps{Type: "string"},
+													},
+												},
+											},
+										},
 									},
 								},
 							},
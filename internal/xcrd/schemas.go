@@ -347,6 +347,21 @@ func CompositeResourceStatusProps() map[string]extv1.JSONSchemaProps {
 				"lastPublishedTime": {Type: "string", Format: "date-time"},
 			},
 		},
+		// Crossplane records which composed resources were not ready, so that
+		// it can tell when their readiness changes. See
+		// composite.FieldPathComposedResourcesNotReady.
+		"composedResources": {
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"notReady": {
+					Description: "NotReady lists the composed resources that were not ready when the XR was last composed. It is managed by Crossplane.",
+					Type:        "array",
+					Items: &extv1.JSONSchemaPropsOrArray{
+						Schema: &extv1.JSONSchemaProps{Type: "string"},
+					},
+				},
+			},
+		},
 	}
 }
 
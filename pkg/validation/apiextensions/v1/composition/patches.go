@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -275,6 +276,8 @@ func validateCombineFromCompositePathPatch(patch v1.Patch, from, to *apiextensio
 			return "", "", field.Required(field.NewPath("combine", "string"), "string combine strategy requires configuration")
 		}
 		fromType = xpschema.KnownJSONTypeString
+	case v1.CombineStrategyObject:
+		fromType = xpschema.KnownJSONTypeObject
 	default:
 		return "", "", field.Invalid(field.NewPath("combine", "strategy"), patch.Combine.Strategy, "combine strategy is not supported")
 	}
@@ -356,6 +359,11 @@ func validateFieldPath(schema *apiextensions.JSONSchemaProps, fieldPath string)
 	if fieldPath == "" {
 		return "", nil
 	}
+	// A field path ending in [] appends to an array. We validate it as a
+	// field path to an element of the array.
+	if p, ok := strings.CutSuffix(fieldPath, "[]"); ok {
+		fieldPath = p + "[0]"
+	}
 	segments, err := fieldpath.Parse(fieldPath)
 	if err != nil {
 		return "", err
@@ -426,12 +434,24 @@ func validateFieldPathSegmentField(parent *apiextensions.JSONSchemaProps, segmen
 			// re-evaluate the segment against the additional properties schema
 			return validateFieldPathSegmentField(parent.AdditionalProperties.Schema, segment)
 		}
-		return nil, errors.Errorf(errFmtFieldInvalid, segment.Field)
+		return nil, fieldNotFoundError{errors.Errorf(errFmtFieldInvalid, segment.Field)}
 
 	}
 	return &prop, nil
 }
 
+// A fieldNotFoundError indicates that a field is not defined by a schema that
+// does not accept unknown fields.
+type fieldNotFoundError struct {
+	error
+}
+
+// isFieldNotFound returns true if the supplied error indicates that a field is
+// not defined by a schema.
+func isFieldNotFound(err error) bool {
+	return errors.As(err, &fieldNotFoundError{})
+}
+
 func validateFieldPathSegmentIndex(parent *apiextensions.JSONSchemaProps, segment fieldpath.Segment) (*apiextensions.JSONSchemaProps, error) {
 	if parent == nil {
 		return nil, nil
@@ -480,7 +500,7 @@ func IsValidInputForTransform(t *v1.Transform, fromType v1.TransformIOType) erro
 		}
 	case v1.TransformTypeString:
 		switch t.String.Type {
-		case v1.StringTransformTypeRegexp, v1.StringTransformTypeTrimSuffix, v1.StringTransformTypeTrimPrefix:
+		case v1.StringTransformTypeRegexp, v1.StringTransformTypeTrimSuffix, v1.StringTransformTypeTrimPrefix, v1.StringTransformTypeEnum:
 			if fromType != v1.TransformIOTypeString {
 				return errors.Errorf("string transform can only be used with string input types, got %s", fromType)
 			}
@@ -491,7 +511,7 @@ func IsValidInputForTransform(t *v1.Transform, fromType v1.TransformIOType) erro
 				return errors.Errorf("string transform convert type is required for convert transform")
 			}
 			switch *t.String.Convert {
-			case v1.StringConversionTypeToLower, v1.StringConversionTypeToUpper, v1.StringConversionTypeFromBase64, v1.StringConversionTypeToBase64:
+			case v1.StringConversionTypeToLower, v1.StringConversionTypeToUpper, v1.StringConversionTypeFromBase64, v1.StringConversionTypeToBase64, v1.StringConversionTypeToSlug:
 				if fromType != v1.TransformIOTypeString {
 					return errors.Errorf("string transform can only be used with string input types, got %s", fromType)
 				}
@@ -507,6 +527,14 @@ func IsValidInputForTransform(t *v1.Transform, fromType v1.TransformIOType) erro
 		if _, err := composite.GetConversionFunc(t.Convert, fromType); err != nil {
 			return err
 		}
+	case v1.TransformTypeHashRing:
+		if fromType != v1.TransformIOTypeString {
+			return errors.Errorf("hashRing transform can only be used with string input types, got %s", fromType)
+		}
+	case v1.TransformTypeConnectionString:
+		if fromType != v1.TransformIOTypeObject {
+			return errors.Errorf("connectionString transform can only be used with object input types, got %s", fromType)
+		}
 	default:
 		return errors.Errorf("unknown transform type %s", t.Type)
 	}
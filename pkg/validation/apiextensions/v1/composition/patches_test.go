@@ -382,7 +382,7 @@ func TestValidateFieldPath(t *testing.T) {
 		},
 		"RejectInvalidFieldPath": {
 			reason: "Should return an error for an invalid field path",
-			want:   want{err: xperrors.Errorf(errFmtFieldInvalid, "wrong")},
+			want:   want{err: fieldNotFoundError{xperrors.Errorf(errFmtFieldInvalid, "wrong")}},
 			args: args{
 				fieldPath: "spec.forProvider.wrong",
 				schema: &apiextensions.JSONSchemaProps{
@@ -408,6 +408,23 @@ func TestValidateFieldPath(t *testing.T) {
 									XPreserveUnknownFields: &[]bool{true}[0],
 								}}}}}},
 		},
+		"AcceptAppendToArray": {
+			reason: "Should validate a field path that appends to an array as a path to an element of the array",
+			want:   want{err: nil, fieldType: "string"},
+			args: args{
+				fieldPath: "spec.forProvider.zones[]",
+				schema: &apiextensions.JSONSchemaProps{
+					Properties: map[string]apiextensions.JSONSchemaProps{
+						"spec": {
+							Properties: map[string]apiextensions.JSONSchemaProps{
+								"forProvider": {
+									Properties: map[string]apiextensions.JSONSchemaProps{
+										"zones": {
+											Type: "array",
+											Items: &apiextensions.JSONSchemaPropsOrArray{
+												Schema: &apiextensions.JSONSchemaProps{Type: "string"},
+											}}}}}}}}},
+		},
 		"AcceptValidArray": {
 			reason: "Should validate arrays properly",
 			want:   want{err: nil, fieldType: "string"},
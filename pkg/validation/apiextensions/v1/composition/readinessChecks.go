@@ -29,51 +29,64 @@ import (
 
 // validateReadinessChecksWithSchemas validates the readiness check of a composition, given the CRDs of the composed resources.
 // It checks that the readiness check field path is valid and that the fields required for the readiness check type are set and valid.
-func (v *Validator) validateReadinessChecksWithSchemas(ctx context.Context, comp *v1.Composition) (errs field.ErrorList) {
+// MatchString and MatchInteger readiness checks of fields that are not defined by the schema are returned as warnings.
+func (v *Validator) validateReadinessChecksWithSchemas(ctx context.Context, comp *v1.Composition) (warns []string, errs field.ErrorList) {
 	for i, resource := range comp.Spec.Resources {
 		if len(resource.ReadinessChecks) == 0 {
 			continue
 		}
 		gvk, err := GetBaseObjectGVK(&comp.Spec.Resources[i])
 		if err != nil {
-			return append(errs, field.InternalError(field.NewPath("spec", "resources").Index(i), errors.Wrap(err, "cannot get object gvk")))
+			return warns, append(errs, field.InternalError(field.NewPath("spec", "resources").Index(i), errors.Wrap(err, "cannot get object gvk")))
 		}
 		crd, err := v.crdGetter.Get(ctx, gvk.GroupKind())
 		if err != nil {
-			return append(errs, field.InternalError(
+			return warns, append(errs, field.InternalError(
 				field.NewPath("spec", "resources").Index(i),
 				err,
 			))
 		}
-		errs = append(errs, verrors.WrapFieldErrorList(validateReadinessChecks(resource, getSchemaForVersion(crd, gvk.Version)), field.NewPath("spec", "resources").Index(i))...)
+		w, e := validateReadinessChecks(resource, getSchemaForVersion(crd, gvk.Version))
+		for _, msg := range w {
+			warns = append(warns, fmt.Sprintf("%s: %s", field.NewPath("spec", "resources").Index(i), msg))
+		}
+		errs = append(errs, verrors.WrapFieldErrorList(e, field.NewPath("spec", "resources").Index(i))...)
 	}
 
 	if len(errs) > 0 {
-		return errs
+		return warns, errs
 	}
 
-	return nil
+	return warns, nil
 }
 
-func validateReadinessChecks(resource v1.ComposedTemplate, schema *apiextensions.JSONSchemaProps) (errs field.ErrorList) {
+func validateReadinessChecks(resource v1.ComposedTemplate, schema *apiextensions.JSONSchemaProps) (warns []string, errs field.ErrorList) {
 	if schema == nil {
-		return nil
+		return nil, nil
 	}
 	for j, r := range resource.ReadinessChecks {
 		if r.FieldPath == "" {
 			continue
 		}
 		fieldType, err := validateFieldPath(schema, r.FieldPath)
+		if isFieldNotFound(err) && (r.Type == v1.ReadinessCheckTypeMatchString || r.Type == v1.ReadinessCheckTypeMatchInteger) {
+			// The composed resource is valid, but the readiness check will
+			// never pass.
+			warns = append(warns, fmt.Sprintf("%s: field path %q is not defined by the schema of the composed resource, so this readiness check will never pass", field.NewPath("readinessCheck").Index(j).Child("fieldPath"), r.FieldPath))
+			continue
+		}
 		if err != nil {
 			errs = append(errs, field.Invalid(field.NewPath("readinessCheck").Index(j).Child("fieldPath"), r.FieldPath, err.Error()))
 			continue
 		}
-		if matchType := getReadinessCheckExpectedType(r); matchType != "" && matchType != fieldType {
+		// Fields that are accepted but not defined by the schema, e.g. because
+		// it preserves unknown fields, have no type to check.
+		if matchType := getReadinessCheckExpectedType(r); matchType != "" && fieldType != "" && matchType != fieldType {
 			errs = append(errs, field.Invalid(field.NewPath("readinessCheck").Index(j).Child("fieldPath"), r.FieldPath, fmt.Sprintf("expected field path to be of type %s", matchType)))
 			continue
 		}
 	}
-	return errs
+	return warns, errs
 }
 
 func getReadinessCheckExpectedType(r v1.ReadinessCheck) xpschema.KnownJSONType {
@@ -81,11 +94,11 @@ func getReadinessCheckExpectedType(r v1.ReadinessCheck) xpschema.KnownJSONType {
 	switch r.Type {
 	case v1.ReadinessCheckTypeMatchString:
 		matchType = xpschema.KnownJSONTypeString
-	case v1.ReadinessCheckTypeMatchInteger:
+	case v1.ReadinessCheckTypeMatchInteger, v1.ReadinessCheckTypeGreaterThanOrEqual:
 		matchType = xpschema.KnownJSONTypeInteger
 	case v1.ReadinessCheckTypeMatchTrue, v1.ReadinessCheckTypeMatchFalse:
 		matchType = xpschema.KnownJSONTypeBoolean
-	case v1.ReadinessCheckTypeNone, v1.ReadinessCheckTypeNonEmpty, v1.ReadinessCheckTypeMatchCondition:
+	case v1.ReadinessCheckTypeNone, v1.ReadinessCheckTypeNonEmpty, v1.ReadinessCheckTypeMatchCondition, v1.ReadinessCheckTypeAnyOf, v1.ReadinessCheckTypeAllOf:
 	}
 	return matchType
 }
@@ -39,7 +39,8 @@ func TestValidateReadinessCheck(t *testing.T) {
 		gkToCRD map[schema.GroupKind]apiextensions.CustomResourceDefinition
 	}
 	type want struct {
-		errs field.ErrorList
+		warns []string
+		errs  field.ErrorList
 	}
 	tests := []struct {
 		name string
@@ -249,6 +250,90 @@ func TestValidateReadinessCheck(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "should warn about readiness check of unknown field path - matchString type",
+			args: args{
+				comp: buildDefaultComposition(t, v1.CompositionValidationModeLoose, nil, withReadinessChecks(
+					0,
+					v1.ReadinessCheck{
+						Type:        v1.ReadinessCheckTypeMatchString,
+						MatchString: "bob",
+						FieldPath:   "spec.someOtherFeild",
+					},
+				)),
+				gkToCRD: defaultGKToCRDs(),
+			},
+			want: want{
+				warns: []string{`spec.resources[0]: readinessCheck[0].fieldPath: field path "spec.someOtherFeild" is not defined by the schema of the composed resource, so this readiness check will never pass`},
+			},
+		},
+		{
+			name: "should warn about readiness check of unknown field path - matchInteger type",
+			args: args{
+				comp: buildDefaultComposition(t, v1.CompositionValidationModeLoose, nil, withReadinessChecks(
+					0,
+					v1.ReadinessCheck{
+						Type:         v1.ReadinessCheckTypeMatchInteger,
+						MatchInteger: 15,
+						FieldPath:    "spec.someOtherField.nested",
+					},
+				)),
+				gkToCRD: buildGkToCRDs(
+					defaultManagedCrdBuilder().withOption(func(crd *extv1.CustomResourceDefinition) {
+						crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["someOtherField"] = extv1.JSONSchemaProps{
+							Type:       "object",
+							Properties: map[string]extv1.JSONSchemaProps{},
+						}
+					}).build()),
+			},
+			want: want{
+				warns: []string{`spec.resources[0]: readinessCheck[0].fieldPath: field path "spec.someOtherField.nested" is not defined by the schema of the composed resource, so this readiness check will never pass`},
+			},
+		},
+		{
+			name: "should accept readiness check of unknown field path - preserve unknown fields",
+			args: args{
+				comp: buildDefaultComposition(t, v1.CompositionValidationModeLoose, nil, withReadinessChecks(
+					0,
+					v1.ReadinessCheck{
+						Type:        v1.ReadinessCheckTypeMatchString,
+						MatchString: "bob",
+						FieldPath:   "spec.unknownField",
+					},
+				)),
+				gkToCRD: buildGkToCRDs(
+					defaultManagedCrdBuilder().withOption(func(crd *extv1.CustomResourceDefinition) {
+						spec := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"]
+						spec.XPreserveUnknownFields = toPointer(true)
+						crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"] = spec
+					}).build()),
+			},
+			want: want{
+				warns: nil,
+				errs:  nil,
+			},
+		},
+		{
+			name: "should accept readiness check if schema is unavailable",
+			args: args{
+				comp: buildDefaultComposition(t, v1.CompositionValidationModeLoose, nil, withReadinessChecks(
+					0,
+					v1.ReadinessCheck{
+						Type:        v1.ReadinessCheckTypeMatchString,
+						MatchString: "bob",
+						FieldPath:   "spec.unknownField",
+					},
+				)),
+				gkToCRD: buildGkToCRDs(
+					defaultManagedCrdBuilder().withOption(func(crd *extv1.CustomResourceDefinition) {
+						crd.Spec.Versions[0].Schema = nil
+					}).build()),
+			},
+			want: want{
+				warns: nil,
+				errs:  nil,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -256,7 +341,10 @@ func TestValidateReadinessCheck(t *testing.T) {
 			if err != nil {
 				t.Fatalf("NewValidator() error = %v", err)
 			}
-			got := v.validateReadinessChecksWithSchemas(context.TODO(), tt.args.comp)
+			warns, got := v.validateReadinessChecksWithSchemas(context.TODO(), tt.args.comp)
+			if diff := cmp.Diff(tt.want.warns, warns); diff != "" {
+				t.Errorf("validateReadinessChecksWithSchemas(...) = -want warnings, +got warnings\n%s\n", diff)
+			}
 			if diff := cmp.Diff(got, tt.want.errs, sortFieldErrors(), cmpopts.IgnoreFields(field.Error{}, "Detail")); diff != "" {
 				t.Errorf("validateReadinessChecksWithSchemas(...) = -want, +got\n%s\n", diff)
 			}
@@ -131,7 +131,6 @@ func (v *Validator) Validate(ctx context.Context, obj runtime.Object) (warns []s
 	// Validate patches given the above CRDs, skip if any of the required CRDs is not available
 	for _, f := range []func(context.Context, *v1.Composition) field.ErrorList{
 		v.validatePatchesWithSchemas,
-		v.validateReadinessChecksWithSchemas,
 		v.validateConnectionDetailsWithSchemas,
 		v.validateEnvironmentPatchesWithSchemas,
 		// TODO(phisco): add more phase 2 validation here
@@ -139,6 +138,10 @@ func (v *Validator) Validate(ctx context.Context, obj runtime.Object) (warns []s
 		errs = append(errs, f(ctx, comp)...)
 	}
 
+	// Readiness checks may be valid, but never pass. We warn about these.
+	warns, rerrs := v.validateReadinessChecksWithSchemas(ctx, comp)
+	errs = append(errs, rerrs...)
+
 	// TODO(phisco): add more  phase 3 validation here
-	return nil, errs
+	return warns, errs
 }